@@ -1,14 +1,29 @@
 package keto
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
 )
 
+// ClientName is the name used to register the Keto client
+const ClientName = "keto"
+
+// Default Keto API URLs, used when the client config leaves them empty
+const (
+	DefaultReadURL  = "http://localhost:4466"
+	DefaultWriteURL = "http://localhost:4467"
+)
+
+// MaxBatchSize is the most tuples sent in one batch check, Keto's default
+// max_batch_size
+const MaxBatchSize = 10
+
 // ClientConfig holds Keto client configuration
 type ClientConfig struct {
 	ReadURL  string
@@ -22,6 +37,11 @@ type Client struct {
 	httpClient *http.Client
 }
 
+// New creates a new Keto client for registration
+func New() *Client {
+	return &Client{httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
 // NewClient creates a new Keto client
 func NewClient(cfg *ClientConfig) *Client {
 	timeout := cfg.Timeout
@@ -30,7 +50,7 @@ func NewClient(cfg *ClientConfig) *Client {
 	}
 
 	return &Client{
-		config: cfg,
+		config: withDefaultURLs(cfg),
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
@@ -39,13 +59,13 @@ func NewClient(cfg *ClientConfig) *Client {
 
 // Name returns the client name
 func (c *Client) Name() string {
-	return "keto"
+	return ClientName
 }
 
 // Initialize sets up the client
 func (c *Client) Initialize(cfg any) error {
 	if clientCfg, ok := cfg.(*ClientConfig); ok {
-		c.config = clientCfg
+		c.config = withDefaultURLs(clientCfg)
 		timeout := clientCfg.Timeout
 		if timeout <= 0 {
 			timeout = 10 * time.Second
@@ -57,6 +77,21 @@ func (c *Client) Initialize(cfg any) error {
 	return nil
 }
 
+// withDefaultURLs returns cfg with empty URLs set to the local defaults
+func withDefaultURLs(cfg *ClientConfig) *ClientConfig {
+	if cfg.ReadURL != "" && cfg.WriteURL != "" {
+		return cfg
+	}
+	resolved := *cfg
+	if resolved.ReadURL == "" {
+		resolved.ReadURL = DefaultReadURL
+	}
+	if resolved.WriteURL == "" {
+		resolved.WriteURL = DefaultWriteURL
+	}
+	return &resolved
+}
+
 // Health checks if Keto is reachable
 func (c *Client) Health() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -86,10 +121,15 @@ func (c *Client) Shutdown() error {
 	return nil
 }
 
-// CheckPermission checks if a subject has a relation on an object
+// CheckPermission checks if a subject has a relation on an object. The
+// subject is a subject ID or a subject set such as "groups:admins#member".
 func (c *Client) CheckPermission(ctx context.Context, subject, relation, namespace, object string) (bool, error) {
+	subjectID, set, err := parseSubject(subject)
+	if err != nil {
+		return false, err
+	}
 	params := url.Values{}
-	params.Set("subject_id", subject)
+	setSubjectParams(params, subjectID, set)
 	params.Set("relation", relation)
 	params.Set("namespace", namespace)
 	params.Set("object", object)
@@ -124,3 +164,75 @@ func (c *Client) CheckPermission(ctx context.Context, subject, relation, namespa
 
 	return result.Allowed, nil
 }
+
+// CheckPermissions checks several permissions, in batches of at most
+// MaxBatchSize. Results are in the order of perms.
+func (c *Client) CheckPermissions(ctx context.Context, perms []Permission) ([]bool, error) {
+	if len(perms) == 0 {
+		return nil, nil
+	}
+
+	allowed := make([]bool, 0, len(perms))
+	for batch := range slices.Chunk(perms, MaxBatchSize) {
+		results, err := c.checkBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, results...)
+	}
+	return allowed, nil
+}
+
+// checkBatch checks up to MaxBatchSize permissions in one request
+func (c *Client) checkBatch(ctx context.Context, perms []Permission) ([]bool, error) {
+	tuples := make([]RelationTuple, len(perms))
+	for i, p := range perms {
+		subjectID, set, err := parseSubject(p.Subject)
+		if err != nil {
+			return nil, err
+		}
+		tuples[i] = RelationTuple{Namespace: p.Namespace, Object: p.Object, Relation: p.Relation, SubjectID: subjectID, SubjectSet: set}
+	}
+	body, err := json.Marshal(map[string]any{"tuples": tuples})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.config.ReadURL+"/relation-tuples/batch/check", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("permission check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []struct {
+			Allowed bool   `json:"allowed"`
+			Error   string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Results) != len(perms) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(perms), len(result.Results))
+	}
+
+	allowed := make([]bool, len(perms))
+	for i, r := range result.Results {
+		if r.Error != "" {
+			return nil, fmt.Errorf("permission check failed for %s:%s#%s: %s", perms[i].Namespace, perms[i].Object, perms[i].Relation, r.Error)
+		}
+		allowed[i] = r.Allowed
+	}
+	return allowed, nil
+}
@@ -3,12 +3,14 @@ package keto
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewClient(t *testing.T) {
@@ -257,6 +259,98 @@ func TestClient_CheckPermission_InvalidURL(t *testing.T) {
 	assert.False(t, allowed)
 }
 
+func TestClient_CheckPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/relation-tuples/batch/check", r.URL.Path)
+
+		var body struct {
+			Tuples []map[string]string `json:"tuples"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Len(t, body.Tuples, 2)
+		assert.Equal(t, "user-123", body.Tuples[0]["subject_id"])
+		assert.Equal(t, "doc-2", body.Tuples[1]["object"])
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{
+			{"allowed": true},
+			{"allowed": false},
+		}})
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: server.URL})
+
+	allowed, err := client.CheckPermissions(context.Background(), []Permission{
+		*NewPermission("user-123", "viewer", "documents", "doc-1"),
+		*NewPermission("user-123", "viewer", "documents", "doc-2"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, allowed)
+}
+
+func TestClient_CheckPermissions_Batches(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Tuples []map[string]string `json:"tuples"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		sizes = append(sizes, len(body.Tuples))
+
+		results := make([]map[string]any, len(body.Tuples))
+		for i, tuple := range body.Tuples {
+			results[i] = map[string]any{"allowed": tuple["object"] == "doc-11"}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: server.URL})
+
+	perms := make([]Permission, 25)
+	for i := range perms {
+		perms[i] = *NewPermission("user-123", "viewer", "documents", fmt.Sprintf("doc-%d", i))
+	}
+	allowed, err := client.CheckPermissions(context.Background(), perms)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 10, 5}, sizes)
+	require.Len(t, allowed, 25)
+	for i, ok := range allowed {
+		assert.Equal(t, i == 11, ok)
+	}
+}
+
+func TestClient_CheckPermissions_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{
+			{"allowed": false, "error": "namespace not found"},
+		}})
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: server.URL})
+
+	_, err := client.CheckPermissions(context.Background(), []Permission{
+		*NewPermission("user-123", "viewer", "unknown", "doc-1"),
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "namespace not found")
+}
+
+func TestClient_CheckPermissions_Empty(t *testing.T) {
+	client := NewClient(&ClientConfig{ReadURL: "http://localhost:0"})
+
+	allowed, err := client.CheckPermissions(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, allowed)
+}
+
 func TestClient_Shutdown(t *testing.T) {
 	client := NewClient(&ClientConfig{
 		ReadURL: "http://localhost:4466",
@@ -2,12 +2,23 @@ package keto
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync"
 )
 
-// MockClient is a mock Keto client for testing
+// maxDepth bounds the subject sets the in-memory mock follows
+const maxDepth = 32
+
+// MockClient is a mock Keto client for testing. Relation tuples written
+// to it are stored in memory; see NewMemoryClient to check against them.
 type MockClient struct {
-	CheckFunc  func(ctx context.Context, subject, relation, namespace, object string) (bool, error)
-	HealthFunc func() error
+	CheckFunc      func(ctx context.Context, subject, relation, namespace, object string) (bool, error)
+	BatchCheckFunc func(ctx context.Context, perms []Permission) ([]bool, error)
+	HealthFunc     func() error
+
+	mu     sync.RWMutex
+	tuples []RelationTuple
 }
 
 // NewMockClient creates a new mock client
@@ -22,8 +33,16 @@ func NewMockClient() *MockClient {
 	}
 }
 
+// NewMemoryClient creates a mock that evaluates checks against its stored
+// relation tuples, following subject sets transitively like Keto
+func NewMemoryClient(tuples ...RelationTuple) *MockClient {
+	m := &MockClient{tuples: append([]RelationTuple(nil), tuples...)}
+	m.CheckFunc = m.Evaluate
+	return m
+}
+
 // Name returns the client name
-func (m *MockClient) Name() string { return "keto" }
+func (m *MockClient) Name() string { return ClientName }
 
 // Initialize sets up the mock client
 func (m *MockClient) Initialize(cfg any) error { return nil }
@@ -46,3 +65,160 @@ func (m *MockClient) CheckPermission(ctx context.Context, subject, relation, nam
 	}
 	return true, nil
 }
+
+// CheckPermissions checks permissions using the mock batch function, or
+// CheckPermission for each permission if it is not set
+func (m *MockClient) CheckPermissions(ctx context.Context, perms []Permission) ([]bool, error) {
+	if m.BatchCheckFunc != nil {
+		return m.BatchCheckFunc(ctx, perms)
+	}
+	allowed := make([]bool, len(perms))
+	for i, p := range perms {
+		ok, err := m.CheckPermission(ctx, p.Subject, p.Relation, p.Namespace, p.Object)
+		if err != nil {
+			return nil, err
+		}
+		allowed[i] = ok
+	}
+	return allowed, nil
+}
+
+// AllowOnly makes the mock grant exactly the given permissions
+func (m *MockClient) AllowOnly(perms ...Permission) {
+	granted := make(map[Permission]bool, len(perms))
+	for _, p := range perms {
+		granted[p] = true
+	}
+	m.CheckFunc = func(ctx context.Context, subject, relation, namespace, object string) (bool, error) {
+		return granted[Permission{Subject: subject, Relation: relation, Namespace: namespace, Object: object}], nil
+	}
+}
+
+// Evaluate checks a permission against the stored tuples. A subject has a
+// relation if a tuple grants it directly, or grants it to a subject set
+// the subject is a member of.
+func (m *MockClient) Evaluate(ctx context.Context, subject, relation, namespace, object string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.evaluate(subject, SubjectSet{Namespace: namespace, Object: object, Relation: relation}, map[SubjectSet]bool{}, 0), nil
+}
+
+func (m *MockClient) evaluate(subject string, set SubjectSet, visited map[SubjectSet]bool, depth int) bool {
+	if depth >= maxDepth || visited[set] {
+		return false
+	}
+	visited[set] = true
+
+	for _, t := range m.tuples {
+		if t.Namespace != set.Namespace || t.Object != set.Object || t.Relation != set.Relation {
+			continue
+		}
+		if t.Subject() == subject {
+			return true
+		}
+		if t.SubjectSet != nil && m.evaluate(subject, *t.SubjectSet, visited, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateRelation stores a relation tuple
+func (m *MockClient) CreateRelation(ctx context.Context, tuple RelationTuple) error {
+	if err := tuple.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tuples {
+		if t.String() == tuple.String() {
+			return nil
+		}
+	}
+	m.tuples = append(m.tuples, tuple)
+	return nil
+}
+
+// DeleteRelation removes a stored relation tuple
+func (m *MockClient) DeleteRelation(ctx context.Context, tuple RelationTuple) error {
+	if err := tuple.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.tuples {
+		if t.String() == tuple.String() {
+			m.tuples = append(m.tuples[:i], m.tuples[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ListRelations returns the stored tuples matching query, in the order
+// they were written. Page tokens are offsets.
+func (m *MockClient) ListRelations(ctx context.Context, query RelationQuery) (*RelationPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []RelationTuple
+	for _, t := range m.tuples {
+		if (query.Namespace == "" || t.Namespace == query.Namespace) &&
+			(query.Object == "" || t.Object == query.Object) &&
+			(query.Relation == "" || t.Relation == query.Relation) &&
+			(query.Subject == "" || t.Subject() == query.Subject) {
+			matches = append(matches, t)
+		}
+	}
+
+	start := 0
+	if query.PageToken != "" {
+		var err error
+		if start, err = strconv.Atoi(query.PageToken); err != nil || start < 0 || start > len(matches) {
+			return nil, fmt.Errorf("invalid page token %q", query.PageToken)
+		}
+	}
+	page := &RelationPage{Tuples: matches[start:]}
+	if query.PageSize > 0 && len(page.Tuples) > query.PageSize {
+		page.Tuples = page.Tuples[:query.PageSize]
+		page.NextPageToken = strconv.Itoa(start + query.PageSize)
+	}
+	return page, nil
+}
+
+// Expand builds the subject tree of a relation from the stored tuples
+func (m *MockClient) Expand(ctx context.Context, namespace, object, relation string, depth int) (*ExpandTree, error) {
+	if depth <= 0 {
+		depth = maxDepth
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.expand(SubjectSet{Namespace: namespace, Object: object, Relation: relation}, depth, map[SubjectSet]bool{}), nil
+}
+
+func (m *MockClient) expand(set SubjectSet, depth int, visited map[SubjectSet]bool) *ExpandTree {
+	tree := &ExpandTree{
+		Type:  "union",
+		Tuple: &RelationTuple{Namespace: set.Namespace, Object: set.Object, Relation: set.Relation},
+	}
+	if depth <= 0 || visited[set] {
+		tree.Type = "leaf"
+		return tree
+	}
+	visited[set] = true
+
+	for _, t := range m.tuples {
+		if t.Namespace != set.Namespace || t.Object != set.Object || t.Relation != set.Relation {
+			continue
+		}
+		if t.SubjectSet != nil {
+			child := m.expand(*t.SubjectSet, depth-1, visited)
+			child.Tuple = &RelationTuple{Namespace: t.Namespace, Object: t.Object, Relation: t.Relation, SubjectSet: t.SubjectSet}
+			tree.Children = append(tree.Children, child)
+			continue
+		}
+		leaf := t
+		tree.Children = append(tree.Children, &ExpandTree{Type: "leaf", Tuple: &leaf})
+	}
+	return tree
+}
@@ -105,3 +105,105 @@ func TestMockClient_CheckPermission_NilFunc(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, allowed)
 }
+
+func TestMockClient_CheckPermissions(t *testing.T) {
+	client := NewMockClient()
+	client.AllowOnly(*NewPermission("user", "viewer", "docs", "doc1"))
+
+	allowed, err := client.CheckPermissions(context.Background(), []Permission{
+		*NewPermission("user", "viewer", "docs", "doc1"),
+		*NewPermission("user", "viewer", "docs", "doc2"),
+		*NewPermission("user", "editor", "docs", "doc1"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false}, allowed)
+}
+
+func TestMockClient_CheckPermissions_WithFunc(t *testing.T) {
+	client := NewMockClient()
+	client.BatchCheckFunc = func(ctx context.Context, perms []Permission) ([]bool, error) {
+		return nil, fmt.Errorf("batch error")
+	}
+
+	_, err := client.CheckPermissions(context.Background(), []Permission{*NewPermission("user", "viewer", "docs", "doc1")})
+
+	assert.Error(t, err)
+}
+
+func TestMemoryClient_Evaluate(t *testing.T) {
+	client := NewMemoryClient()
+	ctx := context.Background()
+	for _, s := range []string{
+		"projects:p1#owner@user-1",
+		"projects:p1#viewer@groups:admins#member",
+		"groups:admins#member@groups:ops#member",
+		"groups:ops#member@user-2",
+		"groups:loop#member@groups:loop#member",
+	} {
+		tuple, err := ParseRelationTuple(s)
+		assert.NoError(t, err)
+		assert.NoError(t, client.CreateRelation(ctx, tuple))
+	}
+
+	allowed, _ := client.CheckPermission(ctx, "user-1", "owner", "projects", "p1")
+	assert.True(t, allowed, "direct tuple")
+
+	allowed, _ = client.CheckPermission(ctx, "user-2", "viewer", "projects", "p1")
+	assert.True(t, allowed, "through two subject sets")
+
+	allowed, _ = client.CheckPermission(ctx, "groups:admins#member", "viewer", "projects", "p1")
+	assert.True(t, allowed, "subject set as subject")
+
+	allowed, _ = client.CheckPermission(ctx, "user-1", "viewer", "projects", "p1")
+	assert.False(t, allowed, "no userset rewrites")
+
+	allowed, _ = client.CheckPermission(ctx, "user-1", "member", "groups", "loop")
+	assert.False(t, allowed, "cycles terminate")
+
+	tuple, _ := ParseRelationTuple("groups:ops#member@user-2")
+	assert.NoError(t, client.DeleteRelation(ctx, tuple))
+	allowed, _ = client.CheckPermission(ctx, "user-2", "viewer", "projects", "p1")
+	assert.False(t, allowed)
+}
+
+func TestMemoryClient_ListRelations(t *testing.T) {
+	ctx := context.Background()
+	var tuples []RelationTuple
+	for _, s := range []string{"projects:p1#owner@user-1", "projects:p2#owner@user-1", "projects:p3#owner@user-1", "projects:p1#viewer@user-2"} {
+		tuple, _ := ParseRelationTuple(s)
+		tuples = append(tuples, tuple)
+	}
+	client := NewMemoryClient(tuples...)
+
+	page, err := client.ListRelations(ctx, RelationQuery{Relation: "owner", Subject: "user-1", PageSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Tuples, 2)
+	assert.Equal(t, "2", page.NextPageToken)
+
+	page, err = client.ListRelations(ctx, RelationQuery{Relation: "owner", Subject: "user-1", PageSize: 2, PageToken: page.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, []RelationTuple{tuples[2]}, page.Tuples)
+	assert.Empty(t, page.NextPageToken)
+
+	_, err = client.ListRelations(ctx, RelationQuery{PageToken: "x"})
+	assert.Error(t, err)
+}
+
+func TestMemoryClient_Expand(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryClient()
+	for _, s := range []string{"projects:p1#viewer@user-1", "projects:p1#viewer@groups:admins#member", "groups:admins#member@user-2"} {
+		tuple, _ := ParseRelationTuple(s)
+		assert.NoError(t, client.CreateRelation(ctx, tuple))
+	}
+
+	tree, err := client.Expand(ctx, "projects", "p1", "viewer", 0)
+	assert.NoError(t, err)
+	assert.Len(t, tree.Children, 2)
+	assert.Equal(t, []string{"user-1", "user-2"}, tree.Subjects())
+
+	tree, err = client.Expand(ctx, "projects", "p1", "viewer", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, tree.Subjects(), "depth limits subject sets")
+}
@@ -0,0 +1,148 @@
+package keto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CreateRelation writes a relation tuple through the write API
+func (c *Client) CreateRelation(ctx context.Context, tuple RelationTuple) error {
+	if err := tuple.Validate(); err != nil {
+		return err
+	}
+	body, err := json.Marshal(tuple)
+	if err != nil {
+		return fmt.Errorf("failed to encode relation tuple: %w", err)
+	}
+
+	resp, err := c.do(ctx, "PUT", c.writeURL("/admin/relation-tuples", nil), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create relation failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// DeleteRelation deletes a relation tuple through the write API. Deleting a
+// tuple that does not exist is not an error.
+func (c *Client) DeleteRelation(ctx context.Context, tuple RelationTuple) error {
+	if err := tuple.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, "DELETE", c.writeURL("/admin/relation-tuples", tuple.query()), nil)
+	if err != nil {
+		return fmt.Errorf("delete relation failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// ListRelations returns a page of the relation tuples matching query
+func (c *Client) ListRelations(ctx context.Context, query RelationQuery) (*RelationPage, error) {
+	params, err := query.query()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, "GET", c.config.ReadURL+"/relation-tuples?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("list relations failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var page RelationPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &page, nil
+}
+
+// Expand returns the tree of subjects that have relation on object, following
+// subject sets up to maxDepth levels (Keto's default if zero)
+func (c *Client) Expand(ctx context.Context, namespace, object, relation string, maxDepth int) (*ExpandTree, error) {
+	params := url.Values{}
+	params.Set("namespace", namespace)
+	params.Set("object", object)
+	params.Set("relation", relation)
+	if maxDepth > 0 {
+		params.Set("max-depth", fmt.Sprint(maxDepth))
+	}
+
+	resp, err := c.do(ctx, "GET", c.config.ReadURL+"/relation-tuples/expand?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("expand failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &ExpandTree{Type: "union"}, nil // No tuples for the relation
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var tree ExpandTree
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &tree, nil
+}
+
+// writeURL returns a write API URL, falling back to the read URL when no
+// write URL is configured
+func (c *Client) writeURL(path string, params url.Values) string {
+	base := c.config.WriteURL
+	if base == "" {
+		base = c.config.ReadURL
+	}
+	if len(params) > 0 {
+		return base + path + "?" + params.Encode()
+	}
+	return base + path
+}
+
+func (c *Client) do(ctx context.Context, method, reqURL string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(req)
+}
+
+// statusError returns an error with Keto's error message, if any
+func statusError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Reason  string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body) == nil && body.Error.Message != "" {
+		if body.Error.Reason != "" {
+			return fmt.Errorf("unexpected status: %d: %s: %s", resp.StatusCode, body.Error.Message, body.Error.Reason)
+		}
+		return fmt.Errorf("unexpected status: %d: %s", resp.StatusCode, body.Error.Message)
+	}
+	return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+}
@@ -0,0 +1,133 @@
+package keto
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateRelation(t *testing.T) {
+	write := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/admin/relation-tuples", r.URL.Path)
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "projects", body["namespace"])
+		assert.Equal(t, map[string]any{"namespace": "groups", "object": "admins", "relation": "member"}, body["subject_set"])
+		assert.NotContains(t, body, "subject_id")
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer write.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: "http://read.invalid", WriteURL: write.URL})
+	tuple, err := ParseRelationTuple("projects:p1#viewer@groups:admins#member")
+	require.NoError(t, err)
+
+	assert.NoError(t, client.CreateRelation(context.Background(), tuple))
+	assert.Error(t, client.CreateRelation(context.Background(), RelationTuple{Namespace: "projects"}))
+}
+
+func TestClient_CreateRelation_Error(t *testing.T) {
+	write := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": "The request was malformed", "reason": "unknown namespace"}})
+	}))
+	defer write.Close()
+
+	client := NewClient(&ClientConfig{WriteURL: write.URL})
+	tuple, _ := NewRelationTuple("nope", "p1", "viewer", "user-1")
+
+	err := client.CreateRelation(context.Background(), tuple)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown namespace")
+}
+
+func TestClient_DeleteRelation(t *testing.T) {
+	write := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/admin/relation-tuples", r.URL.Path)
+		assert.Equal(t, "projects", r.URL.Query().Get("namespace"))
+		assert.Equal(t, "p1", r.URL.Query().Get("object"))
+		assert.Equal(t, "user-1", r.URL.Query().Get("subject_id"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer write.Close()
+
+	client := NewClient(&ClientConfig{WriteURL: write.URL})
+	tuple, _ := NewRelationTuple("projects", "p1", "owner", "user-1")
+
+	assert.NoError(t, client.DeleteRelation(context.Background(), tuple))
+}
+
+func TestClient_ListRelations(t *testing.T) {
+	read := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/relation-tuples", r.URL.Path)
+		assert.Equal(t, "projects", r.URL.Query().Get("namespace"))
+		assert.Equal(t, "user-1", r.URL.Query().Get("subject_id"))
+		assert.Equal(t, "2", r.URL.Query().Get("page_size"))
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"relation_tuples": []map[string]any{
+				{"namespace": "projects", "object": "p1", "relation": "owner", "subject_id": "user-1"},
+				{"namespace": "projects", "object": "p2", "relation": "viewer", "subject_id": "user-1"},
+			},
+			"next_page_token": "abc",
+		})
+	}))
+	defer read.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: read.URL})
+
+	page, err := client.ListRelations(context.Background(), RelationQuery{Namespace: "projects", Subject: "user-1", PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Tuples, 2)
+	assert.Equal(t, "projects:p2#viewer@user-1", page.Tuples[1].String())
+	assert.Equal(t, "abc", page.NextPageToken)
+}
+
+func TestClient_Expand(t *testing.T) {
+	read := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/relation-tuples/expand", r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("max-depth"))
+
+		w.Write([]byte(`{
+			"type": "union",
+			"tuple": {"namespace": "projects", "object": "p1", "relation": "viewer"},
+			"children": [
+				{"type": "leaf", "tuple": {"namespace": "projects", "object": "p1", "relation": "viewer", "subject_id": "user-1"}},
+				{"type": "union", "tuple": {"namespace": "projects", "object": "p1", "relation": "viewer", "subject_set": {"namespace": "groups", "object": "admins", "relation": "member"}},
+				 "children": [{"type": "leaf", "tuple": {"namespace": "groups", "object": "admins", "relation": "member", "subject_id": "user-2"}}]}
+			]
+		}`))
+	}))
+	defer read.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: read.URL})
+
+	tree, err := client.Expand(context.Background(), "projects", "p1", "viewer", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "union", tree.Type)
+	assert.Equal(t, []string{"user-1", "user-2"}, tree.Subjects())
+}
+
+func TestClient_CheckPermission_SubjectSet(t *testing.T) {
+	read := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "groups", r.URL.Query().Get("subject_set.namespace"))
+		assert.False(t, r.URL.Query().Has("subject_id"))
+		json.NewEncoder(w).Encode(map[string]bool{"allowed": true})
+	}))
+	defer read.Close()
+
+	client := NewClient(&ClientConfig{ReadURL: read.URL})
+
+	allowed, err := client.CheckPermission(context.Background(), "groups:admins#member", "viewer", "projects", "p1")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,202 @@
+package keto
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SubjectSet is the set of subjects that have a relation on an object,
+// written "namespace:object#relation", e.g. "groups:admins#member"
+type SubjectSet struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+}
+
+// String returns the set as "namespace:object#relation"
+func (s SubjectSet) String() string {
+	return s.Namespace + ":" + s.Object + "#" + s.Relation
+}
+
+// ParseSubjectSet parses "namespace:object#relation"
+func ParseSubjectSet(s string) (*SubjectSet, error) {
+	ref, relation, ok := strings.Cut(s, "#")
+	if !ok {
+		return nil, fmt.Errorf("invalid subject set %q: missing #relation", s)
+	}
+	namespace, object, ok := strings.Cut(ref, ":")
+	if !ok || namespace == "" || object == "" || relation == "" {
+		return nil, fmt.Errorf("invalid subject set %q: expected namespace:object#relation", s)
+	}
+	return &SubjectSet{Namespace: namespace, Object: object, Relation: relation}, nil
+}
+
+// isSubjectSet reports whether a subject is written as a subject set
+func isSubjectSet(subject string) bool {
+	colon := strings.Index(subject, ":")
+	return colon > 0 && strings.Index(subject, "#") > colon
+}
+
+// RelationTuple grants a subject a relation on an object. The subject is
+// either a subject ID or a subject set.
+type RelationTuple struct {
+	Namespace  string      `json:"namespace"`
+	Object     string      `json:"object"`
+	Relation   string      `json:"relation"`
+	SubjectID  string      `json:"subject_id,omitempty"`
+	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
+}
+
+// NewRelationTuple creates a tuple. A subject written as
+// "namespace:object#relation" becomes a subject set.
+func NewRelationTuple(namespace, object, relation, subject string) (RelationTuple, error) {
+	t := RelationTuple{Namespace: namespace, Object: object, Relation: relation}
+	if isSubjectSet(subject) {
+		set, err := ParseSubjectSet(subject)
+		if err != nil {
+			return RelationTuple{}, err
+		}
+		t.SubjectSet = set
+	} else {
+		t.SubjectID = subject
+	}
+	return t, t.Validate()
+}
+
+// ParseRelationTuple parses Keto's "namespace:object#relation@subject"
+// notation, e.g. "projects:p1#viewer@groups:admins#member"
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	ref, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("invalid relation tuple %q: missing @subject", s)
+	}
+	set, err := ParseSubjectSet(ref)
+	if err != nil {
+		return RelationTuple{}, fmt.Errorf("invalid relation tuple %q: %w", s, err)
+	}
+	return NewRelationTuple(set.Namespace, set.Object, set.Relation, subject)
+}
+
+// Subject returns the subject ID, or the subject set as a string
+func (t RelationTuple) Subject() string {
+	if t.SubjectSet != nil {
+		return t.SubjectSet.String()
+	}
+	return t.SubjectID
+}
+
+// String returns the tuple as "namespace:object#relation@subject"
+func (t RelationTuple) String() string {
+	return t.Namespace + ":" + t.Object + "#" + t.Relation + "@" + t.Subject()
+}
+
+// Validate checks that all parts of the tuple are set
+func (t RelationTuple) Validate() error {
+	if t.Namespace == "" || t.Object == "" || t.Relation == "" {
+		return fmt.Errorf("relation tuple requires namespace, object and relation")
+	}
+	if (t.SubjectID == "") == (t.SubjectSet == nil) {
+		return fmt.Errorf("relation tuple requires exactly one of subject_id and subject_set")
+	}
+	return nil
+}
+
+// query returns the tuple as Keto query parameters
+func (t RelationTuple) query() url.Values {
+	params := url.Values{}
+	setParam(params, "namespace", t.Namespace)
+	setParam(params, "object", t.Object)
+	setParam(params, "relation", t.Relation)
+	setSubjectParams(params, t.SubjectID, t.SubjectSet)
+	return params
+}
+
+// RelationQuery filters ListRelations. Empty fields match everything.
+type RelationQuery struct {
+	Namespace string
+	Object    string
+	Relation  string
+	Subject   string // Subject ID or "namespace:object#relation"
+	PageSize  int    // Keto's default if zero
+	PageToken string // NextPageToken of the previous page
+}
+
+func (q RelationQuery) query() (url.Values, error) {
+	params := url.Values{}
+	setParam(params, "namespace", q.Namespace)
+	setParam(params, "object", q.Object)
+	setParam(params, "relation", q.Relation)
+	if q.Subject != "" {
+		subjectID, set, err := parseSubject(q.Subject)
+		if err != nil {
+			return nil, err
+		}
+		setSubjectParams(params, subjectID, set)
+	}
+	if q.PageSize > 0 {
+		params.Set("page_size", fmt.Sprint(q.PageSize))
+	}
+	setParam(params, "page_token", q.PageToken)
+	return params, nil
+}
+
+// RelationPage is a page of ListRelations results
+type RelationPage struct {
+	Tuples        []RelationTuple `json:"relation_tuples"`
+	NextPageToken string          `json:"next_page_token"` // Empty on the last page
+}
+
+// ExpandTree is the subject tree returned by Expand. Leaves hold subject
+// IDs; inner nodes combine the subjects of their children.
+type ExpandTree struct {
+	Type     string         `json:"type"` // e.g. "union", "leaf"
+	Tuple    *RelationTuple `json:"tuple,omitempty"`
+	Children []*ExpandTree  `json:"children,omitempty"`
+}
+
+// Subjects returns the subject IDs of the tree's leaves, without duplicates
+func (t *ExpandTree) Subjects() []string {
+	seen := make(map[string]bool)
+	var subjects []string
+	var walk func(node *ExpandTree)
+	walk = func(node *ExpandTree) {
+		if node == nil {
+			return
+		}
+		if node.Tuple != nil && node.Tuple.SubjectID != "" && !seen[node.Tuple.SubjectID] {
+			seen[node.Tuple.SubjectID] = true
+			subjects = append(subjects, node.Tuple.SubjectID)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(t)
+	return subjects
+}
+
+// parseSubject splits a subject into a subject ID or a subject set
+func parseSubject(subject string) (string, *SubjectSet, error) {
+	if !isSubjectSet(subject) {
+		return subject, nil, nil
+	}
+	set, err := ParseSubjectSet(subject)
+	return "", set, err
+}
+
+func setSubjectParams(params url.Values, subjectID string, set *SubjectSet) {
+	if set != nil {
+		params.Set("subject_set.namespace", set.Namespace)
+		params.Set("subject_set.object", set.Object)
+		params.Set("subject_set.relation", set.Relation)
+		return
+	}
+	setParam(params, "subject_id", subjectID)
+}
+
+func setParam(params url.Values, key, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
@@ -0,0 +1,78 @@
+package keto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubjectSet(t *testing.T) {
+	set, err := ParseSubjectSet("groups:admins#member")
+	require.NoError(t, err)
+	assert.Equal(t, &SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}, set)
+	assert.Equal(t, "groups:admins#member", set.String())
+
+	for _, invalid := range []string{"groups:admins", "groups#member", ":admins#member", "groups:admins#"} {
+		_, err := ParseSubjectSet(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNewRelationTuple(t *testing.T) {
+	tuple, err := NewRelationTuple("projects", "p1", "viewer", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", tuple.SubjectID)
+	assert.Nil(t, tuple.SubjectSet)
+
+	tuple, err = NewRelationTuple("projects", "p1", "viewer", "groups:admins#member")
+	require.NoError(t, err)
+	assert.Empty(t, tuple.SubjectID)
+	assert.Equal(t, "groups:admins#member", tuple.Subject())
+
+	_, err = NewRelationTuple("projects", "", "viewer", "user-1")
+	assert.Error(t, err)
+	_, err = NewRelationTuple("projects", "p1", "viewer", "")
+	assert.Error(t, err)
+}
+
+func TestParseRelationTuple(t *testing.T) {
+	tuple, err := ParseRelationTuple("projects:p1#viewer@groups:admins#member")
+	require.NoError(t, err)
+	assert.Equal(t, "projects", tuple.Namespace)
+	assert.Equal(t, "p1", tuple.Object)
+	assert.Equal(t, "viewer", tuple.Relation)
+	assert.Equal(t, &SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}, tuple.SubjectSet)
+	assert.Equal(t, "projects:p1#viewer@groups:admins#member", tuple.String())
+
+	tuple, err = ParseRelationTuple("groups:admins#member@user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", tuple.SubjectID)
+
+	_, err = ParseRelationTuple("projects:p1#viewer")
+	assert.Error(t, err)
+}
+
+func TestRelationQuery_Query(t *testing.T) {
+	params, err := RelationQuery{Namespace: "projects", Subject: "groups:admins#member", PageSize: 10, PageToken: "next"}.query()
+	require.NoError(t, err)
+	assert.Equal(t, "projects", params.Get("namespace"))
+	assert.Equal(t, "groups", params.Get("subject_set.namespace"))
+	assert.Equal(t, "admins", params.Get("subject_set.object"))
+	assert.Equal(t, "member", params.Get("subject_set.relation"))
+	assert.Equal(t, "10", params.Get("page_size"))
+	assert.Equal(t, "next", params.Get("page_token"))
+	assert.False(t, params.Has("object"))
+	assert.False(t, params.Has("subject_id"))
+}
+
+func TestExpandTree_Subjects(t *testing.T) {
+	tree := &ExpandTree{Type: "union", Children: []*ExpandTree{
+		{Type: "leaf", Tuple: &RelationTuple{SubjectID: "user-1"}},
+		{Type: "union", Tuple: &RelationTuple{SubjectSet: &SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}}, Children: []*ExpandTree{
+			{Type: "leaf", Tuple: &RelationTuple{SubjectID: "user-2"}},
+			{Type: "leaf", Tuple: &RelationTuple{SubjectID: "user-1"}},
+		}},
+	}}
+	assert.Equal(t, []string{"user-1", "user-2"}, tree.Subjects())
+}
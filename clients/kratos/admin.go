@@ -0,0 +1,326 @@
+package kratos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/auth"
+)
+
+// Identity states
+const (
+	IdentityStateActive   = "active"
+	IdentityStateInactive = "inactive" // Disabled; cannot sign in
+)
+
+// Admin manages identities through the Kratos admin API. Client and
+// MockClient implement it, so admin handlers can depend on the interface.
+type Admin interface {
+	ListIdentities(ctx context.Context, query IdentityQuery) (*IdentityPage, error)
+	GetIdentity(ctx context.Context, id string) (*Identity, error)
+	CreateIdentity(ctx context.Context, params CreateIdentityParams) (*Identity, error)
+	UpdateIdentity(ctx context.Context, identity *Identity) (*Identity, error)
+	SetIdentityState(ctx context.Context, id, state string) (*Identity, error)
+	DisableIdentity(ctx context.Context, id string) (*Identity, error)
+	DeleteIdentity(ctx context.Context, id string) error
+	RevokeSessions(ctx context.Context, identityID string) error
+	CreateRecoveryLink(ctx context.Context, identityID string, expiresIn time.Duration) (*RecoveryLink, error)
+}
+
+// Identity is an identity as returned by the admin API
+type Identity struct {
+	ID             string         `json:"id"`
+	SchemaID       string         `json:"schema_id"`
+	State          string         `json:"state"`
+	Traits         map[string]any `json:"traits"`
+	MetadataPublic map[string]any `json:"metadata_public,omitempty"`
+	MetadataAdmin  map[string]any `json:"metadata_admin,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	VerifiableAddresses []VerifiableAddress `json:"verifiable_addresses,omitempty"`
+}
+
+// Active returns true unless the identity is disabled
+func (i *Identity) Active() bool {
+	return i.State != IdentityStateInactive
+}
+
+// AuthIdentity returns the identity as an auth.Identity
+func (i *Identity) AuthIdentity() *auth.Identity {
+	return &auth.Identity{ID: i.ID, Traits: i.Traits, VerifiedAddresses: verifiedAddresses(i.VerifiableAddresses)}
+}
+
+// IdentityQuery filters and pages ListIdentities
+type IdentityQuery struct {
+	PageSize   int    // Kratos' default if zero
+	PageToken  string // NextPageToken of the previous page
+	Identifier string // Only identities with this credential identifier, e.g. an email
+}
+
+// IdentityPage is a page of ListIdentities results
+type IdentityPage struct {
+	Identities    []*Identity
+	NextPageToken string // Empty on the last page
+}
+
+// CreateIdentityParams describes a new identity
+type CreateIdentityParams struct {
+	SchemaID       string
+	Traits         map[string]any
+	State          string // Active if empty
+	Password       string // Optional initial password
+	MetadataPublic map[string]any
+	MetadataAdmin  map[string]any
+}
+
+// RecoveryLink lets a user recover their account, e.g. to set a password
+type RecoveryLink struct {
+	URL       string    `json:"recovery_link"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Admin API errors
+var (
+	ErrIdentityNotFound = fmt.Errorf("identity not found")
+	ErrIdentityConflict = fmt.Errorf("identity already exists")
+)
+
+// ListIdentities returns a page of identities
+func (c *Client) ListIdentities(ctx context.Context, query IdentityQuery) (*IdentityPage, error) {
+	params := url.Values{}
+	if query.PageSize > 0 {
+		params.Set("page_size", fmt.Sprint(query.PageSize))
+	}
+	if query.PageToken != "" {
+		params.Set("page_token", query.PageToken)
+	}
+	if query.Identifier != "" {
+		params.Set("credentials_identifier", query.Identifier)
+	}
+
+	resp, err := c.admin(ctx, "GET", "/admin/identities?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &IdentityPage{NextPageToken: nextPageToken(resp.Header)}
+	if err := json.NewDecoder(resp.Body).Decode(&page.Identities); err != nil {
+		return nil, fmt.Errorf("failed to decode identities: %w", err)
+	}
+	return page, nil
+}
+
+// GetIdentity returns an identity by ID
+func (c *Client) GetIdentity(ctx context.Context, id string) (*Identity, error) {
+	resp, err := c.admin(ctx, "GET", "/admin/identities/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIdentity(resp)
+}
+
+// CreateIdentity creates an identity
+func (c *Client) CreateIdentity(ctx context.Context, params CreateIdentityParams) (*Identity, error) {
+	body := map[string]any{
+		"schema_id": params.SchemaID,
+		"traits":    params.Traits,
+	}
+	if params.State != "" {
+		body["state"] = params.State
+	}
+	if params.MetadataPublic != nil {
+		body["metadata_public"] = params.MetadataPublic
+	}
+	if params.MetadataAdmin != nil {
+		body["metadata_admin"] = params.MetadataAdmin
+	}
+	if params.Password != "" {
+		body["credentials"] = map[string]any{
+			"password": map[string]any{"config": map[string]string{"password": params.Password}},
+		}
+	}
+
+	resp, err := c.admin(ctx, "POST", "/admin/identities", body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIdentity(resp)
+}
+
+// UpdateIdentity replaces an identity's schema, traits, state and metadata.
+// Get the identity first and change the fields to update.
+func (c *Client) UpdateIdentity(ctx context.Context, identity *Identity) (*Identity, error) {
+	body := map[string]any{
+		"schema_id":       identity.SchemaID,
+		"traits":          identity.Traits,
+		"state":           identity.State,
+		"metadata_public": identity.MetadataPublic,
+		"metadata_admin":  identity.MetadataAdmin,
+	}
+
+	resp, err := c.admin(ctx, "PUT", "/admin/identities/"+url.PathEscape(identity.ID), body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIdentity(resp)
+}
+
+// SetIdentityState enables (IdentityStateActive) or disables
+// (IdentityStateInactive) an identity
+func (c *Client) SetIdentityState(ctx context.Context, id, state string) (*Identity, error) {
+	if state != IdentityStateActive && state != IdentityStateInactive {
+		return nil, fmt.Errorf("invalid identity state %q", state)
+	}
+	patch := []map[string]any{{"op": "replace", "path": "/state", "value": state}}
+
+	resp, err := c.admin(ctx, "PATCH", "/admin/identities/"+url.PathEscape(id), patch)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIdentity(resp)
+}
+
+// DisableIdentity disables an identity. Disabled identities cannot sign in.
+func (c *Client) DisableIdentity(ctx context.Context, id string) (*Identity, error) {
+	return c.SetIdentityState(ctx, id, IdentityStateInactive)
+}
+
+// DeleteIdentity deletes an identity and its credentials
+func (c *Client) DeleteIdentity(ctx context.Context, id string) error {
+	resp, err := c.admin(ctx, "DELETE", "/admin/identities/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RevokeSessions revokes all sessions of an identity
+func (c *Client) RevokeSessions(ctx context.Context, identityID string) error {
+	resp, err := c.admin(ctx, "DELETE", "/admin/identities/"+url.PathEscape(identityID)+"/sessions", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// CreateRecoveryLink creates an account recovery link for an identity. The
+// link expires after expiresIn, or Kratos' configured lifespan if zero.
+func (c *Client) CreateRecoveryLink(ctx context.Context, identityID string, expiresIn time.Duration) (*RecoveryLink, error) {
+	body := map[string]any{"identity_id": identityID}
+	if expiresIn > 0 {
+		body["expires_in"] = expiresIn.String()
+	}
+
+	resp, err := c.admin(ctx, "POST", "/admin/recovery/link", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var link RecoveryLink
+	if err := json.NewDecoder(resp.Body).Decode(&link); err != nil {
+		return nil, fmt.Errorf("failed to decode recovery link: %w", err)
+	}
+	return &link, nil
+}
+
+// admin sends an admin API request with an optional JSON body. Responses
+// other than 2xx are returned as errors.
+func (c *Client) admin(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	if c.config == nil || c.config.AdminURL == "" {
+		return nil, fmt.Errorf("kratos admin URL is not configured")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.AdminURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kratos admin request failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		// Only a path naming an identity means the identity is missing; a
+		// 404 anywhere else is a wrong admin URL or an unsupported endpoint
+		if strings.HasPrefix(path, "/admin/identities/") {
+			return nil, ErrIdentityNotFound
+		}
+	case http.StatusConflict:
+		return nil, ErrIdentityConflict
+	}
+	return nil, adminError(resp)
+}
+
+func decodeIdentity(resp *http.Response) (*Identity, error) {
+	defer resp.Body.Close()
+	var identity Identity
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return nil, fmt.Errorf("failed to decode identity: %w", err)
+	}
+	return &identity, nil
+}
+
+// adminError returns an error with Kratos' error message, if any
+func adminError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Reason  string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body) == nil && body.Error.Message != "" {
+		if body.Error.Reason != "" {
+			return fmt.Errorf("unexpected status: %d: %s: %s", resp.StatusCode, body.Error.Message, body.Error.Reason)
+		}
+		return fmt.Errorf("unexpected status: %d: %s", resp.StatusCode, body.Error.Message)
+	}
+	return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+}
+
+// nextPageToken returns the page_token of the rel="next" Link header
+func nextPageToken(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, _ := strings.Cut(link, ";")
+			if !strings.Contains(params, `rel="next"`) {
+				continue
+			}
+			u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+			if err != nil {
+				continue
+			}
+			return u.Query().Get("page_token")
+		}
+	}
+	return ""
+}
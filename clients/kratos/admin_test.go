@@ -0,0 +1,215 @@
+package kratos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+var (
+	_ Admin = (*Client)(nil)
+	_ Admin = (*MockClient)(nil)
+)
+
+const identityJSON = `{
+	"id": "id-1",
+	"schema_id": "default",
+	"state": "active",
+	"traits": {"email": "jane@example.com", "name": {"first": "Jane", "last": "Doe"}},
+	"metadata_admin": {"plan": "pro"},
+	"created_at": "2025-01-01T00:00:00Z",
+	"updated_at": "2025-01-02T00:00:00Z"
+}`
+
+func newAdminClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(&ClientConfig{PublicURL: "http://public.invalid", AdminURL: server.URL})
+}
+
+func TestClient_ListIdentities(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/admin/identities", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("page_size"))
+		assert.Equal(t, "jane@example.com", r.URL.Query().Get("credentials_identifier"))
+
+		w.Header().Add("Link", `</admin/identities?page_size=1&page_token=first>; rel="first",</admin/identities?page_size=1&page_token=abc>; rel="next"`)
+		w.Write([]byte("[" + identityJSON + "]"))
+	})
+
+	page, err := client.ListIdentities(context.Background(), IdentityQuery{PageSize: 1, Identifier: "jane@example.com"})
+	require.NoError(t, err)
+	require.Len(t, page.Identities, 1)
+	assert.Equal(t, "id-1", page.Identities[0].ID)
+	assert.Equal(t, "abc", page.NextPageToken)
+}
+
+func TestClient_GetIdentity(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/identities/id-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(identityJSON))
+	})
+
+	identity, err := client.GetIdentity(context.Background(), "id-1")
+	require.NoError(t, err)
+	assert.Equal(t, "pro", identity.MetadataAdmin["plan"])
+	assert.True(t, identity.Active())
+
+	authIdentity := identity.AuthIdentity()
+	assert.Equal(t, "id-1", authIdentity.ID)
+	assert.Equal(t, "jane@example.com", authIdentity.Email())
+	assert.Equal(t, "Jane Doe", authIdentity.Name())
+
+	_, err = client.GetIdentity(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrIdentityNotFound)
+	assert.Equal(t, http.StatusNotFound, errors.MapError(err).HTTPStatus)
+}
+
+func TestClient_AdminNotFound(t *testing.T) {
+	// A wrong admin URL answers 404 for every path
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx := context.Background()
+
+	assert.ErrorIs(t, client.DeleteIdentity(ctx, "id-1"), ErrIdentityNotFound)
+	assert.ErrorIs(t, client.RevokeSessions(ctx, "id-1"), ErrIdentityNotFound)
+
+	_, err := client.ListIdentities(ctx, IdentityQuery{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrIdentityNotFound)
+
+	_, err = client.CreateIdentity(ctx, CreateIdentityParams{SchemaID: "default", Traits: map[string]any{}})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrIdentityNotFound)
+
+	_, err = client.CreateRecoveryLink(ctx, "id-1", time.Hour)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrIdentityNotFound)
+	assert.Contains(t, err.Error(), "unexpected status: 404")
+}
+
+func TestClient_CreateIdentity(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "default", body["schema_id"])
+		assert.Equal(t, map[string]any{"password": map[string]any{"config": map[string]any{"password": "s3cret-pass"}}}, body["credentials"])
+		assert.NotContains(t, body, "state")
+
+		if body["traits"].(map[string]any)["email"] == "taken@example.com" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(identityJSON))
+	})
+
+	identity, err := client.CreateIdentity(context.Background(), CreateIdentityParams{
+		SchemaID: "default",
+		Traits:   map[string]any{"email": "jane@example.com"},
+		Password: "s3cret-pass",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "id-1", identity.ID)
+
+	_, err = client.CreateIdentity(context.Background(), CreateIdentityParams{
+		SchemaID: "default",
+		Traits:   map[string]any{"email": "taken@example.com"},
+		Password: "s3cret-pass",
+	})
+	assert.ErrorIs(t, err, ErrIdentityConflict)
+}
+
+func TestClient_UpdateIdentity(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/admin/identities/id-1", r.URL.Path)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "active", body["state"])
+		assert.Equal(t, "new@example.com", body["traits"].(map[string]any)["email"])
+		w.Write([]byte(identityJSON))
+	})
+
+	_, err := client.UpdateIdentity(context.Background(), &Identity{
+		ID:       "id-1",
+		SchemaID: "default",
+		State:    IdentityStateActive,
+		Traits:   map[string]any{"email": "new@example.com"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestClient_DisableIdentity(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		var patch []map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+		assert.Equal(t, []map[string]any{{"op": "replace", "path": "/state", "value": "inactive"}}, patch)
+		w.Write([]byte(identityJSON))
+	})
+
+	_, err := client.DisableIdentity(context.Background(), "id-1")
+	assert.NoError(t, err)
+
+	_, err = client.SetIdentityState(context.Background(), "id-1", "banned")
+	assert.Error(t, err)
+}
+
+func TestClient_DeleteIdentityAndRevokeSessions(t *testing.T) {
+	var paths []string
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	require.NoError(t, client.RevokeSessions(context.Background(), "id-1"))
+	require.NoError(t, client.DeleteIdentity(context.Background(), "id-1"))
+	assert.Equal(t, []string{"/admin/identities/id-1/sessions", "/admin/identities/id-1"}, paths)
+}
+
+func TestClient_CreateRecoveryLink(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/recovery/link", r.URL.Path)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "id-1", body["identity_id"])
+		assert.Equal(t, "1h0m0s", body["expires_in"])
+		w.Write([]byte(`{"recovery_link": "https://auth.example.com/recovery?token=abc", "expires_at": "2025-01-01T01:00:00Z"}`))
+	})
+
+	link, err := client.CreateRecoveryLink(context.Background(), "id-1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com/recovery?token=abc", link.URL)
+	assert.Equal(t, 2025, link.ExpiresAt.Year())
+}
+
+func TestClient_Admin_Errors(t *testing.T) {
+	client := newAdminClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "The request was malformed", "reason": "traits are invalid"}}`))
+	})
+
+	_, err := client.CreateIdentity(context.Background(), CreateIdentityParams{SchemaID: "default"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "traits are invalid")
+
+	_, err = NewClient(&ClientConfig{PublicURL: "http://public.invalid"}).GetIdentity(context.Background(), "id-1")
+	assert.ErrorContains(t, err, "admin URL is not configured")
+}
@@ -101,13 +101,26 @@ func (c *Client) ValidateSession(ctx context.Context, cookie string) (*auth.Iden
 	if cookie == "" {
 		return nil, ErrNoSession
 	}
+	return c.whoami(ctx, "Cookie", fmt.Sprintf("%s=%s", c.config.CookieName, cookie))
+}
+
+// ValidateSessionToken validates a session token, as issued to native apps
+// by the Kratos API flows, and returns the identity
+func (c *Client) ValidateSessionToken(ctx context.Context, token string) (*auth.Identity, error) {
+	if token == "" {
+		return nil, ErrNoSession
+	}
+	return c.whoami(ctx, "X-Session-Token", token)
+}
 
+// whoami resolves the session identified by a request header
+func (c *Client) whoami(ctx context.Context, header, value string) (*auth.Identity, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.config.PublicURL+"/sessions/whoami", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Cookie", fmt.Sprintf("%s=%s", c.config.CookieName, cookie))
+	req.Header.Set(header, value)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -136,6 +149,9 @@ func (c *Client) ValidateSession(ctx context.Context, cookie string) (*auth.Iden
 		ID:        session.Identity.ID,
 		SessionID: session.ID,
 		Traits:    session.Identity.Traits,
+		ExpiresAt: session.ExpiresAt,
+
+		VerifiedAddresses: verifiedAddresses(session.Identity.VerifiableAddresses),
 	}, nil
 }
 
@@ -151,6 +151,10 @@ func TestClient_ValidateSession(t *testing.T) {
 		session.Identity.Traits = map[string]any{
 			"email": "test@example.com",
 		}
+		session.Identity.VerifiableAddresses = []VerifiableAddress{
+			{Value: "test@example.com", Verified: true, Via: "email"},
+			{Value: "old@example.com", Verified: false, Via: "email"},
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(session)
@@ -169,6 +173,36 @@ func TestClient_ValidateSession(t *testing.T) {
 	assert.Equal(t, "user-456", identity.ID)
 	assert.Equal(t, "session-123", identity.SessionID)
 	assert.Equal(t, "test@example.com", identity.GetTraitString("email"))
+	assert.Equal(t, []string{"test@example.com"}, identity.VerifiedAddresses)
+	assert.True(t, identity.EmailVerified())
+}
+
+func TestClient_ValidateSessionToken(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sessions/whoami", r.URL.Path)
+		assert.Equal(t, "token-abc", r.Header.Get("X-Session-Token"))
+		assert.Empty(t, r.Header.Get("Cookie"))
+
+		session := Session{ID: "session-789", Active: true, ExpiresAt: expires}
+		session.Identity.ID = "user-456"
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(session)
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientConfig{PublicURL: server.URL, CookieName: "ory_kratos_session"})
+
+	identity, err := client.ValidateSessionToken(context.Background(), "token-abc")
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-456", identity.ID)
+	assert.Equal(t, "session-789", identity.SessionID)
+	assert.True(t, expires.Equal(identity.ExpiresAt))
+
+	_, err = client.ValidateSessionToken(context.Background(), "")
+	assert.ErrorIs(t, err, ErrNoSession)
 }
 
 func TestClient_ValidateSession_NoSession(t *testing.T) {
@@ -34,4 +34,20 @@ func init() {
 		LogLevel:   errors.LogLevelWarn,
 		Message:    "Session expired",
 	})
+
+	// ErrIdentityNotFound - Admin API identity lookup failed (404)
+	mapper.RegisterSentinel(ErrIdentityNotFound, errors.MappingSpec{
+		Code:       errors.CodeNotFound,
+		HTTPStatus: 404,
+		LogLevel:   errors.LogLevelWarn,
+		Message:    "Identity not found",
+	})
+
+	// ErrIdentityConflict - Identity with the same identifier exists (409)
+	mapper.RegisterSentinel(ErrIdentityConflict, errors.MappingSpec{
+		Code:       errors.CodeConflict,
+		HTTPStatus: 409,
+		LogLevel:   errors.LogLevelWarn,
+		Message:    "Identity already exists",
+	})
 }
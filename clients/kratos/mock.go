@@ -2,14 +2,27 @@ package kratos
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/codoworks/codo-framework/core/auth"
 )
 
-// MockClient is a mock Kratos client for testing
+// MockClient is a mock Kratos client for testing. Its admin API keeps
+// identities in memory.
 type MockClient struct {
-	ValidateFunc func(ctx context.Context, cookie string) (*auth.Identity, error)
-	HealthFunc   func() error
+	ValidateFunc      func(ctx context.Context, cookie string) (*auth.Identity, error)
+	ValidateTokenFunc func(ctx context.Context, token string) (*auth.Identity, error)
+	HealthFunc        func() error
+
+	mu         sync.Mutex
+	identities []*Identity
+	revoked    map[string]bool
 }
 
 // NewMockClient creates a new mock client
@@ -49,7 +62,224 @@ func (m *MockClient) ValidateSession(ctx context.Context, cookie string) (*auth.
 	return &auth.Identity{ID: "test-user"}, nil
 }
 
+// ValidateSessionToken validates a session token using the mock function
+func (m *MockClient) ValidateSessionToken(ctx context.Context, token string) (*auth.Identity, error) {
+	if m.ValidateTokenFunc != nil {
+		return m.ValidateTokenFunc(ctx, token)
+	}
+	return &auth.Identity{ID: "test-user"}, nil
+}
+
 // GetCookieName returns the session cookie name
 func (m *MockClient) GetCookieName() string {
 	return "ory_kratos_session"
 }
+
+// ListIdentities returns a page of the stored identities, in creation
+// order. Page tokens are offsets.
+func (m *MockClient) ListIdentities(ctx context.Context, query IdentityQuery) (*IdentityPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matches []*Identity
+	for _, identity := range m.identities {
+		if query.Identifier == "" || identifier(identity.Traits) == query.Identifier {
+			matches = append(matches, cloneIdentity(identity))
+		}
+	}
+
+	start := 0
+	if query.PageToken != "" {
+		var err error
+		if start, err = strconv.Atoi(query.PageToken); err != nil || start < 0 || start > len(matches) {
+			return nil, fmt.Errorf("invalid page token %q", query.PageToken)
+		}
+	}
+	page := &IdentityPage{Identities: matches[start:]}
+	if query.PageSize > 0 && len(page.Identities) > query.PageSize {
+		page.Identities = page.Identities[:query.PageSize]
+		page.NextPageToken = strconv.Itoa(start + query.PageSize)
+	}
+	return page, nil
+}
+
+// GetIdentity returns a stored identity
+func (m *MockClient) GetIdentity(ctx context.Context, id string) (*Identity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(id)
+	if i < 0 {
+		return nil, ErrIdentityNotFound
+	}
+	return cloneIdentity(m.identities[i]), nil
+}
+
+// CreateIdentity stores a new identity. Identities are unique by their
+// email or username trait.
+func (m *MockClient) CreateIdentity(ctx context.Context, params CreateIdentityParams) (*Identity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkUnique("", params.Traits); err != nil {
+		return nil, err
+	}
+
+	state := params.State
+	if state == "" {
+		state = IdentityStateActive
+	}
+	now := time.Now().UTC()
+	identity := &Identity{
+		ID:             uuid.NewString(),
+		SchemaID:       params.SchemaID,
+		State:          state,
+		Traits:         maps.Clone(params.Traits),
+		MetadataPublic: maps.Clone(params.MetadataPublic),
+		MetadataAdmin:  maps.Clone(params.MetadataAdmin),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	m.identities = append(m.identities, identity)
+	return cloneIdentity(identity), nil
+}
+
+// UpdateIdentity replaces a stored identity's schema, traits, state and
+// metadata
+func (m *MockClient) UpdateIdentity(ctx context.Context, identity *Identity) (*Identity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(identity.ID)
+	if i < 0 {
+		return nil, ErrIdentityNotFound
+	}
+	if err := m.checkUnique(identity.ID, identity.Traits); err != nil {
+		return nil, err
+	}
+
+	stored := m.identities[i]
+	stored.SchemaID = identity.SchemaID
+	stored.State = identity.State
+	stored.Traits = maps.Clone(identity.Traits)
+	stored.MetadataPublic = maps.Clone(identity.MetadataPublic)
+	stored.MetadataAdmin = maps.Clone(identity.MetadataAdmin)
+	stored.UpdatedAt = time.Now().UTC()
+	return cloneIdentity(stored), nil
+}
+
+// SetIdentityState enables or disables a stored identity
+func (m *MockClient) SetIdentityState(ctx context.Context, id, state string) (*Identity, error) {
+	if state != IdentityStateActive && state != IdentityStateInactive {
+		return nil, fmt.Errorf("invalid identity state %q", state)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(id)
+	if i < 0 {
+		return nil, ErrIdentityNotFound
+	}
+	m.identities[i].State = state
+	m.identities[i].UpdatedAt = time.Now().UTC()
+	return cloneIdentity(m.identities[i]), nil
+}
+
+// DisableIdentity disables a stored identity
+func (m *MockClient) DisableIdentity(ctx context.Context, id string) (*Identity, error) {
+	return m.SetIdentityState(ctx, id, IdentityStateInactive)
+}
+
+// DeleteIdentity removes a stored identity
+func (m *MockClient) DeleteIdentity(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(id)
+	if i < 0 {
+		return ErrIdentityNotFound
+	}
+	m.identities = append(m.identities[:i], m.identities[i+1:]...)
+	return nil
+}
+
+// RevokeSessions records that an identity's sessions were revoked
+func (m *MockClient) RevokeSessions(ctx context.Context, identityID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.find(identityID) < 0 {
+		return ErrIdentityNotFound
+	}
+	if m.revoked == nil {
+		m.revoked = make(map[string]bool)
+	}
+	m.revoked[identityID] = true
+	return nil
+}
+
+// SessionsRevoked reports whether RevokeSessions was called for an identity
+func (m *MockClient) SessionsRevoked(identityID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revoked[identityID]
+}
+
+// CreateRecoveryLink returns a fake recovery link for a stored identity
+func (m *MockClient) CreateRecoveryLink(ctx context.Context, identityID string, expiresIn time.Duration) (*RecoveryLink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.find(identityID) < 0 {
+		return nil, ErrIdentityNotFound
+	}
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	return &RecoveryLink{
+		URL:       "http://kratos.test/self-service/recovery?flow=" + uuid.NewString() + "&token=" + uuid.NewString(),
+		ExpiresAt: time.Now().UTC().Add(expiresIn),
+	}, nil
+}
+
+// find returns the index of an identity, or -1. Callers hold m.mu.
+func (m *MockClient) find(id string) int {
+	for i, identity := range m.identities {
+		if identity.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkUnique returns ErrIdentityConflict if another identity has the same
+// identifier. Callers hold m.mu.
+func (m *MockClient) checkUnique(id string, traits map[string]any) error {
+	ident := identifier(traits)
+	if ident == "" {
+		return nil
+	}
+	for _, identity := range m.identities {
+		if identity.ID != id && identifier(identity.Traits) == ident {
+			return ErrIdentityConflict
+		}
+	}
+	return nil
+}
+
+// identifier returns the email or username trait
+func identifier(traits map[string]any) string {
+	if email, ok := traits["email"].(string); ok && email != "" {
+		return email
+	}
+	username, _ := traits["username"].(string)
+	return username
+}
+
+func cloneIdentity(identity *Identity) *Identity {
+	clone := *identity
+	clone.Traits = maps.Clone(identity.Traits)
+	clone.MetadataPublic = maps.Clone(identity.MetadataPublic)
+	clone.MetadataAdmin = maps.Clone(identity.MetadataAdmin)
+	return &clone
+}
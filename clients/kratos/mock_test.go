@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/codoworks/codo-framework/core/auth"
 )
@@ -110,3 +112,62 @@ func TestMockClient_GetCookieName(t *testing.T) {
 	client := NewMockClient()
 	assert.Equal(t, "ory_kratos_session", client.GetCookieName())
 }
+
+func TestMockClient_Admin(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockClient()
+
+	jane, err := client.CreateIdentity(ctx, CreateIdentityParams{SchemaID: "default", Traits: map[string]any{"email": "jane@example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, IdentityStateActive, jane.State)
+	_, err = client.CreateIdentity(ctx, CreateIdentityParams{SchemaID: "default", Traits: map[string]any{"email": "jane@example.com"}})
+	assert.ErrorIs(t, err, ErrIdentityConflict)
+	john, err := client.CreateIdentity(ctx, CreateIdentityParams{SchemaID: "default", Traits: map[string]any{"email": "john@example.com"}})
+	require.NoError(t, err)
+
+	// Returned identities are copies
+	jane.Traits["email"] = "changed@example.com"
+	got, err := client.GetIdentity(ctx, jane.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", got.AuthIdentity().Email())
+
+	page, err := client.ListIdentities(ctx, IdentityQuery{PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Identities, 1)
+	assert.Equal(t, jane.ID, page.Identities[0].ID)
+	page, err = client.ListIdentities(ctx, IdentityQuery{PageSize: 1, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	assert.Equal(t, john.ID, page.Identities[0].ID)
+	assert.Empty(t, page.NextPageToken)
+	page, err = client.ListIdentities(ctx, IdentityQuery{Identifier: "john@example.com"})
+	require.NoError(t, err)
+	assert.Len(t, page.Identities, 1)
+
+	got.Traits["email"] = "john@example.com"
+	_, err = client.UpdateIdentity(ctx, got)
+	assert.ErrorIs(t, err, ErrIdentityConflict)
+	got.Traits["email"] = "jane.doe@example.com"
+	updated, err := client.UpdateIdentity(ctx, got)
+	require.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", updated.Traits["email"])
+
+	disabled, err := client.DisableIdentity(ctx, jane.ID)
+	require.NoError(t, err)
+	assert.False(t, disabled.Active())
+
+	assert.False(t, client.SessionsRevoked(jane.ID))
+	require.NoError(t, client.RevokeSessions(ctx, jane.ID))
+	assert.True(t, client.SessionsRevoked(jane.ID))
+
+	link, err := client.CreateRecoveryLink(ctx, jane.ID, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, link.URL)
+	assert.True(t, link.ExpiresAt.After(time.Now()))
+
+	require.NoError(t, client.DeleteIdentity(ctx, jane.ID))
+	_, err = client.GetIdentity(ctx, jane.ID)
+	assert.ErrorIs(t, err, ErrIdentityNotFound)
+	assert.ErrorIs(t, client.RevokeSessions(ctx, jane.ID), ErrIdentityNotFound)
+	_, err = client.CreateRecoveryLink(ctx, jane.ID, time.Hour)
+	assert.ErrorIs(t, err, ErrIdentityNotFound)
+}
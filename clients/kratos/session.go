@@ -10,11 +10,30 @@ type Session struct {
 	Active    bool      `json:"active"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Identity  struct {
-		ID     string         `json:"id"`
-		Traits map[string]any `json:"traits"`
+		ID                  string              `json:"id"`
+		Traits              map[string]any      `json:"traits"`
+		VerifiableAddresses []VerifiableAddress `json:"verifiable_addresses"`
 	} `json:"identity"`
 }
 
+// VerifiableAddress is an identity address Kratos can verify, e.g. an email
+type VerifiableAddress struct {
+	Value    string `json:"value"`
+	Verified bool   `json:"verified"`
+	Via      string `json:"via"`
+}
+
+// verifiedAddresses returns the values of the verified addresses
+func verifiedAddresses(addresses []VerifiableAddress) []string {
+	var verified []string
+	for _, addr := range addresses {
+		if addr.Verified {
+			verified = append(verified, addr.Value)
+		}
+	}
+	return verified
+}
+
 // IsExpired returns true if the session has expired
 func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
@@ -73,6 +73,37 @@ func (l *Logger) Name() string {
 
 // Initialize sets up the logger with configuration.
 func (l *Logger) Initialize(cfg any) error {
+	config, err := parseConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	l.apply(config)
+
+	// Set output
+	if config.Output != nil {
+		l.logger.SetOutput(config.Output)
+	}
+
+	return l.BaseClient.Initialize(cfg)
+}
+
+// Reload applies a new level and format. The output is kept.
+func (l *Logger) Reload(cfg any) error {
+	config, err := parseConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if l.config != nil {
+		config.Output = l.config.Output
+	}
+
+	l.apply(config)
+	return nil
+}
+
+// parseConfig converts a supported config value to a Config
+func parseConfig(cfg any) (*Config, error) {
 	config := DefaultConfig()
 
 	if cfg != nil {
@@ -89,10 +120,15 @@ func (l *Logger) Initialize(cfg any) error {
 				config.Format = Format(format)
 			}
 		default:
-			return fmt.Errorf("invalid config type: %T", cfg)
+			return nil, fmt.Errorf("invalid config type: %T", cfg)
 		}
 	}
 
+	return config, nil
+}
+
+// apply sets the level and format from config
+func (l *Logger) apply(config *Config) {
 	l.config = config
 
 	// Set level
@@ -108,13 +144,6 @@ func (l *Logger) Initialize(cfg any) error {
 	} else {
 		l.logger.SetFormatter(&logrus.TextFormatter{})
 	}
-
-	// Set output
-	if config.Output != nil {
-		l.logger.SetOutput(config.Output)
-	}
-
-	return l.BaseClient.Initialize(cfg)
 }
 
 // Health checks if the logger is healthy.
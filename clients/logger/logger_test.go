@@ -278,6 +278,22 @@ func TestLogger_SetLevel(t *testing.T) {
 	assert.Equal(t, LevelInfo, l.GetLevel())
 }
 
+func TestLogger_Reload(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New()
+	l.Initialize(&Config{Level: LevelInfo, Format: FormatJSON, Output: buf})
+
+	var _ clients.Reloadable = l
+	assert.NoError(t, l.Reload(map[string]any{"level": "debug", "format": "text"}))
+	assert.Equal(t, LevelDebug, l.GetLevel())
+
+	l.Debug("after reload")
+	assert.Contains(t, buf.String(), "msg=\"after reload\"")
+
+	assert.Error(t, l.Reload(42))
+	assert.Equal(t, LevelDebug, l.GetLevel())
+}
+
 func TestLogger_GetLevel(t *testing.T) {
 	l := New()
 	l.Initialize(&Config{Level: LevelWarn})
@@ -20,6 +20,9 @@ type MockClient struct {
 	sets    map[string]map[string]struct{}
 	healthy bool
 	closed  bool
+
+	// EvalFunc handles Eval calls; the mock has no Lua interpreter.
+	EvalFunc func(ctx context.Context, script string, keys []string, args ...any) (any, error)
 }
 
 type mockValue struct {
@@ -114,6 +117,29 @@ func (m *MockClient) Set(ctx context.Context, key string, value any, expiration
 	return nil
 }
 
+// SetNX sets a key-value pair only if the key does not exist.
+func (m *MockClient) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mv, exists := m.data[key]; exists {
+		if mv.expiration.IsZero() || time.Now().Before(mv.expiration) {
+			return false, nil
+		}
+	}
+
+	var exp time.Time
+	if expiration > 0 {
+		exp = time.Now().Add(expiration)
+	}
+
+	m.data[key] = mockValue{
+		value:      fmt.Sprintf("%v", value),
+		expiration: exp,
+	}
+	return true, nil
+}
+
 // Get retrieves a value.
 func (m *MockClient) Get(ctx context.Context, key string) (string, error) {
 	m.mu.RLock()
@@ -433,5 +459,13 @@ func (m *MockClient) SIsMember(ctx context.Context, key string, member any) (boo
 	return false, nil
 }
 
+// Eval delegates to EvalFunc, or fails if it is not set.
+func (m *MockClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	if m.EvalFunc == nil {
+		return nil, fmt.Errorf("mock redis does not support scripting; set EvalFunc")
+	}
+	return m.EvalFunc(ctx, script, keys, args...)
+}
+
 // Ensure MockClient implements RedisClient interface.
 var _ RedisClient = (*MockClient)(nil)
@@ -56,6 +56,10 @@ type RedisClient interface {
 	// Set sets a key-value pair with expiration.
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
 
+	// SetNX sets a key-value pair only if the key does not exist.
+	// Returns true if the key was set.
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
+
 	// Get retrieves a value by key.
 	Get(ctx context.Context, key string) (string, error)
 
@@ -118,6 +122,9 @@ type RedisClient interface {
 
 	// SIsMember checks if a value is a member of a set.
 	SIsMember(ctx context.Context, key string, member any) (bool, error)
+
+	// Eval runs a Lua script atomically.
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
 }
 
 // Client is the Redis client implementation.
@@ -218,6 +225,11 @@ func (c *Client) Set(ctx context.Context, key string, value any, expiration time
 	return c.client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX sets a key-value pair only if the key does not exist.
+func (c *Client) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 // Get retrieves a value by key.
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
@@ -322,3 +334,9 @@ func (c *Client) SRem(ctx context.Context, key string, members ...any) error {
 func (c *Client) SIsMember(ctx context.Context, key string, member any) (bool, error) {
 	return c.client.SIsMember(ctx, key, member).Result()
 }
+
+// Eval runs a Lua script atomically. The script is sent by SHA first and
+// loaded on a cache miss.
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return redis.NewScript(script).Run(ctx, c.client, keys, args...).Result()
+}
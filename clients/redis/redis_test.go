@@ -287,6 +287,31 @@ func TestMockClient_SetGet(t *testing.T) {
 	})
 }
 
+func TestMockClient_SetNX(t *testing.T) {
+	m := NewMock()
+	ctx := context.Background()
+
+	ok, err := m.SetNX(ctx, "lock", "a", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = m.SetNX(ctx, "lock", "b", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	val, _ := m.Get(ctx, "lock")
+	assert.Equal(t, "a", val)
+
+	t.Run("expired key can be set", func(t *testing.T) {
+		m.SetNX(ctx, "short", "a", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		ok, err := m.SetNX(ctx, "short", "b", 0)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
 func TestMockClient_Del(t *testing.T) {
 	m := NewMock()
 	ctx := context.Background()
@@ -590,3 +615,18 @@ func TestMockClient_DelAllTypes(t *testing.T) {
 	count, _ := m.Exists(ctx, "string", "hash", "list", "set")
 	assert.Equal(t, int64(0), count)
 }
+
+func TestMockClient_Eval(t *testing.T) {
+	m := NewMock()
+	ctx := context.Background()
+
+	_, err := m.Eval(ctx, "return 1", nil)
+	assert.Error(t, err)
+
+	m.EvalFunc = func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+		return int64(len(keys)), nil
+	}
+	res, err := m.Eval(ctx, "return #KEYS", []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res)
+}
@@ -0,0 +1,249 @@
+// Package storage provides an object storage client with local filesystem
+// and S3-compatible backends.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/clients"
+)
+
+const (
+	// ClientName is the name of the storage client.
+	ClientName = "storage"
+
+	// DefaultURLExpiry is the signed URL lifetime used when none is configured.
+	DefaultURLExpiry = 15 * time.Minute
+)
+
+// Backend drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+
+	// ErrInvalidKey is returned for empty keys or keys that escape the bucket root.
+	ErrInvalidKey = errors.New("storage: invalid object key")
+
+	// ErrSigningNotConfigured is returned by SignedURL when the backend cannot sign URLs.
+	ErrSigningNotConfigured = errors.New("storage: signed URLs are not configured")
+)
+
+// Object describes a stored object.
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+}
+
+// Backend is implemented by storage drivers.
+type Backend interface {
+	// Put streams r to key. size is -1 when the length is unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error)
+
+	// Get opens key for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+
+	// Stat returns object metadata without reading the content.
+	Stat(ctx context.Context, key string) (*Object, error)
+
+	// Delete removes key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL returns a time-limited download URL for key.
+	SignedURL(key string, expiry time.Duration) (string, error)
+
+	// Health checks that the backend is reachable.
+	Health(ctx context.Context) error
+}
+
+// StorageClient is the interface for storage operations.
+// Both Client and MockClient implement it.
+type StorageClient interface {
+	clients.Client
+
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(key string, expiry time.Duration) (string, error)
+
+	// Store streams r to key and returns the number of bytes written.
+	// It satisfies http.UploadStore for Context.StreamUpload.
+	Store(ctx context.Context, key string, r io.Reader, contentType string) (int64, error)
+}
+
+// Config holds storage client configuration.
+type Config struct {
+	Driver     string        // "local" or "s3"
+	SigningKey string        // HMAC key for local signed URLs
+	URLExpiry  time.Duration // Default signed URL lifetime
+	Local      LocalConfig
+	S3         S3Config
+}
+
+// Client is the storage client. It delegates to the configured Backend.
+type Client struct {
+	clients.BaseClient
+	backend   Backend
+	urlExpiry time.Duration
+}
+
+// New creates a new storage client for registration.
+func New() *Client {
+	return &Client{
+		BaseClient: clients.NewBaseClient(ClientName),
+		urlExpiry:  DefaultURLExpiry,
+	}
+}
+
+// NewClient creates a storage client around an existing backend.
+func NewClient(backend Backend) *Client {
+	c := New()
+	c.backend = backend
+	return c
+}
+
+// Name returns the client name.
+func (c *Client) Name() string {
+	return ClientName
+}
+
+// Initialize builds the backend from a *Config, or uses a Backend directly.
+func (c *Client) Initialize(cfg any) error {
+	switch v := cfg.(type) {
+	case *Config:
+		backend, err := newBackend(v)
+		if err != nil {
+			return err
+		}
+		c.backend = backend
+		if v.URLExpiry > 0 {
+			c.urlExpiry = v.URLExpiry
+		}
+	case Backend:
+		c.backend = v
+	case nil:
+		if c.backend == nil {
+			return fmt.Errorf("storage: configuration is required")
+		}
+	default:
+		return fmt.Errorf("storage: unsupported config type %T", cfg)
+	}
+	return c.BaseClient.Initialize(cfg)
+}
+
+func newBackend(cfg *Config) (Backend, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		return NewLocalBackend(cfg.Local, cfg.SigningKey)
+	case DriverS3:
+		return NewS3Backend(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+	}
+}
+
+// Health checks that the backend is reachable.
+func (c *Client) Health() error {
+	if c.backend == nil {
+		return fmt.Errorf("storage: not initialized")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return c.backend.Health(ctx)
+}
+
+// Shutdown releases the backend.
+func (c *Client) Shutdown() error {
+	if s3, ok := c.backend.(*S3Backend); ok {
+		s3.httpClient.CloseIdleConnections()
+	}
+	return c.BaseClient.Shutdown()
+}
+
+// Backend returns the underlying backend.
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+// Put streams r to key. size is -1 when the length is unknown.
+func (c *Client) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return c.backend.Put(ctx, key, r, size, contentType)
+}
+
+// Get opens key for reading. The caller must close the reader.
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, nil, err
+	}
+	return c.backend.Get(ctx, key)
+}
+
+// Stat returns object metadata.
+func (c *Client) Stat(ctx context.Context, key string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return c.backend.Stat(ctx, key)
+}
+
+// Delete removes key.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	return c.backend.Delete(ctx, key)
+}
+
+// SignedURL returns a download URL for key valid for expiry.
+// A zero expiry uses the configured default.
+func (c *Client) SignedURL(key string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if expiry <= 0 {
+		expiry = c.urlExpiry
+	}
+	return c.backend.SignedURL(key, expiry)
+}
+
+// Store streams r to key and returns the number of bytes written.
+func (c *Client) Store(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	obj, err := c.Put(ctx, key, r, -1, contentType)
+	if err != nil {
+		return 0, err
+	}
+	return obj.Size, nil
+}
+
+// ValidateKey rejects empty keys, absolute keys and keys containing ".." segments.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." {
+			return ErrInvalidKey
+		}
+	}
+	if path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	return nil
+}
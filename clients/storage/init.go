@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+func init() {
+	mapper := errors.GetMapper()
+
+	// ErrNotFound - Object does not exist (404)
+	mapper.RegisterSentinel(ErrNotFound, errors.MappingSpec{
+		Code:       errors.CodeNotFound,
+		HTTPStatus: 404,
+		LogLevel:   errors.LogLevelWarn,
+		Message:    "Object not found",
+	})
+
+	// ErrInvalidKey - Key is empty or escapes the storage root (400)
+	mapper.RegisterSentinel(ErrInvalidKey, errors.MappingSpec{
+		Code:       errors.CodeBadRequest,
+		HTTPStatus: 400,
+		LogLevel:   errors.LogLevelWarn,
+		Message:    "Invalid object key",
+	})
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalConfig configures the local filesystem backend.
+type LocalConfig struct {
+	Root    string // Directory objects are written to
+	BaseURL string // URL the backend's download handler is mounted at
+}
+
+// LocalBackend stores objects as files under a root directory.
+//
+// Signed URLs point at BaseURL and carry an expiry and HMAC signature.
+// LocalBackend implements http.Handler to serve them; mount it with the
+// BaseURL path stripped, e.g.
+//
+//	e.GET("/files/*", echo.WrapHandler(http.StripPrefix("/files", backend)))
+type LocalBackend struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	now        func() time.Time
+}
+
+// NewLocalBackend creates the root directory if needed and returns a backend.
+func NewLocalBackend(cfg LocalConfig, signingKey string) (*LocalBackend, error) {
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("storage: local root is required")
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("storage: resolve root: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: create root: %w", err)
+	}
+	return &LocalBackend{
+		root:       root,
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		signingKey: []byte(signingKey),
+		now:        time.Now,
+	}, nil
+}
+
+func (b *LocalBackend) path(key string) string {
+	return filepath.Join(b.root, filepath.FromSlash(key))
+}
+
+// Put writes r to a temporary file and renames it into place, so readers
+// never see a partial object.
+func (b *LocalBackend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	dest := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return nil, fmt.Errorf("storage: create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("storage: create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	n, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, fmt.Errorf("storage: wrote %d bytes, expected %d", n, size)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return nil, fmt.Errorf("storage: rename: %w", err)
+	}
+
+	if contentType == "" {
+		contentType = contentTypeForKey(key)
+	}
+	return &Object{Key: key, Size: n, ContentType: contentType, LastModified: b.now()}, nil
+}
+
+// Get opens the file for key.
+func (b *LocalBackend) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	f, err := os.Open(b.path(key))
+	if err != nil {
+		return nil, nil, localError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, localError(err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+	return f, localObject(key, info), nil
+}
+
+// Stat returns metadata for key.
+func (b *LocalBackend) Stat(ctx context.Context, key string) (*Object, error) {
+	info, err := os.Stat(b.path(key))
+	if err != nil {
+		return nil, localError(err)
+	}
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+	return localObject(key, info), nil
+}
+
+// Delete removes the file for key.
+func (b *LocalBackend) Delete(ctx context.Context, key string) error {
+	err := os.Remove(b.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL returns BaseURL/key with an expiry and HMAC signature.
+func (b *LocalBackend) SignedURL(key string, expiry time.Duration) (string, error) {
+	if b.baseURL == "" || len(b.signingKey) == 0 {
+		return "", ErrSigningNotConfigured
+	}
+	expires := b.now().Add(expiry).Unix()
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", b.sign(key, expires))
+	return b.baseURL + "/" + escapeKey(key) + "?" + q.Encode(), nil
+}
+
+// VerifySignature checks a signature produced by SignedURL.
+func (b *LocalBackend) VerifySignature(key string, expires int64, signature string) bool {
+	if len(b.signingKey) == 0 || b.now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(b.sign(key, expires)))
+}
+
+func (b *LocalBackend) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, b.signingKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves objects requested through signed URLs. The request path,
+// relative to BaseURL, is the object key. Responses are never content
+// sniffed, and types browsers could run as a page are sent as attachments.
+func (b *LocalBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || ValidateKey(key) != nil || !b.VerifySignature(key, expires, r.URL.Query().Get("signature")) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	f, obj, err := b.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !servedInline(obj.ContentType) {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	}
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(expires-b.now().Unix(), 0), 10))
+	http.ServeContent(w, r, path.Base(key), obj.LastModified, f.(io.ReadSeeker))
+}
+
+// Health checks that the root directory exists.
+func (b *LocalBackend) Health(ctx context.Context) error {
+	info, err := os.Stat(b.root)
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage: root %s is not a directory", b.root)
+	}
+	return nil
+}
+
+func localObject(key string, info fs.FileInfo) *Object {
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  contentTypeForKey(key),
+		LastModified: info.ModTime(),
+	}
+}
+
+func localError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// contentTypeForKey guesses a content type from the key's extension. The
+// local backend does not persist metadata.
+func contentTypeForKey(key string) string {
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// inlineContentTypes are the types ServeHTTP lets browsers display. Others,
+// including HTML and SVG which can run scripts, are sent as attachments.
+var inlineContentTypes = map[string]bool{
+	"application/pdf": true,
+	"audio/mpeg":      true,
+	"audio/ogg":       true,
+	"image/avif":      true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+}
+
+// servedInline reports whether contentType is in inlineContentTypes.
+func servedInline(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && inlineContentTypes[mediaType]
+}
+
+// escapeKey percent-encodes each segment of key, keeping the slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// contextReader stops a copy when the context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
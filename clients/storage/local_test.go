@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codohttp "github.com/codoworks/codo-framework/core/http"
+)
+
+func newLocalClient(t *testing.T) (*Client, *LocalBackend) {
+	t.Helper()
+	c := New()
+	require.NoError(t, c.Initialize(&Config{
+		Driver:     DriverLocal,
+		SigningKey: "secret",
+		Local:      LocalConfig{Root: t.TempDir(), BaseURL: "http://localhost/files/"},
+	}))
+	return c, c.Backend().(*LocalBackend)
+}
+
+func TestLocalBackend_PutGetDelete(t *testing.T) {
+	c, _ := newLocalClient(t)
+	ctx := context.Background()
+
+	obj, err := c.Put(ctx, "docs/readme.txt", strings.NewReader("hello"), 5, "text/plain")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+
+	r, obj, err := c.Get(ctx, "docs/readme.txt")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, "text/plain; charset=utf-8", obj.ContentType)
+
+	n, err := c.Store(ctx, "docs/stream.bin", strings.NewReader("streamed"), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), n)
+
+	require.NoError(t, c.Delete(ctx, "docs/readme.txt"))
+	require.NoError(t, c.Delete(ctx, "docs/readme.txt"), "deleting a missing object is not an error")
+
+	_, err = c.Stat(ctx, "docs/readme.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, c.Health())
+}
+
+func TestLocalBackend_SizeMismatchLeavesNoObject(t *testing.T) {
+	c, _ := newLocalClient(t)
+	ctx := context.Background()
+
+	_, err := c.Put(ctx, "short.txt", strings.NewReader("abc"), 10, "")
+	require.Error(t, err)
+
+	_, err = c.Stat(ctx, "short.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a.txt", "a/b/c.png", "avatars/123.jpg"} {
+		assert.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", "a\\b", "./a"} {
+		assert.ErrorIs(t, ValidateKey(key), ErrInvalidKey, key)
+	}
+}
+
+func TestLocalBackend_SignedURL(t *testing.T) {
+	c, backend := newLocalClient(t)
+	_, err := c.Put(context.Background(), "reports/q1 final.pdf", strings.NewReader("%PDF-1.4"), -1, "")
+	require.NoError(t, err)
+
+	signed, err := c.SignedURL("reports/q1 final.pdf", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "http://localhost/files/reports/q1%20final.pdf?"))
+
+	handler := http.StripPrefix("/files", backend)
+	serve := func(rawURL string) *httptest.ResponseRecorder {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+		return rec
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		rec := serve(signed)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "%PDF-1.4", rec.Body.String())
+		assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("tampered key", func(t *testing.T) {
+		rec := serve(strings.Replace(signed, "q1", "q2", 1))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expires := time.Now().Add(-time.Minute).Unix()
+		q := url.Values{
+			"expires":   {strconv.FormatInt(expires, 10)},
+			"signature": {backend.sign("reports/q1 final.pdf", expires)},
+		}
+		rec := serve("http://localhost/files/reports/q1%20final.pdf?" + q.Encode())
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		missing, err := c.SignedURL("reports/missing.pdf", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, serve(missing).Code)
+	})
+}
+
+func TestLocalBackend_ServeHTTPHeaders(t *testing.T) {
+	c, backend := newLocalClient(t)
+	serve := func(key string) *httptest.ResponseRecorder {
+		_, err := c.Put(context.Background(), key, strings.NewReader("<script>alert(1)</script>"), -1, "")
+		require.NoError(t, err)
+		signed, err := c.SignedURL(key, time.Minute)
+		require.NoError(t, err)
+		u, err := url.Parse(signed)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		http.StripPrefix("/files", backend).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	for _, key := range []string{"pages/x.html", "images/x.svg", "data/x"} {
+		rec := serve(key)
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), key)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;"), key)
+	}
+
+	rec := serve("notes/x.txt")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Empty(t, rec.Header().Get("Content-Disposition"), "plain text is served inline")
+}
+
+// An HTML page disguised as a PNG passes an image/* rule, but is stored and
+// served as the PNG it sniffs as
+func TestLocalBackend_ServesUploadAsSniffedType(t *testing.T) {
+	c, backend := newLocalClient(t)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "x.html")
+	require.NoError(t, err)
+	part.Write([]byte("\x89PNG\r\n\x1a\n<html><script>alert(1)</script></html>"))
+	require.NoError(t, w.Close())
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	ctx := &codohttp.Context{Context: echo.New().NewContext(req, httptest.NewRecorder())}
+
+	var form struct {
+		File codohttp.UploadedFile `form:"file" upload:"types=image/*"`
+	}
+	require.NoError(t, ctx.StreamUpload(&form, c))
+	assert.NotContains(t, form.File.Key, ".html")
+
+	signed, err := c.SignedURL(form.File.Key, time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	http.StripPrefix("/files", backend).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+}
+
+func TestLocalBackend_SignedURLNotConfigured(t *testing.T) {
+	backend, err := NewLocalBackend(LocalConfig{Root: t.TempDir()}, "")
+	require.NoError(t, err)
+
+	_, err = NewClient(backend).SignedURL("a.txt", 0)
+	assert.ErrorIs(t, err, ErrSigningNotConfigured)
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/clients"
+)
+
+// MockClient is an in-memory storage client for testing.
+type MockClient struct {
+	clients.BaseClient
+	mu      sync.RWMutex
+	objects map[string]mockObject
+	healthy bool
+
+	// PutErr, when set, is returned by Put and Store.
+	PutErr error
+}
+
+type mockObject struct {
+	data []byte
+	obj  Object
+}
+
+// NewMock creates a new mock storage client.
+func NewMock() *MockClient {
+	return &MockClient{
+		BaseClient: clients.NewBaseClient(ClientName),
+		objects:    make(map[string]mockObject),
+		healthy:    true,
+	}
+}
+
+// Name returns the client name.
+func (m *MockClient) Name() string {
+	return ClientName
+}
+
+// Initialize initializes the mock client.
+func (m *MockClient) Initialize(cfg any) error {
+	return m.BaseClient.Initialize(cfg)
+}
+
+// Health checks if the mock client is healthy.
+func (m *MockClient) Health() error {
+	if !m.healthy {
+		return fmt.Errorf("mock storage is unhealthy")
+	}
+	return nil
+}
+
+// Shutdown shuts down the mock client.
+func (m *MockClient) Shutdown() error {
+	return m.BaseClient.Shutdown()
+}
+
+// SetHealthy sets the health status.
+func (m *MockClient) SetHealthy(healthy bool) {
+	m.healthy = healthy
+}
+
+// Reset removes all objects.
+func (m *MockClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects = make(map[string]mockObject)
+	m.PutErr = nil
+}
+
+// Keys returns the stored keys in sorted order.
+func (m *MockClient) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.objects))
+	for k := range m.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Data returns the content stored at key.
+func (m *MockClient) Data(key string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.objects[key]
+	return o.data, ok
+}
+
+// Put stores the content of r at key.
+func (m *MockClient) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	if m.PutErr != nil {
+		return nil, m.PutErr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return nil, fmt.Errorf("storage: wrote %d bytes, expected %d", len(data), size)
+	}
+
+	obj := Object{Key: key, Size: int64(len(data)), ContentType: contentType, LastModified: time.Now()}
+	m.mu.Lock()
+	m.objects[key] = mockObject{data: data, obj: obj}
+	m.mu.Unlock()
+	return &obj, nil
+}
+
+// Get returns a reader for key.
+func (m *MockClient) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.objects[key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	obj := o.obj
+	return io.NopCloser(bytes.NewReader(o.data)), &obj, nil
+}
+
+// Stat returns metadata for key.
+func (m *MockClient) Stat(ctx context.Context, key string) (*Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	obj := o.obj
+	return &obj, nil
+}
+
+// Delete removes key.
+func (m *MockClient) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// SignedURL returns a fake URL of the form mock://storage/<key>?expires=<unix>.
+func (m *MockClient) SignedURL(key string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if expiry <= 0 {
+		expiry = DefaultURLExpiry
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	return "mock://storage/" + escapeKey(key) + "?" + url.Values{"expires": {expires}}.Encode(), nil
+}
+
+// Store stores the content of r at key.
+func (m *MockClient) Store(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	obj, err := m.Put(ctx, key, r, -1, contentType)
+	if err != nil {
+		return 0, err
+	}
+	return obj.Size, nil
+}
+
+// Verify interface compliance
+var (
+	_ StorageClient = (*Client)(nil)
+	_ StorageClient = (*MockClient)(nil)
+)
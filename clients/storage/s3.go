@@ -0,0 +1,545 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPartSize is the multipart upload part size (S3 minimum is 5 MiB).
+	DefaultPartSize = 8 << 20
+
+	minPartSize      = 5 << 20
+	maxPresignExpiry = 7 * 24 * time.Hour
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+)
+
+// S3Config configures the S3-compatible backend.
+type S3Config struct {
+	Endpoint  string // e.g. "https://s3.amazonaws.com" or "http://minio:9000"
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool          // endpoint/bucket/key instead of bucket.endpoint/key
+	PartSize  int64         // Multipart part size (default: DefaultPartSize)
+	Timeout   time.Duration // Per-request timeout, 0 means none (uploads may be long)
+}
+
+// S3Backend talks to S3-compatible object stores using Signature Version 4.
+//
+// Uploads of unknown or large size use the multipart upload API, buffering
+// one part at a time, so memory use is bounded by PartSize.
+type S3Backend struct {
+	config     S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Backend returns an S3 backend for cfg.
+func NewS3Backend(cfg S3Config) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("storage: s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < minPartSize {
+		return nil, fmt.Errorf("storage: s3 part size must be at least %d bytes", minPartSize)
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	endpoint.Path = strings.TrimRight(endpoint.Path, "/")
+
+	return &S3Backend{
+		config:     cfg,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		now:        time.Now,
+	}, nil
+}
+
+// objectURL returns the URL for key, or the bucket URL when key is empty.
+func (b *S3Backend) objectURL(key string, query url.Values) *url.URL {
+	u := *b.endpoint
+	p := u.Path
+	if b.config.PathStyle {
+		p += "/" + b.config.Bucket
+	} else {
+		u.Host = b.config.Bucket + "." + u.Host
+	}
+	p += "/" + key
+	u.Path = p
+	u.RawPath = uriEncode(p, false)
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return &u
+}
+
+// Put uploads r to key, using a single PUT when the object fits in one part
+// and the multipart upload API otherwise.
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if size >= 0 && size <= b.config.PartSize {
+		return b.putObject(ctx, key, io.LimitReader(r, size), size, contentType)
+	}
+
+	first, err := readPart(r, b.config.PartSize)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(first)) < b.config.PartSize {
+		return b.putObject(ctx, key, bytes.NewReader(first), int64(len(first)), contentType)
+	}
+	return b.multipartUpload(ctx, key, first, r, contentType)
+}
+
+func (b *S3Backend) putObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) (*Object, error) {
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := b.newRequest(ctx, http.MethodPut, key, nil, body, unsignedPayload)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := b.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return &Object{
+		Key:          key,
+		Size:         size,
+		ContentType:  contentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: b.now(),
+	}, nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (b *S3Backend) multipartUpload(ctx context.Context, key string, first []byte, rest io.Reader, contentType string) (*Object, error) {
+	uploadID, err := b.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []completedPart
+	var total int64
+	part := first
+	for len(part) > 0 {
+		etag, err := b.uploadPart(ctx, key, uploadID, len(parts)+1, part)
+		if err != nil {
+			b.abortMultipartUpload(key, uploadID)
+			return nil, err
+		}
+		parts = append(parts, completedPart{PartNumber: len(parts) + 1, ETag: etag})
+		total += int64(len(part))
+
+		if int64(len(part)) < b.config.PartSize {
+			break
+		}
+		if part, err = readPart(rest, b.config.PartSize); err != nil {
+			b.abortMultipartUpload(key, uploadID)
+			return nil, err
+		}
+	}
+
+	etag, err := b.completeMultipartUpload(ctx, key, uploadID, parts)
+	if err != nil {
+		b.abortMultipartUpload(key, uploadID)
+		return nil, err
+	}
+	return &Object{Key: key, Size: total, ContentType: contentType, ETag: etag, LastModified: b.now()}, nil
+}
+
+func (b *S3Backend) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	req, err := b.newRequest(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, "")
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := b.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("storage: s3 create multipart upload: invalid response")
+	}
+	return result.UploadID, nil
+}
+
+func (b *S3Backend) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	req, err := b.newRequest(ctx, http.MethodPut, key, query, bytes.NewReader(data), unsignedPayload)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+
+	resp, err := b.do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (b *S3Backend) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) (string, error) {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := b.newRequest(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), "")
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(body))
+
+	resp, err := b.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// S3 may report a failure with a 200 status once the body is read
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if s3Err := parseS3Error(data); s3Err != nil {
+		return "", s3Err
+	}
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	_ = xml.Unmarshal(data, &result)
+	return strings.Trim(result.ETag, `"`), nil
+}
+
+// abortMultipartUpload releases stored parts. It runs detached from the
+// request context, which may already be cancelled.
+func (b *S3Backend) abortMultipartUpload(key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := b.newRequest(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, "")
+	if err != nil {
+		return
+	}
+	if resp, err := b.do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// Get downloads key.
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	req, err := b.newRequest(ctx, http.MethodGet, key, nil, nil, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s3Object(key, resp), nil
+}
+
+// Stat returns metadata for key with a HEAD request.
+func (b *S3Backend) Stat(ctx context.Context, key string) (*Object, error) {
+	req, err := b.newRequest(ctx, http.MethodHead, key, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s3Object(key, resp), nil
+}
+
+// Delete removes key.
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, key, nil, nil, "")
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET URL (query-string SigV4) valid for expiry.
+// S3 caps presigned URLs at seven days.
+func (b *S3Backend) SignedURL(key string, expiry time.Duration) (string, error) {
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+	return b.presign(http.MethodGet, key, expiry, b.now().UTC()), nil
+}
+
+func (b *S3Backend) presign(method, key string, expiry time.Duration, now time.Time) string {
+	amzDate := now.Format(amzDateFormat)
+	scope := b.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", b.config.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	u := b.objectURL(key, query)
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	u.RawQuery += "&X-Amz-Signature=" + b.signature(now, amzDate, scope, canonical)
+	return u.String()
+}
+
+// Health checks that the bucket is reachable.
+func (b *S3Backend) Health(ctx context.Context) error {
+	req, err := b.newRequest(ctx, http.MethodHead, "", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// newRequest builds a request signed with the Authorization header.
+// payloadHash is the hex SHA-256 of body, UNSIGNED-PAYLOAD, or empty to
+// hash a nil body.
+func (b *S3Backend) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader, payloadHash string) (*http.Request, error) {
+	u := b.objectURL(key, query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if payloadHash == "" {
+		payloadHash = emptyPayloadHash
+		if rs, ok := body.(io.ReadSeeker); ok {
+			h := sha256.New()
+			if _, err := io.Copy(h, rs); err != nil {
+				return nil, err
+			}
+			if _, err := rs.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			payloadHash = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	b.sign(req, payloadHash, b.now().UTC())
+	return req, nil
+}
+
+var emptyPayloadHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// sign adds SigV4 Authorization headers, signing host and the x-amz-* headers.
+func (b *S3Backend) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	scope := b.scope(now)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, b.config.AccessKey, scope, signedHeaders, b.signature(now, amzDate, scope, canonical)))
+}
+
+func (b *S3Backend) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + b.config.Region + "/s3/aws4_request"
+}
+
+func (b *S3Backend) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+b.config.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, b.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// do sends req and converts error responses.
+func (b *S3Backend) do(req *http.Request) (*http.Response, error) {
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 %s: %w", req.Method, err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if s3Err := parseS3Error(data); s3Err != nil {
+		return nil, s3Err
+	}
+	return nil, fmt.Errorf("storage: s3 %s: status %d", req.Method, resp.StatusCode)
+}
+
+// S3Error is an error response returned by the object store.
+type S3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("storage: s3 %s: %s", e.Code, e.Message)
+}
+
+func parseS3Error(data []byte) error {
+	if !bytes.Contains(data, []byte("<Error>")) {
+		return nil
+	}
+	var s3Err S3Error
+	if err := xml.Unmarshal(data, &s3Err); err != nil || s3Err.Code == "" {
+		return nil
+	}
+	if s3Err.Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return &s3Err
+}
+
+func s3Object(key string, resp *http.Response) *Object {
+	obj := &Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = t
+	}
+	return obj
+}
+
+// readPart reads up to size bytes, returning fewer only at EOF.
+func readPart(r io.Reader, size int64) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// canonicalQuery encodes query sorted by key with SigV4 escaping.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes everything except RFC 3986 unreserved characters, and
+// slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
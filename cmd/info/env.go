@@ -141,6 +141,7 @@ func printServerConfig(out io.Writer, cfg *config.Config) {
 	fmt.Fprintf(out, "Write Timeout:    %s\n", cfg.Server.WriteTimeout.Duration())
 	fmt.Fprintf(out, "Idle Timeout:     %s\n", cfg.Server.IdleTimeout.Duration())
 	fmt.Fprintf(out, "Shutdown Grace:   %s\n", cfg.Server.ShutdownGrace.Duration())
+	fmt.Fprintf(out, "Pre-Stop Delay:   %s\n", cfg.Server.PreStopDelay.Duration())
 	fmt.Fprintln(out)
 }
 
@@ -227,7 +228,7 @@ func printMiddlewareConfig(out io.Writer, cfg *config.Config) {
 	fmt.Fprintf(out, "Timeout:          %s (%s)\n", enabledStr(cfg.Middleware.Timeout.Enabled), cfg.Middleware.Timeout.Duration)
 	fmt.Fprintf(out, "Recover:          %s\n", enabledStr(cfg.Middleware.Recover.Enabled))
 	fmt.Fprintf(out, "Gzip:             %s (level %d)\n", enabledStr(cfg.Middleware.Gzip.Enabled), cfg.Middleware.Gzip.Level)
-	fmt.Fprintf(out, "XSS:              %s\n", enabledStr(cfg.Middleware.XSS.Enabled))
+	fmt.Fprintf(out, "Security Headers: %s\n", enabledStr(cfg.Middleware.SecurityHeaders.Enabled))
 	fmt.Fprintf(out, "Auth:             %s\n", enabledStr(cfg.Middleware.Auth.Enabled))
 	fmt.Fprintf(out, "Health:           %s\n", enabledStr(cfg.Middleware.Health.Enabled))
 	fmt.Fprintln(out)
@@ -12,6 +12,7 @@ import (
 	"github.com/codoworks/codo-framework/cmd"
 	"github.com/codoworks/codo-framework/core/app"
 	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
 )
 
 var scopeFilter string
@@ -19,7 +20,7 @@ var scopeFilter string
 var routesCmd = &cobra.Command{
 	Use:   "routes",
 	Short: "Show registered routes",
-	Long:  "Display all registered HTTP handlers and their routes",
+	Long:  "Display all registered HTTP handlers, their routes and each route's effective middleware chain",
 	RunE: func(c *cobra.Command, args []string) error {
 		cfg := cmd.GetConfig()
 
@@ -49,6 +50,7 @@ var routesCmd = &cobra.Command{
 			return fmt.Errorf("expected HTTPApp, got %T", application)
 		}
 		server := httpApp.Server()
+		orchestrator := httpApp.Middleware()
 
 		out := cmd.GetOutput()
 		httpHandlers := http.AllHandlers()
@@ -72,8 +74,10 @@ var routesCmd = &cobra.Command{
 
 		// For each handler, show prefix and detailed routes
 		for _, h := range httpHandlers {
+			prefix := http.MountPrefix(h)
+
 			// Print handler prefix
-			fmt.Fprintf(out, "%-12s %-40s\n", h.Scope(), h.Prefix())
+			fmt.Fprintf(out, "%-12s %-40s\n", h.Scope(), prefix)
 
 			// Get router for this scope
 			router := server.Router(h.Scope())
@@ -87,7 +91,7 @@ var routesCmd = &cobra.Command{
 			// Filter routes that belong to this handler (match prefix)
 			var handlerRoutes []*echo.Route
 			for _, route := range routes {
-				if strings.HasPrefix(route.Path, h.Prefix()) {
+				if strings.HasPrefix(route.Path, prefix) {
 					handlerRoutes = append(handlerRoutes, route)
 				}
 			}
@@ -102,6 +106,10 @@ var routesCmd = &cobra.Command{
 					continue
 				}
 				fmt.Fprintf(out, "             %-7s %s\n", route.Method, route.Path)
+				if orchestrator != nil {
+					chain := orchestrator.Chain(router, middleware.RouterFor(h.Scope()), route.Method, route.Path)
+					fmt.Fprintf(out, "                     %s\n", formatChain(chain))
+				}
 			}
 
 			fmt.Fprintln(out) // Blank line between handlers
@@ -116,6 +124,35 @@ func ResetRoutesFlags() {
 	scopeFilter = ""
 }
 
+// formatChain renders a route's middleware chain in execution order, marking
+// middleware added or reconfigured by handlers and route rules
+func formatChain(chain *middleware.RouteChain) string {
+	parts := make([]string, 0, len(chain.Middleware))
+	for _, m := range chain.Middleware {
+		var tags []string
+		if m.Source != middleware.SourceRouter {
+			tags = append(tags, m.Source)
+		}
+		if m.Overridden {
+			tags = append(tags, "override")
+		}
+		part := m.Middleware.Name()
+		if len(tags) > 0 {
+			part += "(" + strings.Join(tags, ",") + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	line := "middleware: " + strings.Join(parts, " > ")
+	if len(parts) == 0 {
+		line = "middleware: (none)"
+	}
+	if len(chain.Missing) > 0 {
+		line += "  MISSING: " + strings.Join(chain.Missing, ", ")
+	}
+	return line
+}
+
 // sortRoutes sorts routes by path (primary) and method (secondary)
 func sortRoutes(routes []*echo.Route) {
 	sort.Slice(routes, func(i, j int) bool {
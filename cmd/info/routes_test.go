@@ -11,6 +11,7 @@ import (
 	"github.com/codoworks/codo-framework/cmd"
 	"github.com/codoworks/codo-framework/core/config"
 	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
 )
 
 // mockHandler is a test handler implementation
@@ -176,3 +177,22 @@ func TestResetRoutesFlags(t *testing.T) {
 	ResetRoutesFlags()
 	assert.Equal(t, "", scopeFilter)
 }
+
+func TestFormatChain(t *testing.T) {
+	step := func(name, source string, overridden bool) middleware.RouteMiddleware {
+		m := middleware.NewBaseMiddleware(name, "", 0, middleware.RouterAll)
+		return middleware.RouteMiddleware{Middleware: &m, Source: source, Overridden: overridden}
+	}
+
+	chain := &middleware.RouteChain{
+		Middleware: []middleware.RouteMiddleware{
+			step("recover", middleware.SourceRouter, false),
+			step("auth", middleware.SourceHandler, false),
+			step("timeout", middleware.SourceConfig, true),
+		},
+	}
+	assert.Equal(t, "middleware: recover > auth(handler) > timeout(config,override)", formatChain(chain))
+
+	chain = &middleware.RouteChain{Missing: []string{"csrf"}}
+	assert.Equal(t, "middleware: (none)  MISSING: csrf", formatChain(chain))
+}
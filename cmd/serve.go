@@ -75,7 +75,7 @@ var serveCmd = &cobra.Command{
 		<-ctx.Done()
 
 		log.Info("Shutting down...")
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownGrace.Duration())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout())
 		defer cancel()
 
 		// Shutdown app (which handles server + clients)
@@ -80,7 +80,7 @@ var publicCmd = &cobra.Command{
 		<-ctx.Done()
 
 		log.Info("Shutting down...")
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownGrace.Duration())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout())
 		defer cancel()
 
 		// Shutdown app (which handles router + clients)
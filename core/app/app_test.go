@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/codoworks/codo-framework/clients/keto"
+	"github.com/codoworks/codo-framework/core/clients"
 	"github.com/codoworks/codo-framework/core/config"
 	"github.com/codoworks/codo-framework/core/metadata"
 )
@@ -49,3 +51,19 @@ func TestGetMetadata_WithoutProvider(t *testing.T) {
 	assert.Equal(t, "Codo Framework CLI", meta.Short())
 	assert.Equal(t, "Codo Framework - A production-ready Go backend framework", meta.Long())
 }
+
+func TestFrameworkClientConfigs_Keto(t *testing.T) {
+	clients.ResetRegistry()
+	t.Cleanup(clients.ResetRegistry)
+
+	// An app's own Keto client keeps its settings
+	clients.MustRegister(keto.NewClient(&keto.ClientConfig{ReadURL: "http://keto:4466"}))
+	cfg := config.NewWithDefaults()
+	assert.False(t, cfg.Auth.KetoConfigured())
+	assert.NotContains(t, frameworkClientConfigs(cfg), keto.ClientName)
+
+	cfg.Auth.KetoReadURL = "http://keto.internal:4466"
+	ketoCfg, ok := frameworkClientConfigs(cfg)[keto.ClientName].(*keto.ClientConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "http://keto.internal:4466", ketoCfg.ReadURL)
+}
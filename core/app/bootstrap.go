@@ -3,9 +3,11 @@ package app
 import (
 	"time"
 
+	"github.com/codoworks/codo-framework/clients/keto"
 	"github.com/codoworks/codo-framework/clients/kratos"
 	"github.com/codoworks/codo-framework/clients/logger"
 	"github.com/codoworks/codo-framework/clients/rabbitmq"
+	"github.com/codoworks/codo-framework/clients/storage"
 	"github.com/codoworks/codo-framework/core/clients"
 	"github.com/codoworks/codo-framework/core/config"
 	"github.com/codoworks/codo-framework/core/db"
@@ -15,14 +17,19 @@ import (
 
 	// Import middleware packages to trigger auto-registration
 	_ "github.com/codoworks/codo-framework/core/middleware/auth"
+	_ "github.com/codoworks/codo-framework/core/middleware/bodylimit"
+	_ "github.com/codoworks/codo-framework/core/middleware/cache"
 	_ "github.com/codoworks/codo-framework/core/middleware/cors"
+	_ "github.com/codoworks/codo-framework/core/middleware/csrf"
 	_ "github.com/codoworks/codo-framework/core/middleware/gzip"
+	_ "github.com/codoworks/codo-framework/core/middleware/idempotency"
 	_ "github.com/codoworks/codo-framework/core/middleware/logger"
 	_ "github.com/codoworks/codo-framework/core/middleware/pagination"
+	_ "github.com/codoworks/codo-framework/core/middleware/ratelimit"
 	_ "github.com/codoworks/codo-framework/core/middleware/recover"
 	_ "github.com/codoworks/codo-framework/core/middleware/requestid"
+	_ "github.com/codoworks/codo-framework/core/middleware/securityheaders"
 	_ "github.com/codoworks/codo-framework/core/middleware/timeout"
-	_ "github.com/codoworks/codo-framework/core/middleware/xss"
 )
 
 // Bootstrap initializes an application based on the specified mode
@@ -128,11 +135,46 @@ func registerFrameworkClients(cfg *config.Config) error {
 		log.Warn("Kratos client disabled via feature toggle")
 	}
 
+	// Register Keto only when its URLs are configured and the app has not
+	// registered its own client
+	if cfg.Features.IsEnabled(config.FeatureKeto) {
+		if cfg.Auth.KetoConfigured() && !clients.Has(keto.ClientName) {
+			clients.MustRegister(keto.New())
+			log.Info("Keto client registered")
+		}
+	} else if cfg.Auth.KetoConfigured() {
+		log.Warnf("Keto configuration detected (URL set) but feature is disabled")
+	}
+
+	// Conditionally register storage when a driver is configured
+	if cfg.Features.IsEnabled(config.FeatureStorage) {
+		if cfg.Storage.IsEnabled() {
+			clients.MustRegister(storage.New())
+			log.Infof("Storage client registered (driver: %s)", cfg.Storage.Driver)
+		}
+	} else if cfg.Storage.IsEnabled() {
+		log.Warnf("Storage configuration detected (driver set) but feature is disabled")
+	}
+
 	// Always register database (required)
 	dbClient := db.NewClient(nil)
 	clients.MustRegister(dbClient)
 
 	// Build configs only for registered clients
+	clientConfigs := frameworkClientConfigs(cfg)
+
+	// Initialize with enhanced error handling
+	if err := initializeClients(clientConfigs, log, cfg.Startup); err != nil {
+		return errors.WrapInternal(err, "Failed to initialize clients").
+			WithPhase(errors.PhaseClient)
+	}
+
+	return nil
+}
+
+// frameworkClientConfigs builds the config of each registered framework
+// client from the framework config
+func frameworkClientConfigs(cfg *config.Config) map[string]any {
 	clientConfigs := make(map[string]any)
 	clientConfigs["logger"] = map[string]any{
 		"level":  cfg.Logger.Level,
@@ -161,13 +203,38 @@ func registerFrameworkClients(cfg *config.Config) error {
 		}
 	}
 
-	// Initialize with enhanced error handling
-	if err := clients.InitializeAllWithMetadata(clientConfigs, log); err != nil {
-		return errors.WrapInternal(err, "Failed to initialize clients").
-			WithPhase(errors.PhaseClient)
+	// Only add Keto config if registered and configured; an app's own client
+	// keeps its settings
+	if clients.Has(keto.ClientName) && cfg.Auth.KetoConfigured() {
+		clientConfigs[keto.ClientName] = &keto.ClientConfig{
+			ReadURL:  cfg.Auth.KetoReadURL,
+			WriteURL: cfg.Auth.KetoWriteURL,
+			Timeout:  10 * time.Second,
+		}
 	}
 
-	return nil
+	// Only add storage config if registered
+	if clients.Has(storage.ClientName) {
+		clientConfigs[storage.ClientName] = &storage.Config{
+			Driver:     cfg.Storage.Driver,
+			SigningKey: cfg.Storage.SigningKey,
+			URLExpiry:  cfg.Storage.URLExpiry.Duration(),
+			Local: storage.LocalConfig{
+				Root:    cfg.Storage.Local.Root,
+				BaseURL: cfg.Storage.Local.BaseURL,
+			},
+			S3: storage.S3Config{
+				Endpoint:  cfg.Storage.S3.Endpoint,
+				Region:    cfg.Storage.S3.Region,
+				Bucket:    cfg.Storage.S3.Bucket,
+				AccessKey: cfg.Storage.S3.AccessKey,
+				SecretKey: cfg.Storage.S3.SecretKey,
+				PathStyle: cfg.Storage.S3.PathStyle,
+			},
+		}
+	}
+
+	return clientConfigs
 }
 
 // registerFrameworkClientMetadata registers metadata for all framework clients
@@ -189,6 +256,18 @@ func registerFrameworkClientMetadata() {
 		FeatureName: config.FeatureKratos,
 	})
 
+	clients.RegisterMetadata(clients.ClientMetadata{
+		Name:        keto.ClientName,
+		Requirement: clients.ClientOptional,
+		FeatureName: config.FeatureKeto,
+	})
+
+	clients.RegisterMetadata(clients.ClientMetadata{
+		Name:        storage.ClientName,
+		Requirement: clients.ClientOptional,
+		FeatureName: config.FeatureStorage,
+	})
+
 	clients.RegisterMetadata(clients.ClientMetadata{
 		Name:        "db",
 		Requirement: clients.ClientRequired,
@@ -210,7 +289,7 @@ func getOrCreateLogger() *logger.Logger {
 }
 
 // initializeMiddleware initializes and applies middleware to all routers
-func initializeMiddleware(server *http.Server, cfg *config.Config) error {
+func initializeMiddleware(server *http.Server, cfg *config.Config) (*middleware.Orchestrator, error) {
 	// Import middleware packages to trigger auto-registration
 	// Note: This is handled by blank imports at the top of the file
 
@@ -219,7 +298,7 @@ func initializeMiddleware(server *http.Server, cfg *config.Config) error {
 
 	// Initialize all registered middleware
 	if err := orchestrator.Initialize(); err != nil {
-		return errors.WrapInternal(err, "Failed to initialize middleware").
+		return nil, errors.WrapInternal(err, "Failed to initialize middleware").
 			WithPhase(errors.PhaseMiddleware)
 	}
 
@@ -233,7 +312,7 @@ func initializeMiddleware(server *http.Server, cfg *config.Config) error {
 		printMiddlewareStatus(orchestrator)
 	}
 
-	return nil
+	return orchestrator, nil
 }
 
 // printMiddlewareStatus prints active middleware in dev mode
@@ -12,6 +12,7 @@ import (
 type workerDaemonApp struct {
 	*foundation
 	workers []Worker
+	watcher *configWatcher
 	mode    AppMode
 }
 
@@ -54,6 +55,7 @@ func (d *workerDaemonApp) Stop(ctx context.Context) error {
 
 // Shutdown stops all workers and cleans up clients
 func (d *workerDaemonApp) Shutdown(ctx context.Context) error {
+	d.watcher.Stop()
 	if err := d.Stop(ctx); err != nil {
 		return err
 	}
@@ -95,5 +97,6 @@ func bootstrapWorkerDaemon(cfg *config.Config, opts BootstrapOptions) (BaseApp,
 		return nil, fmt.Errorf("worker registration: %w", err)
 	}
 
+	daemon.watcher = startConfigWatcher(cfg, nil)
 	return daemon, nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/codoworks/codo-framework/core/clients"
 	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db"
 	"github.com/codoworks/codo-framework/core/http"
 	"github.com/codoworks/codo-framework/core/middleware"
 )
@@ -12,8 +14,10 @@ import (
 // httpServerApp implements HTTPApp for full multi-router server
 type httpServerApp struct {
 	*foundation
-	server *http.Server
-	mode   AppMode
+	server       *http.Server
+	orchestrator *middleware.Orchestrator
+	watcher      *configWatcher
+	mode         AppMode
 }
 
 // Server returns the HTTP server
@@ -21,6 +25,11 @@ func (a *httpServerApp) Server() *http.Server {
 	return a.server
 }
 
+// Middleware returns the middleware orchestrator
+func (a *httpServerApp) Middleware() *middleware.Orchestrator {
+	return a.orchestrator
+}
+
 // Start starts the HTTP server
 func (a *httpServerApp) Start(ctx context.Context) error {
 	return a.server.Start()
@@ -31,12 +40,20 @@ func (a *httpServerApp) Mode() AppMode {
 	return a.mode
 }
 
+// Shutdown stops config reloads, drains the server, then shuts down clients
+func (a *httpServerApp) Shutdown(ctx context.Context) error {
+	a.watcher.Stop()
+	err := a.server.Shutdown(ctx)
+	return shutdownClients(err)
+}
+
 // singleRouterApp implements SingleRouterApp for single router mode
 type singleRouterApp struct {
 	*foundation
-	router *http.Router
-	scope  http.RouterScope
-	mode   AppMode
+	router  *http.Router
+	scope   http.RouterScope
+	watcher *configWatcher
+	mode    AppMode
 }
 
 // Router returns the single HTTP router
@@ -59,8 +76,20 @@ func (a *singleRouterApp) Mode() AppMode {
 	return a.mode
 }
 
+// Shutdown stops config reloads, drains the router, then shuts down clients
+func (a *singleRouterApp) Shutdown(ctx context.Context) error {
+	a.watcher.Stop()
+	err := http.Drain(ctx, drainConfig(a.config), a.router)
+	return shutdownClients(err)
+}
+
 // bootstrapHTTPServer creates a full multi-router HTTP server
 func bootstrapHTTPServer(cfg *config.Config, opts BootstrapOptions) (BaseApp, error) {
+	// Phase 0: Serve the startup probe while dependencies are awaited
+	probe := startStartupProbe(cfg, cfg.Server.PublicAddr())
+	var handoff *http.Router // Set once bootstrap succeeds
+	defer func() { finishStartup(probe, handoff) }()
+
 	// Phase 1: Initialize foundation (clients)
 	foundation, err := initFoundation(cfg, opts)
 	if err != nil {
@@ -78,13 +107,30 @@ func bootstrapHTTPServer(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 		ProtectedAddr: cfg.Server.ProtectedAddr(),
 		HiddenAddr:    cfg.Server.HiddenAddr(),
 		ShutdownGrace: cfg.Server.ShutdownGrace.Duration(),
+		PreStopDelay:  cfg.Server.PreStopDelay.Duration(),
+		Logger:        getOrCreateLogger(),
 	})
 
+	// Configure TLS per router
+	for _, scope := range []http.RouterScope{http.ScopePublic, http.ScopeProtected, http.ScopeHidden} {
+		if err := configureRouterTLS(server.Router(scope), cfg); err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+	}
+
 	// Initialize and apply middleware to all routers
-	if err := initializeMiddleware(server, cfg); err != nil {
+	orchestrator, err := initializeMiddleware(server, cfg)
+	if err != nil {
 		return nil, fmt.Errorf("middleware init: %w", err)
 	}
 
+	// Enable the batch route per router
+	for _, scope := range []http.RouterScope{http.ScopePublic, http.ScopeProtected, http.ScopeHidden} {
+		if err := configureBatch(server.Router(scope), cfg); err != nil {
+			return nil, fmt.Errorf("batch: %w", err)
+		}
+	}
+
 	// Make config accessible to handlers (must be before HandlerRegistrar so handlers can read config)
 	http.SetGlobalConfig(cfg)
 
@@ -100,10 +146,19 @@ func bootstrapHTTPServer(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 		return nil, fmt.Errorf("prepare routes: %w", err)
 	}
 
+	// Route inspection only reads the routes, so there is nothing to reload
+	var watcher *configWatcher
+	if opts.Mode != RouteInspector {
+		watcher = startConfigWatcher(cfg, orchestrator)
+		handoff = server.Router(http.ScopePublic)
+	}
+
 	return &httpServerApp{
-		foundation: foundation,
-		server:     server,
-		mode:       HTTPServer,
+		foundation:   foundation,
+		server:       server,
+		orchestrator: orchestrator,
+		watcher:      watcher,
+		mode:         HTTPServer,
 	}, nil
 }
 
@@ -114,6 +169,15 @@ func bootstrapHTTPRouter(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 		return nil, fmt.Errorf("RouterScope is required for HTTPRouter mode")
 	}
 
+	// Phase 0: Serve the startup probe while dependencies are awaited
+	scope := *opts.RouterScope
+	var probe *http.StartupProbeServer
+	if scope == http.ScopePublic {
+		probe = startStartupProbe(cfg, getAddressForScope(cfg, scope))
+	}
+	var handoff *http.Router // Set once bootstrap succeeds
+	defer func() { finishStartup(probe, handoff) }()
+
 	// Phase 1: Initialize foundation
 	foundation, err := initFoundation(cfg, opts)
 	if err != nil {
@@ -126,9 +190,11 @@ func bootstrapHTTPRouter(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 	}
 
 	// Phase 3: Create single router
-	scope := *opts.RouterScope
 	addr := getAddressForScope(cfg, scope)
 	router := http.NewRouter(scope, addr)
+	if err := configureRouterTLS(router, cfg); err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
 
 	// Initialize middleware orchestrator
 	orchestrator := middleware.NewOrchestrator(cfg)
@@ -140,6 +206,10 @@ func bootstrapHTTPRouter(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 	routerType := routerTypeFromScope(scope)
 	orchestrator.Apply(router, routerType)
 
+	if err := configureBatch(router, cfg); err != nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+
 	// Make config accessible to handlers (must be before HandlerRegistrar so handlers can read config)
 	http.SetGlobalConfig(cfg)
 
@@ -155,10 +225,12 @@ func bootstrapHTTPRouter(cfg *config.Config, opts BootstrapOptions) (BaseApp, er
 		return nil, fmt.Errorf("prepare routes: %w", err)
 	}
 
+	handoff = router
 	return &singleRouterApp{
 		foundation: foundation,
 		router:     router,
 		scope:      scope,
+		watcher:    startConfigWatcher(cfg, orchestrator),
 		mode:       HTTPRouter,
 	}, nil
 }
@@ -184,16 +256,94 @@ func getAddressForScope(cfg *config.Config, scope http.RouterScope) string {
 	}
 }
 
-// routerTypeFromScope maps http.RouterScope to middleware.Router
-func routerTypeFromScope(scope http.RouterScope) middleware.Router {
+// drainConfig returns the shutdown settings for a single router
+func drainConfig(cfg *config.Config) *http.ServerConfig {
+	return &http.ServerConfig{
+		ShutdownGrace: cfg.Server.ShutdownGrace.Duration(),
+		PreStopDelay:  cfg.Server.PreStopDelay.Duration(),
+		Logger:        getOrCreateLogger(),
+	}
+}
+
+// shutdownClients stops clients in reverse registration order once the
+// routers have drained. drainErr is returned if the drain failed.
+func shutdownClients(drainErr error) error {
+	log := getOrCreateLogger()
+	log.Info("Shutdown: stopping clients")
+	if err := clients.ShutdownAllWithLog(log); err != nil && drainErr == nil {
+		return err
+	}
+	return drainErr
+}
+
+// configureRouterTLS enables TLS on a router if configured for its scope
+func configureRouterTLS(router *http.Router, cfg *config.Config) error {
+	tlsConfig, err := routerTLSConfig(router.Scope(), cfg)
+	if err != nil || tlsConfig == nil {
+		return err
+	}
+	return router.SetTLS(tlsConfig)
+}
+
+// routerTLSConfig returns the TLS settings for a router scope, or nil if TLS
+// is disabled for it
+func routerTLSConfig(scope http.RouterScope, cfg *config.Config) (*http.TLSConfig, error) {
+	var tlsCfg config.TLSConfig
 	switch scope {
 	case http.ScopePublic:
-		return middleware.RouterPublic
+		tlsCfg = cfg.Server.TLS.Public
 	case http.ScopeProtected:
-		return middleware.RouterProtected
+		tlsCfg = cfg.Server.TLS.Protected
 	case http.ScopeHidden:
-		return middleware.RouterHidden
-	default:
-		return middleware.RouterPublic
+		tlsCfg = cfg.Server.TLS.Hidden
+	}
+	if !tlsCfg.Enabled {
+		return nil, nil
+	}
+
+	clientAuth, err := http.ParseClientAuth(tlsCfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	minVersion, err := http.ParseTLSVersion(tlsCfg.MinVersion)
+	if err != nil {
+		return nil, err
 	}
+
+	return &http.TLSConfig{
+		CertFile:       tlsCfg.CertFile,
+		KeyFile:        tlsCfg.KeyFile,
+		ClientCAFile:   tlsCfg.ClientCAFile,
+		ClientAuth:     clientAuth,
+		MinVersion:     minVersion,
+		ReloadInterval: tlsCfg.ReloadInterval.Duration(),
+	}, nil
+}
+
+// configureBatch registers the batch route on a router if enabled for its scope
+func configureBatch(router *http.Router, cfg *config.Config) error {
+	batchCfg := cfg.Server.Batch
+	if !batchCfg.EnabledFor(router.Scope().String()) {
+		return nil
+	}
+
+	opts := http.BatchConfig{
+		Path:        batchCfg.Path,
+		MaxRequests: batchCfg.MaxRequests,
+	}
+	if batchCfg.Transactional {
+		dbClient, err := clients.GetTyped[*db.Client]("db")
+		if err != nil {
+			return fmt.Errorf("transactional batches require the db client: %w", err)
+		}
+		opts.DB = dbClient
+	}
+
+	router.EnableBatch(opts)
+	return nil
+}
+
+// routerTypeFromScope maps http.RouterScope to middleware.Router
+func routerTypeFromScope(scope http.RouterScope) middleware.Router {
+	return middleware.RouterFor(scope)
 }
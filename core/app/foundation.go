@@ -9,6 +9,7 @@ import (
 	"github.com/codoworks/codo-framework/core/db/migrations"
 	"github.com/codoworks/codo-framework/core/errors"
 	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/policy"
 )
 
 // foundation holds the core infrastructure components (clients, config)
@@ -20,6 +21,11 @@ type foundation struct {
 func initFoundation(cfg *config.Config, opts BootstrapOptions) (*foundation, error) {
 	// 0. Configure error handling from config
 	configureErrorHandling(cfg)
+	configureVersioning(cfg)
+	if err := policy.Configure(cfg); err != nil {
+		return nil, errors.WrapBadRequest(err, "Invalid policies").
+			WithPhase(errors.PhaseConfig)
+	}
 
 	// 0.5. Validate consumer environment variables (if registrar provided)
 	if opts.EnvVarRegistrar != nil {
@@ -102,6 +108,16 @@ func configureErrorHandling(cfg *config.Config) {
 	})
 }
 
+// configureVersioning sets up how versioned handlers are mounted
+func configureVersioning(cfg *config.Config) {
+	http.SetVersioningConfig(http.VersioningConfig{
+		Prefix:         cfg.Server.Versioning.Prefix,
+		Header:         cfg.Server.Versioning.Header,
+		Negotiate:      cfg.Server.Versioning.Negotiate,
+		DefaultVersion: cfg.Server.Versioning.DefaultVersion,
+	})
+}
+
 // Config returns the application configuration
 func (f *foundation) Config() *config.Config {
 	return f.config
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+	"github.com/codoworks/codo-framework/core/policy"
+)
+
+// configWatcher reloads the config when its file changes or the process
+// receives SIGHUP, and applies it to reloadable clients and middleware
+type configWatcher struct {
+	mu           sync.Mutex
+	cfg          *config.Config
+	orchestrator *middleware.Orchestrator // nil without HTTP routers
+
+	modTime time.Time
+	signals chan os.Signal
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// startConfigWatcher starts watching the config if reload is enabled.
+// Returns nil otherwise.
+func startConfigWatcher(cfg *config.Config, orchestrator *middleware.Orchestrator) *configWatcher {
+	if !cfg.Reload.Enabled {
+		return nil
+	}
+
+	w := newConfigWatcher(cfg, orchestrator)
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go w.run(cfg.Reload.Interval.Duration())
+
+	getOrCreateLogger().Infof("Config reload enabled (source: %s)", sourceName(cfg))
+	return w
+}
+
+func newConfigWatcher(cfg *config.Config, orchestrator *middleware.Orchestrator) *configWatcher {
+	w := &configWatcher{
+		cfg:          cfg,
+		orchestrator: orchestrator,
+		signals:      make(chan os.Signal, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	w.modTime = w.fileModTime()
+	return w
+}
+
+// run polls the config file every interval and reloads on change or signal
+func (w *configWatcher) run(interval time.Duration) {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if interval > 0 && w.cfg.Source != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.signals:
+			w.Reload("SIGHUP")
+		case <-tick:
+			if modTime := w.fileModTime(); !modTime.Equal(w.modTime) {
+				w.modTime = modTime
+				w.Reload("file changed")
+			}
+		}
+	}
+}
+
+// Stop stops watching. Safe to call on a nil watcher.
+func (w *configWatcher) Stop() {
+	if w == nil {
+		return
+	}
+	signal.Stop(w.signals)
+	close(w.stop)
+	<-w.done
+}
+
+// Reload loads and validates the config again and applies what changed:
+// middleware is reconfigured, policies are replaced and each Reloadable
+// client whose settings changed is reloaded. If the config cannot be loaded
+// or the middleware cannot be configured, nothing is applied. If a policy or
+// client fails to reload, the middleware keeps its new settings but the
+// current config stays in place, so the next reload retries every client
+// whose settings differ from it. A client that fails to reload keeps its
+// previous settings.
+func (w *configWatcher) Reload(trigger string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	log := getOrCreateLogger()
+
+	next, err := config.Reload(w.cfg)
+	if err != nil {
+		return w.fail(log, errors.WrapInternal(err, "Config reload failed").
+			WithPhase(errors.PhaseConfig).
+			WithDetail("source", sourceName(w.cfg)))
+	}
+
+	changed := config.ChangedSections(w.cfg, next)
+	if len(changed) == 0 {
+		log.Debugf("Config reload (%s): no changes", trigger)
+		return nil
+	}
+
+	var reconfigured []string
+	if w.orchestrator != nil {
+		reconfigured, err = w.orchestrator.Reload(next)
+		if err != nil {
+			return w.fail(log, errors.WrapInternal(err, "Config reload failed").
+				WithPhase(errors.PhaseMiddleware).
+				WithDetail("source", sourceName(w.cfg)))
+		}
+	}
+
+	// Policies are validated with the config, so this only fails on a bug
+	if slices.Contains(changed, "policies") || slices.Contains(changed, "dev_mode") {
+		if err := policy.Configure(next); err != nil {
+			return w.fail(log, errors.WrapInternal(err, "Policy reload failed").
+				WithPhase(errors.PhaseConfig))
+		}
+	}
+
+	if err := clients.ReloadAll(reloadConfigs(w.cfg, next), log); err != nil {
+		return w.fail(log, errors.WrapInternal(err, "Client reload failed").
+			WithPhase(errors.PhaseClient))
+	}
+
+	w.cfg = next
+	http.SetGlobalConfig(next)
+
+	log.WithFields(map[string]any{
+		"changed":    changed,
+		"middleware": reconfigured,
+	}).Infof("Config reloaded (%s)", trigger)
+	return nil
+}
+
+// fail logs a reload error and returns it
+func (w *configWatcher) fail(log *logger.Logger, err *errors.Error) error {
+	fields := map[string]any{
+		"code":  err.Code,
+		"phase": err.Phase,
+	}
+	if err.Caller != nil {
+		fields["location"] = err.Caller.File + ":" + strconv.Itoa(err.Caller.Line)
+	}
+	if len(err.Details) > 0 {
+		fields["details"] = err.Details
+	}
+	log.WithFields(fields).Error(err.Error())
+
+	if w.cfg.IsDevMode() {
+		errors.RenderCLI(err)
+	}
+	return err
+}
+
+// fileModTime returns the config file's modification time, or the zero
+// time if there is no file
+func (w *configWatcher) fileModTime() time.Time {
+	if w.cfg.Source == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(w.cfg.Source)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadConfigs returns the configs to reload clients with: framework
+// clients whose settings changed, and every other client with the full
+// config
+func reloadConfigs(old, next *config.Config) map[string]any {
+	previous := frameworkClientConfigs(old)
+	configs := make(map[string]any)
+	for name, cfg := range frameworkClientConfigs(next) {
+		if !reflect.DeepEqual(previous[name], cfg) {
+			configs[name] = cfg
+		}
+	}
+	for _, name := range clients.Names() {
+		if _, ok := previous[name]; !ok {
+			configs[name] = next
+		}
+	}
+	return configs
+}
+
+func sourceName(cfg *config.Config) string {
+	if cfg.Source == "" {
+		return "defaults"
+	}
+	return cfg.Source
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/policy"
+)
+
+// settingsClient records the config it was last reloaded with
+type settingsClient struct {
+	clients.BaseClient
+	reloaded *config.Config
+	fail     error // Returned by Reload when set
+}
+
+func (c *settingsClient) Reload(cfg any) error {
+	if c.fail != nil {
+		return c.fail
+	}
+	c.reloaded = cfg.(*config.Config)
+	return nil
+}
+
+func setupReloadTest(t *testing.T, yaml string) (string, *config.Config, *logger.Logger, *settingsClient) {
+	t.Helper()
+	clients.ResetRegistry()
+	t.Cleanup(func() {
+		clients.ResetRegistry()
+		http.SetGlobalConfig(nil)
+	})
+
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+	cfg, err := config.LoadFromFile(path)
+	require.NoError(t, err)
+
+	log := logger.New()
+	require.NoError(t, log.Initialize(&logger.Config{Level: logger.LevelInfo, Format: logger.FormatJSON, Output: &bytes.Buffer{}}))
+	custom := &settingsClient{BaseClient: clients.NewBaseClient("settings")}
+	require.NoError(t, clients.Register(log))
+	require.NoError(t, clients.Register(custom))
+
+	return path, cfg, log, custom
+}
+
+func TestConfigWatcher_Reload(t *testing.T) {
+	path, cfg, log, custom := setupReloadTest(t, "logger:\n  level: info\n")
+	w := newConfigWatcher(cfg, nil)
+
+	require.NoError(t, w.Reload("test"))
+	assert.Nil(t, custom.reloaded, "nothing changed")
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: debug\n"), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.Equal(t, logger.LevelDebug, log.GetLevel())
+	require.NotNil(t, custom.reloaded)
+	assert.Equal(t, "debug", custom.reloaded.Logger.Level)
+	assert.Same(t, custom.reloaded, http.GetGlobalConfig())
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: warn\nserver:\n  public_port: -1\n"), 0o600))
+	require.Error(t, w.Reload("test"))
+	assert.Equal(t, logger.LevelDebug, log.GetLevel(), "failed reload keeps the current config")
+	assert.Equal(t, "debug", w.cfg.Logger.Level)
+}
+
+func TestConfigWatcher_ClientReloadFails(t *testing.T) {
+	path, cfg, _, custom := setupReloadTest(t, "logger:\n  level: info\n")
+	http.SetGlobalConfig(cfg)
+	w := newConfigWatcher(cfg, nil)
+
+	custom.fail = fmt.Errorf("connection refused")
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: debug\n"), 0o600))
+	require.Error(t, w.Reload("test"))
+	assert.Same(t, cfg, w.cfg, "failed client reload keeps the current config")
+	assert.Same(t, cfg, http.GetGlobalConfig())
+
+	// The next trigger retries the client
+	custom.fail = nil
+	require.NoError(t, w.Reload("test"))
+	require.NotNil(t, custom.reloaded)
+	assert.Equal(t, "debug", custom.reloaded.Logger.Level)
+	assert.Equal(t, "debug", w.cfg.Logger.Level)
+	assert.Same(t, w.cfg, http.GetGlobalConfig())
+}
+
+func TestConfigWatcher_FileChange(t *testing.T) {
+	path, cfg, log, _ := setupReloadTest(t, "logger:\n  level: info\n")
+	w := newConfigWatcher(cfg, nil)
+	go w.run(5 * time.Millisecond)
+	defer w.Stop()
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: error\n"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.cfg.Logger.Level == "error"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, logger.LevelError, log.GetLevel())
+}
+
+func TestConfigWatcher_ReloadPolicies(t *testing.T) {
+	path, cfg, _, _ := setupReloadTest(t, "policies:\n  admin:\n    trait: role\n    equals: admin\n")
+	t.Cleanup(policy.Reset)
+	require.NoError(t, policy.Configure(cfg))
+	w := newConfigWatcher(cfg, nil)
+
+	admin := &auth.Identity{ID: "u1", Traits: map[string]any{"role": "admin"}}
+	assert.NoError(t, policy.Authorize(admin, policy.Named("admin"), nil))
+
+	require.NoError(t, os.WriteFile(path, []byte("policies:\n  admin:\n    trait: role\n    equals: owner\n"), 0o600))
+	require.NoError(t, w.Reload("test"))
+	assert.Error(t, policy.Authorize(admin, policy.Named("admin"), nil))
+}
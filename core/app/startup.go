@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/http"
+)
+
+// initializeClients initializes registered clients, waiting for their
+// dependencies if configured
+func initializeClients(configs map[string]any, log *logger.Logger, cfg config.StartupConfig) error {
+	if !cfg.WaitForDependencies {
+		return clients.InitializeAllWithMetadata(configs, log)
+	}
+	return waitForDependencies(configs, log, cfg)
+}
+
+// waitForDependencies initializes clients in registration order, retrying
+// Initialize and Health with exponential backoff until the startup deadline.
+// Required clients that are not ready by the deadline fail bootstrap; optional
+// clients are removed from the registry, as with InitializeAllWithMetadata.
+func waitForDependencies(configs map[string]any, log *logger.Logger, cfg config.StartupConfig) error {
+	deadline := time.Now().Add(cfg.Timeout.Duration())
+	names := clients.RegistrationOrder()
+
+	http.BeginStartup(names...)
+
+	log.Infof("Waiting up to %s for dependencies: %v", cfg.Timeout.Duration(), names)
+
+	for _, name := range names {
+		client, err := clients.Get(name)
+		if err != nil {
+			continue
+		}
+
+		initialized := false
+		attempts, err := retryUntil(deadline, cfg.InitialBackoff.Duration(), cfg.MaxBackoff.Duration(), func(attempt int) error {
+			if !initialized {
+				if err := client.Initialize(configs[name]); err != nil {
+					return fmt.Errorf("initialize: %w", err)
+				}
+				initialized = true
+			}
+			if err := client.Health(); err != nil {
+				return fmt.Errorf("health: %w", err)
+			}
+			return nil
+		}, func(attempt int, err error, wait time.Duration) {
+			http.SetDependencyPending(name, attempt, err)
+			log.Warnf("Dependency %s not ready (attempt %d): %v; retrying in %s", name, attempt, err, wait)
+		})
+		if err == nil {
+			http.SetDependencyReady(name, attempts)
+			log.Infof("Dependency ready: %s", name)
+			continue
+		}
+
+		http.SetDependencyFailed(name, attempts, err)
+		meta, _ := clients.GetMetadata(name)
+		if meta.Requirement == clients.ClientRequired {
+			log.Errorf("Required dependency %s not ready after %d attempt(s): %v", name, attempts, err)
+			return fmt.Errorf("required client %q not ready: %w", name, err)
+		}
+		log.Warnf("Optional dependency %s not ready after %d attempt(s), continuing without it: %v", name, attempts, err)
+		clients.Remove(name)
+	}
+	return nil
+}
+
+// retryUntil calls fn until it succeeds or the deadline passes, doubling the
+// wait between attempts up to maxBackoff. It returns the number of attempts
+// and the last error.
+func retryUntil(deadline time.Time, backoff, maxBackoff time.Duration, fn func(attempt int) error, onRetry func(attempt int, err error, wait time.Duration)) (int, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return attempt, nil
+		}
+
+		wait := min(backoff, time.Until(deadline))
+		if wait <= 0 {
+			return attempt, err
+		}
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// startStartupProbe serves /health/startup on addr while dependencies are
+// awaited, with the public router's TLS settings. Returns nil if waiting is
+// disabled or the address is unavailable.
+func startStartupProbe(cfg *config.Config, addr string) *http.StartupProbeServer {
+	if !cfg.Startup.WaitForDependencies {
+		return nil
+	}
+	http.BeginStartup()
+
+	var tlsConfig *tls.Config
+	routerTLS, err := routerTLSConfig(http.ScopePublic, cfg)
+	if err == nil && routerTLS != nil {
+		tlsConfig, err = http.NewTLSConfig(routerTLS)
+	}
+	if err != nil {
+		// The public router reports the error when it is configured
+		getOrCreateLogger().Warnf("Startup probe unavailable: tls: %v", err)
+		return nil
+	}
+
+	probe, err := http.StartStartupProbe(addr, tlsConfig)
+	if err != nil {
+		getOrCreateLogger().Warnf("Startup probe unavailable on %s: %v", addr, err)
+		return nil
+	}
+	return probe
+}
+
+// finishStartup marks startup complete and hands the probe's listener to
+// router, so its address is never left unbound. Without a router (bootstrap
+// failed or routes are only inspected) the probe is closed. probe may be nil.
+func finishStartup(probe *http.StartupProbeServer, router *http.Router) {
+	http.CompleteStartup()
+	if probe == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if router == nil {
+		if err := probe.Stop(ctx); err != nil {
+			getOrCreateLogger().Warnf("Failed to stop startup probe: %v", err)
+		}
+		return
+	}
+	ln, err := probe.Handover(ctx)
+	if err != nil {
+		// The router binds the address itself
+		getOrCreateLogger().Warnf("Failed to hand over startup probe listener: %v", err)
+		return
+	}
+	router.UseListener(ln)
+}
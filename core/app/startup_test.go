@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/http"
+)
+
+// flakyClient fails Initialize and Health a set number of times
+type flakyClient struct {
+	clients.BaseClient
+	initFailures   int
+	healthFailures int
+	onInit         func()
+}
+
+func (c *flakyClient) Initialize(cfg any) error {
+	if c.onInit != nil {
+		c.onInit()
+	}
+	if c.initFailures > 0 {
+		c.initFailures--
+		return errors.New("connection refused")
+	}
+	return c.BaseClient.Initialize(cfg)
+}
+
+func (c *flakyClient) Health() error {
+	if c.healthFailures > 0 {
+		c.healthFailures--
+		return errors.New("not accepting connections")
+	}
+	return nil
+}
+
+func setupStartupTest(t *testing.T) (*logger.Logger, config.StartupConfig) {
+	t.Helper()
+	clients.ResetRegistry()
+	http.ResetStartup()
+	t.Cleanup(func() {
+		clients.ResetRegistry()
+		http.ResetStartup()
+	})
+
+	log := logger.New()
+	require.NoError(t, log.Initialize(nil))
+
+	return log, config.StartupConfig{
+		WaitForDependencies: true,
+		Timeout:             config.Duration(time.Second),
+		InitialBackoff:      config.Duration(time.Millisecond),
+		MaxBackoff:          config.Duration(5 * time.Millisecond),
+	}
+}
+
+func TestWaitForDependencies_RetriesUntilReady(t *testing.T) {
+	log, cfg := setupStartupTest(t)
+
+	var pendingDuringWait []string
+	db := &flakyClient{BaseClient: clients.NewBaseClient("test-db"), initFailures: 2, healthFailures: 1}
+	mq := &flakyClient{BaseClient: clients.NewBaseClient("test-mq")}
+	mq.onInit = func() { pendingDuringWait = http.GetStartupStatus().Pending }
+
+	clients.RegisterMetadata(clients.ClientMetadata{Name: "test-db", Requirement: clients.ClientRequired})
+	require.NoError(t, clients.Register(db))
+	require.NoError(t, clients.Register(mq))
+
+	require.NoError(t, initializeClients(nil, log, cfg))
+
+	assert.True(t, db.IsInitialized())
+	assert.Equal(t, []string{"test-mq"}, pendingDuringWait)
+
+	status := http.GetStartupStatus()
+	assert.Equal(t, "starting", status.Status)
+	assert.Empty(t, status.Pending)
+	assert.Equal(t, http.DependencyStatus{Status: http.DependencyReady, Attempts: 4}, status.Dependencies["test-db"])
+
+	finishStartup(nil, nil)
+	assert.Equal(t, "started", http.GetStartupStatus().Status)
+}
+
+func TestWaitForDependencies_RequiredTimeout(t *testing.T) {
+	log, cfg := setupStartupTest(t)
+	cfg.Timeout = config.Duration(20 * time.Millisecond)
+
+	clients.RegisterMetadata(clients.ClientMetadata{Name: "test-db", Requirement: clients.ClientRequired})
+	require.NoError(t, clients.Register(&flakyClient{BaseClient: clients.NewBaseClient("test-db"), initFailures: 1000}))
+
+	err := initializeClients(nil, log, cfg)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `required client "test-db" not ready`)
+	assert.Equal(t, http.DependencyFailed, http.GetStartupStatus().Dependencies["test-db"].Status)
+}
+
+func TestWaitForDependencies_OptionalTimeout(t *testing.T) {
+	log, cfg := setupStartupTest(t)
+	cfg.Timeout = config.Duration(20 * time.Millisecond)
+
+	clients.RegisterMetadata(clients.ClientMetadata{Name: "test-queue", Requirement: clients.ClientOptional})
+	require.NoError(t, clients.Register(&flakyClient{BaseClient: clients.NewBaseClient("test-queue"), healthFailures: 1000}))
+
+	require.NoError(t, initializeClients(nil, log, cfg))
+	assert.False(t, clients.Has("test-queue"))
+}
+
+func TestRetryUntil_Backoff(t *testing.T) {
+	var waits []time.Duration
+	attempts, err := retryUntil(time.Now().Add(time.Second), time.Millisecond, 3*time.Millisecond,
+		func(attempt int) error {
+			if attempt < 4 {
+				return errors.New("not yet")
+			}
+			return nil
+		},
+		func(attempt int, err error, wait time.Duration) {
+			waits = append(waits, wait)
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, attempts)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, waits)
+}
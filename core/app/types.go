@@ -8,6 +8,7 @@ import (
 	"github.com/codoworks/codo-framework/core/db/migrations"
 	"github.com/codoworks/codo-framework/core/db/seeds"
 	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
 )
 
 // AppMode defines the type of application to bootstrap
@@ -165,6 +166,10 @@ type HTTPApp interface {
 	// Server returns the HTTP server
 	Server() *http.Server
 
+	// Middleware returns the orchestrator that applied middleware to the
+	// server's routers
+	Middleware() *middleware.Orchestrator
+
 	// Start starts the HTTP server
 	Start(ctx context.Context) error
 }
@@ -2,6 +2,18 @@ package auth
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Auth methods recorded on identities by the auth middleware
+const (
+	MethodSessionCookie = "session_cookie" // Kratos session cookie
+	MethodSessionToken  = "session_token"  // Kratos session token (X-Session-Token)
+	MethodJWT           = "jwt"            // Bearer JWT verified against a JWKS
+	MethodAPIKey        = "api_key"        // API key stored in the database
+	MethodDev           = "dev"            // Static identity from dev_bypass_auth
 )
 
 // Identity represents an authenticated user
@@ -9,6 +21,10 @@ type Identity struct {
 	ID        string         `json:"id"`
 	SessionID string         `json:"session_id,omitempty"`
 	Traits    map[string]any `json:"traits"`
+	Method    string         `json:"method,omitempty"`    // How the request authenticated, e.g. MethodJWT
+	ExpiresAt time.Time      `json:"expires_at,omitzero"` // When the credential expires, zero if unknown
+
+	VerifiedAddresses []string `json:"verified_addresses,omitempty"` // Addresses the identity provider verified, e.g. emails
 }
 
 // GetTrait retrieves a trait value by key
@@ -49,6 +65,16 @@ func (i *Identity) Email() string {
 	return i.GetTraitString("email")
 }
 
+// EmailVerified returns true if the email trait is one of the verified
+// addresses. Traits are set by the user, so only a verified email proves
+// they own it.
+func (i *Identity) EmailVerified() bool {
+	email := i.Email()
+	return email != "" && slices.ContainsFunc(i.VerifiedAddresses, func(addr string) bool {
+		return strings.EqualFold(addr, email)
+	})
+}
+
 // getNameMap extracts the nested name object from traits
 func (i *Identity) getNameMap() map[string]any {
 	val, ok := i.GetTrait("name")
@@ -149,6 +149,23 @@ func TestIdentity_Email_Missing(t *testing.T) {
 	assert.Equal(t, "", identity.Email())
 }
 
+func TestIdentity_EmailVerified(t *testing.T) {
+	identity := &Identity{
+		ID:     "user-123",
+		Traits: map[string]any{"email": "Test@Example.com"},
+	}
+	assert.False(t, identity.EmailVerified())
+
+	identity.VerifiedAddresses = []string{"other@example.com"}
+	assert.False(t, identity.EmailVerified())
+
+	identity.VerifiedAddresses = append(identity.VerifiedAddresses, "test@example.com")
+	assert.True(t, identity.EmailVerified())
+
+	identity.Traits = map[string]any{}
+	assert.False(t, identity.EmailVerified())
+}
+
 func TestIdentity_FirstName(t *testing.T) {
 	identity := &Identity{
 		ID: "user-123",
@@ -1,6 +1,7 @@
 package clients
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
 	"sync"
@@ -12,6 +13,12 @@ import (
 var (
 	globalRegistry *registry.Registry[Client]
 	once           sync.Once
+
+	// registrationOrder records client names in registration order.
+	// Clients registered later may depend on earlier ones, so they are
+	// shut down first.
+	registrationOrder   []string
+	registrationOrderMu sync.Mutex
 )
 
 func getRegistry() *registry.Registry[Client] {
@@ -25,11 +32,22 @@ func getRegistry() *registry.Registry[Client] {
 func ResetRegistry() {
 	once = sync.Once{}
 	globalRegistry = nil
+
+	registrationOrderMu.Lock()
+	registrationOrder = nil
+	registrationOrderMu.Unlock()
 }
 
 // Register adds a client to the global registry.
 func Register(client Client) error {
-	return getRegistry().Register(client.Name(), client)
+	if err := getRegistry().Register(client.Name(), client); err != nil {
+		return err
+	}
+
+	registrationOrderMu.Lock()
+	registrationOrder = append(registrationOrder, client.Name())
+	registrationOrderMu.Unlock()
+	return nil
 }
 
 // MustRegister adds a client to the global registry and panics on error.
@@ -65,6 +83,11 @@ func MustGet(name string) Client {
 	return client
 }
 
+// Remove removes a client from the registry without shutting it down.
+func Remove(name string) bool {
+	return getRegistry().Remove(name)
+}
+
 // Has checks if a client exists.
 func Has(name string) bool {
 	return getRegistry().Has(name)
@@ -239,13 +262,89 @@ func InitializeAllWithMetadata(configs map[string]any, log Logger) error {
 	return nil
 }
 
-// ShutdownAll shuts down all registered clients.
+// ReloadAll calls Reload on each registered Reloadable client that has an
+// entry in configs, in registration order. A client whose reload fails
+// keeps its previous settings; the others are still reloaded. Returns the
+// joined errors of the failed clients.
+func ReloadAll(configs map[string]any, log Logger) error {
+	var errs []error
+	for _, name := range RegistrationOrder() {
+		cfg, ok := configs[name]
+		if !ok {
+			continue
+		}
+		client, err := Get(name)
+		if err != nil {
+			continue
+		}
+		reloadable, ok := client.(Reloadable)
+		if !ok {
+			continue
+		}
+
+		if err := reloadable.Reload(cfg); err != nil {
+			if log != nil {
+				log.Errorf("Failed to reload client %q: %v", name, err)
+			}
+			errs = append(errs, fmt.Errorf("reload client %q: %w", name, err))
+		} else if log != nil {
+			log.Infof("Reloaded client: %s", name)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// RegistrationOrder returns the names of registered clients in the order
+// they were registered.
+func RegistrationOrder() []string {
+	registrationOrderMu.Lock()
+	order := make([]string, len(registrationOrder))
+	copy(order, registrationOrder)
+	registrationOrderMu.Unlock()
+
+	names := make([]string, 0, len(order))
+	for _, name := range order {
+		if Has(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ShutdownOrder returns the names of registered clients in reverse
+// registration order, the order in which they are shut down.
+func ShutdownOrder() []string {
+	names := RegistrationOrder()
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+// ShutdownAll shuts down all registered clients in reverse registration order.
 // Returns the last error encountered, if any.
 func ShutdownAll() error {
+	return ShutdownAllWithLog(nil)
+}
+
+// ShutdownAllWithLog shuts down all registered clients in reverse registration
+// order, logging each client. Returns the last error encountered, if any.
+func ShutdownAllWithLog(log Logger) error {
 	var lastErr error
-	for name, client := range getRegistry().All() {
+	for _, name := range ShutdownOrder() {
+		client, err := Get(name)
+		if err != nil {
+			continue
+		}
 		if err := client.Shutdown(); err != nil {
 			lastErr = fmt.Errorf("failed to shutdown client %q: %w", name, err)
+			if log != nil {
+				log.Errorf("Failed to shut down client %s: %v", name, err)
+			}
+			continue
+		}
+		if log != nil {
+			log.Infof("Shut down client: %s", name)
 		}
 	}
 	return lastErr
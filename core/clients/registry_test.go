@@ -306,6 +306,66 @@ func TestShutdownAll_WithErrors(t *testing.T) {
 	assert.True(t, client2.shutdownCalled)
 }
 
+type orderedClient struct {
+	*mockClient
+	order *[]string
+}
+
+func (c *orderedClient) Shutdown() error {
+	*c.order = append(*c.order, c.Name())
+	return c.mockClient.Shutdown()
+}
+
+func TestShutdownAll_ReverseRegistrationOrder(t *testing.T) {
+	setupTest(t)
+
+	var order []string
+	for _, name := range []string{"logger", "db", "cache"} {
+		Register(&orderedClient{mockClient: newMockClient(name), order: &order})
+	}
+	getRegistry().Remove("db")
+
+	assert.Equal(t, []string{"cache", "logger"}, ShutdownOrder())
+
+	err := ShutdownAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cache", "logger"}, order)
+}
+
+type reloadableClient struct {
+	*mockClient
+	reloaded  any
+	reloadErr error
+}
+
+func (c *reloadableClient) Reload(cfg any) error {
+	if c.reloadErr != nil {
+		return c.reloadErr
+	}
+	c.reloaded = cfg
+	return nil
+}
+
+func TestReloadAll(t *testing.T) {
+	setupTest(t)
+
+	logger := &reloadableClient{mockClient: newMockClient("logger")}
+	db := &reloadableClient{mockClient: newMockClient("db"), reloadErr: errors.New("requires restart")}
+	cache := &reloadableClient{mockClient: newMockClient("cache")}
+	Register(logger)
+	Register(db)
+	Register(cache)
+	Register(newMockClient("queue"))
+
+	err := ReloadAll(map[string]any{"logger": "debug", "db": "dsn", "queue": "ignored"}, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `reload client "db": requires restart`)
+	assert.Equal(t, "debug", logger.reloaded)
+	assert.Nil(t, cache.reloaded, "clients without a config are skipped")
+}
+
 func TestHealthAll(t *testing.T) {
 	setupTest(t)
 
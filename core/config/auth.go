@@ -8,8 +8,8 @@ import (
 type AuthConfig struct {
 	KratosPublicURL string `yaml:"kratos_public_url"`
 	KratosAdminURL  string `yaml:"kratos_admin_url"`
-	KetoReadURL     string `yaml:"keto_read_url"`
-	KetoWriteURL    string `yaml:"keto_write_url"`
+	KetoReadURL     string `yaml:"keto_read_url"`  // Registers the keto client when set
+	KetoWriteURL    string `yaml:"keto_write_url"` // Registers the keto client when set
 	SessionCookie   string `yaml:"session_cookie"`
 }
 
@@ -18,12 +18,15 @@ func DefaultAuthConfig() AuthConfig {
 	return AuthConfig{
 		KratosPublicURL: "http://localhost:4433",
 		KratosAdminURL:  "http://localhost:4434",
-		KetoReadURL:     "http://localhost:4466",
-		KetoWriteURL:    "http://localhost:4467",
 		SessionCookie:   "ory_kratos_session",
 	}
 }
 
+// KetoConfigured returns true if a Keto URL is set
+func (c *AuthConfig) KetoConfigured() bool {
+	return c.KetoReadURL != "" || c.KetoWriteURL != ""
+}
+
 // Validate validates auth configuration
 func (c *AuthConfig) Validate() error {
 	if c.SessionCookie == "" {
@@ -12,8 +12,9 @@ func TestDefaultAuthConfig(t *testing.T) {
 
 	assert.Equal(t, "http://localhost:4433", cfg.KratosPublicURL)
 	assert.Equal(t, "http://localhost:4434", cfg.KratosAdminURL)
-	assert.Equal(t, "http://localhost:4466", cfg.KetoReadURL)
-	assert.Equal(t, "http://localhost:4467", cfg.KetoWriteURL)
+	assert.Empty(t, cfg.KetoReadURL)
+	assert.Empty(t, cfg.KetoWriteURL)
+	assert.False(t, cfg.KetoConfigured())
 	assert.Equal(t, "ory_kratos_session", cfg.SessionCookie)
 }
 
@@ -37,6 +38,7 @@ func TestAuthConfig_Validate_CustomValues(t *testing.T) {
 	err := cfg.Validate()
 
 	assert.NoError(t, err)
+	assert.True(t, cfg.KetoConfigured())
 }
 
 func TestAuthConfig_Validate_EmptySessionCookie(t *testing.T) {
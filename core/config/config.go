@@ -15,8 +15,14 @@ type Config struct {
 	Middleware MiddlewareConfig `yaml:"middleware"`
 	Errors     ErrorsConfig     `yaml:"errors"`
 	Response   ResponseConfig   `yaml:"response"`
+	Startup    StartupConfig    `yaml:"startup"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Reload     ReloadConfig     `yaml:"reload"`
 	DevMode    bool             `yaml:"dev_mode"` // Loaded from YAML, overridable by env/CLI
 
+	// Policies declares trait-based authorization policies by name
+	Policies map[string]PolicyConfig `yaml:"policies"`
+
 	// Extensions captures any additional app-specific config sections
 	// The ,inline tag merges unknown fields into this map instead of discarding them
 	Extensions map[string]interface{} `yaml:",inline"`
@@ -25,6 +31,10 @@ type Config struct {
 	// This is populated during bootstrap if EnvVarRegistrar is provided
 	// Not loaded from YAML - programmatically set by the framework
 	EnvRegistry *EnvVarRegistry `yaml:"-" json:"-"`
+
+	// Source is the path of the config file this config was loaded from,
+	// empty if none was found. Reload reads it again.
+	Source string `yaml:"-" json:"-"`
 }
 
 // NewWithDefaults creates a new Config with all default values
@@ -40,6 +50,9 @@ func NewWithDefaults() *Config {
 		Middleware: DefaultMiddlewareConfig(),
 		Errors:     DefaultErrorsConfig(),
 		Response:   DefaultResponseConfig(),
+		Startup:    DefaultStartupConfig(),
+		Storage:    DefaultStorageConfig(),
+		Reload:     DefaultReloadConfig(),
 		DevMode:    false,
 		Extensions: make(map[string]interface{}),
 	}
@@ -64,6 +77,21 @@ func (c *Config) Validate() error {
 	if err := c.Auth.Validate(); err != nil {
 		return err
 	}
+	if err := c.Startup.Validate(); err != nil {
+		return err
+	}
+	if err := c.Storage.Validate(); err != nil {
+		return err
+	}
+	if err := c.Middleware.Validate(); err != nil {
+		return err
+	}
+	if err := c.Reload.Validate(); err != nil {
+		return err
+	}
+	if err := validatePolicies(c.Policies); err != nil {
+		return err
+	}
 
 	// Validate RabbitMQ based on feature toggle
 	if c.Features.IsEnabled(FeatureRabbitMQ) {
@@ -97,11 +125,15 @@ func (c *Config) Clone() *Config {
 		Middleware: c.Middleware,
 		Errors:     c.Errors,
 		Response:   c.Response,
+		Startup:    c.Startup,
+		Storage:    c.Storage,
+		Reload:     c.Reload,
 		Features: FeaturesConfig{
 			DisabledFeatures: make([]string, len(c.Features.DisabledFeatures)),
 		},
 		DevMode:    c.DevMode,
 		Extensions: make(map[string]interface{}),
+		Source:     c.Source,
 	}
 	copy(clone.Features.DisabledFeatures, c.Features.DisabledFeatures)
 
@@ -38,6 +38,37 @@ func (c *Config) ApplyDefaults() {
 	if c.Server.ShutdownGrace == 0 {
 		c.Server.ShutdownGrace = defaults.Server.ShutdownGrace
 	}
+	if c.Server.Batch.Path == "" {
+		c.Server.Batch.Path = defaults.Server.Batch.Path
+	}
+	if c.Server.Batch.MaxRequests == 0 {
+		c.Server.Batch.MaxRequests = defaults.Server.Batch.MaxRequests
+	}
+
+	// Startup defaults
+	if c.Startup.Timeout == 0 {
+		c.Startup.Timeout = defaults.Startup.Timeout
+	}
+	if c.Startup.InitialBackoff == 0 {
+		c.Startup.InitialBackoff = defaults.Startup.InitialBackoff
+	}
+	if c.Startup.MaxBackoff == 0 {
+		c.Startup.MaxBackoff = defaults.Startup.MaxBackoff
+	}
+
+	// Storage defaults
+	if c.Storage.URLExpiry == 0 {
+		c.Storage.URLExpiry = defaults.Storage.URLExpiry
+	}
+	if c.Storage.Local.Root == "" {
+		c.Storage.Local.Root = defaults.Storage.Local.Root
+	}
+	if c.Storage.S3.Endpoint == "" {
+		c.Storage.S3.Endpoint = defaults.Storage.S3.Endpoint
+	}
+	if c.Storage.S3.Region == "" {
+		c.Storage.S3.Region = defaults.Storage.S3.Region
+	}
 
 	// Database defaults
 	if c.Database.Driver == "" {
@@ -75,12 +106,6 @@ func (c *Config) ApplyDefaults() {
 	if c.Auth.KratosAdminURL == "" {
 		c.Auth.KratosAdminURL = defaults.Auth.KratosAdminURL
 	}
-	if c.Auth.KetoReadURL == "" {
-		c.Auth.KetoReadURL = defaults.Auth.KetoReadURL
-	}
-	if c.Auth.KetoWriteURL == "" {
-		c.Auth.KetoWriteURL = defaults.Auth.KetoWriteURL
-	}
 	if c.Auth.SessionCookie == "" {
 		c.Auth.SessionCookie = defaults.Auth.SessionCookie
 	}
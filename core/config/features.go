@@ -12,6 +12,7 @@ const (
 	FeatureKeto     = "keto"
 	FeatureRedis    = "redis"
 	FeatureRabbitMQ = "rabbitmq"
+	FeatureStorage  = "storage"
 	FeatureCORS     = "cors"
 	FeatureGzip     = "gzip"
 )
@@ -162,15 +162,15 @@ func FrameworkEnvVars() []FrameworkEnvVar {
 		{
 			Name:        "KETO_READ_URL",
 			Type:        "string",
-			Default:     "http://localhost:4466",
-			Description: "Ory Keto read API URL",
+			Default:     "",
+			Description: "Ory Keto read API URL (registers the keto client)",
 			ConfigPath:  "auth.keto_read_url",
 		},
 		{
 			Name:        "KETO_WRITE_URL",
 			Type:        "string",
-			Default:     "http://localhost:4467",
-			Description: "Ory Keto write API URL",
+			Default:     "",
+			Description: "Ory Keto write API URL (registers the keto client)",
 			ConfigPath:  "auth.keto_write_url",
 		},
 		{
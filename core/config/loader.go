@@ -62,7 +62,12 @@ func LoadFromFile(path string) (*Config, error) {
 	}
 	defer f.Close()
 
-	return LoadFromReader(f)
+	cfg, err := LoadFromReader(f)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Source = path
+	return cfg, nil
 }
 
 // Load loads configuration from default locations
@@ -83,6 +88,7 @@ func Load() (*Config, error) {
 				return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
 			}
 			f.Close()
+			cfg.Source = path
 			loaded = true
 			break
 		}
@@ -1,6 +1,11 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 // BaseMiddlewareConfig provides common configuration fields for all middleware
 type BaseMiddlewareConfig struct {
@@ -23,15 +28,84 @@ func (b *BaseMiddlewareConfig) IsEnabled(isDevMode bool) bool {
 
 // MiddlewareConfig holds configuration for all middleware
 type MiddlewareConfig struct {
-	Logger     LoggerMiddlewareConfig     `yaml:"logger"`
-	CORS       CORSMiddlewareConfig       `yaml:"cors"`
-	Timeout    TimeoutMiddlewareConfig    `yaml:"timeout"`
-	Recover    RecoverMiddlewareConfig    `yaml:"recover"`
-	Gzip       GzipMiddlewareConfig       `yaml:"gzip"`
-	XSS        XSSMiddlewareConfig        `yaml:"xss"`
-	Auth       AuthMiddlewareConfig       `yaml:"auth"`
-	Health     HealthConfig               `yaml:"health"`
-	Pagination PaginationMiddlewareConfig `yaml:"pagination"`
+	Logger      LoggerMiddlewareConfig      `yaml:"logger"`
+	CORS        CORSMiddlewareConfig        `yaml:"cors"`
+	Timeout     TimeoutMiddlewareConfig     `yaml:"timeout"`
+	Recover     RecoverMiddlewareConfig     `yaml:"recover"`
+	Gzip        GzipMiddlewareConfig        `yaml:"gzip"`
+	Auth        AuthMiddlewareConfig        `yaml:"auth"`
+	Health      HealthConfig                `yaml:"health"`
+	Pagination  PaginationMiddlewareConfig  `yaml:"pagination"`
+	Idempotency IdempotencyMiddlewareConfig `yaml:"idempotency"`
+	RateLimit   RateLimitMiddlewareConfig   `yaml:"rate_limit"`
+	Cache       CacheMiddlewareConfig       `yaml:"cache"`
+	BodyLimit   BodyLimitMiddlewareConfig   `yaml:"body_limit"`
+	CSRF        CSRFMiddlewareConfig        `yaml:"csrf"`
+
+	SecurityHeaders SecurityHeadersMiddlewareConfig `yaml:"security_headers"`
+
+	// XSS catches the removed xss middleware's settings so Validate can
+	// reject them instead of silently dropping the headers they configured
+	XSS any `yaml:"xss"`
+
+	Routes []MiddlewareRouteConfig `yaml:"routes"` // Per-route rules, checked in order
+}
+
+// MiddlewareRouteConfig enables, disables or reconfigures middleware for
+// matching routes. For each middleware, the first matching rule that names
+// it wins.
+type MiddlewareRouteConfig struct {
+	Path     string                    `yaml:"path"`     // Glob matched against the route pattern (e.g. "/api/v1/reports/**")
+	Regex    string                    `yaml:"regex"`    // Regular expression matched against the route pattern, instead of path
+	Methods  []string                  `yaml:"methods"`  // HTTP methods, empty matches any
+	Enable   []string                  `yaml:"enable"`   // Middleware to run even if off for the router or disabled
+	Disable  []string                  `yaml:"disable"`  // Middleware to skip
+	Override map[string]map[string]any `yaml:"override"` // Settings by middleware name, e.g. timeout: {duration: 5m}
+}
+
+// Validate validates the per-route middleware rules
+func (c *MiddlewareConfig) Validate() error {
+	if c.XSS != nil {
+		return fmt.Errorf("middleware.xss was removed: configure the headers under middleware.security_headers instead")
+	}
+	if err := c.Auth.Validate(); err != nil {
+		return err
+	}
+	if err := c.CSRF.Validate(); err != nil {
+		return err
+	}
+	if err := c.SecurityHeaders.Validate(); err != nil {
+		return err
+	}
+	for i, route := range c.Routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("middleware.routes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates a per-route middleware rule
+func (c *MiddlewareRouteConfig) Validate() error {
+	if (c.Path == "") == (c.Regex == "") {
+		return fmt.Errorf("exactly one of path or regex is required")
+	}
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	if len(c.Enable) == 0 && len(c.Disable) == 0 && len(c.Override) == 0 {
+		return fmt.Errorf("one of enable, disable or override is required")
+	}
+	for _, name := range c.Enable {
+		for _, other := range c.Disable {
+			if name == other {
+				return fmt.Errorf("middleware %q is both enabled and disabled", name)
+			}
+		}
+	}
+	return nil
 }
 
 // LoggerMiddlewareConfig holds configuration for the logger middleware
@@ -69,24 +143,104 @@ type GzipMiddlewareConfig struct {
 	MinSize              int `yaml:"min_size"` // minimum size in bytes, default 1024
 }
 
-// XSSMiddlewareConfig holds configuration for the XSS protection middleware
-type XSSMiddlewareConfig struct {
-	BaseMiddlewareConfig `yaml:",inline"`
-	XSSProtection        string `yaml:"xss_protection"`       // X-XSS-Protection header
-	ContentTypeNosniff   string `yaml:"content_type_nosniff"` // X-Content-Type-Options header
-	XFrameOptions        string `yaml:"x_frame_options"`      // X-Frame-Options header
-	HSTSMaxAge           int    `yaml:"hsts_max_age"`         // Strict-Transport-Security max-age
+// SecurityHeadersMiddlewareConfig holds configuration for the security
+// headers middleware. The top-level headers apply to every router; an entry
+// in Routers replaces them field by field for that router.
+type SecurityHeadersMiddlewareConfig struct {
+	BaseMiddlewareConfig  `yaml:",inline"`
+	SecurityHeadersConfig `yaml:",inline"`
+	Routers               map[string]SecurityHeadersConfig `yaml:"routers"`         // Per-router headers by router name: "public", "protected" or "hidden"
+	CSPReportOnly         bool                             `yaml:"csp_report_only"` // Send Content-Security-Policy-Report-Only instead of enforcing (default: true)
+	ReportPath            string                           `yaml:"report_path"`     // CSP violation report endpoint on the public router, "" disables (default: "/csp-report")
+}
+
+// SecurityHeadersConfig lists the security headers to send. Empty values
+// are not sent; in a router entry, empty values keep the top-level value and
+// "-" removes it.
+type SecurityHeadersConfig struct {
+	CSP                       map[string][]string `yaml:"csp"`                          // Content-Security-Policy directives to sources; the source 'nonce' becomes the request's nonce
+	ReportURI                 string              `yaml:"report_uri"`                   // CSP report-uri (default: report_path on the public router)
+	ReferrerPolicy            string              `yaml:"referrer_policy"`              // Referrer-Policy
+	PermissionsPolicy         map[string][]string `yaml:"permissions_policy"`           // Permissions-Policy features to allowlists, e.g. camera: [] or geolocation: [self]
+	CrossOriginOpenerPolicy   string              `yaml:"cross_origin_opener_policy"`   // Cross-Origin-Opener-Policy
+	CrossOriginEmbedderPolicy string              `yaml:"cross_origin_embedder_policy"` // Cross-Origin-Embedder-Policy
+	CrossOriginResourcePolicy string              `yaml:"cross_origin_resource_policy"` // Cross-Origin-Resource-Policy
+	FrameOptions              string              `yaml:"frame_options"`                // X-Frame-Options
+	ContentTypeOptions        string              `yaml:"content_type_options"`         // X-Content-Type-Options
+	StrictTransportSecurity   string              `yaml:"strict_transport_security"`    // Strict-Transport-Security
+	XSSProtection             string              `yaml:"xss_protection"`               // X-XSS-Protection; "0" disables the legacy filter
+}
+
+// Validate validates the security headers settings
+func (c *SecurityHeadersMiddlewareConfig) Validate() error {
+	for name := range c.Routers {
+		switch name {
+		case "public", "protected", "hidden":
+		default:
+			return fmt.Errorf("middleware.security_headers.routers: unknown router %q", name)
+		}
+	}
+	if c.ReportPath != "" && !strings.HasPrefix(c.ReportPath, "/") {
+		return fmt.Errorf("middleware.security_headers.report_path: must start with /")
+	}
+	return nil
 }
 
 // AuthMiddlewareConfig holds configuration for the authentication middleware
 type AuthMiddlewareConfig struct {
 	BaseMiddlewareConfig `yaml:",inline"`
 	SkipPaths            []string           `yaml:"skip_paths"`
-	DevMode              bool               `yaml:"dev_mode"`         // Enables verbose logging (user ID/name)
-	DevBypassAuth        bool               `yaml:"dev_bypass_auth"`  // Skip real auth, use DevIdentity
+	DevMode              bool               `yaml:"dev_mode"`        // Enables verbose logging (user ID/name)
+	DevBypassAuth        bool               `yaml:"dev_bypass_auth"` // Skip real auth, use DevIdentity
 	DevIdentity          *DevIdentityConfig `yaml:"dev_identity"`
-	CacheEnabled         bool               `yaml:"cache_enabled"`    // Enable session caching
-	CacheTTL             time.Duration      `yaml:"cache_ttl"`        // Cache time-to-live
+	CacheEnabled         bool               `yaml:"cache_enabled"`     // Enable session caching
+	CacheTTL             time.Duration      `yaml:"cache_ttl"`         // Cache time-to-live
+	CacheStore           string             `yaml:"cache_store"`       // "memory" or "redis" (default: "memory")
+	CacheMaxEntries      int                `yaml:"cache_max_entries"` // Memory store size, least recently used are evicted (default: 10000)
+	CacheKeyPrefix       string             `yaml:"cache_key_prefix"`  // Redis key prefix (default: "session:")
+
+	// Authenticators are tried in order; the first whose credential the
+	// request carries decides. Built in: session_cookie, session_token,
+	// jwt, api_key. Empty means session_cookie only.
+	Authenticators []string         `yaml:"authenticators"`
+	JWT            JWTAuthConfig    `yaml:"jwt"`
+	APIKey         APIKeyAuthConfig `yaml:"api_key"`
+}
+
+// JWTAuthConfig holds configuration for bearer JWT authentication
+type JWTAuthConfig struct {
+	JWKSURL         string        `yaml:"jwks_url"`         // Key set endpoint, fetched and cached
+	JWKSFile        string        `yaml:"jwks_file"`        // Local key set, instead of jwks_url (e.g. for tests)
+	Issuer          string        `yaml:"issuer"`           // Required iss claim, empty skips the check
+	Audience        []string      `yaml:"audience"`         // Accepted aud values, empty skips the check
+	SubjectClaim    string        `yaml:"subject_claim"`    // Claim used as the identity ID (default "sub")
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // Key set cache lifetime (default 1h)
+	Leeway          time.Duration `yaml:"leeway"`           // Allowed clock skew for exp, nbf and iat
+	Algorithms      []string      `yaml:"algorithms"`       // Accepted signature algorithms (default RS*, PS*, ES* and EdDSA)
+}
+
+// APIKeyAuthConfig holds configuration for database-backed API key authentication
+type APIKeyAuthConfig struct {
+	Header string `yaml:"header"` // Request header carrying the key (default "X-API-Key")
+	Table  string `yaml:"table"`  // Table of hashed keys, created on first use (default "api_keys")
+}
+
+// Validate validates the authenticator settings
+func (c *AuthMiddlewareConfig) Validate() error {
+	for _, name := range c.Authenticators {
+		if name == "jwt" && c.JWT.JWKSURL == "" && c.JWT.JWKSFile == "" {
+			return fmt.Errorf("middleware.auth.jwt: jwks_url or jwks_file is required")
+		}
+	}
+	if c.JWT.JWKSURL != "" && c.JWT.JWKSFile != "" {
+		return fmt.Errorf("middleware.auth.jwt: only one of jwks_url and jwks_file may be set")
+	}
+	switch c.CacheStore {
+	case "", "memory", "redis":
+	default:
+		return fmt.Errorf("middleware.auth.cache_store: unknown store %q", c.CacheStore)
+	}
+	return nil
 }
 
 // DevIdentityConfig holds configuration for dev mode identity bypass
@@ -97,8 +251,10 @@ type DevIdentityConfig struct {
 
 // HealthConfig holds configuration for health check endpoints
 type HealthConfig struct {
-	Enabled           bool `yaml:"enabled" default:"true"`
-	ShowDetailsInProd bool `yaml:"show_details_in_prod" default:"false"`
+	Enabled           bool          `yaml:"enabled" default:"true"`
+	ShowDetailsInProd bool          `yaml:"show_details_in_prod" default:"false"`
+	Timeout           time.Duration `yaml:"timeout"`   // Default per-check timeout (default: 2s)
+	CacheTTL          time.Duration `yaml:"cache_ttl"` // How long check results are reused (default: 2s)
 }
 
 // PaginationMiddlewareConfig holds configuration for the pagination middleware
@@ -119,6 +275,124 @@ type PaginationParamNames struct {
 	Direction string `yaml:"direction"` // Direction param for cursor pagination: "next" or "prev" (default: "direction")
 }
 
+// IdempotencyMiddlewareConfig holds configuration for the Idempotency-Key middleware
+type IdempotencyMiddlewareConfig struct {
+	BaseMiddlewareConfig `yaml:",inline"`
+	Store                string        `yaml:"store"`       // "redis", "sql", or empty to prefer redis and fall back to sql
+	HeaderName           string        `yaml:"header_name"` // Request header carrying the key (default: "Idempotency-Key")
+	TTL                  time.Duration `yaml:"ttl"`         // How long completed responses are replayed (default: 24h)
+	LockTTL              time.Duration `yaml:"lock_ttl"`    // How long an in-flight claim is held (default: 1m)
+	Methods              []string      `yaml:"methods"`     // Methods the middleware applies to (default: POST, PUT, PATCH, DELETE)
+	Paths                []string      `yaml:"paths"`       // Path globs the middleware applies to; empty means all
+	SkipPaths            []string      `yaml:"skip_paths"`  // Path globs that are never handled
+	KeyPrefix            string        `yaml:"key_prefix"`  // Redis key prefix (default: "idempotency:")
+	TableName            string        `yaml:"table_name"`  // SQL table name (default: "idempotency_keys")
+}
+
+// RateLimitMiddlewareConfig holds configuration for the rate limiting middleware
+type RateLimitMiddlewareConfig struct {
+	BaseMiddlewareConfig `yaml:",inline"`
+	Algorithm            string                 `yaml:"algorithm"`      // "token_bucket" or "sliding_window" (default: "token_bucket")
+	Limit                int                    `yaml:"limit"`          // Requests allowed per window (default: 100)
+	Window               time.Duration          `yaml:"window"`         // Window length (default: 1m)
+	Burst                int                    `yaml:"burst"`          // Token bucket capacity (default: limit)
+	KeyBy                string                 `yaml:"key_by"`         // "ip", "identity" or "api_key" (default: "ip")
+	APIKeyHeader         string                 `yaml:"api_key_header"` // Header read when keying by API key (default: "X-API-Key")
+	Store                string                 `yaml:"store"`          // "memory" or "redis" (default: "memory")
+	KeyPrefix            string                 `yaml:"key_prefix"`     // Redis key prefix (default: "ratelimit:")
+	SkipPaths            []string               `yaml:"skip_paths"`     // Path globs that are never limited
+	Routes               []RateLimitRouteConfig `yaml:"routes"`         // Per-route overrides, first match wins
+}
+
+// RateLimitRouteConfig overrides the rate limit for matching routes.
+// Zero fields inherit from the middleware configuration.
+type RateLimitRouteConfig struct {
+	Path      string        `yaml:"path"`   // Glob matched against the route pattern or request path
+	Method    string        `yaml:"method"` // HTTP method, empty matches any
+	Algorithm string        `yaml:"algorithm"`
+	Limit     int           `yaml:"limit"`
+	Window    time.Duration `yaml:"window"`
+	Burst     int           `yaml:"burst"`
+	KeyBy     string        `yaml:"key_by"`
+	Disabled  bool          `yaml:"disabled"` // Exempt matching routes
+}
+
+// CacheMiddlewareConfig holds configuration for the response cache middleware.
+// ETags are computed for all successful GET responses; responses are only
+// stored when Store is set and the route matches Paths or its handler
+// returns a CachePolicy.
+type CacheMiddlewareConfig struct {
+	BaseMiddlewareConfig `yaml:",inline"`
+	ETag                 bool          `yaml:"etag"`          // Compute strong ETags and answer If-None-Match (default: true)
+	Store                string        `yaml:"store"`         // "", "memory" or "redis" (default: "" - no stored responses)
+	TTL                  time.Duration `yaml:"ttl"`           // Stored response lifetime (default: 5m)
+	Paths                []string      `yaml:"paths"`         // Path globs whose responses are stored
+	SkipPaths            []string      `yaml:"skip_paths"`    // Path globs that are never cached
+	VaryHeaders          []string      `yaml:"vary_headers"`  // Request headers that select a stored variant
+	MaxBodySize          int           `yaml:"max_body_size"` // Larger responses are streamed uncached (default: 1MB)
+	KeyPrefix            string        `yaml:"key_prefix"`    // Redis key prefix (default: "httpcache:")
+}
+
+// BodyLimitMiddlewareConfig holds configuration for the request body limit
+// and decompression middleware. Handlers override the limits per route by
+// implementing http.BodyLimitedHandler.
+type BodyLimitMiddlewareConfig struct {
+	BaseMiddlewareConfig `yaml:",inline"`
+	MaxSize              int64    `yaml:"max_size"`              // Largest body accepted as sent, in bytes (default: 10MB)
+	Decompress           bool     `yaml:"decompress"`            // Decode gzip, deflate and br request bodies (default: true)
+	MaxDecompressedSize  int64    `yaml:"max_decompressed_size"` // Largest decoded body, in bytes (default: max_size)
+	MaxRatio             int      `yaml:"max_ratio"`             // Largest decoded/encoded size ratio before a body is treated as a bomb (default: 100)
+	SkipPaths            []string `yaml:"skip_paths"`            // Path globs that are never limited
+}
+
+// CSRF protection modes
+const (
+	CSRFModeDoubleSubmit = "double_submit" // Token in a cookie, echoed in a header or form field
+	CSRFModeSynchronizer = "synchronizer"  // Token stored server-side per session
+)
+
+// CSRFMiddlewareConfig holds configuration for the CSRF middleware. Unsafe
+// requests must carry the token in HeaderName or FormField; the token is
+// sent in the HeaderName response header.
+type CSRFMiddlewareConfig struct {
+	BaseMiddlewareConfig `yaml:",inline"`
+	Mode                 string        `yaml:"mode"`            // "double_submit" or "synchronizer" (default: "double_submit")
+	HeaderName           string        `yaml:"header_name"`     // Request and response header carrying the token (default: "X-CSRF-Token")
+	FormField            string        `yaml:"form_field"`      // Form field carrying the token (default: "csrf_token")
+	TTL                  time.Duration `yaml:"ttl"`             // Token lifetime (default: 12h)
+	CookieName           string        `yaml:"cookie_name"`     // Double-submit cookie (default: "csrf_token")
+	CookiePath           string        `yaml:"cookie_path"`     // (default: "/")
+	CookieDomain         string        `yaml:"cookie_domain"`   // Empty scopes the cookie to the host
+	CookieSecure         bool          `yaml:"cookie_secure"`   // Send the cookie over HTTPS only (default: true)
+	SameSite             string        `yaml:"same_site"`       // "lax", "strict" or "none" (default: "lax")
+	CheckOrigin          bool          `yaml:"check_origin"`    // Reject unsafe requests from other origins (default: true)
+	TrustedOrigins       []string      `yaml:"trusted_origins"` // Other origins allowed to send unsafe requests, e.g. "https://app.example.com"
+	ExemptBearer         bool          `yaml:"exempt_bearer"`   // Skip requests not authenticated by cookie, e.g. bearer tokens or API keys (default: true)
+	SkipPaths            []string      `yaml:"skip_paths"`      // Path globs that are never checked
+	Store                string        `yaml:"store"`           // Synchronizer token store: "memory" or "redis" (default: "memory")
+	KeyPrefix            string        `yaml:"key_prefix"`      // Redis key prefix (default: "csrf:")
+}
+
+// Validate validates the CSRF settings
+func (c *CSRFMiddlewareConfig) Validate() error {
+	switch c.Mode {
+	case "", CSRFModeDoubleSubmit, CSRFModeSynchronizer:
+	default:
+		return fmt.Errorf("middleware.csrf.mode: unknown mode %q", c.Mode)
+	}
+	switch strings.ToLower(c.SameSite) {
+	case "", "lax", "strict", "none":
+	default:
+		return fmt.Errorf("middleware.csrf.same_site: unknown value %q", c.SameSite)
+	}
+	switch c.Store {
+	case "", "memory", "redis":
+	default:
+		return fmt.Errorf("middleware.csrf.store: unknown store %q", c.Store)
+	}
+	return nil
+}
+
 // DefaultMiddlewareConfig returns default middleware configuration
 func DefaultMiddlewareConfig() MiddlewareConfig {
 	return MiddlewareConfig{
@@ -136,8 +410,8 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 			},
 			AllowOrigins:     []string{"*"},
 			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-			ExposeHeaders:    []string{"X-Request-ID"},
+			AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-CSRF-Token"},
+			ExposeHeaders:    []string{"X-Request-ID", "X-CSRF-Token"},
 			AllowCredentials: false,
 			MaxAge:           86400, // 24 hours
 		},
@@ -162,30 +436,79 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 			Level:   5,
 			MinSize: 1024,
 		},
-		XSS: XSSMiddlewareConfig{
+		SecurityHeaders: SecurityHeadersMiddlewareConfig{
 			BaseMiddlewareConfig: BaseMiddlewareConfig{
 				Enabled:          true,
 				DisableInDevMode: false,
 			},
-			XSSProtection:      "1; mode=block",
-			ContentTypeNosniff: "nosniff",
-			XFrameOptions:      "SAMEORIGIN",
-			HSTSMaxAge:         31536000, // 1 year
+			// Suits HTML pages; scripts and styles need the request's nonce
+			SecurityHeadersConfig: SecurityHeadersConfig{
+				CSP: map[string][]string{
+					"default-src":     {"'self'"},
+					"script-src":      {"'self'", "'nonce'"},
+					"style-src":       {"'self'", "'nonce'"},
+					"img-src":         {"'self'", "data:"},
+					"object-src":      {"'none'"},
+					"base-uri":        {"'self'"},
+					"form-action":     {"'self'"},
+					"frame-ancestors": {"'self'"},
+				},
+				ReferrerPolicy: "strict-origin-when-cross-origin",
+				PermissionsPolicy: map[string][]string{
+					"camera":      {},
+					"geolocation": {},
+					"microphone":  {},
+				},
+				CrossOriginOpenerPolicy:   "same-origin",
+				CrossOriginResourcePolicy: "same-origin",
+				FrameOptions:              "SAMEORIGIN",
+				ContentTypeOptions:        "nosniff",
+				StrictTransportSecurity:   "max-age=31536000", // 1 year
+				XSSProtection:             "0",
+			},
+			// The protected and hidden routers serve APIs, which load nothing
+			Routers: map[string]SecurityHeadersConfig{
+				"protected": {
+					CSP:          map[string][]string{"default-src": {"'none'"}, "frame-ancestors": {"'none'"}},
+					FrameOptions: "DENY",
+				},
+				"hidden": {
+					CSP:          map[string][]string{"default-src": {"'none'"}, "frame-ancestors": {"'none'"}},
+					FrameOptions: "DENY",
+				},
+			},
+			// Report violations until the policy is known not to break pages
+			CSPReportOnly: true,
+			ReportPath:    "/csp-report",
 		},
 		Auth: AuthMiddlewareConfig{
 			BaseMiddlewareConfig: BaseMiddlewareConfig{
 				Enabled:          true, // ENABLED BY DEFAULT
 				DisableInDevMode: false,
 			},
-			SkipPaths:     []string{"/health"},
-			DevMode:       false,
-			DevBypassAuth: false,
-			CacheEnabled:  true,
-			CacheTTL:      15 * time.Minute,
+			SkipPaths:       []string{"/health"},
+			DevMode:         false,
+			DevBypassAuth:   false,
+			CacheEnabled:    true,
+			CacheTTL:        15 * time.Minute,
+			CacheStore:      "memory",
+			CacheMaxEntries: 10000,
+			CacheKeyPrefix:  "session:",
+			JWT: JWTAuthConfig{
+				SubjectClaim:    "sub",
+				RefreshInterval: time.Hour,
+				Leeway:          30 * time.Second,
+			},
+			APIKey: APIKeyAuthConfig{
+				Header: "X-API-Key",
+				Table:  "api_keys",
+			},
 		},
 		Health: HealthConfig{
 			Enabled:           true,  // ENABLED BY DEFAULT
 			ShowDetailsInProd: false, // Details only in dev mode
+			Timeout:           2 * time.Second,
+			CacheTTL:          2 * time.Second,
 		},
 		Pagination: PaginationMiddlewareConfig{
 			BaseMiddlewareConfig: BaseMiddlewareConfig{
@@ -203,5 +526,70 @@ func DefaultMiddlewareConfig() MiddlewareConfig {
 				Direction: "direction",
 			},
 		},
+		Idempotency: IdempotencyMiddlewareConfig{
+			BaseMiddlewareConfig: BaseMiddlewareConfig{
+				Enabled:          true, // Only acts on requests carrying the header
+				DisableInDevMode: false,
+			},
+			HeaderName: "Idempotency-Key",
+			TTL:        24 * time.Hour,
+			LockTTL:    time.Minute,
+			Methods:    []string{"POST", "PUT", "PATCH", "DELETE"},
+			KeyPrefix:  "idempotency:",
+			TableName:  "idempotency_keys",
+		},
+		RateLimit: RateLimitMiddlewareConfig{
+			BaseMiddlewareConfig: BaseMiddlewareConfig{
+				Enabled:          false, // DISABLED BY DEFAULT - limits are app-specific
+				DisableInDevMode: false,
+			},
+			Algorithm:    "token_bucket",
+			Limit:        100,
+			Window:       time.Minute,
+			KeyBy:        "ip",
+			APIKeyHeader: "X-API-Key",
+			Store:        "memory",
+			KeyPrefix:    "ratelimit:",
+			SkipPaths:    []string{"/health", "/health/**"},
+		},
+		Cache: CacheMiddlewareConfig{
+			BaseMiddlewareConfig: BaseMiddlewareConfig{
+				Enabled:          false, // DISABLED BY DEFAULT - buffers and hashes every GET response
+				DisableInDevMode: false,
+			},
+			ETag:        true,
+			TTL:         5 * time.Minute,
+			SkipPaths:   []string{"/health", "/health/**"},
+			VaryHeaders: []string{"Accept", "Accept-Language"},
+			MaxBodySize: 1 << 20,
+			KeyPrefix:   "httpcache:",
+		},
+		BodyLimit: BodyLimitMiddlewareConfig{
+			BaseMiddlewareConfig: BaseMiddlewareConfig{
+				Enabled:          true,
+				DisableInDevMode: false,
+			},
+			MaxSize:    10 << 20,
+			Decompress: true,
+			MaxRatio:   100,
+		},
+		CSRF: CSRFMiddlewareConfig{
+			BaseMiddlewareConfig: BaseMiddlewareConfig{
+				Enabled:          true, // ENABLED BY DEFAULT - protected router only
+				DisableInDevMode: false,
+			},
+			Mode:         CSRFModeDoubleSubmit,
+			HeaderName:   "X-CSRF-Token",
+			FormField:    "csrf_token",
+			TTL:          12 * time.Hour,
+			CookieName:   "csrf_token",
+			CookiePath:   "/",
+			CookieSecure: true,
+			SameSite:     "lax",
+			CheckOrigin:  true,
+			ExemptBearer: true,
+			Store:        "memory",
+			KeyPrefix:    "csrf:",
+		},
 	}
 }
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareConfig_Validate(t *testing.T) {
+	cfg := DefaultMiddlewareConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Routes = []MiddlewareRouteConfig{
+		{Path: "/api/v1/reports/**", Override: map[string]map[string]any{"timeout": {"duration": "5m"}}},
+		{Regex: `^/webhooks/[a-z]+$`, Methods: []string{"POST"}, Disable: []string{"auth"}},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	tests := []struct {
+		name  string
+		route MiddlewareRouteConfig
+		want  string
+	}{
+		{"no matcher", MiddlewareRouteConfig{Disable: []string{"gzip"}}, "exactly one of path or regex"},
+		{"both matchers", MiddlewareRouteConfig{Path: "/a", Regex: "^/a$", Disable: []string{"gzip"}}, "exactly one of path or regex"},
+		{"bad regex", MiddlewareRouteConfig{Regex: "(", Disable: []string{"gzip"}}, "invalid regex"},
+		{"no action", MiddlewareRouteConfig{Path: "/a"}, "one of enable, disable or override"},
+		{"conflict", MiddlewareRouteConfig{Path: "/a", Enable: []string{"gzip"}, Disable: []string{"gzip"}}, `"gzip" is both enabled and disabled`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultMiddlewareConfig()
+			cfg.Routes = []MiddlewareRouteConfig{tt.route}
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "middleware.routes[0]")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestAuthMiddlewareConfig_Validate(t *testing.T) {
+	cfg := DefaultMiddlewareConfig()
+	assert.Equal(t, "sub", cfg.Auth.JWT.SubjectClaim)
+	assert.Equal(t, "X-API-Key", cfg.Auth.APIKey.Header)
+
+	cfg.Auth.Authenticators = []string{"session_cookie", "jwt", "api_key"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jwks_url or jwks_file is required")
+
+	cfg.Auth.JWT.JWKSFile = "testdata/jwks.json"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Auth.JWT.JWKSURL = "https://auth.example.com/.well-known/jwks.json"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only one of jwks_url and jwks_file")
+}
+
+func TestAuthMiddlewareConfig_ValidateCacheStore(t *testing.T) {
+	cfg := DefaultMiddlewareConfig()
+	assert.Equal(t, "memory", cfg.Auth.CacheStore)
+
+	cfg.Auth.CacheStore = "redis"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Auth.CacheStore = "memcached"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown store "memcached"`)
+}
+
+func TestCSRFMiddlewareConfig_Validate(t *testing.T) {
+	cfg := DefaultMiddlewareConfig()
+	assert.True(t, cfg.CSRF.Enabled)
+	assert.Equal(t, CSRFModeDoubleSubmit, cfg.CSRF.Mode)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.CSRF.Mode = "magic"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown mode "magic"`)
+
+	cfg.CSRF.Mode = CSRFModeSynchronizer
+	cfg.CSRF.SameSite = "loose"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown value "loose"`)
+
+	cfg.CSRF.SameSite = "Strict"
+	cfg.CSRF.Store = "memcached"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown store "memcached"`)
+}
+
+func TestMiddlewareConfig_ValidateXSS(t *testing.T) {
+	cfg, err := LoadFromReader(strings.NewReader("middleware:\n  xss:\n    enabled: true\n"))
+	if err == nil {
+		err = cfg.Middleware.Validate()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "middleware.xss was removed")
+}
+
+func TestSecurityHeadersMiddlewareConfig_Validate(t *testing.T) {
+	cfg := DefaultMiddlewareConfig()
+	assert.True(t, cfg.SecurityHeaders.Enabled)
+	assert.Equal(t, "/csp-report", cfg.SecurityHeaders.ReportPath)
+	assert.True(t, cfg.SecurityHeaders.CSPReportOnly)
+	assert.Contains(t, cfg.SecurityHeaders.Routers, "protected")
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SecurityHeaders.Routers["admin"] = SecurityHeadersConfig{}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown router "admin"`)
+
+	delete(cfg.SecurityHeaders.Routers, "admin")
+	cfg.SecurityHeaders.ReportPath = "csp-report"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must start with /")
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PolicyConfig declares an authorization policy over the authenticated
+// identity's traits. Set one combinator (all, any, not), a reference to
+// another policy, or a condition. Values may interpolate path parameters
+// as {name}.
+type PolicyConfig struct {
+	All    []PolicyConfig `yaml:"all"`    // Allowed if every rule allows
+	Any    []PolicyConfig `yaml:"any"`    // Allowed if at least one rule allows
+	Not    *PolicyConfig  `yaml:"not"`    // Allowed if the rule denies
+	Policy string         `yaml:"policy"` // Another named policy
+
+	Trait   string   `yaml:"trait"`   // Dotted trait path, e.g. "role" or "name.first"; list traits match any element
+	Equals  string   `yaml:"equals"`  // Trait equals the value
+	In      []string `yaml:"in"`      // Trait equals one of the values
+	Suffix  string   `yaml:"suffix"`  // Trait ends with the value
+	Present bool     `yaml:"present"` // Trait is set and not empty
+
+	IdentityID  string   `yaml:"identity_id"`  // Identity ID equals the value, e.g. "{id}"
+	EmailDomain []string `yaml:"email_domain"` // Verified email's domain is one of the values
+}
+
+// Validate validates a policy declaration
+func (c *PolicyConfig) Validate() error {
+	kinds := 0
+	for _, set := range []bool{
+		c.All != nil, c.Any != nil, c.Not != nil, c.Policy != "",
+		c.Trait != "", c.IdentityID != "", c.EmailDomain != nil,
+	} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of all, any, not, policy, trait, identity_id or email_domain is required")
+	}
+
+	if c.Trait != "" {
+		conditions := 0
+		for _, set := range []bool{c.Equals != "", c.In != nil, c.Suffix != "", c.Present} {
+			if set {
+				conditions++
+			}
+		}
+		if conditions != 1 {
+			return fmt.Errorf("trait %q: exactly one of equals, in, suffix or present is required", c.Trait)
+		}
+	}
+
+	rules := c.All
+	if c.Any != nil {
+		rules = c.Any
+	}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	if c.Not != nil {
+		if err := c.Not.Validate(); err != nil {
+			return fmt.Errorf("not: %w", err)
+		}
+	}
+	return nil
+}
+
+// validatePolicies validates named policy declarations in name order
+func validatePolicies(policies map[string]PolicyConfig) error {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		policy := policies[name]
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policies.%s: %w", name, err)
+		}
+	}
+	return nil
+}
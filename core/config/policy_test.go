@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPolicyConfig_Load(t *testing.T) {
+	data := `
+policies:
+  staff:
+    any:
+      - trait: role
+        in: [admin, support]
+      - email_domain: [example.com]
+  self_or_admin:
+    any:
+      - identity_id: "{id}"
+      - policy: staff
+  not_banned:
+    not:
+      trait: banned
+      equals: "true"
+`
+	cfg := NewWithDefaults()
+	require.NoError(t, yaml.Unmarshal([]byte(data), cfg))
+	require.NoError(t, cfg.Validate())
+
+	assert.Len(t, cfg.Policies, 3)
+	assert.Equal(t, []string{"admin", "support"}, cfg.Policies["staff"].Any[0].In)
+	assert.Equal(t, "{id}", cfg.Policies["self_or_admin"].Any[0].IdentityID)
+	assert.Equal(t, "banned", cfg.Policies["not_banned"].Not.Trait)
+}
+
+func TestPolicyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PolicyConfig
+		errMsg string
+	}{
+		{"empty", PolicyConfig{}, "exactly one of all, any"},
+		{"two kinds", PolicyConfig{Trait: "role", Equals: "admin", Policy: "staff"}, "exactly one of all, any"},
+		{"trait without condition", PolicyConfig{Trait: "role"}, `trait "role": exactly one of equals`},
+		{"trait with two conditions", PolicyConfig{Trait: "role", Equals: "admin", Present: true}, `trait "role": exactly one of equals`},
+		{"nested", PolicyConfig{All: []PolicyConfig{{Policy: "staff"}, {Trait: "role"}}}, "rule 1: trait"},
+		{"not", PolicyConfig{Not: &PolicyConfig{}}, "not: exactly one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, tt.policy.Validate(), tt.errMsg)
+		})
+	}
+
+	cfg := NewWithDefaults()
+	cfg.Policies = map[string]PolicyConfig{"admin": {Trait: "role"}}
+	assert.ErrorContains(t, cfg.Validate(), "policies.admin: trait")
+}
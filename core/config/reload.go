@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ReloadConfig holds configuration for reloading config at runtime
+type ReloadConfig struct {
+	// Enabled watches the config file and handles SIGHUP
+	Enabled bool `yaml:"enabled"`
+	// Interval between config file checks; 0 reloads on SIGHUP only
+	Interval Duration `yaml:"interval"`
+}
+
+// DefaultReloadConfig returns default reload configuration
+func DefaultReloadConfig() ReloadConfig {
+	return ReloadConfig{
+		Enabled:  false,
+		Interval: Duration(5 * time.Second),
+	}
+}
+
+// Validate validates reload configuration
+func (c *ReloadConfig) Validate() error {
+	if c.Interval.Duration() < 0 {
+		return fmt.Errorf("reload.interval must not be negative")
+	}
+	return nil
+}
+
+// Reload loads the configuration again from the file c was loaded from, or
+// from the default locations, and validates it. Settings applied outside
+// the config file (dev mode, the env var registry) carry over.
+func Reload(c *Config) (*Config, error) {
+	var (
+		next *Config
+		err  error
+	)
+	if c.Source != "" {
+		next, err = LoadFromFile(c.Source)
+	} else {
+		next, err = Load()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DevMode && !next.DevMode {
+		next.SetDevMode(true)
+	}
+	next.EnvRegistry = c.EnvRegistry
+	return next, nil
+}
+
+// ChangedSections returns the config sections that differ between old and
+// new, by YAML key. Middleware is compared per middleware (e.g.
+// "middleware.cors") and extension sections by their own key.
+func ChangedSections(old, new *Config) []string {
+	var changed []string
+
+	oldVal, newVal := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	for i := 0; i < oldVal.NumField(); i++ {
+		field := oldVal.Type().Field(i)
+		key := yamlKey(field)
+		if key == "" || key == "-" {
+			continue
+		}
+
+		if key == "middleware" {
+			changed = append(changed, changedFields("middleware", oldVal.Field(i), newVal.Field(i))...)
+			continue
+		}
+		if !reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			changed = append(changed, key)
+		}
+	}
+
+	for key, value := range new.Extensions {
+		if !reflect.DeepEqual(old.Extensions[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range old.Extensions {
+		if _, ok := new.Extensions[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+// changedFields returns the fields of a struct section that differ, as
+// "<prefix>.<yaml key>"
+func changedFields(prefix string, old, new reflect.Value) []string {
+	var changed []string
+	for i := 0; i < old.NumField(); i++ {
+		key := yamlKey(old.Type().Field(i))
+		if key == "" || key == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(old.Field(i).Interface(), new.Field(i).Interface()) {
+			changed = append(changed, prefix+"."+key)
+		}
+	}
+	return changed
+}
+
+// yamlKey returns the YAML key of a struct field, or "" for inlined fields
+func yamlKey(field reflect.StructField) string {
+	tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if tag == "" && strings.Contains(field.Tag.Get("yaml"), "inline") {
+		return ""
+	}
+	if tag == "" {
+		return strings.ToLower(field.Name)
+	}
+	return tag
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfig_Validate(t *testing.T) {
+	cfg := DefaultReloadConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Interval = Duration(-time.Second)
+	assert.ErrorContains(t, cfg.Validate(), "reload.interval")
+}
+
+func TestChangedSections(t *testing.T) {
+	old := NewWithDefaults()
+	assert.Empty(t, ChangedSections(old, old.Clone()))
+
+	next := old.Clone()
+	next.Logger.Level = "debug"
+	next.Middleware.CORS.AllowOrigins = []string{"https://example.com"}
+	next.Extensions["billing"] = map[string]any{"plan": "pro"}
+
+	assert.ElementsMatch(t, []string{"logger", "middleware.cors", "billing"}, ChangedSections(old, next))
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: info\n"), 0o600))
+
+	cfg, err := LoadFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, cfg.Source)
+	cfg.SetDevMode(true)
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: warn\n"), 0o600))
+	next, err := Reload(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", next.Logger.Level)
+	assert.Equal(t, path, next.Source)
+	assert.True(t, next.IsDevMode())
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  public_port: -1\n"), 0o600))
+	_, err = Reload(cfg)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,110 @@ type ServerConfig struct {
 	WriteTimeout  Duration `yaml:"write_timeout"`  // e.g., "30s", "1m"
 	IdleTimeout   Duration `yaml:"idle_timeout"`   // e.g., "60s", "1m"
 	ShutdownGrace Duration `yaml:"shutdown_grace"` // e.g., "20s", "30s"
+	PreStopDelay  Duration `yaml:"pre_stop_delay"` // Readiness reports 503 this long before draining, e.g. "5s"
+
+	Versioning VersioningConfig `yaml:"versioning"`
+	TLS        ServerTLSConfig  `yaml:"tls"`
+	Batch      BatchConfig      `yaml:"batch"`
+}
+
+// ServerTLSConfig holds TLS configuration for each router
+type ServerTLSConfig struct {
+	Public    TLSConfig `yaml:"public"`
+	Protected TLSConfig `yaml:"protected"`
+	Hidden    TLSConfig `yaml:"hidden"`
+}
+
+// TLSConfig holds TLS configuration for a single router
+type TLSConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	CertFile       string   `yaml:"cert_file"`
+	KeyFile        string   `yaml:"key_file"`
+	ClientCAFile   string   `yaml:"client_ca_file"`  // PEM bundle of CAs trusted for client certificates (mTLS)
+	ClientAuth     string   `yaml:"client_auth"`     // none, request, require, verify_if_given, require_and_verify
+	MinVersion     string   `yaml:"min_version"`     // "1.2" or "1.3"
+	ReloadInterval Duration `yaml:"reload_interval"` // How often rotated files are picked up, e.g. "1m"
+}
+
+// Validate validates a router's TLS configuration
+func (c *TLSConfig) Validate(name string) error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("server.tls.%s.cert_file and key_file are required when TLS is enabled", name)
+	}
+	switch c.ClientAuth {
+	case "", "none", "request", "require", "verify_if_given", "require_and_verify":
+	default:
+		return fmt.Errorf("server.tls.%s.client_auth must be one of none, request, require, verify_if_given, require_and_verify", name)
+	}
+	if (c.ClientAuth == "verify_if_given" || c.ClientAuth == "require_and_verify") && c.ClientCAFile == "" {
+		return fmt.Errorf("server.tls.%s.client_ca_file is required to verify client certificates", name)
+	}
+	switch c.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("server.tls.%s.min_version must be 1.2 or 1.3", name)
+	}
+	if c.ReloadInterval.Duration() < 0 {
+		return fmt.Errorf("server.tls.%s.reload_interval must not be negative", name)
+	}
+	return nil
+}
+
+// VersioningConfig holds API versioning configuration
+type VersioningConfig struct {
+	Prefix         string `yaml:"prefix"`          // Mount prefix for versioned handlers, must contain "{version}"
+	Header         string `yaml:"header"`          // Header used for version negotiation
+	Negotiate      bool   `yaml:"negotiate"`       // Serve unversioned paths by negotiating the version
+	DefaultVersion string `yaml:"default_version"` // Version used when the header is absent (empty: latest)
+}
+
+// BatchConfig holds configuration for the built-in batch route
+type BatchConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	Path          string   `yaml:"path"`          // Route path on each router, e.g. "/batch"
+	MaxRequests   int      `yaml:"max_requests"`  // Largest number of sub-requests per batch
+	Routers       []string `yaml:"routers"`       // public, protected, hidden (empty: all)
+	Transactional bool     `yaml:"transactional"` // Allow batches to run in one database transaction
+}
+
+// Validate validates batch configuration
+func (c *BatchConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("server.batch.path must start with /")
+	}
+	if c.MaxRequests < 1 {
+		return fmt.Errorf("server.batch.max_requests must be positive")
+	}
+	for _, r := range c.Routers {
+		switch r {
+		case "public", "protected", "hidden":
+		default:
+			return fmt.Errorf("server.batch.routers must contain only public, protected or hidden")
+		}
+	}
+	return nil
+}
+
+// EnabledFor reports whether the batch route is served on a router
+func (c *BatchConfig) EnabledFor(router string) bool {
+	if !c.Enabled {
+		return false
+	}
+	if len(c.Routers) == 0 {
+		return true
+	}
+	for _, r := range c.Routers {
+		if r == router {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultServerConfig returns default server configuration
@@ -26,6 +131,14 @@ func DefaultServerConfig() ServerConfig {
 		WriteTimeout:  Duration(30 * time.Second),
 		IdleTimeout:   Duration(60 * time.Second),
 		ShutdownGrace: Duration(20 * time.Second),
+		Versioning: VersioningConfig{
+			Prefix: "/api/{version}",
+			Header: "Accept-Version",
+		},
+		Batch: BatchConfig{
+			Path:        "/batch",
+			MaxRequests: 20,
+		},
 	}
 }
 
@@ -62,9 +175,33 @@ func (c *ServerConfig) Validate() error {
 	if c.ShutdownGrace.Duration() <= 0 {
 		return fmt.Errorf("server.shutdown_grace must be positive")
 	}
+	if c.PreStopDelay.Duration() < 0 {
+		return fmt.Errorf("server.pre_stop_delay must not be negative")
+	}
+	if c.Versioning.Prefix != "" && !strings.Contains(c.Versioning.Prefix, "{version}") {
+		return fmt.Errorf("server.versioning.prefix must contain {version}")
+	}
+	if err := c.TLS.Public.Validate("public"); err != nil {
+		return err
+	}
+	if err := c.TLS.Protected.Validate("protected"); err != nil {
+		return err
+	}
+	if err := c.TLS.Hidden.Validate("hidden"); err != nil {
+		return err
+	}
+	if err := c.Batch.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// ShutdownTimeout returns the total time allowed for shutdown: the pre-stop
+// delay followed by the drain grace period
+func (c *ServerConfig) ShutdownTimeout() time.Duration {
+	return c.PreStopDelay.Duration() + c.ShutdownGrace.Duration()
+}
+
 // PublicAddr returns the public API address
 func (c *ServerConfig) PublicAddr() string {
 	return fmt.Sprintf(":%d", c.PublicPort)
@@ -230,6 +230,56 @@ func TestServerConfig_Validate_NegativeShutdownGrace(t *testing.T) {
 	assert.Contains(t, err.Error(), "server.shutdown_grace must be positive")
 }
 
+func TestServerConfig_Validate_VersioningPrefix(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.Versioning.Prefix = "/api/v1"
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.versioning.prefix must contain {version}")
+}
+
+func TestServerConfig_Validate_TLS(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.TLS.Hidden = TLSConfig{Enabled: true, CertFile: "server.crt"}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.tls.hidden.cert_file and key_file are required")
+
+	cfg.TLS.Hidden.KeyFile = "server.key"
+	cfg.TLS.Hidden.ClientAuth = "require_and_verify"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.tls.hidden.client_ca_file is required")
+
+	cfg.TLS.Hidden.ClientCAFile = "clients.pem"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.TLS.Public = TLSConfig{Enabled: true, CertFile: "a", KeyFile: "b", MinVersion: "1.1"}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.tls.public.min_version")
+}
+
+func TestServerConfig_Validate_NegativePreStopDelay(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.PreStopDelay = Duration(-1 * time.Second)
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.pre_stop_delay must not be negative")
+}
+
+func TestServerConfig_ShutdownTimeout(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.PreStopDelay = Duration(5 * time.Second)
+
+	assert.Equal(t, 25*time.Second, cfg.ShutdownTimeout())
+}
+
 func TestServerConfig_PublicAddr(t *testing.T) {
 	cfg := DefaultServerConfig()
 
@@ -326,3 +376,30 @@ func TestServerConfig_Validate_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchConfig_Validate(t *testing.T) {
+	cfg := DefaultServerConfig()
+	assert.NoError(t, cfg.Batch.Validate(), "disabled batch is always valid")
+
+	cfg.Batch.Enabled = true
+	assert.NoError(t, cfg.Batch.Validate())
+
+	cfg.Batch.Routers = []string{"public", "internal"}
+	assert.ErrorContains(t, cfg.Batch.Validate(), "server.batch.routers")
+
+	cfg.Batch.Routers = nil
+	cfg.Batch.Path = "batch"
+	assert.ErrorContains(t, cfg.Batch.Validate(), "server.batch.path")
+}
+
+func TestBatchConfig_EnabledFor(t *testing.T) {
+	cfg := BatchConfig{Enabled: true}
+	assert.True(t, cfg.EnabledFor("hidden"))
+
+	cfg.Routers = []string{"public"}
+	assert.True(t, cfg.EnabledFor("public"))
+	assert.False(t, cfg.EnabledFor("protected"))
+
+	cfg.Enabled = false
+	assert.False(t, cfg.EnabledFor("public"))
+}
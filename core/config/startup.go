@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StartupConfig holds configuration for the dependency-wait phase at boot
+type StartupConfig struct {
+	// WaitForDependencies retries client initialization and health checks
+	// until Timeout instead of failing on the first error
+	WaitForDependencies bool     `yaml:"wait_for_dependencies"`
+	Timeout             Duration `yaml:"timeout"`         // Deadline for all dependencies, e.g. "2m"
+	InitialBackoff      Duration `yaml:"initial_backoff"` // First retry delay, doubled after each attempt
+	MaxBackoff          Duration `yaml:"max_backoff"`     // Upper bound for the retry delay
+}
+
+// DefaultStartupConfig returns default startup configuration
+func DefaultStartupConfig() StartupConfig {
+	return StartupConfig{
+		WaitForDependencies: false,
+		Timeout:             Duration(60 * time.Second),
+		InitialBackoff:      Duration(500 * time.Millisecond),
+		MaxBackoff:          Duration(5 * time.Second),
+	}
+}
+
+// Validate validates startup configuration
+func (c *StartupConfig) Validate() error {
+	if !c.WaitForDependencies {
+		return nil
+	}
+	if c.Timeout.Duration() <= 0 {
+		return fmt.Errorf("startup.timeout must be positive")
+	}
+	if c.InitialBackoff.Duration() <= 0 {
+		return fmt.Errorf("startup.initial_backoff must be positive")
+	}
+	if c.MaxBackoff.Duration() < c.InitialBackoff.Duration() {
+		return fmt.Errorf("startup.max_backoff must not be less than startup.initial_backoff")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStartupConfig(t *testing.T) {
+	cfg := DefaultStartupConfig()
+
+	assert.False(t, cfg.WaitForDependencies)
+	assert.Equal(t, 60*time.Second, cfg.Timeout.Duration())
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestStartupConfig_Validate(t *testing.T) {
+	cfg := DefaultStartupConfig()
+	cfg.WaitForDependencies = true
+	assert.NoError(t, cfg.Validate())
+
+	cfg.MaxBackoff = Duration(100 * time.Millisecond)
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "startup.max_backoff")
+
+	cfg = DefaultStartupConfig()
+	cfg.WaitForDependencies = true
+	cfg.Timeout = 0
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "startup.timeout must be positive")
+}
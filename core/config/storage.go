@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Storage drivers
+const (
+	StorageDriverLocal = "local"
+	StorageDriverS3    = "s3"
+)
+
+// StorageConfig holds object storage configuration
+type StorageConfig struct {
+	Driver     string             `yaml:"driver"`      // "local" or "s3"; empty disables the storage client
+	SigningKey string             `yaml:"signing_key"` // HMAC key for local signed download URLs
+	URLExpiry  Duration           `yaml:"url_expiry"`  // Default lifetime of signed download URLs
+	Local      LocalStorageConfig `yaml:"local"`
+	S3         S3StorageConfig    `yaml:"s3"`
+}
+
+// LocalStorageConfig configures the local filesystem backend
+type LocalStorageConfig struct {
+	Root    string `yaml:"root"`     // Directory objects are written to
+	BaseURL string `yaml:"base_url"` // Public URL the download handler is mounted at
+}
+
+// S3StorageConfig configures the S3-compatible backend
+type S3StorageConfig struct {
+	Endpoint  string `yaml:"endpoint"` // e.g. "https://s3.amazonaws.com" or "http://minio:9000"
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"` // Use endpoint/bucket/key instead of bucket.endpoint/key (MinIO etc.)
+}
+
+// DefaultStorageConfig returns default storage configuration
+func DefaultStorageConfig() StorageConfig {
+	return StorageConfig{
+		URLExpiry: Duration(15 * time.Minute),
+		Local: LocalStorageConfig{
+			Root: "./storage",
+		},
+		S3: S3StorageConfig{
+			Endpoint: "https://s3.amazonaws.com",
+			Region:   "us-east-1",
+		},
+	}
+}
+
+// IsEnabled returns true if a storage driver is configured
+func (c *StorageConfig) IsEnabled() bool {
+	return c.Driver != ""
+}
+
+// Validate validates storage configuration
+func (c *StorageConfig) Validate() error {
+	switch c.Driver {
+	case "":
+		return nil
+	case StorageDriverLocal:
+		if c.Local.Root == "" {
+			return fmt.Errorf("storage.local.root is required")
+		}
+		if c.Local.BaseURL != "" && c.SigningKey == "" {
+			return fmt.Errorf("storage.signing_key is required when storage.local.base_url is set")
+		}
+	case StorageDriverS3:
+		if c.S3.Endpoint == "" {
+			return fmt.Errorf("storage.s3.endpoint is required")
+		}
+		if c.S3.Bucket == "" {
+			return fmt.Errorf("storage.s3.bucket is required")
+		}
+		if c.S3.Region == "" {
+			return fmt.Errorf("storage.s3.region is required")
+		}
+		if c.S3.AccessKey == "" || c.S3.SecretKey == "" {
+			return fmt.Errorf("storage.s3.access_key and storage.s3.secret_key are required")
+		}
+	default:
+		return fmt.Errorf("storage.driver must be %q or %q, got %q", StorageDriverLocal, StorageDriverS3, c.Driver)
+	}
+	if c.URLExpiry.Duration() < 0 {
+		return fmt.Errorf("storage.url_expiry must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStorageConfig(t *testing.T) {
+	cfg := DefaultStorageConfig()
+
+	assert.False(t, cfg.IsEnabled())
+	assert.Equal(t, 15*time.Minute, cfg.URLExpiry.Duration())
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestStorageConfig_Validate(t *testing.T) {
+	cfg := DefaultStorageConfig()
+	cfg.Driver = StorageDriverLocal
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Local.BaseURL = "https://api.example.com/files"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage.signing_key")
+
+	cfg = DefaultStorageConfig()
+	cfg.Driver = StorageDriverS3
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage.s3.bucket")
+
+	cfg.S3.Bucket = "uploads"
+	cfg.S3.AccessKey = "key"
+	cfg.S3.SecretKey = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Driver = "ftp"
+	assert.Error(t, cfg.Validate())
+}
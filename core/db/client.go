@@ -80,23 +80,47 @@ func (c *Client) Initialize(cfg any) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if c.config.MaxOpenConns > 0 {
-		db.SetMaxOpenConns(c.config.MaxOpenConns)
-	}
-	if c.config.MaxIdleConns > 0 {
-		db.SetMaxIdleConns(c.config.MaxIdleConns)
+	setPool(db, c.config)
+
+	c.db = db
+	return nil
+}
+
+// Reload applies new connection pool settings without closing open
+// connections. Changing the driver or DSN requires a restart.
+func (c *Client) Reload(cfg any) error {
+	clientCfg, ok := cfg.(*ClientConfig)
+	if !ok || clientCfg == nil {
+		return fmt.Errorf("invalid config type: %T", cfg)
 	}
-	if c.config.ConnMaxLifetime > 0 {
-		db.SetConnMaxLifetime(c.config.ConnMaxLifetime)
+	if c.db == nil {
+		return fmt.Errorf("database not initialized")
 	}
-	if c.config.ConnMaxIdleTime > 0 {
-		db.SetConnMaxIdleTime(c.config.ConnMaxIdleTime)
+	if clientCfg.Driver != c.config.Driver || clientCfg.DSN != c.config.DSN {
+		return fmt.Errorf("changing the database driver or DSN requires a restart")
 	}
 
-	c.db = db
+	setPool(c.db, clientCfg)
+	c.config = clientCfg
 	return nil
 }
 
+// setPool applies the connection pool settings of cfg
+func setPool(db *sqlx.DB, cfg *ClientConfig) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
 // Health checks database connectivity
 func (c *Client) Health() error {
 	if c.db == nil {
@@ -152,7 +176,7 @@ func (c *Client) ExecContext(ctx context.Context, query string, args ...any) (sq
 	if c.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
-	return c.db.ExecContext(ctx, query, args...)
+	return c.conn(ctx).ExecContext(ctx, query, args...)
 }
 
 // QueryContext executes a query that returns rows
@@ -160,12 +184,12 @@ func (c *Client) QueryContext(ctx context.Context, query string, args ...any) (*
 	if c.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
-	return c.db.QueryContext(ctx, query, args...)
+	return c.conn(ctx).QueryContext(ctx, query, args...)
 }
 
 // QueryRowContext executes a query expected to return at most one row
 func (c *Client) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
-	return c.db.QueryRowContext(ctx, query, args...)
+	return c.conn(ctx).QueryRowContext(ctx, query, args...)
 }
 
 // GetContext queries a single row into dest
@@ -173,7 +197,7 @@ func (c *Client) GetContext(ctx context.Context, dest any, query string, args ..
 	if c.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
-	return c.db.GetContext(ctx, dest, query, args...)
+	return c.conn(ctx).GetContext(ctx, dest, query, args...)
 }
 
 // SelectContext queries multiple rows into dest slice
@@ -181,7 +205,7 @@ func (c *Client) SelectContext(ctx context.Context, dest any, query string, args
 	if c.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
-	return c.db.SelectContext(ctx, dest, query, args...)
+	return c.conn(ctx).SelectContext(ctx, dest, query, args...)
 }
 
 // NamedExecContext executes a named query
@@ -189,7 +213,7 @@ func (c *Client) NamedExecContext(ctx context.Context, query string, arg any) (s
 	if c.db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
-	return c.db.NamedExecContext(ctx, query, arg)
+	return c.conn(ctx).NamedExecContext(ctx, query, arg)
 }
 
 // Rebind transforms a query from ? placeholders to the driver-specific placeholder
@@ -114,6 +114,29 @@ func TestClient_Initialize(t *testing.T) {
 	})
 }
 
+func TestClient_Reload(t *testing.T) {
+	client := NewClient(&ClientConfig{Driver: "sqlite3", DSN: ":memory:", MaxOpenConns: 1})
+	if err := client.Initialize(nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer client.Shutdown()
+
+	next := &ClientConfig{Driver: "sqlite3", DSN: ":memory:", MaxOpenConns: 4}
+	if err := client.Reload(next); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := client.db.Stats().MaxOpenConnections; got != 4 {
+		t.Errorf("MaxOpenConnections = %d, want 4", got)
+	}
+
+	if err := client.Reload(&ClientConfig{Driver: "sqlite3", DSN: "other.db"}); err == nil {
+		t.Error("Reload should reject a DSN change")
+	}
+	if client.Config() != next {
+		t.Error("failed Reload should keep the previous config")
+	}
+}
+
 func TestClient_Initialize_Errors(t *testing.T) {
 	t.Run("nil config", func(t *testing.T) {
 		client := &Client{}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WriteOp identifies the kind of repository write
+type WriteOp string
+
+const (
+	WriteCreate  WriteOp = "create"
+	WriteUpdate  WriteOp = "update"
+	WriteDelete  WriteOp = "delete"
+	WriteRestore WriteOp = "restore"
+)
+
+// WriteEvent describes a successful repository write.
+// ID is empty for bulk writes (DeleteWhere, UpdateWhere).
+type WriteEvent struct {
+	Table string
+	ID    string
+	Op    WriteOp
+}
+
+// WriteListener is notified after repository writes. Writes made inside a
+// transaction opened by Client.RunInTx or Repository.Transaction are reported
+// once it commits, and not at all if it rolls back.
+type WriteListener func(ctx context.Context, event WriteEvent)
+
+var (
+	writeListeners   []WriteListener
+	writeListenersMu sync.RWMutex
+)
+
+// pendingWrites queues the events of open transactions until they commit
+var (
+	pendingWrites   = make(map[*sqlx.Tx][]WriteEvent)
+	pendingWritesMu sync.Mutex
+)
+
+// OnWrite registers a listener for repository writes
+func OnWrite(fn WriteListener) {
+	writeListenersMu.Lock()
+	defer writeListenersMu.Unlock()
+	writeListeners = append(writeListeners, fn)
+}
+
+// ClearWriteListeners removes all write listeners (useful for testing)
+func ClearWriteListeners() {
+	writeListenersMu.Lock()
+	defer writeListenersMu.Unlock()
+	writeListeners = nil
+}
+
+// notifyWrite calls all registered write listeners, or queues the event if
+// tx is being tracked by trackWrites
+func notifyWrite(ctx context.Context, tx *sqlx.Tx, table, id string, op WriteOp) {
+	event := WriteEvent{Table: table, ID: id, Op: op}
+
+	if tx != nil {
+		pendingWritesMu.Lock()
+		events, ok := pendingWrites[tx]
+		if ok {
+			pendingWrites[tx] = append(events, event)
+		}
+		pendingWritesMu.Unlock()
+		if ok {
+			return
+		}
+	}
+
+	emitWrites(ctx, event)
+}
+
+// emitWrites calls all registered write listeners for each event
+func emitWrites(ctx context.Context, events ...WriteEvent) {
+	writeListenersMu.RLock()
+	listeners := writeListeners
+	writeListenersMu.RUnlock()
+
+	for _, event := range events {
+		for _, fn := range listeners {
+			fn(ctx, event)
+		}
+	}
+}
+
+// trackWrites queues the write events of tx until the returned function is
+// called with whether it committed. Committed events are then emitted;
+// rolled back ones are dropped.
+func trackWrites(ctx context.Context, tx *sqlx.Tx) func(committed bool) {
+	pendingWritesMu.Lock()
+	pendingWrites[tx] = nil
+	pendingWritesMu.Unlock()
+
+	return func(committed bool) {
+		pendingWritesMu.Lock()
+		events := pendingWrites[tx]
+		delete(pendingWrites, tx)
+		pendingWritesMu.Unlock()
+
+		if committed {
+			emitWrites(ctx, events...)
+		}
+	}
+}
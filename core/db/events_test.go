@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnWrite(t *testing.T) {
+	ClearWriteListeners()
+	defer ClearWriteListeners()
+
+	var mu sync.Mutex
+	var events []WriteEvent
+	OnWrite(func(ctx context.Context, event WriteEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	client := setupTestDB(t)
+	repo := NewRepository[*TestCat](client)
+	ctx := context.Background()
+
+	cat := &TestCat{Name: "Whiskers"}
+	if err := repo.Create(ctx, cat); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	cat.Age = 3
+	if err := repo.Update(ctx, cat); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Delete(ctx, cat); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.DeleteWhere(ctx, Where("name = ?", "nobody")); err != nil {
+		t.Fatalf("DeleteWhere failed: %v", err)
+	}
+
+	want := []WriteEvent{
+		{Table: "cats", ID: cat.ID, Op: WriteCreate},
+		{Table: "cats", ID: cat.ID, Op: WriteUpdate},
+		{Table: "cats", ID: cat.ID, Op: WriteDelete},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestOnWrite_Transactions(t *testing.T) {
+	ClearWriteListeners()
+	defer ClearWriteListeners()
+
+	var events []WriteEvent
+	OnWrite(func(ctx context.Context, event WriteEvent) {
+		events = append(events, event)
+	})
+
+	client := setupTestDB(t)
+	repo := NewRepository[*TestCat](client)
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	t.Run("Transaction", func(t *testing.T) {
+		events = nil
+		err := repo.Transaction(ctx, func(tx *TxRepository[*TestCat]) error {
+			if err := tx.Create(ctx, &TestCat{Name: "Tom"}); err != nil {
+				return err
+			}
+			if len(events) != 0 {
+				t.Errorf("got %d events before commit, want 0", len(events))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Transaction() error = %v", err)
+		}
+		if len(events) != 1 || events[0].Op != WriteCreate {
+			t.Errorf("events after commit = %+v, want one create", events)
+		}
+
+		events = nil
+		err = repo.Transaction(ctx, func(tx *TxRepository[*TestCat]) error {
+			if err := tx.Create(ctx, &TestCat{Name: "Jerry"}); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("Transaction() error = %v, want %v", err, errBoom)
+		}
+		if len(events) != 0 {
+			t.Errorf("events after rollback = %+v, want none", events)
+		}
+	})
+
+	t.Run("RunInTx", func(t *testing.T) {
+		events = nil
+		err := client.RunInTx(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &TestCat{Name: "Tom"}); err != nil {
+				return err
+			}
+			if err := repo.Transaction(ctx, func(tx *TxRepository[*TestCat]) error {
+				return tx.Create(ctx, &TestCat{Name: "Jerry"})
+			}); err != nil {
+				return err
+			}
+			if len(events) != 0 {
+				t.Errorf("got %d events before commit, want 0", len(events))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunInTx() error = %v", err)
+		}
+		if len(events) != 2 {
+			t.Errorf("events after commit = %+v, want 2", events)
+		}
+
+		events = nil
+		err = client.RunInTx(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &TestCat{Name: "Spike"}); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("RunInTx() error = %v, want %v", err, errBoom)
+		}
+		if len(events) != 0 {
+			t.Errorf("events after rollback = %+v, want none", events)
+		}
+	})
+}
+
+func TestLastUpdated(t *testing.T) {
+	older := &TestCat{Model: Model{UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	newer := &TestCat{Model: Model{UpdatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}}
+
+	if got := LastUpdated(older, newer); !got.Equal(newer.UpdatedAt) {
+		t.Errorf("LastUpdated() = %v, want %v", got, newer.UpdatedAt)
+	}
+	if got := LastUpdated[*TestCat](); !got.IsZero() {
+		t.Errorf("LastUpdated() with no items = %v, want zero", got)
+	}
+
+	repo := NewRepository[*TestCat](nil)
+	records := []*Record[*TestCat]{repo.Wrap(newer), repo.Wrap(older)}
+	if got := LastUpdated(records...); !got.Equal(newer.UpdatedAt) {
+		t.Errorf("LastUpdated(records) = %v, want %v", got, newer.UpdatedAt)
+	}
+}
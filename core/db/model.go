@@ -15,6 +15,12 @@ type IDGetter interface {
 	GetID() string
 }
 
+// UpdatedAtGetter is an optional interface for models to provide their
+// last modification time
+type UpdatedAtGetter interface {
+	GetUpdatedAt() time.Time
+}
+
 // Model is the base struct for all models with common fields
 type Model struct {
 	ID        string     `db:"id"`
@@ -33,6 +39,11 @@ func (m *Model) GetID() string {
 	return m.ID
 }
 
+// GetUpdatedAt returns when the model was last updated
+func (m *Model) GetUpdatedAt() time.Time {
+	return m.UpdatedAt
+}
+
 // SetID sets the model's ID
 func (m *Model) SetID(id string) {
 	m.ID = id
@@ -63,3 +74,15 @@ func (m *Model) MarkDeleted() {
 func (m *Model) Restore() {
 	m.DeletedAt = nil
 }
+
+// LastUpdated returns the latest UpdatedAt among the given models or records.
+// Useful for setting Last-Modified on list responses.
+func LastUpdated[T UpdatedAtGetter](items ...T) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if t := item.GetUpdatedAt(); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"unicode"
 )
 
 // QueryBuilder builds SQL queries with fluent API
@@ -183,6 +185,87 @@ func Select(columns ...string) QueryOption {
 	}
 }
 
+// SelectFields selects only the columns of model T named by a sparse
+// fieldset (e.g. from http.Context.Fields). Fields match db tags directly,
+// in snake_case form ("firstName" matches first_name), or through aliases
+// (response field -> column). Nested paths match on their first segment and
+// unknown fields are ignored. The primary key and the base Model columns
+// are always selected. It is a no-op when fields is empty.
+func SelectFields[T Modeler](fields []string, aliases map[string]string) QueryOption {
+	return func(qb *QueryBuilder) {
+		if len(fields) == 0 {
+			return
+		}
+
+		var zero T
+		modelType := reflect.TypeOf(zero).Elem()
+		model := reflect.New(modelType).Interface().(T)
+		known := make(map[string]bool)
+		for _, col := range modelColumns(modelType) {
+			known[col] = true
+		}
+
+		selected := make(map[string]bool)
+		var columns []string
+		addColumn := func(col string) {
+			if known[col] && !selected[col] {
+				selected[col] = true
+				columns = append(columns, col)
+			}
+		}
+
+		addColumn(model.PrimaryKey())
+		for _, col := range modelColumns(reflect.TypeOf(Model{})) {
+			addColumn(col)
+		}
+		for _, field := range fields {
+			name := strings.SplitN(field, ".", 2)[0]
+			if col, ok := aliases[name]; ok {
+				addColumn(col)
+				continue
+			}
+			addColumn(name)
+			addColumn(toSnakeCase(name))
+		}
+
+		qb.columns = append(qb.columns, columns...)
+	}
+}
+
+// modelColumns returns the db tag columns of a struct type, including
+// embedded structs
+func modelColumns(t reflect.Type) []string {
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			columns = append(columns, modelColumns(field.Type)...)
+			continue
+		}
+		dbTag := field.Tag.Get("db")
+		if dbTag == "" || dbTag == "-" {
+			continue
+		}
+		columns = append(columns, dbTag)
+	}
+	return columns
+}
+
+// toSnakeCase converts camelCase to snake_case
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // Join adds a JOIN clause
 func Join(join string) QueryOption {
 	return func(qb *QueryBuilder) {
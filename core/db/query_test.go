@@ -425,6 +425,38 @@ func TestSelect(t *testing.T) {
 	}
 }
 
+func TestSelectFields(t *testing.T) {
+	qb := NewQueryBuilder("cats")
+	SelectFields[*TestCat]([]string{"name", "owner.name", "catType", "unknown"}, map[string]string{"catType": "type"})(qb)
+
+	query, _ := qb.Build()
+
+	want := "SELECT id, created_at, updated_at, deleted_at, name, type FROM cats"
+	if !strings.HasPrefix(query, want) {
+		t.Errorf("query = %s, want prefix %s", query, want)
+	}
+}
+
+func TestSelectFields_Empty(t *testing.T) {
+	qb := NewQueryBuilder("cats")
+	SelectFields[*TestCat](nil, nil)(qb)
+
+	query, _ := qb.Build()
+
+	if !strings.HasPrefix(query, "SELECT * FROM cats") {
+		t.Errorf("empty fieldset should select all columns, got: %s", query)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{"name": "name", "firstName": "first_name", "createdAt": "created_at"}
+	for in, want := range tests {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func TestJoin(t *testing.T) {
 	qb := NewQueryBuilder("users")
 	Join("LEFT JOIN orders ON users.id = orders.user_id")(qb)
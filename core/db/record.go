@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 // Record wraps a model and provides active record methods
@@ -25,6 +26,15 @@ func (r *Record[T]) ID() string {
 	return getModelID(r.model)
 }
 
+// GetUpdatedAt returns when the model was last updated, or the zero time if
+// the model does not track updates
+func (r *Record[T]) GetUpdatedAt() time.Time {
+	if getter, ok := any(r.model).(UpdatedAtGetter); ok {
+		return getter.GetUpdatedAt()
+	}
+	return time.Time{}
+}
+
 // IsNew returns true if the record hasn't been persisted
 func (r *Record[T]) IsNew() bool {
 	if baseModel := getBaseModel(r.model); baseModel != nil {
@@ -68,11 +68,13 @@ func (r *Repository[T]) Create(ctx context.Context, model T) error {
 
 	// Build and execute insert query
 	query, _ := r.buildInsertQuery(model)
-	_, err := r.client.db.NamedExecContext(ctx, query, model)
+	_, err := r.client.conn(ctx).NamedExecContext(ctx, query, model)
 	if err != nil {
 		return WrapDBError(err, "create")
 	}
 
+	notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, getModelID(model), WriteCreate)
+
 	// Run after create hooks
 	if err := RunAfterCreateHooks(model); err != nil {
 		return err
@@ -102,7 +104,7 @@ func (r *Repository[T]) Update(ctx context.Context, model T) error {
 
 	// Build and execute update query
 	query, _ := r.buildUpdateQuery(model)
-	result, err := r.client.db.NamedExecContext(ctx, query, model)
+	result, err := r.client.conn(ctx).NamedExecContext(ctx, query, model)
 	if err != nil {
 		return WrapDBError(err, "update")
 	}
@@ -115,6 +117,8 @@ func (r *Repository[T]) Update(ctx context.Context, model T) error {
 		return ErrNotFound
 	}
 
+	notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, getModelID(model), WriteUpdate)
+
 	// Run after update hooks
 	if err := RunAfterUpdateHooks(model); err != nil {
 		return err
@@ -149,7 +153,7 @@ func (r *Repository[T]) Delete(ctx context.Context, model T) error {
 	query := fmt.Sprintf("UPDATE %s SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", r.tableName)
 	query = r.client.Rebind(query)
 
-	result, err := r.client.db.ExecContext(ctx, query, time.Now(), id)
+	result, err := r.client.conn(ctx).ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return WrapDBError(err, "delete")
 	}
@@ -162,6 +166,8 @@ func (r *Repository[T]) Delete(ctx context.Context, model T) error {
 		return ErrNotFound
 	}
 
+	notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, id, WriteDelete)
+
 	// Update model's DeletedAt
 	if baseModel := getBaseModel(model); baseModel != nil {
 		ApplyBeforeDelete(baseModel)
@@ -190,7 +196,7 @@ func (r *Repository[T]) HardDelete(ctx context.Context, model T) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", r.tableName)
 	query = r.client.Rebind(query)
 
-	result, err := r.client.db.ExecContext(ctx, query, id)
+	result, err := r.client.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return WrapDBError(err, "hard delete")
 	}
@@ -203,6 +209,8 @@ func (r *Repository[T]) HardDelete(ctx context.Context, model T) error {
 		return ErrNotFound
 	}
 
+	notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, id, WriteDelete)
+
 	// Run after delete hooks
 	if err := RunAfterDeleteHooks(model); err != nil {
 		return err
@@ -221,7 +229,7 @@ func (r *Repository[T]) Restore(ctx context.Context, model T) error {
 	query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL", r.tableName)
 	query = r.client.Rebind(query)
 
-	result, err := r.client.db.ExecContext(ctx, query, time.Now(), id)
+	result, err := r.client.conn(ctx).ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return WrapDBError(err, "restore")
 	}
@@ -234,6 +242,8 @@ func (r *Repository[T]) Restore(ctx context.Context, model T) error {
 		return ErrNotFound
 	}
 
+	notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, id, WriteRestore)
+
 	// Update model
 	if baseModel := getBaseModel(model); baseModel != nil {
 		baseModel.Restore()
@@ -252,7 +262,7 @@ func (r *Repository[T]) FindByID(ctx context.Context, id string) (*Record[T], er
 	query := fmt.Sprintf("SELECT * FROM %s WHERE id = ? AND deleted_at IS NULL", r.tableName)
 	query = r.client.Rebind(query)
 
-	err := r.client.db.GetContext(ctx, modelPtr, query, id)
+	err := r.client.conn(ctx).GetContext(ctx, modelPtr, query, id)
 	if err != nil {
 		return nil, WrapDBError(err, "find by id")
 	}
@@ -280,7 +290,7 @@ func (r *Repository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]*Re
 	sliceType := reflect.SliceOf(reflect.TypeOf(model).Elem())
 	modelsPtr := reflect.New(sliceType)
 
-	err := r.client.db.SelectContext(ctx, modelsPtr.Interface(), query, args...)
+	err := r.client.conn(ctx).SelectContext(ctx, modelsPtr.Interface(), query, args...)
 	if err != nil {
 		return nil, WrapDBError(err, "find all")
 	}
@@ -333,7 +343,7 @@ func (r *Repository[T]) Count(ctx context.Context, opts ...QueryOption) (int64,
 	query = r.client.Rebind(query)
 
 	var count int64
-	err := r.client.db.GetContext(ctx, &count, query, args...)
+	err := r.client.conn(ctx).GetContext(ctx, &count, query, args...)
 	if err != nil {
 		return 0, WrapDBError(err, "count")
 	}
@@ -347,7 +357,7 @@ func (r *Repository[T]) Exists(ctx context.Context, id string) (bool, error) {
 	query = r.client.Rebind(query)
 
 	var exists bool
-	err := r.client.db.GetContext(ctx, &exists, query, id)
+	err := r.client.conn(ctx).GetContext(ctx, &exists, query, id)
 	if err != nil {
 		return false, WrapDBError(err, "exists check")
 	}
@@ -383,12 +393,19 @@ func (r *Repository[T]) DeleteWhere(ctx context.Context, opts ...QueryOption) (i
 		r.tableName, strings.Join(conditions, " AND "))
 	query = r.client.Rebind(query)
 
-	result, err := r.client.db.ExecContext(ctx, query, args...)
+	result, err := r.client.conn(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, WrapDBError(err, "delete where")
 	}
 
-	return result.RowsAffected()
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows > 0 {
+		notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, "", WriteDelete)
+	}
+	return rows, nil
 }
 
 // UpdateWhere updates all records matching the conditions
@@ -424,12 +441,19 @@ func (r *Repository[T]) UpdateWhere(ctx context.Context, updates map[string]any,
 		strings.Join(conditions, " AND "))
 	query = r.client.Rebind(query)
 
-	result, err := r.client.db.ExecContext(ctx, query, args...)
+	result, err := r.client.conn(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, WrapDBError(err, "update where")
 	}
 
-	return result.RowsAffected()
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows > 0 {
+		notifyWrite(ctx, TxFromContext(ctx, r.client), r.tableName, "", WriteUpdate)
+	}
+	return rows, nil
 }
 
 // WithTx returns a new repository using the given transaction
@@ -440,16 +464,23 @@ func (r *Repository[T]) WithTx(tx *sqlx.Tx) *TxRepository[T] {
 	}
 }
 
-// Transaction executes a function within a transaction
+// Transaction executes a function within a transaction. If ctx carries an
+// ambient transaction (see Client.RunInTx), fn joins it instead.
 func (r *Repository[T]) Transaction(ctx context.Context, fn func(*TxRepository[T]) error) error {
+	if tx := TxFromContext(ctx, r.client); tx != nil {
+		return fn(r.WithTx(tx))
+	}
+
 	tx, err := r.client.BeginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("begin transaction failed: %w", err)
 	}
 
 	txRepo := r.WithTx(tx)
+	done := trackWrites(ctx, tx)
 
 	if err := fn(txRepo); err != nil {
+		done(false)
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("rollback failed: %v (original error: %w)", rbErr, err)
 		}
@@ -457,9 +488,11 @@ func (r *Repository[T]) Transaction(ctx context.Context, fn func(*TxRepository[T
 	}
 
 	if err := tx.Commit(); err != nil {
+		done(false)
 		return fmt.Errorf("commit failed: %w", err)
 	}
 
+	done(true)
 	return nil
 }
 
@@ -614,6 +647,7 @@ func (r *TxRepository[T]) Create(ctx context.Context, model T) error {
 		return WrapDBError(err, "create")
 	}
 
+	notifyWrite(ctx, r.tx, r.repo.tableName, getModelID(model), WriteCreate)
 	return RunAfterCreateHooks(model)
 }
 
@@ -641,6 +675,7 @@ func (r *TxRepository[T]) Update(ctx context.Context, model T) error {
 		return ErrNotFound
 	}
 
+	notifyWrite(ctx, r.tx, r.repo.tableName, getModelID(model), WriteUpdate)
 	return RunAfterUpdateHooks(model)
 }
 
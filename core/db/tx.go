@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// queryer is the query interface shared by *sqlx.DB and *sqlx.Tx
+type queryer interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// txKey scopes an ambient transaction to the client that opened it
+type txKey struct {
+	client *Client
+}
+
+// anyTxKey marks a context carrying an ambient transaction of any client
+type anyTxKey struct{}
+
+// ContextWithTx returns a context in which queries made through client, its
+// repositories and records run inside tx.
+func ContextWithTx(ctx context.Context, client *Client, tx *sqlx.Tx) context.Context {
+	ctx = context.WithValue(ctx, anyTxKey{}, true)
+	return context.WithValue(ctx, txKey{client: client}, tx)
+}
+
+// TxFromContext returns the ambient transaction for client, or nil.
+func TxFromContext(ctx context.Context, client *Client) *sqlx.Tx {
+	tx, _ := ctx.Value(txKey{client: client}).(*sqlx.Tx)
+	return tx
+}
+
+// InTx reports whether ctx carries an ambient transaction for any client,
+// for code that does not know the client, such as middleware.
+func InTx(ctx context.Context) bool {
+	inTx, _ := ctx.Value(anyTxKey{}).(bool)
+	return inTx
+}
+
+// conn returns the ambient transaction for ctx if any, otherwise the pool
+func (c *Client) conn(ctx context.Context) queryer {
+	if tx := TxFromContext(ctx, c); tx != nil {
+		return tx
+	}
+	return c.db
+}
+
+// RunInTx runs fn with an ambient transaction on ctx. The transaction is
+// committed if fn returns nil and rolled back otherwise. If ctx already
+// carries a transaction for this client, fn joins it. Write events are held
+// until the transaction commits.
+func (c *Client) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if TxFromContext(ctx, c) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := c.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+
+	done := trackWrites(ctx, tx)
+
+	defer func() {
+		if p := recover(); p != nil {
+			done(false)
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(ContextWithTx(ctx, c, tx)); err != nil {
+		done(false)
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("rollback failed: %v (original error: %w)", rbErr, err)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		done(false)
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	done(true)
+	return nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClient_RunInTx(t *testing.T) {
+	client := setupTestDB(t)
+	repo := NewRepository[*TestCat](client)
+	ctx := context.Background()
+
+	t.Run("commit", func(t *testing.T) {
+		err := client.RunInTx(ctx, func(ctx context.Context) error {
+			if TxFromContext(ctx, client) == nil || !InTx(ctx) {
+				t.Fatal("expected an ambient transaction")
+			}
+			if err := repo.Create(ctx, &TestCat{Name: "Tom"}); err != nil {
+				return err
+			}
+			// The test pool has one connection, so this only returns if
+			// the query runs on the ambient transaction
+			count, err := repo.Count(ctx)
+			if err != nil || count != 1 {
+				t.Errorf("Count() in tx = %d, %v; want 1", count, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunInTx() error = %v", err)
+		}
+
+		if count, _ := repo.Count(ctx); count != 1 {
+			t.Errorf("Count() after commit = %d, want 1", count)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := client.RunInTx(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &TestCat{Name: "Jerry"}); err != nil {
+				return err
+			}
+			// Nested transactions join the ambient one
+			return repo.Transaction(ctx, func(tx *TxRepository[*TestCat]) error {
+				return errBoom
+			})
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("RunInTx() error = %v, want %v", err, errBoom)
+		}
+
+		if count, _ := repo.Count(ctx); count != 1 {
+			t.Errorf("Count() after rollback = %d, want 1", count)
+		}
+	})
+}
@@ -30,6 +30,9 @@ const (
 	// CodePreconditionFailed represents precondition failed errors (412).
 	CodePreconditionFailed = "PRECONDITION_FAILED"
 
+	// CodePayloadTooLarge represents request entity too large errors (413).
+	CodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+
 	// CodeUnsupportedMedia represents unsupported media type errors (415).
 	CodeUnsupportedMedia = "UNSUPPORTED_MEDIA_TYPE"
 
@@ -64,6 +67,7 @@ func AllCodes() []string {
 		CodeConflict,
 		CodeGone,
 		CodePreconditionFailed,
+		CodePayloadTooLarge,
 		CodeUnsupportedMedia,
 		CodeValidation,
 		CodeTooManyRequests,
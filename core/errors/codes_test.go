@@ -19,6 +19,7 @@ func TestCodes(t *testing.T) {
 		{"conflict", CodeConflict, "CONFLICT"},
 		{"gone", CodeGone, "GONE"},
 		{"precondition failed", CodePreconditionFailed, "PRECONDITION_FAILED"},
+		{"payload too large", CodePayloadTooLarge, "PAYLOAD_TOO_LARGE"},
 		{"unsupported media", CodeUnsupportedMedia, "UNSUPPORTED_MEDIA_TYPE"},
 		{"validation", CodeValidation, "VALIDATION_ERROR"},
 		{"too many requests", CodeTooManyRequests, "TOO_MANY_REQUESTS"},
@@ -51,6 +52,7 @@ func TestAllCodes(t *testing.T) {
 			"CONFLICT",
 			"GONE",
 			"PRECONDITION_FAILED",
+			"PAYLOAD_TOO_LARGE",
 			"UNSUPPORTED_MEDIA_TYPE",
 			"VALIDATION_ERROR",
 			"TOO_MANY_REQUESTS",
@@ -501,6 +501,11 @@ func PreconditionFailed(msg string) *Error {
 	return newWithSkip(CodePreconditionFailed, msg, http.StatusPreconditionFailed, 3)
 }
 
+// PayloadTooLarge creates a request entity too large error.
+func PayloadTooLarge(msg string) *Error {
+	return newWithSkip(CodePayloadTooLarge, msg, http.StatusRequestEntityTooLarge, 3)
+}
+
 // UnsupportedMediaType creates an unsupported media type error.
 func UnsupportedMediaType(msg string) *Error {
 	return newWithSkip(CodeUnsupportedMedia, msg, http.StatusUnsupportedMediaType, 3)
@@ -38,12 +38,24 @@ func NewListMeta(total int64, page, perPage int) ListMeta {
 	}
 }
 
+// Collection is implemented by list payloads. Response projection applies
+// sparse fieldsets to each item under CollectionKey rather than to the
+// envelope.
+type Collection interface {
+	CollectionKey() string // JSON key holding the items
+}
+
 // ListResponse wraps a slice of items with pagination metadata
 type ListResponse[T any] struct {
 	Items []T      `json:"items"`
 	Meta  ListMeta `json:"meta"`
 }
 
+// CollectionKey implements Collection
+func (r *ListResponse[T]) CollectionKey() string {
+	return "items"
+}
+
 // NewListResponse creates a ListResponse with calculated metadata
 func NewListResponse[T any](items []T, total int64, page, perPage int) *ListResponse[T] {
 	if items == nil {
@@ -206,6 +218,11 @@ type CursorListResponse[T any] struct {
 	Meta  CursorMeta `json:"meta"`
 }
 
+// CollectionKey implements Collection
+func (r *CursorListResponse[T]) CollectionKey() string {
+	return "items"
+}
+
 // NewCursorListResponse creates a cursor-based list response
 func NewCursorListResponse[T any](items []T, nextCursor, prevCursor string, hasMore bool, perPage int) *CursorListResponse[T] {
 	if items == nil {
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// errBatchRolledBack aborts a transactional batch after a failed sub-request
+var errBatchRolledBack = fmt.Errorf("batch rolled back")
+
+// WarningCodeBatchRolledBack is added to transactional batch responses
+// whose transaction was rolled back
+const WarningCodeBatchRolledBack = "BATCH_ROLLED_BACK"
+
+// BatchConfig configures the built-in batch route
+type BatchConfig struct {
+	Path        string     // Route path (default: "/batch")
+	MaxRequests int        // Largest number of sub-requests per batch (default: 20)
+	DB          *db.Client // Enables "transactional": true when set
+}
+
+// BatchRequest is the body of a batch call. A bare JSON array of
+// sub-requests is also accepted.
+type BatchRequest struct {
+	Transactional bool        `json:"transactional"`
+	Requests      []BatchItem `json:"requests"`
+}
+
+// BatchItem is a single sub-request
+type BatchItem struct {
+	ID      string            `json:"id,omitempty"` // Echoed back in the result
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // Path and optional query, e.g. "/api/v1/contacts?page=2"
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResult is the outcome of a single sub-request. Body holds the
+// sub-request's response envelope.
+type BatchResult struct {
+	ID      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// batchSkippedHeaders are not copied from the batch request to sub-requests
+var batchSkippedHeaders = []string{
+	echo.HeaderContentLength,
+	echo.HeaderContentType,
+	echo.HeaderContentEncoding,
+	echo.HeaderAcceptEncoding, // Sub-responses are embedded, never compressed
+	"Idempotency-Key",
+	"If-Match",
+	"If-None-Match",
+	echo.HeaderIfModifiedSince,
+	"If-Unmodified-Since",
+}
+
+// batchResultHeaders are copied from sub-responses into results
+var batchResultHeaders = []string{
+	echo.HeaderContentType,
+	echo.HeaderLocation,
+	"ETag",
+	echo.HeaderLastModified,
+	echo.HeaderRetryAfter,
+}
+
+// EnableBatch registers a POST route that runs several API calls in one
+// HTTP request. Each sub-request is dispatched through this router's Echo
+// instance, so middleware, authentication and handlers apply exactly as for
+// a direct call; headers such as Authorization and Cookie are inherited from
+// the batch request. Sub-requests run in order.
+//
+// With a DB configured, {"transactional": true} runs the batch in one
+// transaction on that client (see db.Client.RunInTx). The first sub-request
+// with a status of 400 or above rolls it back, later ones are skipped with
+// 424, and the response carries a BATCH_ROLLED_BACK warning. Only work done
+// through that client is rolled back.
+func (r *Router) EnableBatch(cfg BatchConfig) *echo.Route {
+	if cfg.Path == "" {
+		cfg.Path = "/batch"
+	}
+	if cfg.MaxRequests <= 0 {
+		cfg.MaxRequests = 20
+	}
+	return r.POST(cfg.Path, func(c *Context) error {
+		return r.serveBatch(c, cfg)
+	})
+}
+
+func (r *Router) serveBatch(c *Context, cfg BatchConfig) error {
+	batch, err := readBatch(c)
+	if err != nil {
+		return err
+	}
+	if len(batch.Requests) == 0 {
+		return errors.BadRequest("Batch contains no requests")
+	}
+	if len(batch.Requests) > cfg.MaxRequests {
+		return errors.BadRequest(fmt.Sprintf("Batch contains %d requests; the maximum is %d", len(batch.Requests), cfg.MaxRequests)).
+			WithDetail("max_requests", cfg.MaxRequests)
+	}
+	if errs := validateBatch(batch.Requests, cfg.Path); len(errs) > 0 {
+		return &ValidationErrorList{Errors: errs}
+	}
+	if batch.Transactional && cfg.DB == nil {
+		return errors.BadRequest("Transactional batches are not enabled")
+	}
+
+	results := make([]BatchResult, len(batch.Requests))
+	if !batch.Transactional {
+		for i, item := range batch.Requests {
+			results[i] = r.dispatch(c.Request(), item)
+		}
+		return c.Success(results)
+	}
+
+	err = cfg.DB.RunInTx(c.Request().Context(), func(ctx context.Context) error {
+		outer := c.Request().WithContext(ctx)
+		for i, item := range batch.Requests {
+			results[i] = r.dispatch(outer, item)
+			if results[i].Status >= http.StatusBadRequest {
+				for j := i + 1; j < len(batch.Requests); j++ {
+					results[j] = BatchResult{ID: batch.Requests[j].ID, Status: http.StatusFailedDependency, Body: json.RawMessage("null")}
+				}
+				return errBatchRolledBack
+			}
+		}
+		return nil
+	})
+	if err == errBatchRolledBack {
+		c.AddWarning(WarningCodeBatchRolledBack, "A sub-request failed; no changes from this batch were saved")
+	} else if err != nil {
+		return err
+	}
+	return c.Success(results)
+}
+
+func readBatch(c *Context) (*BatchRequest, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(c.Request().Body).Decode(&raw); err != nil {
+		return nil, &BindError{Cause: err, BindType: BindTypeJSON}
+	}
+
+	batch := &BatchRequest{}
+	var err error
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &batch.Requests)
+	} else {
+		err = json.Unmarshal(raw, batch)
+	}
+	if err != nil {
+		return nil, &BindError{Cause: err, BindType: BindTypeJSON}
+	}
+	return batch, nil
+}
+
+func validateBatch(items []BatchItem, batchPath string) []ValidationError {
+	var errs []ValidationError
+	for i, item := range items {
+		field := fmt.Sprintf("requests[%d]", i)
+		if !batchMethods[strings.ToUpper(item.Method)] {
+			errs = append(errs, ValidationError{Field: field + ".method", Message: "must be one of: GET, HEAD, POST, PUT, PATCH, DELETE"})
+		}
+		u, err := url.Parse(item.Path)
+		switch {
+		case err != nil || !strings.HasPrefix(item.Path, "/") || u.IsAbs():
+			errs = append(errs, ValidationError{Field: field + ".path", Message: "must be an absolute path"})
+		case u.Path == batchPath:
+			errs = append(errs, ValidationError{Field: field + ".path", Message: "must not be the batch route"})
+		}
+	}
+	return errs
+}
+
+// dispatch runs one sub-request through the router and records its response
+func (r *Router) dispatch(outer *http.Request, item BatchItem) BatchResult {
+	// Paths are validated upfront, so this cannot fail
+	req, _ := http.NewRequestWithContext(outer.Context(), strings.ToUpper(item.Method), item.Path, bytes.NewReader(item.Body))
+	req.Host = outer.Host
+	req.RemoteAddr = outer.RemoteAddr
+	req.TLS = outer.TLS
+	req.Header = outer.Header.Clone()
+	for _, h := range batchSkippedHeaders {
+		req.Header.Del(h)
+	}
+	if len(item.Body) > 0 {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for k, v := range item.Headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := newBatchRecorder()
+	r.echo.ServeHTTP(rec, req)
+	return rec.result(item.ID)
+}
+
+// batchRecorder captures a sub-response in memory
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header)}
+}
+
+func (w *batchRecorder) Header() http.Header { return w.header }
+
+func (w *batchRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+func (w *batchRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchRecorder) result(id string) BatchResult {
+	res := BatchResult{ID: id, Status: w.status}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+
+	for _, h := range batchResultHeaders {
+		if v := w.header.Get(h); v != "" {
+			if res.Headers == nil {
+				res.Headers = make(map[string]string)
+			}
+			res.Headers[h] = v
+		}
+	}
+
+	data := bytes.TrimSpace(w.body.Bytes())
+	switch {
+	case len(data) == 0:
+		res.Body = json.RawMessage("null")
+	case json.Valid(data):
+		res.Body = append(json.RawMessage(nil), data...)
+	default:
+		res.Body, _ = json.Marshal(string(data))
+	}
+	return res
+}
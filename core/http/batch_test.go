@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+type batchResponse struct {
+	Payload  []BatchResult     `json:"payload"`
+	Warnings []Warning         `json:"warnings"`
+	Errors   []ValidationError `json:"errors"`
+}
+
+func newBatchRouter(t *testing.T, cfg BatchConfig) *Router {
+	t.Helper()
+
+	r := NewRouter(ScopePublic, ":0")
+	r.SetErrorHandler(func(err error, c echo.Context) {
+		resp := ErrorResponse(err)
+		_ = c.JSON(resp.HTTPStatus, resp)
+	})
+	// Stands in for authentication: every call, including sub-requests,
+	// must carry a token
+	r.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+				return errors.Unauthorized("missing token")
+			}
+			return next(c)
+		}
+	})
+	r.GET("/contacts/:id", func(c *Context) error {
+		if c.Param("id") != "1" {
+			return errors.NotFound("contact not found")
+		}
+		return c.Success(map[string]string{"id": "1", "auth": c.Request().Header.Get(echo.HeaderAuthorization)})
+	})
+	r.POST("/contacts", func(c *Context) error {
+		var body struct {
+			Name string `json:"name" validate:"required"`
+		}
+		if err := c.BindAndValidate(&body); err != nil {
+			return err
+		}
+		if cfg.DB != nil {
+			if _, err := cfg.DB.ExecContext(c.Request().Context(), "INSERT INTO contacts (name) VALUES (?)", body.Name); err != nil {
+				return err
+			}
+		}
+		c.Response().Header().Set(echo.HeaderLocation, "/contacts/"+body.Name)
+		return c.Created(body)
+	})
+	r.EnableBatch(cfg)
+	return r
+}
+
+func postBatch(t *testing.T, r *Router, body string) (*httptest.ResponseRecorder, batchResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var resp batchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return rec, resp
+}
+
+func statuses(results []BatchResult) []int {
+	out := make([]int, len(results))
+	for i, res := range results {
+		out[i] = res.Status
+	}
+	return out
+}
+
+func TestRouter_EnableBatch(t *testing.T) {
+	r := newBatchRouter(t, BatchConfig{MaxRequests: 3})
+
+	t.Run("dispatches each sub-request", func(t *testing.T) {
+		rec, resp := postBatch(t, r, `[
+			{"id": "a", "method": "GET", "path": "/contacts/1"},
+			{"id": "b", "method": "get", "path": "/contacts/2"},
+			{"id": "c", "method": "POST", "path": "/contacts", "body": {"name": "Jane"}}
+		]`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, resp.Payload, 3)
+
+		assert.Equal(t, []int{200, 404, 201}, statuses(resp.Payload))
+		assert.Equal(t, "a", resp.Payload[0].ID)
+		assert.Contains(t, string(resp.Payload[0].Body), `"auth":"Bearer token"`)
+		assert.Contains(t, string(resp.Payload[1].Body), `"code":"NOT_FOUND"`)
+		assert.Equal(t, "/contacts/Jane", resp.Payload[2].Headers[echo.HeaderLocation])
+	})
+
+	t.Run("per-item headers override inherited ones", func(t *testing.T) {
+		_, resp := postBatch(t, r, `{"requests": [
+			{"method": "GET", "path": "/contacts/1", "headers": {"Authorization": "Bearer other"}}
+		]}`)
+		require.Len(t, resp.Payload, 1)
+		assert.Contains(t, string(resp.Payload[0].Body), `"auth":"Bearer other"`)
+	})
+
+	t.Run("sub-request validation errors", func(t *testing.T) {
+		_, resp := postBatch(t, r, `[{"method": "POST", "path": "/contacts", "body": {}}]`)
+		require.Len(t, resp.Payload, 1)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Payload[0].Status)
+	})
+
+	t.Run("rejects invalid items", func(t *testing.T) {
+		rec, resp := postBatch(t, r, `[
+			{"method": "TRACE", "path": "/contacts/1"},
+			{"method": "POST", "path": "/batch"},
+			{"method": "GET", "path": "http://example.com/contacts"}
+		]`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, []ValidationError{
+			{Field: "requests[0].method", Message: "must be one of: GET, HEAD, POST, PUT, PATCH, DELETE"},
+			{Field: "requests[1].path", Message: "must not be the batch route"},
+			{Field: "requests[2].path", Message: "must be an absolute path"},
+		}, resp.Errors)
+	})
+
+	t.Run("enforces the request limit", func(t *testing.T) {
+		rec, _ := postBatch(t, r, `[
+			{"method": "GET", "path": "/contacts/1"},
+			{"method": "GET", "path": "/contacts/1"},
+			{"method": "GET", "path": "/contacts/1"},
+			{"method": "GET", "path": "/contacts/1"}
+		]`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("transactional requires a db client", func(t *testing.T) {
+		rec, _ := postBatch(t, r, `{"transactional": true, "requests": [{"method": "GET", "path": "/contacts/1"}]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestRouter_EnableBatch_Transactional(t *testing.T) {
+	client := db.NewClient(&db.ClientConfig{Driver: "sqlite3", DSN: ":memory:", MaxOpenConns: 1, MaxIdleConns: 1})
+	require.NoError(t, client.Initialize(nil))
+	t.Cleanup(func() { _ = client.Shutdown() })
+	_, err := client.ExecContext(context.Background(), "CREATE TABLE contacts (name TEXT NOT NULL)")
+	require.NoError(t, err)
+
+	r := newBatchRouter(t, BatchConfig{DB: client})
+	count := func() int {
+		var n int
+		require.NoError(t, client.GetContext(context.Background(), &n, "SELECT COUNT(*) FROM contacts"))
+		return n
+	}
+
+	t.Run("commits when every sub-request succeeds", func(t *testing.T) {
+		_, resp := postBatch(t, r, `{"transactional": true, "requests": [
+			{"method": "POST", "path": "/contacts", "body": {"name": "Jane"}},
+			{"method": "POST", "path": "/contacts", "body": {"name": "Max"}}
+		]}`)
+		assert.Equal(t, []int{201, 201}, statuses(resp.Payload))
+		assert.Empty(t, resp.Warnings)
+		assert.Equal(t, 2, count())
+	})
+
+	t.Run("rolls back on the first failure", func(t *testing.T) {
+		_, resp := postBatch(t, r, `{"transactional": true, "requests": [
+			{"method": "POST", "path": "/contacts", "body": {"name": "Ada"}},
+			{"method": "GET", "path": "/contacts/9"},
+			{"id": "skipped", "method": "POST", "path": "/contacts", "body": {"name": "Bob"}}
+		]}`)
+		assert.Equal(t, []int{201, 404, 424}, statuses(resp.Payload))
+		assert.Equal(t, "skipped", resp.Payload[2].ID)
+		assert.Equal(t, "null", string(resp.Payload[2].Body))
+		require.Len(t, resp.Warnings, 1)
+		assert.Equal(t, WarningCodeBatchRolledBack, resp.Warnings[0].Code)
+		assert.Equal(t, 2, count())
+	})
+}
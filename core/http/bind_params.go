@@ -0,0 +1,326 @@
+package http
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// ParamErrorList reports every query, header or cookie parameter that
+// failed to parse or validate. It maps to a single 400 response.
+type ParamErrorList struct {
+	ParamType string // "query", "header" or "cookie"
+	Errors    []ValidationError
+}
+
+func (e *ParamErrorList) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("invalid %s parameters", e.ParamType)
+	}
+	return fmt.Sprintf("%s parameter %s %s", e.ParamType, e.Errors[0].Field, e.Errors[0].Message)
+}
+
+// paramSource looks up raw values for one kind of request parameter
+type paramSource struct {
+	tag       string
+	paramType string
+	lookup    func(name string) []string
+}
+
+// BindQuery fills form from query parameters named by `query` tags, then
+// validates it. Slices accept repeated (?tag=a&tag=b) and comma-separated
+// (?tag=a,b) values. Supported types are strings, bools, numbers,
+// time.Time, time.Duration, encoding.TextUnmarshaler, pointers (nil when
+// absent) and slices of these. Optional tags:
+//
+//	default:"20"            value used when the parameter is absent
+//	enum:"asc|desc"         allowed values
+//	format:"2006-01-02"     time layout (default: RFC 3339 or a date)
+//
+// All invalid fields are returned together in a *ParamErrorList.
+func (c *Context) BindQuery(form any) error {
+	query := c.QueryParams()
+	return bindParams(form, paramSource{
+		tag:       "query",
+		paramType: ParamTypeQuery,
+		lookup: func(name string) []string {
+			return query[name]
+		},
+	})
+}
+
+// BindHeaders fills form from request headers named by `header` tags.
+// See BindQuery for supported types and tag options.
+func (c *Context) BindHeaders(form any) error {
+	header := c.Request().Header
+	return bindParams(form, paramSource{
+		tag:       "header",
+		paramType: ParamTypeHeader,
+		lookup: func(name string) []string {
+			return header.Values(name)
+		},
+	})
+}
+
+// BindCookies fills form from cookies named by `cookie` tags.
+// See BindQuery for supported types and tag options.
+func (c *Context) BindCookies(form any) error {
+	return bindParams(form, paramSource{
+		tag:       "cookie",
+		paramType: ParamTypeCookie,
+		lookup: func(name string) []string {
+			cookie, err := c.Cookie(name)
+			if err != nil {
+				return nil
+			}
+			return []string{cookie.Value}
+		},
+	})
+}
+
+func bindParams(form any, src paramSource) error {
+	val := reflect.ValueOf(form)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return errors.Internal(fmt.Sprintf("Bind%s requires a pointer to a struct", paramBinderName(src.paramType)))
+	}
+	val = val.Elem()
+	typ := val.Type()
+
+	var errs []ValidationError
+	names := make(map[string]string) // struct field -> parameter name
+	failed := make(map[string]bool)  // struct fields that failed to parse
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		name := strings.SplitN(sf.Tag.Get(src.tag), ",", 2)[0]
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		names[sf.Name] = name
+
+		values := nonEmpty(src.lookup(name))
+		if len(values) == 0 {
+			def, ok := sf.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			values = []string{def}
+		}
+
+		if msg := setParamValue(val.Field(i), values, paramOptionsFor(sf)); msg != "" {
+			errs = append(errs, ValidationError{Field: name, Message: msg})
+			failed[sf.Name] = true
+		}
+	}
+
+	if err := validate.Struct(form); err != nil {
+		validationErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+		for _, e := range validationErrs {
+			if failed[e.StructField()] {
+				continue // Already reported as unparseable
+			}
+			field := e.Field()
+			if name, ok := names[e.StructField()]; ok {
+				field = name
+			}
+			errs = append(errs, ValidationError{Field: field, Message: formatParamConstraintMessage(e)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ParamErrorList{ParamType: src.paramType, Errors: errs}
+	}
+	return nil
+}
+
+func paramBinderName(paramType string) string {
+	switch paramType {
+	case ParamTypeHeader:
+		return "Headers"
+	case ParamTypeCookie:
+		return "Cookies"
+	default:
+		return "Query"
+	}
+}
+
+// nonEmpty drops empty values so "?page=" counts as absent
+func nonEmpty(values []string) []string {
+	out := values[:0:0]
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// paramOptions holds the per-field tag options
+type paramOptions struct {
+	enum   []string
+	layout string
+}
+
+func paramOptionsFor(sf reflect.StructField) paramOptions {
+	opts := paramOptions{layout: sf.Tag.Get("format")}
+	if enum := sf.Tag.Get("enum"); enum != "" {
+		opts.enum = strings.Split(enum, "|")
+	}
+	return opts
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setParamValue parses values into dst and returns a validation message on failure
+func setParamValue(dst reflect.Value, values []string, opts paramOptions) string {
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if msg := setParamValue(elem.Elem(), values, opts); msg != "" {
+			return msg
+		}
+		dst.Set(elem)
+		return ""
+	}
+
+	if dst.Kind() == reflect.Slice && !reflect.PointerTo(dst.Type()).Implements(textUnmarshalerType) {
+		var items []string
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if msg := parseParam(slice.Index(i), item, opts); msg != "" {
+				return msg
+			}
+		}
+		dst.Set(slice)
+		return ""
+	}
+
+	return parseParam(dst, values[0], opts)
+}
+
+// parseParam parses a single value into dst
+func parseParam(dst reflect.Value, s string, opts paramOptions) string {
+	if len(opts.enum) > 0 && !containsString(opts.enum, s) {
+		return "must be one of: " + strings.Join(opts.enum, ", ")
+	}
+
+	switch dst.Type() {
+	case timeType:
+		return parseTimeParam(dst, s, opts.layout)
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return "must be a duration (e.g. 30s, 5m)"
+		}
+		dst.SetInt(int64(d))
+		return ""
+	}
+
+	if u, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return "is invalid"
+		}
+		return ""
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return "must be a boolean"
+		}
+		dst.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		dst.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return "must be a non-negative integer"
+		}
+		dst.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		dst.SetFloat(v)
+	default:
+		return "has an unsupported type"
+	}
+	return ""
+}
+
+func parseTimeParam(dst reflect.Value, s, layout string) string {
+	if layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return "must be a time in the format " + layout
+		}
+		dst.Set(reflect.ValueOf(t))
+		return ""
+	}
+	for _, l := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(l, s); err == nil {
+			dst.Set(reflect.ValueOf(t))
+			return ""
+		}
+	}
+	return "must be an RFC 3339 time or a date (YYYY-MM-DD)"
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// formatParamConstraintMessage is formatConstraintMessage with min and max
+// named by the field's kind, since parameters are often numbers. Form
+// messages keep "characters" for every kind.
+func formatParamConstraintMessage(e validator.FieldError) string {
+	var unit string
+	switch e.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch e.Tag() {
+	case "min":
+		return "must be at least " + e.Param() + unit
+	case "max":
+		return "must be at most " + e.Param() + unit
+	default:
+		return formatConstraintMessage(e)
+	}
+}
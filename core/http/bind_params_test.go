@@ -0,0 +1,188 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+type listParams struct {
+	Page     int           `query:"page" default:"1" validate:"min=1"`
+	PerPage  int           `query:"per_page" default:"20" validate:"max=100"`
+	Sort     string        `query:"sort" enum:"asc|desc"`
+	Tags     []string      `query:"tag"`
+	IDs      []int         `query:"ids"`
+	Active   *bool         `query:"active"`
+	Since    time.Time     `query:"since"`
+	Day      time.Time     `query:"day" format:"02/01/2006"`
+	Timeout  time.Duration `query:"timeout"`
+	Score    float64       `query:"score"`
+	Internal string
+}
+
+func paramErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var list *ParamErrorList
+	require.ErrorAs(t, err, &list)
+	out := make(map[string]string, len(list.Errors))
+	for _, e := range list.Errors {
+		out[e.Field] = e.Message
+	}
+	return out
+}
+
+func TestContext_BindQuery(t *testing.T) {
+	t.Run("all types", func(t *testing.T) {
+		c, _ := newTestContext(http.MethodGet,
+			"/items?page=3&sort=desc&tag=a,b&tag=c&ids=1,2&active=false"+
+				"&since=2024-05-01T10:00:00Z&day=31/12/2024&timeout=1m30s&score=4.5", "")
+
+		var p listParams
+		require.NoError(t, c.BindQuery(&p))
+		assert.Equal(t, 3, p.Page)
+		assert.Equal(t, 20, p.PerPage, "default applied")
+		assert.Equal(t, "desc", p.Sort)
+		assert.Equal(t, []string{"a", "b", "c"}, p.Tags)
+		assert.Equal(t, []int{1, 2}, p.IDs)
+		require.NotNil(t, p.Active)
+		assert.False(t, *p.Active)
+		assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), p.Since)
+		assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), p.Day)
+		assert.Equal(t, 90*time.Second, p.Timeout)
+		assert.Equal(t, 4.5, p.Score)
+	})
+
+	t.Run("absent values", func(t *testing.T) {
+		c, _ := newTestContext(http.MethodGet, "/items?page=", "")
+
+		var p listParams
+		require.NoError(t, c.BindQuery(&p))
+		assert.Equal(t, 1, p.Page)
+		assert.Nil(t, p.Active)
+		assert.Nil(t, p.Tags)
+		assert.True(t, p.Since.IsZero())
+	})
+
+	t.Run("reports every invalid field", func(t *testing.T) {
+		c, _ := newTestContext(http.MethodGet,
+			"/items?page=abc&per_page=500&sort=up&ids=1,x&active=maybe&since=yesterday&timeout=10", "")
+
+		var p listParams
+		errs := paramErrors(t, c.BindQuery(&p))
+		assert.Equal(t, map[string]string{
+			"page":     "must be an integer",
+			"per_page": "must be at most 100",
+			"sort":     "must be one of: asc, desc",
+			"ids":      "must be an integer",
+			"active":   "must be a boolean",
+			"since":    "must be an RFC 3339 time or a date (YYYY-MM-DD)",
+			"timeout":  "must be a duration (e.g. 30s, 5m)",
+		}, errs)
+	})
+
+	t.Run("requires a struct pointer", func(t *testing.T) {
+		c, _ := newTestContext(http.MethodGet, "/items", "")
+		var p listParams
+		assert.Error(t, c.BindQuery(p))
+	})
+}
+
+type traceHeaders struct {
+	RequestID string   `header:"X-Request-ID" validate:"required"`
+	Retries   int      `header:"X-Retry-Count"`
+	Accept    []string `header:"Accept-Language"`
+}
+
+func TestContext_BindHeaders(t *testing.T) {
+	c, _ := newTestContext(http.MethodGet, "/", "")
+	c.Request().Header.Set("x-request-id", "req-1")
+	c.Request().Header.Add("Accept-Language", "en, fr")
+	c.Request().Header.Add("Accept-Language", "de")
+
+	var h traceHeaders
+	require.NoError(t, c.BindHeaders(&h))
+	assert.Equal(t, "req-1", h.RequestID)
+	assert.Equal(t, []string{"en", "fr", "de"}, h.Accept)
+
+	c, _ = newTestContext(http.MethodGet, "/", "")
+	c.Request().Header.Set("X-Retry-Count", "two")
+	errs := paramErrors(t, c.BindHeaders(&traceHeaders{}))
+	assert.Equal(t, "is required", errs["X-Request-ID"])
+	assert.Equal(t, "must be an integer", errs["X-Retry-Count"])
+}
+
+type prefCookies struct {
+	Theme string `cookie:"theme" enum:"light|dark" default:"light"`
+	Size  int    `cookie:"size"`
+}
+
+func TestContext_BindCookies(t *testing.T) {
+	c, _ := newTestContext(http.MethodGet, "/", "")
+	c.Request().AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	c.Request().AddCookie(&http.Cookie{Name: "size", Value: "14"})
+
+	var p prefCookies
+	require.NoError(t, c.BindCookies(&p))
+	assert.Equal(t, prefCookies{Theme: "dark", Size: 14}, p)
+
+	c, _ = newTestContext(http.MethodGet, "/", "")
+	c.Request().AddCookie(&http.Cookie{Name: "theme", Value: "neon"})
+	err := c.BindCookies(&p)
+	assert.Equal(t, "must be one of: light, dark", paramErrors(t, err)["theme"])
+	assert.True(t, strings.HasPrefix(err.Error(), "cookie parameter theme"))
+}
+
+func TestParamErrorListMapping(t *testing.T) {
+	err := &ParamErrorList{
+		ParamType: ParamTypeQuery,
+		Errors: []ValidationError{
+			{Field: "page", Message: "must be an integer"},
+			{Field: "sort", Message: "must be one of: asc, desc"},
+		},
+	}
+	fwkErr := errors.MapError(err)
+
+	assert.Equal(t, errors.CodeBadRequest, fwkErr.Code)
+	assert.Equal(t, 400, fwkErr.HTTPStatus)
+	assert.Equal(t, "Invalid query parameters", fwkErr.Message)
+
+	validationErrs, ok := fwkErr.Details["validationErrors"].([]ValidationError)
+	require.True(t, ok)
+	assert.Len(t, validationErrs, 2)
+}
+
+func TestConstraintMessages(t *testing.T) {
+	type form struct {
+		Name  string   `json:"name" query:"name" validate:"min=2"`
+		Count int      `json:"count" query:"count" validate:"min=1"`
+		Tags  []string `json:"tags" query:"tag" validate:"max=1"`
+	}
+
+	// Form validation keeps its messages
+	var list *ValidationErrorList
+	require.ErrorAs(t, Validate(&form{Name: "a", Tags: []string{"x", "y"}}), &list)
+	messages := make(map[string]string, len(list.Errors))
+	for _, e := range list.Errors {
+		messages[e.Field] = e.Message
+	}
+	assert.Equal(t, map[string]string{
+		"name":  "must be at least 2 characters",
+		"count": "must be at least 1 characters",
+		"tags":  "must be at most 1 characters",
+	}, messages)
+
+	// Parameter binding names the unit by kind
+	c, _ := newTestContext(http.MethodGet, "/items?name=a&count=0&tag=x,y", "")
+	var p form
+	assert.Equal(t, map[string]string{
+		"name":  "must be at least 2 characters",
+		"count": "must be at least 1",
+		"tag":   "must be at most 1 items",
+	}, paramErrors(t, c.BindQuery(&p)))
+}
@@ -0,0 +1,18 @@
+package http
+
+// BodyLimitPolicy overrides the global request body limits for a route.
+// Zero fields inherit from the body limit middleware configuration.
+type BodyLimitPolicy struct {
+	MaxSize             int64 // Largest body accepted as sent, in bytes
+	MaxDecompressedSize int64 // Largest decoded body for gzip, deflate or br requests
+	Disabled            bool  // Skip limits and decompression (e.g. routes using StreamUpload with their own limits)
+}
+
+// BodyLimitedHandler is an optional Handler extension for per-route request
+// body limits.
+type BodyLimitedHandler interface {
+	// BodyLimit returns the policy for a route, identified by its method and
+	// full path pattern (e.g. "/api/v1/uploads"). Return nil to use the
+	// default limits.
+	BodyLimit(method, path string) *BodyLimitPolicy
+}
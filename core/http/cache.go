@@ -0,0 +1,21 @@
+package http
+
+import "time"
+
+// CachePolicy opts a route into server-side response caching.
+// Zero fields inherit from the cache middleware configuration.
+type CachePolicy struct {
+	TTL      time.Duration // How long responses are stored
+	Tags     []string      // Tags used to invalidate stored responses
+	Vary     []string      // Extra request headers that select a variant
+	Disabled bool          // Never store responses for the route
+}
+
+// CacheableHandler is an optional Handler extension for per-route response
+// caching. ETags and conditional requests work without it.
+type CacheableHandler interface {
+	// CachePolicy returns the policy for a route, identified by its method
+	// and full path pattern (e.g. "/api/v1/contacts/:id"). Return nil to
+	// leave the route uncached.
+	CachePolicy(method, path string) *CachePolicy
+}
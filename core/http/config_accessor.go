@@ -1,17 +1,22 @@
 package http
 
-import "github.com/codoworks/codo-framework/core/config"
+import (
+	"sync/atomic"
 
-var globalConfig *config.Config
+	"github.com/codoworks/codo-framework/core/config"
+)
+
+var globalConfig atomic.Pointer[config.Config]
 
 // SetGlobalConfig stores the config for handlers to access.
-// This is called during bootstrap before handler initialization.
+// This is called during bootstrap before handler initialization, and again
+// when the config is reloaded.
 func SetGlobalConfig(cfg *config.Config) {
-	globalConfig = cfg
+	globalConfig.Store(cfg)
 }
 
 // GetGlobalConfig returns the current config.
 // Returns nil if SetGlobalConfig has not been called yet.
 func GetGlobalConfig() *config.Config {
-	return globalConfig
+	return globalConfig.Load()
 }
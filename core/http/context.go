@@ -1,10 +1,12 @@
 package http
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -12,6 +14,10 @@ import (
 	"github.com/codoworks/codo-framework/core/pagination"
 )
 
+// CSPNonceKey holds the request's Content-Security-Policy nonce on the echo
+// context
+const CSPNonceKey = "codo.csp_nonce"
+
 // Context extends echo.Context with additional helpers
 type Context struct {
 	echo.Context
@@ -153,8 +159,14 @@ func (c *Context) QueryUUIDOptional(name string) (string, error) {
 	return val, nil
 }
 
-// Success sends a 200 OK response with any accumulated warnings
+// Success sends a 200 OK response with any accumulated warnings. The
+// payload is pruned to the sparse fieldset requested with ?fields=.
 func (c *Context) Success(payload any) error {
+	payload, err := c.project(payload)
+	if err != nil {
+		return err
+	}
+
 	resp := Success(payload)
 	resp.Warnings = c.warnings
 
@@ -209,6 +221,51 @@ func (c *Context) GetRequestID() string {
 	return ""
 }
 
+// CSPNonce returns the request's Content-Security-Policy nonce, for inline
+// <script nonce="..."> and <style nonce="..."> tags, or "" if the policy
+// has none
+func (c *Context) CSPNonce() string {
+	nonce, _ := c.Get(CSPNonceKey).(string)
+	return nonce
+}
+
+// SetCSPNonce sets the request's Content-Security-Policy nonce. The security
+// headers middleware uses a nonce set before it runs instead of generating
+// one, e.g. to match a nonce from an upstream proxy.
+func (c *Context) SetCSPNonce(nonce string) {
+	c.Set(CSPNonceKey, nonce)
+}
+
+// SetLastModified sets the Last-Modified header so clients can revalidate
+// with If-Modified-Since. Zero times are ignored.
+// Example: c.SetLastModified(db.LastUpdated(records...))
+func (c *Context) SetLastModified(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	c.Response().Header().Set(echo.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+}
+
+// ClientCertificate returns the verified client certificate of an mTLS
+// connection, or nil if the client did not present a verified certificate
+func (c *Context) ClientCertificate() *x509.Certificate {
+	state := c.Request().TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// ClientSubject returns the subject of the verified client certificate,
+// e.g. "CN=billing-service,O=Acme", or "" if there is none
+func (c *Context) ClientSubject() string {
+	cert := c.ClientCertificate()
+	if cert == nil {
+		return ""
+	}
+	return cert.Subject.String()
+}
+
 // RealIP returns the client's real IP address
 func (c *Context) RealIP() string {
 	return c.Context.RealIP()
@@ -273,13 +330,14 @@ const (
 	ParamTypePath   = "path"
 	ParamTypeQuery  = "query"
 	ParamTypeHeader = "header"
+	ParamTypeCookie = "cookie"
 )
 
 // ParamError represents a parameter error with context
 type ParamError struct {
 	Param     string // Parameter name
 	Message   string // Error message
-	ParamType string // "path", "query", "header" - where the parameter comes from
+	ParamType string // "path", "query", "header", "cookie" - where the parameter comes from
 	Value     string // The invalid value that was provided
 }
 
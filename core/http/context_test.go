@@ -157,6 +157,15 @@ func TestContext_SendError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
+func TestContext_CSPNonce(t *testing.T) {
+	c, _ := newTestContext(http.MethodGet, "/", "")
+	assert.Equal(t, "", c.CSPNonce())
+
+	c.SetCSPNonce("abc123")
+	assert.Equal(t, "abc123", c.CSPNonce())
+	assert.Equal(t, "abc123", c.Get(CSPNonceKey))
+}
+
 func TestContext_GetRequestID(t *testing.T) {
 	t.Run("from request header", func(t *testing.T) {
 		e := echo.New()
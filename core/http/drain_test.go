@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...any) { l.record(format, args...) }
+func (l *recordingLogger) Warnf(format string, args ...any) { l.record(format, args...) }
+
+func (l *recordingLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestDrain_Phases(t *testing.T) {
+	t.Cleanup(func() { SetDraining(false) })
+
+	r := NewRouter(ScopePublic, "127.0.0.1:0")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.GET("/slow", func(c *Context) error {
+		close(started)
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	ln, err := r.Listen()
+	require.NoError(t, err)
+	go func() { _ = r.Serve(ln) }()
+
+	// Start a request that stays in flight during shutdown
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+	<-started
+	assert.Equal(t, int64(1), r.InFlight())
+
+	log := &recordingLogger{}
+	done := make(chan error, 1)
+	go func() {
+		done <- Drain(context.Background(), &ServerConfig{
+			ShutdownGrace: time.Second,
+			PreStopDelay:  50 * time.Millisecond,
+			Logger:        log,
+		}, r)
+	}()
+
+	// Readiness flips before the listener closes
+	require.Eventually(t, IsDraining, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Drain returned before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, "done", <-result)
+	assert.Equal(t, int64(0), r.InFlight())
+
+	assert.Equal(t, []string{
+		"Shutdown: readiness set to not ready",
+		"Shutdown: waiting 50ms before draining",
+		"Shutdown: draining 1 in-flight request(s)",
+		"Shutdown: all requests drained",
+	}, log.lines)
+}
+
+func TestDrain_GraceExceeded(t *testing.T) {
+	t.Cleanup(func() { SetDraining(false) })
+
+	r := NewRouter(ScopePublic, "127.0.0.1:0")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	r.GET("/stuck", func(c *Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	ln, err := r.Listen()
+	require.NoError(t, err)
+	go func() { _ = r.Serve(ln) }()
+	go func() {
+		if resp, err := http.Get("http://" + ln.Addr().String() + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	log := &recordingLogger{}
+	err = Drain(context.Background(), &ServerConfig{ShutdownGrace: 50 * time.Millisecond, Logger: log}, r)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, log.lines[len(log.lines)-1], "drain incomplete, 1 request(s) still in flight")
+}
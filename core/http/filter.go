@@ -0,0 +1,333 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/pagination"
+)
+
+// SortParam is the query parameter holding the sort order (e.g. "-created_at,name")
+const SortParam = "sort"
+
+// FilterType is the value type of a filterable field
+type FilterType string
+
+const (
+	FilterString FilterType = "string"
+	FilterInt    FilterType = "int"
+	FilterFloat  FilterType = "float"
+	FilterBool   FilterType = "bool"
+	FilterTime   FilterType = "time" // RFC 3339 or YYYY-MM-DD
+)
+
+// Filter operators, used as filter[field][op]=value. A bare filter[field]
+// means eq.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in" // Comma-separated or repeated values
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpBetween  = "between" // Two comma-separated values, inclusive
+	OpContains = "contains"
+	OpNull     = "null" // true for IS NULL, false for IS NOT NULL
+)
+
+// defaultOperators are allowed when a FilterField lists none
+var defaultOperators = map[FilterType][]string{
+	FilterString: {OpEq, OpNe, OpIn, OpContains},
+	FilterInt:    {OpEq, OpNe, OpIn, OpGt, OpGte, OpLt, OpLte, OpBetween},
+	FilterFloat:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpBetween},
+	FilterBool:   {OpEq},
+	FilterTime:   {OpEq, OpGt, OpGte, OpLt, OpLte, OpBetween},
+}
+
+var filterValueTypes = map[FilterType]reflect.Type{
+	FilterString: reflect.TypeOf(""),
+	FilterInt:    reflect.TypeOf(int64(0)),
+	FilterFloat:  reflect.TypeOf(float64(0)),
+	FilterBool:   reflect.TypeOf(false),
+	FilterTime:   reflect.TypeOf(time.Time{}),
+}
+
+// FilterField describes one filterable field.
+type FilterField struct {
+	Column    string     // Database column (default: the filter name)
+	Type      FilterType // Value type (default: FilterString)
+	Operators []string   // Allowed operators (default: by type, see defaultOperators)
+	Enum      []string   // Allowed values for string fields
+}
+
+// FilterSpec declares the filters and sort orders a list route accepts.
+type FilterSpec struct {
+	Fields      map[string]FilterField // Filterable fields by query name
+	Sort        []string               // Sortable fields by query name; columns resolve through Fields
+	DefaultSort string                 // Sort applied when none is requested (e.g. "-created_at")
+}
+
+// FilteredHandler is an optional Handler extension declaring the filter and
+// sort query language for list routes.
+type FilteredHandler interface {
+	// Filters returns the spec for a route, identified by its method and
+	// full path pattern (e.g. "/api/v1/contacts"). Return nil to reject
+	// filter[...] and sort parameters.
+	Filters(method, path string) *FilterSpec
+}
+
+// Filter is a validated filter condition
+type Filter struct {
+	Field    string
+	Column   string
+	Operator string
+	Values   []any
+}
+
+// SortField is a validated sort column
+type SortField struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// ListQuery holds the parsed filters, sort order and pagination of a list
+// request.
+type ListQuery struct {
+	Filters []Filter
+	Sort    []SortField
+	Page    *pagination.Params // nil when the pagination middleware is off
+}
+
+// ListQuery parses filter[...] and sort parameters against the handler's
+// FilterSpec and merges in the pagination middleware's Params. Every invalid
+// filter or sort field is reported in one *ParamErrorList.
+//
+//	GET /contacts?filter[status]=active&filter[created_at][gte]=2024-01-01&sort=-created_at,name
+func (c *Context) ListQuery() (*ListQuery, error) {
+	var spec *FilterSpec
+	if h, ok := HandlerFor(c.Context).(FilteredHandler); ok {
+		spec = h.Filters(c.Request().Method, c.Path())
+	}
+
+	q, err := ParseListQuery(c.QueryParams(), spec)
+	if err != nil {
+		return nil, err
+	}
+	q.Page = pagination.Get(c.Context)
+	return q, nil
+}
+
+// FilterOptions returns the WHERE options for the filters, e.g. for Count.
+func (q *ListQuery) FilterOptions() []db.QueryOption {
+	opts := make([]db.QueryOption, 0, len(q.Filters))
+	for _, f := range q.Filters {
+		opts = append(opts, f.option())
+	}
+	return opts
+}
+
+// QueryOptions returns the filter, order and pagination options.
+func (q *ListQuery) QueryOptions() []db.QueryOption {
+	opts := q.FilterOptions()
+	for _, s := range q.Sort {
+		if s.Desc {
+			opts = append(opts, db.OrderByDesc(s.Column))
+		} else {
+			opts = append(opts, db.OrderByAsc(s.Column))
+		}
+	}
+	return append(opts, q.Page.QueryOptions()...)
+}
+
+func (f Filter) option() db.QueryOption {
+	switch f.Operator {
+	case OpNe:
+		return db.Where(f.Column+" <> ?", f.Values[0])
+	case OpIn:
+		return db.WhereIn(f.Column, f.Values...)
+	case OpGt:
+		return db.Where(f.Column+" > ?", f.Values[0])
+	case OpGte:
+		return db.Where(f.Column+" >= ?", f.Values[0])
+	case OpLt:
+		return db.Where(f.Column+" < ?", f.Values[0])
+	case OpLte:
+		return db.Where(f.Column+" <= ?", f.Values[0])
+	case OpBetween:
+		return db.WhereBetween(f.Column, f.Values[0], f.Values[1])
+	case OpContains:
+		return db.Where(f.Column+" LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(f.Values[0].(string))+"%")
+	case OpNull:
+		if f.Values[0].(bool) {
+			return db.WhereNull(f.Column)
+		}
+		return db.WhereNotNull(f.Column)
+	default:
+		return db.WhereEq(f.Column, f.Values[0])
+	}
+}
+
+// likeEscaper escapes LIKE wildcards so contains matches them literally. "!"
+// is used as the escape character because backslash is itself an escape in
+// MySQL string literals.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// ParseListQuery parses filter[...] and sort parameters from query against
+// spec. A nil spec rejects any filter or sort parameter.
+func ParseListQuery(query url.Values, spec *FilterSpec) (*ListQuery, error) {
+	if spec == nil {
+		spec = &FilterSpec{}
+	}
+	q := &ListQuery{}
+	var errs []ValidationError
+
+	// Sorted for a stable filter and error order
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		filter, msg := parseFilter(key, nonEmpty(query[key]), spec)
+		if msg != "" {
+			errs = append(errs, ValidationError{Field: key, Message: msg})
+			continue
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+
+	sortValue, requested := query.Get(SortParam), true
+	if sortValue == "" {
+		sortValue, requested = spec.DefaultSort, false
+	}
+	for _, name := range strings.Split(sortValue, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		s := SortField{Field: strings.TrimLeft(name, "+-"), Desc: strings.HasPrefix(name, "-")}
+		if requested && !containsString(spec.Sort, s.Field) {
+			errs = append(errs, ValidationError{Field: SortParam, Message: fmt.Sprintf("cannot sort by %q", s.Field)})
+			continue
+		}
+		s.Column = spec.column(s.Field)
+		q.Sort = append(q.Sort, s)
+	}
+
+	if len(errs) > 0 {
+		return nil, &ParamErrorList{ParamType: ParamTypeQuery, Errors: errs}
+	}
+	return q, nil
+}
+
+func (s *FilterSpec) column(name string) string {
+	if f, ok := s.Fields[name]; ok && f.Column != "" {
+		return f.Column
+	}
+	return name
+}
+
+// parseFilter parses one filter[name] or filter[name][op] parameter
+func parseFilter(key string, values []string, spec *FilterSpec) (Filter, string) {
+	name, op, ok := parseFilterKey(key)
+	if !ok {
+		return Filter{}, "must be filter[field] or filter[field][operator]"
+	}
+	field, ok := spec.Fields[name]
+	if !ok {
+		return Filter{}, "unknown filter"
+	}
+	if field.Type == "" {
+		field.Type = FilterString
+	}
+	allowed := field.Operators
+	if len(allowed) == 0 {
+		allowed = defaultOperators[field.Type]
+	}
+	if !containsString(allowed, op) {
+		return Filter{}, fmt.Sprintf("operator %q is not allowed (allowed: %s)", op, strings.Join(allowed, ", "))
+	}
+	if len(values) == 0 {
+		return Filter{}, "is required"
+	}
+
+	filter := Filter{Field: name, Column: spec.column(name), Operator: op}
+
+	raw := values
+	switch op {
+	case OpIn, OpBetween:
+		raw = nil
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					raw = append(raw, item)
+				}
+			}
+		}
+		if len(raw) == 0 {
+			return Filter{}, "is required"
+		}
+		if op == OpBetween && len(raw) != 2 {
+			return Filter{}, "must be two comma-separated values"
+		}
+	case OpNull:
+		v, err := parseFilterValue(FilterBool, values[0], nil)
+		if err != "" {
+			return Filter{}, err
+		}
+		filter.Values = []any{v}
+		return filter, ""
+	default:
+		if len(values) > 1 {
+			return Filter{}, "must be a single value"
+		}
+	}
+
+	for _, s := range raw {
+		v, msg := parseFilterValue(field.Type, s, field.Enum)
+		if msg != "" {
+			return Filter{}, msg
+		}
+		filter.Values = append(filter.Values, v)
+	}
+	return filter, ""
+}
+
+// parseFilterKey splits "filter[name]" or "filter[name][op]"
+func parseFilterKey(key string) (name, op string, ok bool) {
+	rest := strings.TrimPrefix(key, "filter[")
+	end := strings.Index(rest, "]")
+	if end <= 0 {
+		return "", "", false
+	}
+	name, rest = rest[:end], rest[end+1:]
+	if rest == "" {
+		return name, OpEq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", false
+	}
+	return name, rest[1 : len(rest)-1], true
+}
+
+func parseFilterValue(typ FilterType, s string, enum []string) (any, string) {
+	valueType, ok := filterValueTypes[typ]
+	if !ok {
+		return nil, "has an unsupported type"
+	}
+	v := reflect.New(valueType).Elem()
+	if msg := parseParam(v, s, paramOptions{enum: enum}); msg != "" {
+		return nil, msg
+	}
+	return v.Interface(), ""
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/pagination"
+)
+
+var contactFilters = &FilterSpec{
+	Fields: map[string]FilterField{
+		"status":     {Enum: []string{"active", "archived"}},
+		"name":       {},
+		"age":        {Type: FilterInt},
+		"created_at": {Type: FilterTime},
+		"company":    {Column: "company_id", Operators: []string{OpEq, OpNull}},
+	},
+	Sort:        []string{"name", "created_at"},
+	DefaultSort: "-created_at",
+}
+
+func buildQuery(q *ListQuery) (string, []any) {
+	return db.NewQueryBuilder("contacts").Apply(q.QueryOptions()...).Build()
+}
+
+func TestParseListQuery(t *testing.T) {
+	t.Run("filters and sort", func(t *testing.T) {
+		query, _ := url.ParseQuery("filter[status]=active&filter[age][between]=18,65" +
+			"&filter[created_at][gte]=2024-01-01&filter[name][in]=Jane,Max&filter[company][null]=true&sort=-name,created_at")
+
+		q, err := ParseListQuery(query, contactFilters)
+		require.NoError(t, err)
+
+		sql, args := buildQuery(q)
+		assert.Equal(t, "SELECT * FROM contacts WHERE deleted_at IS NULL"+
+			" AND age BETWEEN ? AND ? AND company_id IS NULL AND created_at >= ? AND name IN (?, ?) AND status = ?"+
+			" ORDER BY name DESC, created_at ASC", sql)
+		assert.Equal(t, []any{
+			int64(18), int64(65),
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			"Jane", "Max", "active",
+		}, args)
+	})
+
+	t.Run("default sort", func(t *testing.T) {
+		q, err := ParseListQuery(url.Values{}, contactFilters)
+		require.NoError(t, err)
+		sql, _ := buildQuery(q)
+		assert.Contains(t, sql, "ORDER BY created_at DESC")
+	})
+
+	t.Run("contains", func(t *testing.T) {
+		q, err := ParseListQuery(url.Values{"filter[name][contains]": {"an"}}, contactFilters)
+		require.NoError(t, err)
+		sql, args := buildQuery(q)
+		assert.Contains(t, sql, "name LIKE ? ESCAPE '!'")
+		assert.Equal(t, []any{"%an%"}, args)
+
+		q, err = ParseListQuery(url.Values{"filter[name][contains]": {"50%_off!"}}, contactFilters)
+		require.NoError(t, err)
+		_, args = buildQuery(q)
+		assert.Equal(t, []any{"%50!%!_off!!%"}, args)
+	})
+
+	t.Run("empty in list", func(t *testing.T) {
+		_, err := ParseListQuery(url.Values{"filter[name][in]": {","}}, contactFilters)
+		assert.Equal(t, "is required", paramErrors(t, err)["filter[name][in]"])
+	})
+
+	t.Run("reports every invalid parameter", func(t *testing.T) {
+		query, _ := url.ParseQuery("filter[status]=deleted&filter[age][gt]=old&filter[age][contains]=1" +
+			"&filter[email]=x&filter[age][between]=1&filter[name]]=x&sort=name,password")
+
+		_, err := ParseListQuery(query, contactFilters)
+		assert.Equal(t, map[string]string{
+			"filter[age][between]":  "must be two comma-separated values",
+			"filter[age][contains]": `operator "contains" is not allowed (allowed: eq, ne, in, gt, gte, lt, lte, between)`,
+			"filter[age][gt]":       "must be an integer",
+			"filter[email]":         "unknown filter",
+			"filter[name]]":         "must be filter[field] or filter[field][operator]",
+			"filter[status]":        "must be one of: active, archived",
+			"sort":                  `cannot sort by "password"`,
+		}, paramErrors(t, err))
+	})
+
+	t.Run("nil spec rejects filters", func(t *testing.T) {
+		_, err := ParseListQuery(url.Values{"filter[status]": {"active"}}, nil)
+		assert.Equal(t, "unknown filter", paramErrors(t, err)["filter[status]"])
+	})
+}
+
+type filteredContactHandler struct {
+	mockHandler
+}
+
+func (h *filteredContactHandler) Filters(method, path string) *FilterSpec {
+	return contactFilters
+}
+
+func TestContext_ListQuery(t *testing.T) {
+	isolateHandlers(t)
+
+	var got *ListQuery
+	RegisterHandler(&filteredContactHandler{mockHandler{
+		prefix: "/contacts",
+		scope:  ScopePublic,
+		routes: func(g *echo.Group) {
+			g.GET("", WrapHandler(func(c *Context) error {
+				pagination.Set(c.Context, &pagination.Params{Type: pagination.TypeOffset, Page: 2, PerPage: 10, Offset: 10})
+				q, err := c.ListQuery()
+				got = q
+				return err
+			}))
+		},
+	}})
+
+	r := NewRouter(ScopePublic, ":0")
+	require.NoError(t, r.RegisterHandlers())
+
+	rec := httptest.NewRecorder()
+	r.Echo().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contacts?filter%5Bstatus%5D=active&sort=name", nil))
+	require.NotNil(t, got)
+
+	sql, args := buildQuery(got)
+	assert.Equal(t, "SELECT * FROM contacts WHERE deleted_at IS NULL AND status = ? ORDER BY name ASC LIMIT 10 OFFSET 10", sql)
+	assert.Equal(t, []any{"active"}, args)
+
+	countSQL, _ := db.NewQueryBuilder("contacts").Apply(got.FilterOptions()...).BuildCount()
+	assert.Equal(t, "SELECT COUNT(*) FROM contacts WHERE deleted_at IS NULL AND status = ?", countSQL)
+}
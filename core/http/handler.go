@@ -28,7 +28,7 @@ type HandlerFunc func(*Context) error
 // WrapHandler wraps a HandlerFunc to work with Echo
 func WrapHandler(fn HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		cc := &Context{Context: c}
+		cc := &Context{Context: c, warnings: queuedWarnings(c)}
 		return fn(cc)
 	}
 }
@@ -43,8 +43,10 @@ type Middleware func(next HandlerFunc) HandlerFunc
 func WrapMiddleware(m Middleware) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			cc := &Context{Context: c}
+			cc := &Context{Context: c, warnings: queuedWarnings(c)}
 			wrappedNext := func(ctx *Context) error {
+				// Pass warnings added by the middleware on to the handler
+				ctx.Set(warningsKey, ctx.warnings)
 				return next(ctx.Context)
 			}
 			return m(wrappedNext)(cc)
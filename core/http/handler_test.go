@@ -94,4 +94,35 @@ func TestWrapMiddleware(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "value", rec.Header().Get("X-Custom"))
 	})
+	t.Run("warnings reach the handler", func(t *testing.T) {
+		queue := func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				AppendWarning(c, NewWarning("QUEUED", "from echo middleware"))
+				return next(c)
+			}
+		}
+		middleware := WrapMiddleware(func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) error {
+				c.AddWarning("ADDED", "from handler middleware")
+				return next(c)
+			}
+		})
+
+		var warnings []Warning
+		handler := WrapHandler(func(c *Context) error {
+			warnings = c.GetWarnings()
+			return c.Success(nil)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := queue(middleware(handler))(c)
+		assert.NoError(t, err)
+		if assert.Len(t, warnings, 2) {
+			assert.Equal(t, "QUEUED", warnings[0].Code)
+			assert.Equal(t, "ADDED", warnings[1].Code)
+		}
+	})
 }
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"sync"
 
@@ -65,6 +66,12 @@ func handleAlive(c echo.Context) error {
 }
 
 func handleReady(c echo.Context) error {
+	if IsDraining() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"status": "draining",
+		})
+	}
+
 	healthCheckersMu.RLock()
 	checkers := make([]HealthChecker, len(healthCheckers))
 	copy(checkers, healthCheckers)
@@ -84,10 +91,21 @@ func handleReady(c echo.Context) error {
 	})
 }
 
+// HealthStatus is the overall readiness status
+type HealthStatus string
+
+const (
+	HealthStatusReady    HealthStatus = "ready"
+	HealthStatusDegraded HealthStatus = "degraded"  // A non-critical check failed
+	HealthStatusNotReady HealthStatus = "not ready" // A critical check failed
+	HealthStatusDraining HealthStatus = "draining"  // Shutting down
+)
+
 // HealthResponse represents a health check response
 type HealthResponse struct {
-	Status  string            `json:"status"`
-	Details map[string]string `json:"details,omitempty"`
+	Status  string                       `json:"status"`
+	Details map[string]string            `json:"details,omitempty"` // "ok" or the error, by check
+	Checks  map[string]HealthCheckResult `json:"checks,omitempty"`  // Full results, by check
 }
 
 // NamedHealthChecker is a health checker with a name
@@ -96,46 +114,24 @@ type NamedHealthChecker struct {
 	Checker HealthChecker
 }
 
-var (
-	namedHealthCheckers   []NamedHealthChecker
-	namedHealthCheckersMu sync.RWMutex
-)
-
-// RegisterNamedHealthChecker adds a named health checker
+// RegisterNamedHealthChecker adds a critical health checker with the default timeout
 func RegisterNamedHealthChecker(name string, checker HealthChecker) {
-	namedHealthCheckersMu.Lock()
-	defer namedHealthCheckersMu.Unlock()
-	namedHealthCheckers = append(namedHealthCheckers, NamedHealthChecker{
-		Name:    name,
-		Checker: checker,
+	RegisterHealthCheck(HealthCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			return checker()
+		},
 	})
 }
 
 // ClearNamedHealthCheckers removes all named health checkers (for testing)
 func ClearNamedHealthCheckers() {
-	namedHealthCheckersMu.Lock()
-	defer namedHealthCheckersMu.Unlock()
-	namedHealthCheckers = nil
+	ClearHealthChecks()
 }
 
-// RunHealthChecks runs all named health checkers and returns results
+// RunHealthChecks runs all health checks and returns whether the service is
+// ready, with "ok" or the error message for each check
 func RunHealthChecks() (bool, map[string]string) {
-	namedHealthCheckersMu.RLock()
-	checkers := make([]NamedHealthChecker, len(namedHealthCheckers))
-	copy(checkers, namedHealthCheckers)
-	namedHealthCheckersMu.RUnlock()
-
-	results := make(map[string]string)
-	healthy := true
-
-	for _, nc := range checkers {
-		if err := nc.Checker(); err != nil {
-			results[nc.Name] = err.Error()
-			healthy = false
-		} else {
-			results[nc.Name] = "ok"
-		}
-	}
-
-	return healthy, results
+	report := CheckHealth(context.Background())
+	return report.Status != HealthStatusNotReady, report.Summary()
 }
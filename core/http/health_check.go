@@ -0,0 +1,233 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HealthCriticality determines how a failing check affects readiness
+type HealthCriticality int
+
+const (
+	// Critical checks make the service not ready when they fail
+	Critical HealthCriticality = iota
+	// NonCritical checks only degrade the service when they fail
+	NonCritical
+)
+
+// HealthCheckFunc checks a dependency. It should return promptly when ctx is done.
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthCheck is a named dependency check
+type HealthCheck struct {
+	Name        string
+	Check       HealthCheckFunc
+	Timeout     time.Duration     // Defaults to HealthCheckConfig.DefaultTimeout
+	Criticality HealthCriticality // Defaults to Critical
+}
+
+// HealthCheckResult is the outcome of a single check
+type HealthCheckResult struct {
+	Status      string     `json:"status"` // "ok" or "failed"
+	Critical    bool       `json:"critical"`
+	Error       string     `json:"error,omitempty"`
+	LatencyMs   float64    `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// HealthReport is the aggregated result of all checks
+type HealthReport struct {
+	Status  HealthStatus
+	Details map[string]HealthCheckResult
+}
+
+// Summary returns "ok" or the error message for each check
+func (r HealthReport) Summary() map[string]string {
+	summary := make(map[string]string, len(r.Details))
+	for name, result := range r.Details {
+		if result.Error != "" {
+			summary[name] = result.Error
+		} else {
+			summary[name] = "ok"
+		}
+	}
+	return summary
+}
+
+// HealthCheckConfig controls check execution
+// Set via SetHealthCheckConfig during initialization
+type HealthCheckConfig struct {
+	DefaultTimeout time.Duration // Per-check timeout when HealthCheck.Timeout is zero (default: 2s)
+	CacheTTL       time.Duration // How long results are reused (default: 2s, 0 disables caching)
+}
+
+// DefaultHealthCheckConfig returns the default health check configuration
+func DefaultHealthCheckConfig() HealthCheckConfig {
+	return HealthCheckConfig{
+		DefaultTimeout: 2 * time.Second,
+		CacheTTL:       2 * time.Second,
+	}
+}
+
+var (
+	healthCheckConfig   = DefaultHealthCheckConfig()
+	healthCheckConfigMu sync.RWMutex
+)
+
+// SetHealthCheckConfig sets the global health check configuration
+func SetHealthCheckConfig(cfg HealthCheckConfig) {
+	if cfg.DefaultTimeout <= 0 {
+		cfg.DefaultTimeout = DefaultHealthCheckConfig().DefaultTimeout
+	}
+	if cfg.CacheTTL < 0 {
+		cfg.CacheTTL = 0
+	}
+
+	healthCheckConfigMu.Lock()
+	defer healthCheckConfigMu.Unlock()
+	healthCheckConfig = cfg
+}
+
+// GetHealthCheckConfig returns the current health check configuration
+func GetHealthCheckConfig() HealthCheckConfig {
+	healthCheckConfigMu.RLock()
+	defer healthCheckConfigMu.RUnlock()
+	return healthCheckConfig
+}
+
+// healthCheckEntry is a registered check with its cached result
+type healthCheckEntry struct {
+	check HealthCheck
+
+	mu          sync.Mutex
+	result      *HealthCheckResult
+	lastSuccess time.Time
+}
+
+var (
+	healthChecks   []*healthCheckEntry
+	healthChecksMu sync.RWMutex
+)
+
+// RegisterHealthCheck adds a health check to the readiness probe
+func RegisterHealthCheck(check HealthCheck) {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	healthChecks = append(healthChecks, &healthCheckEntry{check: check})
+}
+
+// ClearHealthChecks removes all health checks (for testing)
+func ClearHealthChecks() {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	healthChecks = nil
+}
+
+// CheckHealth runs all checks in parallel, reusing results younger than
+// HealthCheckConfig.CacheTTL
+func CheckHealth(ctx context.Context) HealthReport {
+	healthChecksMu.RLock()
+	entries := make([]*healthCheckEntry, len(healthChecks))
+	copy(entries, healthChecks)
+	healthChecksMu.RUnlock()
+
+	cfg := GetHealthCheckConfig()
+	results := make([]HealthCheckResult, len(entries))
+
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = entry.run(ctx, cfg)
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status:  HealthStatusReady,
+		Details: make(map[string]HealthCheckResult, len(entries)),
+	}
+	for i, entry := range entries {
+		result := results[i]
+		report.Details[entry.check.Name] = result
+		if result.Error == "" {
+			continue
+		}
+		if result.Critical {
+			report.Status = HealthStatusNotReady
+		} else if report.Status == HealthStatusReady {
+			report.Status = HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+// run returns the cached result or executes the check. The entry lock is
+// held while checking so concurrent probes share one execution. Results are
+// not cached if ctx was cancelled.
+func (e *healthCheckEntry) run(ctx context.Context, cfg HealthCheckConfig) HealthCheckResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	if e.result != nil && now.Sub(e.result.CheckedAt) < cfg.CacheTTL {
+		return *e.result
+	}
+
+	timeout := e.check.Timeout
+	if timeout <= 0 {
+		timeout = cfg.DefaultTimeout
+	}
+	err := runWithTimeout(ctx, e.check.Check, timeout)
+
+	result := HealthCheckResult{
+		Status:    "ok",
+		Critical:  e.check.Criticality == Critical,
+		LatencyMs: float64(time.Since(now).Microseconds()) / 1000,
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+	} else {
+		e.lastSuccess = now
+	}
+	if !e.lastSuccess.IsZero() {
+		lastSuccess := e.lastSuccess
+		result.LastSuccess = &lastSuccess
+	}
+
+	// A check cut short by the caller says nothing about the dependency
+	if ctx.Err() == nil {
+		e.result = &result
+	}
+	return result
+}
+
+// runWithTimeout runs check, returning when it finishes or the timeout
+// elapses, so a check that ignores its context cannot block the probe
+func runWithTimeout(ctx context.Context, check HealthCheckFunc, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/config"
+)
+
+func setupHealthChecks(t *testing.T, cfg HealthCheckConfig) {
+	t.Helper()
+	ClearHealthChecks()
+	previous := GetHealthCheckConfig()
+	SetHealthCheckConfig(cfg)
+	t.Cleanup(func() {
+		ClearHealthChecks()
+		SetHealthCheckConfig(previous)
+	})
+}
+
+func TestCheckHealth_Statuses(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{})
+
+	RegisterHealthCheck(HealthCheck{Name: "db", Check: func(ctx context.Context) error { return nil }})
+	RegisterHealthCheck(HealthCheck{
+		Name:        "search",
+		Criticality: NonCritical,
+		Check:       func(ctx context.Context) error { return errors.New("index offline") },
+	})
+
+	report := CheckHealth(context.Background())
+	assert.Equal(t, HealthStatusDegraded, report.Status)
+	assert.Equal(t, "ok", report.Details["db"].Status)
+	assert.True(t, report.Details["db"].Critical)
+	assert.NotNil(t, report.Details["db"].LastSuccess)
+	assert.Equal(t, "failed", report.Details["search"].Status)
+	assert.False(t, report.Details["search"].Critical)
+	assert.Equal(t, "index offline", report.Details["search"].Error)
+	assert.Nil(t, report.Details["search"].LastSuccess)
+
+	RegisterHealthCheck(HealthCheck{Name: "cache", Check: func(ctx context.Context) error { return errors.New("down") }})
+	report = CheckHealth(context.Background())
+	assert.Equal(t, HealthStatusNotReady, report.Status)
+
+	healthy, results := RunHealthChecks()
+	assert.False(t, healthy)
+	assert.Equal(t, "down", results["cache"])
+	assert.Equal(t, "ok", results["db"])
+}
+
+func TestCheckHealth_TimeoutAndParallel(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{DefaultTimeout: time.Second})
+
+	// Ignores its context entirely
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	RegisterHealthCheck(HealthCheck{
+		Name:    "stuck",
+		Timeout: 50 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			<-block
+			return nil
+		},
+	})
+	RegisterHealthCheck(HealthCheck{
+		Name: "slow",
+		Check: func(ctx context.Context) error {
+			time.Sleep(40 * time.Millisecond)
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := CheckHealth(context.Background())
+	elapsed := time.Since(start)
+
+	assert.Less(t, elapsed, 500*time.Millisecond)
+	assert.Equal(t, HealthStatusNotReady, report.Status)
+	assert.Equal(t, "timed out after 50ms", report.Details["stuck"].Error)
+	assert.Equal(t, "ok", report.Details["slow"].Status)
+	assert.GreaterOrEqual(t, report.Details["slow"].LatencyMs, 40.0)
+}
+
+func TestCheckHealth_Cache(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{CacheTTL: 50 * time.Millisecond})
+
+	var calls atomic.Int32
+	var fail atomic.Bool
+	RegisterHealthCheck(HealthCheck{
+		Name: "redis",
+		Check: func(ctx context.Context) error {
+			calls.Add(1)
+			if fail.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+
+	first := CheckHealth(context.Background())
+	CheckHealth(context.Background())
+	assert.Equal(t, int32(1), calls.Load())
+
+	fail.Store(true)
+	time.Sleep(60 * time.Millisecond)
+
+	report := CheckHealth(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, HealthStatusNotReady, report.Status)
+	require.NotNil(t, report.Details["redis"].LastSuccess)
+	assert.Equal(t, first.Details["redis"].CheckedAt, *report.Details["redis"].LastSuccess)
+}
+
+func TestCheckHealth_CancelledNotCached(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{CacheTTL: time.Minute})
+
+	RegisterHealthCheck(HealthCheck{
+		Name: "db",
+		Check: func(ctx context.Context) error {
+			return ctx.Err()
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, HealthStatusNotReady, CheckHealth(ctx).Status)
+
+	report := CheckHealth(context.Background())
+	assert.Equal(t, HealthStatusReady, report.Status, "cancelled result must not be cached")
+}
+
+func TestHealthHandler_ReadyEndpoint_Degraded(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{})
+
+	RegisterHealthCheck(HealthCheck{Name: "db", Check: func(ctx context.Context) error { return nil }})
+	RegisterHealthCheck(HealthCheck{
+		Name:        "mailer",
+		Criticality: NonCritical,
+		Check:       func(ctx context.Context) error { return errors.New("smtp unreachable") },
+	})
+
+	cfg := config.NewWithDefaults()
+	cfg.DevMode = true
+	SetGlobalConfig(cfg)
+	defer SetGlobalConfig(nil)
+
+	h := &HealthHandler{}
+	require.NoError(t, h.Initialize())
+	e := echo.New()
+	h.Routes(e.Group("/health"))
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, map[string]string{"db": "ok", "mailer": "smtp unreachable"}, resp.Details)
+	assert.Equal(t, "smtp unreachable", resp.Checks["mailer"].Error)
+	assert.NotNil(t, resp.Checks["db"].LastSuccess)
+	assert.Contains(t, rec.Body.String(), `"latency_ms"`)
+}
+
+func TestHealthHandler_ReadyEndpoint_NoDetailsWhenReady(t *testing.T) {
+	setupHealthChecks(t, HealthCheckConfig{})
+
+	RegisterHealthCheck(HealthCheck{Name: "db", Check: func(ctx context.Context) error { return nil }})
+
+	cfg := config.NewWithDefaults()
+	cfg.DevMode = true
+	SetGlobalConfig(cfg)
+	defer SetGlobalConfig(nil)
+
+	h := &HealthHandler{}
+	require.NoError(t, h.Initialize())
+	e := echo.New()
+	h.Routes(e.Group("/health"))
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
+}
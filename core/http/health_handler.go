@@ -36,6 +36,12 @@ func (h *HealthHandler) Middlewares() []echo.MiddlewareFunc {
 func (h *HealthHandler) Initialize() error {
 	// Get config from global accessor
 	h.cfg = GetGlobalConfig()
+	if h.cfg != nil {
+		SetHealthCheckConfig(HealthCheckConfig{
+			DefaultTimeout: h.cfg.Middleware.Health.Timeout,
+			CacheTTL:       h.cfg.Middleware.Health.CacheTTL,
+		})
+	}
 	return nil
 }
 
@@ -57,6 +63,10 @@ func (h *HealthHandler) Routes(g *echo.Group) {
 	// /health/ready - readiness probe (checks dependencies)
 	g.GET("/ready", h.handleReady)
 	g.HEAD("/ready", h.handleReady)
+
+	// /health/startup - startup probe (dependency wait at boot)
+	g.GET("/startup", handleStartup)
+	g.HEAD("/startup", handleStartup)
 }
 
 // handleHealthRoot redirects to /health/ready
@@ -83,37 +93,37 @@ func (h *HealthHandler) handleLive(c echo.Context) error {
 
 // handleReady returns readiness status (checks dependencies)
 func (h *HealthHandler) handleReady(c echo.Context) error {
-	// Run all named health checkers
-	healthy, results := RunHealthChecks()
-
-	// Determine if we should show details
-	showDetails := h.shouldShowDetails()
-
-	// Build response
-	response := HealthResponse{
-		Status: "ready",
-	}
-
-	if !healthy {
-		response.Status = "not ready"
-
-		// Only include details if configured
-		if showDetails {
-			response.Details = results
+	// Report not ready while draining so traffic moves elsewhere
+	if IsDraining() {
+		if c.Request().Method == http.MethodHead {
+			return c.NoContent(http.StatusServiceUnavailable)
 		}
+		return c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: string(HealthStatusDraining)})
 	}
 
+	// Run all health checks (in parallel, results cached briefly)
+	report := CheckHealth(c.Request().Context())
+	ready := report.Status != HealthStatusNotReady
+
 	// For HEAD requests, just return status code
 	if c.Request().Method == http.MethodHead {
-		if healthy {
+		if ready {
 			return c.NoContent(http.StatusOK)
 		}
 		return c.NoContent(http.StatusServiceUnavailable)
 	}
 
-	// For GET, return JSON
+	response := HealthResponse{Status: string(report.Status)}
+
+	// Only include details of failing checks, and only if configured
+	if report.Status != HealthStatusReady && h.shouldShowDetails() {
+		response.Details = report.Summary()
+		response.Checks = report.Details
+	}
+
+	// Degraded services still receive traffic
 	statusCode := http.StatusOK
-	if !healthy {
+	if !ready {
 		statusCode = http.StatusServiceUnavailable
 	}
 
@@ -353,6 +353,31 @@ func TestHealthHandler_ReadyEndpoint_Unhealthy(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), `"status":"not ready"`)
 }
 
+func TestHealthHandler_ReadyEndpoint_Draining(t *testing.T) {
+	ClearNamedHealthCheckers()
+	defer ClearNamedHealthCheckers()
+	SetDraining(true)
+	defer SetDraining(false)
+
+	h := &HealthHandler{}
+	e := echo.New()
+	g := e.Group("/health")
+	h.Routes(g)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"draining"`)
+
+	// Liveness is unaffected
+	req = httptest.NewRequest(http.MethodGet, "/health/live", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
 func TestHealthHandler_DevModeDetails(t *testing.T) {
 	ClearNamedHealthCheckers()
 	defer ClearNamedHealthCheckers()
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"fmt"
+
 	"github.com/codoworks/codo-framework/core/errors"
 	"github.com/codoworks/codo-framework/core/forms"
 )
@@ -51,6 +53,19 @@ func init() {
 		return nil
 	})
 
+	// ParamErrorList - Query/header/cookie binding failures
+	// Reported as one 400 with every invalid parameter listed
+	mapper.RegisterConverter(func(err error) *errors.Error {
+		if paramErr, ok := err.(*ParamErrorList); ok {
+			fwkErr := errors.New(errors.CodeBadRequest, fmt.Sprintf("Invalid %s parameters", paramErr.ParamType), 400)
+			fwkErr.Details = map[string]any{
+				"validationErrors": paramErr.Errors,
+			}
+			return fwkErr
+		}
+		return nil
+	})
+
 	// forms.ValidationErrors - Domain validation failures from forms package
 	// Converts to the same format as ValidationErrorList for consistency
 	mapper.RegisterConverter(func(err error) *errors.Error {
@@ -0,0 +1,19 @@
+package http
+
+// MiddlewarePolicy names orchestrated middleware a route needs or must not
+// run, by middleware name (e.g. "auth", "timeout"). Per-route rules in
+// middleware.routes take precedence.
+type MiddlewarePolicy struct {
+	Require []string // Run even if off for the route's router (e.g. "auth" on a public route)
+	Skip    []string // Never run
+}
+
+// MiddlewareHandler is an optional Handler extension declaring middleware
+// requirements per handler or route.
+type MiddlewareHandler interface {
+	// MiddlewarePolicy returns the policy for a route, identified by its
+	// method and full path pattern (e.g. "/api/v1/contacts/:id"). Return
+	// nil to use the router's middleware unchanged. Requests to a route
+	// that requires unavailable middleware fail with 500.
+	MiddlewarePolicy(method, path string) *MiddlewarePolicy
+}
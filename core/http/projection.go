@@ -0,0 +1,270 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codoworks/codo-framework/core/forms"
+)
+
+// Query parameters for sparse fieldsets
+const (
+	FieldsParam  = "fields"
+	IncludeParam = "include"
+)
+
+// ProjectionPolicy restricts the sparse fieldsets a route accepts.
+type ProjectionPolicy struct {
+	// Fields clients may request. An entry also allows its nested paths
+	// ("address" allows "address.city"). Empty allows any field.
+	Fields []string
+	// Include lists the related resources clients may request. Empty allows any.
+	Include []string
+	// Always lists fields kept in every projected response (e.g. "id")
+	Always []string
+	// Disabled returns payloads unpruned and ignores fields= and include=
+	Disabled bool
+}
+
+// ProjectedHandler is an optional Handler extension that sets the allowed
+// fields= and include= values per route.
+type ProjectedHandler interface {
+	// Projection returns the policy for a route, identified by its method and
+	// full path pattern (e.g. "/api/v1/contacts"). Return nil to allow any
+	// field.
+	Projection(method, path string) *ProjectionPolicy
+}
+
+// Fields returns the sparse fieldset requested with
+// ?fields=name,address.city (repeated parameters are merged). It returns
+// nil when no fieldset was requested, and a *ParamErrorList when a field is
+// not allowed by the handler's ProjectionPolicy.
+func (c *Context) Fields() ([]string, error) {
+	policy := c.projectionPolicy()
+	if policy != nil && policy.Disabled {
+		return nil, nil
+	}
+	var allowed []string
+	if policy != nil {
+		allowed = policy.Fields
+	}
+	return c.projectionParam(FieldsParam, allowed, "unknown field")
+}
+
+// Includes returns the related resources requested with
+// ?include=company,tags, checked against the handler's ProjectionPolicy.
+func (c *Context) Includes() ([]string, error) {
+	policy := c.projectionPolicy()
+	if policy != nil && policy.Disabled {
+		return nil, nil
+	}
+	var allowed []string
+	if policy != nil {
+		allowed = policy.Include
+	}
+	return c.projectionParam(IncludeParam, allowed, "unknown include")
+}
+
+// Included reports whether the client asked for a related resource via
+// include=. Disallowed includes are never reported.
+func (c *Context) Included(name string) bool {
+	includes, err := c.Includes()
+	if err != nil {
+		return false
+	}
+	return containsString(includes, name)
+}
+
+func (c *Context) projectionPolicy() *ProjectionPolicy {
+	if h, ok := HandlerFor(c.Context).(ProjectedHandler); ok {
+		return h.Projection(c.Request().Method, c.Path())
+	}
+	return nil
+}
+
+func (c *Context) projectionParam(param string, allowed []string, message string) ([]string, error) {
+	var values []string
+	var errs []ValidationError
+	seen := make(map[string]bool)
+
+	for _, raw := range c.QueryParams()[param] {
+		for _, v := range strings.Split(raw, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			if !fieldAllowed(v, allowed) {
+				errs = append(errs, ValidationError{Field: param, Message: fmt.Sprintf("%s %q", message, v)})
+				continue
+			}
+			values = append(values, v)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ParamErrorList{ParamType: ParamTypeQuery, Errors: errs}
+	}
+	return values, nil
+}
+
+// fieldAllowed reports whether path is an allowed entry or nested under one
+func fieldAllowed(path string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if path == a || strings.HasPrefix(path, a+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// project prunes payload to the requested sparse fieldset. Payloads are
+// returned unchanged when no fields were requested.
+func (c *Context) project(payload any) (any, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	fields, fieldsErr := c.Fields()
+	includes, includesErr := c.Includes()
+	if fieldsErr != nil || includesErr != nil {
+		merged := &ParamErrorList{ParamType: ParamTypeQuery}
+		for _, err := range []error{fieldsErr, includesErr} {
+			if list, ok := err.(*ParamErrorList); ok {
+				merged.Errors = append(merged.Errors, list.Errors...)
+			}
+		}
+		return nil, merged
+	}
+	if len(fields) == 0 {
+		return payload, nil
+	}
+
+	tree := make(fieldTree)
+	tree.add(fields...)
+	tree.add(includes...)
+	if policy := c.projectionPolicy(); policy != nil {
+		tree.add(policy.Always...)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if coll, ok := payload.(forms.Collection); ok {
+		return projectCollection(data, coll.CollectionKey(), tree)
+	}
+	return projectJSON(data, tree)
+}
+
+// fieldTree is a set of dotted field paths; a node without children keeps
+// the whole value.
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(paths ...string) {
+	for _, path := range paths {
+		node := t
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, exists := node[part]
+			if exists && len(child) == 0 {
+				break // Already kept whole
+			}
+			if i == len(parts)-1 {
+				node[part] = make(fieldTree)
+				break
+			}
+			if !exists {
+				child = make(fieldTree)
+				node[part] = child
+			}
+			node = child
+		}
+	}
+}
+
+// projectCollection prunes the items under key and keeps the envelope.
+func projectCollection(data []byte, key string, tree fieldTree) (json.RawMessage, error) {
+	return projectJSON(data, fieldTree{key: tree, "*": nil})
+}
+
+// projectJSON prunes a JSON document to tree, preserving key order. Arrays
+// are pruned element by element. A "*" entry keeps every other key as is.
+func projectJSON(data []byte, tree fieldTree) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(tree) == 0 || len(trimmed) == 0 {
+		return trimmed, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, item := range items {
+			pruned, err := projectJSON(item, tree)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(pruned)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		_, keepRest := tree["*"]
+
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		first := true
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := tok.(string)
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			child, ok := tree[key]
+			if !ok && !keepRest {
+				continue
+			}
+			if ok {
+				if value, err = projectJSON(value, child); err != nil {
+					return nil, err
+				}
+			}
+
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			keyJSON, _ := json.Marshal(key)
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+
+	default:
+		return trimmed, nil
+	}
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/forms"
+)
+
+type projAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type projContact struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Email   string      `json:"email"`
+	Address projAddress `json:"address"`
+	Phones  []projPhone `json:"phones"`
+}
+
+type projPhone struct {
+	Kind   string `json:"kind"`
+	Number string `json:"number"`
+}
+
+var testContact = projContact{
+	ID:      "c1",
+	Name:    "Jane",
+	Email:   "jane@example.com",
+	Address: projAddress{City: "Berlin", Country: "DE"},
+	Phones:  []projPhone{{Kind: "mobile", Number: "123"}},
+}
+
+func payloadOf(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return string(resp.Payload)
+}
+
+func TestContext_SuccessProjection(t *testing.T) {
+	t.Run("no fields keeps payload", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts/c1", "")
+		require.NoError(t, c.Success(testContact))
+		assert.Contains(t, payloadOf(t, rec), `"email":"jane@example.com"`)
+	})
+
+	t.Run("top-level and nested fields", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts/c1?fields=name,address.city&fields=phones.number", "")
+		require.NoError(t, c.Success(testContact))
+		assert.Equal(t, `{"name":"Jane","address":{"city":"Berlin"},"phones":[{"number":"123"}]}`, payloadOf(t, rec))
+	})
+
+	t.Run("parent field keeps subtree", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts/c1?fields=address.city,address", "")
+		require.NoError(t, c.Success(testContact))
+		assert.Equal(t, `{"address":{"city":"Berlin","country":"DE"}}`, payloadOf(t, rec))
+	})
+
+	t.Run("list response items", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts?fields=id,name", "")
+		list := forms.NewListResponse([]projContact{testContact, {ID: "c2", Name: "Max"}}, 2, 1, 20)
+		require.NoError(t, c.Success(list))
+		assert.Equal(t,
+			`{"items":[{"id":"c1","name":"Jane"},{"id":"c2","name":"Max"}],"meta":{"total":2,"page":1,"per_page":20,"pages":1,"has_next":false,"has_prev":false}}`,
+			payloadOf(t, rec))
+	})
+
+	t.Run("plain slice", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts?fields=name", "")
+		require.NoError(t, c.Success([]projContact{testContact}))
+		assert.Equal(t, `[{"name":"Jane"}]`, payloadOf(t, rec))
+	})
+
+	t.Run("include keeps relation", func(t *testing.T) {
+		c, rec := newTestContext(http.MethodGet, "/contacts/c1?fields=name&include=phones", "")
+		require.NoError(t, c.Success(testContact))
+		assert.Equal(t, `{"name":"Jane","phones":[{"kind":"mobile","number":"123"}]}`, payloadOf(t, rec))
+		assert.True(t, c.Included("phones"))
+		assert.False(t, c.Included("company"))
+	})
+}
+
+// contactHandler restricts fieldsets on its routes.
+type contactHandler struct {
+	mockHandler
+}
+
+func (h *contactHandler) Projection(method, path string) *ProjectionPolicy {
+	if path == "/contacts/raw" {
+		return &ProjectionPolicy{Disabled: true}
+	}
+	return &ProjectionPolicy{
+		Fields:  []string{"name", "address"},
+		Include: []string{"phones"},
+		Always:  []string{"id"},
+	}
+}
+
+func TestContext_ProjectionPolicy(t *testing.T) {
+	isolateHandlers(t)
+
+	respond := WrapHandler(func(c *Context) error {
+		return c.Success(testContact)
+	})
+	RegisterHandler(&contactHandler{mockHandler{
+		prefix: "/contacts",
+		scope:  ScopePublic,
+		routes: func(g *echo.Group) {
+			g.GET("", respond)
+			g.GET("/raw", respond)
+		},
+	}})
+
+	r := NewRouter(ScopePublic, ":0")
+	r.SetErrorHandler(func(err error, c echo.Context) {
+		fwkErr := errors.MapError(err)
+		_ = c.JSON(fwkErr.HTTPStatus, fwkErr.Details)
+	})
+	require.NoError(t, r.RegisterHandlers())
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.Echo().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Run("allowed fields plus always", func(t *testing.T) {
+		rec := get("/contacts?fields=name,address.country")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"id":"c1","name":"Jane","address":{"country":"DE"}}`, payloadOf(t, rec))
+	})
+
+	t.Run("disallowed field and include", func(t *testing.T) {
+		rec := get("/contacts?fields=name,email&include=company")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `unknown field \"email\"`)
+		assert.Contains(t, rec.Body.String(), `unknown include \"company\"`)
+	})
+
+	t.Run("disabled route ignores fields", func(t *testing.T) {
+		rec := get("/contacts/raw?fields=name")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, payloadOf(t, rec), `"email"`)
+	})
+}
+
+func TestFieldTree(t *testing.T) {
+	tree := make(fieldTree)
+	tree.add("a.b.c", "a.b", "a.d", "e")
+
+	assert.Equal(t, fieldTree{
+		"a": {"b": {}, "d": {}},
+		"e": {},
+	}, tree)
+}
@@ -0,0 +1,23 @@
+package http
+
+import "time"
+
+// RateLimitPolicy overrides the global rate limit for a handler or route.
+// Zero fields inherit from the rate limit middleware configuration.
+type RateLimitPolicy struct {
+	Algorithm string        // "token_bucket" or "sliding_window"
+	Limit     int           // Requests allowed per window
+	Window    time.Duration // Window length
+	Burst     int           // Token bucket capacity
+	KeyBy     string        // "ip", "identity" or "api_key"
+	Disabled  bool          // Exempt the route from rate limiting
+}
+
+// RateLimitedHandler is an optional Handler extension for per-handler or
+// per-route rate limits.
+type RateLimitedHandler interface {
+	// RateLimit returns the policy for a route, identified by its method and
+	// full path pattern (e.g. "/api/v1/contacts/:id"). Return nil to use
+	// the default policy.
+	RateLimit(method, path string) *RateLimitPolicy
+}
@@ -30,6 +30,20 @@ func (h *mockHandler) Routes(g *echo.Group) {
 	}
 }
 
+// isolateHandlers clears the registry for a test and restores the
+// auto-registered handlers afterwards
+func isolateHandlers(t *testing.T) {
+	t.Helper()
+	saved := AllHandlers()
+	ClearHandlers()
+	t.Cleanup(func() {
+		ClearHandlers()
+		for _, h := range saved {
+			RegisterHandler(h)
+		}
+	})
+}
+
 func TestRegisterHandler(t *testing.T) {
 	ClearHandlers()
 	defer ClearHandlers()
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// routeOwners maps each registered route back to the Handler that declared
+// it, so router-level middleware can consult optional Handler extensions.
+var (
+	routeOwners   = make(map[*echo.Echo]map[string]Handler)
+	routeOwnersMu sync.RWMutex
+)
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// routeSet returns the keys of all routes currently registered on e.
+func routeSet(e *echo.Echo) map[string]bool {
+	set := make(map[string]bool)
+	for _, r := range e.Routes() {
+		set[routeKey(r.Method, r.Path)] = true
+	}
+	return set
+}
+
+// indexRoutes records h as the owner of every route added since before.
+func indexRoutes(e *echo.Echo, h Handler, before map[string]bool) {
+	routeOwnersMu.Lock()
+	defer routeOwnersMu.Unlock()
+
+	owners, ok := routeOwners[e]
+	if !ok {
+		owners = make(map[string]Handler)
+		routeOwners[e] = owners
+	}
+	for _, r := range e.Routes() {
+		key := routeKey(r.Method, r.Path)
+		if !before[key] {
+			owners[key] = h
+		}
+	}
+}
+
+// HandlerFor returns the Handler that registered the route matched by c,
+// or nil if the route was not registered through a Handler.
+func HandlerFor(c echo.Context) Handler {
+	return HandlerForRoute(c.Echo(), c.Request().Method, c.Path())
+}
+
+// HandlerForRoute returns the Handler that registered method and path on e.
+func HandlerForRoute(e *echo.Echo, method, path string) Handler {
+	routeOwnersMu.RLock()
+	defer routeOwnersMu.RUnlock()
+	return routeOwners[e][routeKey(method, path)]
+}
@@ -2,19 +2,31 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/labstack/echo/v4"
 )
 
 // Router wraps an Echo instance for a specific scope.
 type Router struct {
-	echo   *echo.Echo
-	scope  RouterScope
-	addr   string
-	server *http.Server
+	echo     *echo.Echo
+	scope    RouterScope
+	addr     string
+	server   *http.Server
+	listener net.Listener // Bound by someone else, see UseListener
+	tls      *tls.Config
+	inFlight atomic.Int64
+
+	versions     map[string]bool
+	versioned    []string // Prefix() of versioned handlers
+	versionsMu   sync.RWMutex
+	negotiating  bool
+	deprecations bool
 }
 
 // NewRouter creates a new router for the given scope and address.
@@ -119,41 +131,90 @@ func (r *Router) RegisterHandlers() error {
 		if err := h.Initialize(); err != nil {
 			return fmt.Errorf("failed to initialize handler %s: %w", h.Prefix(), err)
 		}
-		g := r.echo.Group(h.Prefix(), h.Middlewares()...)
+		before := routeSet(r.echo)
+		g := r.echo.Group(MountPrefix(h), h.Middlewares()...)
 		h.Routes(g)
+		indexRoutes(r.echo, h, before)
+
+		if v, ok := h.(VersionedHandler); ok && v.Version() != "" {
+			r.addVersion(v.Version(), h.Prefix())
+		}
+		if _, ok := h.(DeprecatedHandler); ok && !r.deprecations {
+			r.deprecations = true
+			r.echo.Use(deprecationMiddleware)
+		}
+	}
+
+	if GetVersioningConfig().Negotiate && len(r.Versions()) > 0 && !r.negotiating {
+		r.negotiating = true
+		r.echo.Pre(r.negotiateVersion)
 	}
 	return nil
 }
 
-// Start starts the router.
-func (r *Router) Start() error {
-	r.server = &http.Server{
-		Addr:    r.addr,
-		Handler: r.echo,
+// SetTLS enables TLS for the router. Certificate files are loaded
+// immediately so misconfiguration fails at startup. A nil cfg disables TLS.
+func (r *Router) SetTLS(cfg *TLSConfig) error {
+	if cfg == nil {
+		r.tls = nil
+		return nil
+	}
+	tlsConfig, err := NewTLSConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("%s router TLS: %w", r.scope, err)
 	}
+	r.tls = tlsConfig
+	return nil
+}
+
+// TLSEnabled returns whether the router serves TLS.
+func (r *Router) TLSEnabled() bool {
+	return r.tls != nil
+}
 
-	if err := r.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+// Start starts the router.
+func (r *Router) Start() error {
+	ln, err := r.Listen()
+	if err != nil {
 		return err
 	}
-	return nil
+	return r.Serve(ln)
 }
 
 // Listen creates the network listener without serving.
 // This allows checking if the port is available before starting the server.
 // Use with Serve() for a two-phase startup that fails fast on port conflicts.
 func (r *Router) Listen() (net.Listener, error) {
+	if ln := r.listener; ln != nil {
+		r.listener = nil
+		return ln, nil
+	}
 	return net.Listen("tcp", r.addr)
 }
 
-// Serve starts serving HTTP on an existing listener.
+// UseListener makes the next Listen return ln instead of binding the
+// router's address, e.g. the listener handed over by the startup probe.
+func (r *Router) UseListener(ln net.Listener) {
+	r.listener = ln
+}
+
+// Serve starts serving HTTP on an existing listener, over TLS if configured.
 // Use this with Listen() for two-phase startup.
 func (r *Router) Serve(ln net.Listener) error {
 	r.server = &http.Server{
-		Addr:    r.addr,
-		Handler: r.echo,
+		Addr:      r.addr,
+		Handler:   r.trackInFlight(r.echo),
+		TLSConfig: r.tls,
 	}
 
-	if err := r.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+	var err error
+	if r.tls != nil {
+		// Certificates come from TLSConfig.GetCertificate
+		err = r.server.ServeTLS(ln, "", "")
+	} else {
+		err = r.server.Serve(ln)
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
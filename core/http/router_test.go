@@ -193,6 +193,41 @@ func TestRouter_RegisterHandlers(t *testing.T) {
 	})
 }
 
+func TestRouter_HandlerFor(t *testing.T) {
+	ClearHandlers()
+	defer ClearHandlers()
+
+	noop := func(c echo.Context) error { return nil }
+	contacts := &mockHandler{
+		prefix: "/contacts",
+		scope:  ScopePublic,
+		routes: func(g *echo.Group) {
+			g.GET("/:id", noop)
+		},
+	}
+	groups := &mockHandler{
+		prefix: "/groups",
+		scope:  ScopePublic,
+		routes: func(g *echo.Group) {
+			g.POST("", noop)
+		},
+	}
+	RegisterHandler(contacts)
+	RegisterHandler(groups)
+
+	r := NewRouter(ScopePublic, ":8080")
+	assert.NoError(t, r.RegisterHandlers())
+
+	assert.Equal(t, contacts, HandlerForRoute(r.Echo(), http.MethodGet, "/contacts/:id"))
+	assert.Equal(t, groups, HandlerForRoute(r.Echo(), http.MethodPost, "/groups"))
+	assert.Nil(t, HandlerForRoute(r.Echo(), http.MethodDelete, "/groups"))
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts/42", nil)
+	c := r.Echo().NewContext(req, httptest.NewRecorder())
+	r.Echo().Router().Find(http.MethodGet, "/contacts/42", c)
+	assert.Equal(t, contacts, HandlerFor(c))
+}
+
 func TestRouter_SetValidator(t *testing.T) {
 	r := NewRouter(ScopePublic, ":8080")
 
@@ -15,7 +15,9 @@ type ServerConfig struct {
 	PublicAddr    string
 	ProtectedAddr string
 	HiddenAddr    string
-	ShutdownGrace time.Duration
+	ShutdownGrace time.Duration // Time allowed for in-flight requests to drain
+	PreStopDelay  time.Duration // Time readiness reports 503 before draining starts
+	Logger        Logger        // Optional, logs each shutdown phase
 }
 
 // DefaultServerConfig returns the default server configuration.
@@ -183,7 +185,8 @@ func (s *Server) Start() error {
 	return g.Wait()
 }
 
-// Shutdown gracefully shuts down all routers.
+// Shutdown gracefully shuts down all routers. Readiness flips to 503 first,
+// then draining starts after the pre-stop delay (see Drain).
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.mu.Lock()
 	if !s.started {
@@ -192,21 +195,12 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	}
 	s.mu.Unlock()
 
-	g := new(errgroup.Group)
-
-	g.Go(func() error {
-		return s.public.Shutdown(ctx)
-	})
-
-	g.Go(func() error {
-		return s.protected.Shutdown(ctx)
-	})
-
-	g.Go(func() error {
-		return s.hidden.Shutdown(ctx)
-	})
+	return Drain(ctx, s.config, s.public, s.protected, s.hidden)
+}
 
-	return g.Wait()
+// InFlight returns the number of requests being served across all routers.
+func (s *Server) InFlight() int64 {
+	return inFlight([]*Router{s.public, s.protected, s.hidden})
 }
 
 // Config returns the server configuration.
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Dependency states reported by the startup probe
+const (
+	DependencyPending = "pending"
+	DependencyReady   = "ready"
+	DependencyFailed  = "failed"
+)
+
+// DependencyStatus is the startup state of a single dependency
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// StartupStatus is the response of the startup probe
+type StartupStatus struct {
+	Status       string                      `json:"status"` // "starting" or "started"
+	Pending      []string                    `json:"pending,omitempty"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// startupState tracks dependencies while the app waits for them at boot.
+// Processes that never call BeginStartup report as started.
+type startupState struct {
+	mu       sync.RWMutex
+	starting bool
+	deps     map[string]DependencyStatus
+}
+
+var startup = &startupState{}
+
+// BeginStartup marks the listed dependencies as pending
+func BeginStartup(names ...string) {
+	startup.mu.Lock()
+	defer startup.mu.Unlock()
+	startup.starting = true
+	startup.deps = make(map[string]DependencyStatus, len(names))
+	for _, name := range names {
+		startup.deps[name] = DependencyStatus{Status: DependencyPending}
+	}
+}
+
+// SetDependencyPending records a failed attempt for a dependency that is still being retried
+func SetDependencyPending(name string, attempts int, err error) {
+	setDependency(name, DependencyPending, attempts, err)
+}
+
+// SetDependencyReady marks a dependency as ready
+func SetDependencyReady(name string, attempts int) {
+	setDependency(name, DependencyReady, attempts, nil)
+}
+
+// SetDependencyFailed marks a dependency as given up on (optional dependencies only)
+func SetDependencyFailed(name string, attempts int, err error) {
+	setDependency(name, DependencyFailed, attempts, err)
+}
+
+func setDependency(name, status string, attempts int, err error) {
+	dep := DependencyStatus{Status: status, Attempts: attempts}
+	if err != nil {
+		dep.Error = err.Error()
+	}
+
+	startup.mu.Lock()
+	defer startup.mu.Unlock()
+	if startup.deps == nil {
+		startup.deps = make(map[string]DependencyStatus)
+	}
+	startup.deps[name] = dep
+}
+
+// CompleteStartup marks startup as finished
+func CompleteStartup() {
+	startup.mu.Lock()
+	defer startup.mu.Unlock()
+	startup.starting = false
+}
+
+// ResetStartup clears startup state (for testing)
+func ResetStartup() {
+	startup.mu.Lock()
+	defer startup.mu.Unlock()
+	startup.starting = false
+	startup.deps = nil
+}
+
+// GetStartupStatus returns the current startup state
+func GetStartupStatus() StartupStatus {
+	startup.mu.RLock()
+	defer startup.mu.RUnlock()
+
+	status := StartupStatus{Status: "started"}
+	if startup.starting {
+		status.Status = "starting"
+	}
+	if len(startup.deps) > 0 {
+		status.Dependencies = make(map[string]DependencyStatus, len(startup.deps))
+		for name, dep := range startup.deps {
+			status.Dependencies[name] = dep
+			if dep.Status == DependencyPending {
+				status.Pending = append(status.Pending, name)
+			}
+		}
+		sort.Strings(status.Pending)
+	}
+	return status
+}
+
+// handleStartup reports 200 once startup has completed, 503 while
+// dependencies are still pending
+func handleStartup(c echo.Context) error {
+	status := GetStartupStatus()
+	code := http.StatusOK
+	if status.Status != "started" {
+		code = http.StatusServiceUnavailable
+	}
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(code)
+	}
+	return c.JSON(code, status)
+}
+
+// StartupProbeServer serves /health/startup and /health/live on the public
+// address while the app waits for its dependencies, before the routers bind.
+type StartupProbeServer struct {
+	server *http.Server
+	ln     *handoffListener
+	tls    bool
+	done   chan error
+}
+
+// StartStartupProbe binds addr and serves the startup probe in the
+// background, over TLS if tlsConfig is set
+func StartStartupProbe(addr string, tlsConfig *tls.Config) (*StartupProbeServer, error) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	g := e.Group("/health")
+	g.GET("/startup", handleStartup)
+	g.HEAD("/startup", handleStartup)
+	g.GET("/live", handleAlive)
+	g.HEAD("/live", handleAlive)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &StartupProbeServer{
+		server: &http.Server{Handler: e, ReadHeaderTimeout: 5 * time.Second, TLSConfig: tlsConfig},
+		ln:     &handoffListener{Listener: ln},
+		tls:    tlsConfig != nil,
+		done:   make(chan error, 1),
+	}
+	go func() {
+		if p.tls {
+			// Certificates come from TLSConfig.GetCertificate
+			p.done <- p.server.ServeTLS(p.ln, "", "")
+			return
+		}
+		p.done <- p.server.Serve(p.ln)
+	}()
+	return p, nil
+}
+
+// Addr returns the address the probe server is listening on
+func (p *StartupProbeServer) Addr() string {
+	return p.ln.Addr().String()
+}
+
+// Stop closes the probe server and its address
+func (p *StartupProbeServer) Stop(ctx context.Context) error {
+	if err := p.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := <-p.done; err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handover stops the probe server but keeps its socket open, and returns it
+// for the public router to serve (see Router.UseListener). Connections made
+// in between wait in the accept queue instead of being refused.
+func (p *StartupProbeServer) Handover(ctx context.Context) (net.Listener, error) {
+	deadliner, ok := p.ln.Listener.(interface{ SetDeadline(time.Time) error })
+	if !ok {
+		return nil, fmt.Errorf("startup probe listener cannot be handed over")
+	}
+
+	// Unblock the pending Accept so the probe server can return
+	p.ln.handover.Store(true)
+	if err := deadliner.SetDeadline(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := p.Stop(ctx); err != nil {
+		p.ln.Listener.Close()
+		return nil, err
+	}
+	if err := deadliner.SetDeadline(time.Time{}); err != nil {
+		p.ln.Listener.Close()
+		return nil, err
+	}
+	return p.ln.Listener, nil
+}
+
+// handoffListener is the probe's listener. Once handover is set, Accept
+// reports it closed and Close leaves the socket open.
+type handoffListener struct {
+	net.Listener
+	handover atomic.Bool
+}
+
+func (l *handoffListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil && l.handover.Load() {
+		return nil, net.ErrClosed
+	}
+	return conn, err
+}
+
+func (l *handoffListener) Close() error {
+	if l.handover.Load() {
+		return nil
+	}
+	return l.Listener.Close()
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupStatus(t *testing.T) {
+	ResetStartup()
+	t.Cleanup(ResetStartup)
+
+	// Never started waiting: reported as started
+	assert.Equal(t, "started", GetStartupStatus().Status)
+
+	BeginStartup("db", "rabbitmq", "logger")
+	SetDependencyReady("logger", 1)
+	SetDependencyPending("db", 3, errors.New("connection refused"))
+
+	status := GetStartupStatus()
+	assert.Equal(t, "starting", status.Status)
+	assert.Equal(t, []string{"db", "rabbitmq"}, status.Pending)
+	assert.Equal(t, "connection refused", status.Dependencies["db"].Error)
+
+	CompleteStartup()
+	assert.Equal(t, "started", GetStartupStatus().Status)
+}
+
+func TestStartupProbeServer(t *testing.T) {
+	ResetStartup()
+	t.Cleanup(ResetStartup)
+	BeginStartup("db")
+
+	probe, err := StartStartupProbe("127.0.0.1:0", nil)
+	require.NoError(t, err)
+
+	resp, err := http.Get("http://" + probe.Addr() + "/health/startup")
+	require.NoError(t, err)
+	var status StartupStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, []string{"db"}, status.Pending)
+
+	SetDependencyReady("db", 1)
+	CompleteStartup()
+
+	resp, err = http.Get("http://" + probe.Addr() + "/health/startup")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, probe.Stop(context.Background()))
+	_, err = http.Get("http://" + probe.Addr() + "/health/startup")
+	assert.Error(t, err)
+}
+
+func TestStartupProbeServer_HandoverTLS(t *testing.T) {
+	ResetStartup()
+	t.Cleanup(ResetStartup)
+	BeginStartup("db")
+
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, true)
+	certFile, keyFile := newTestCert(t, "localhost", ca, false).write(t, dir, "server")
+	tlsConfig := &TLSConfig{CertFile: certFile, KeyFile: keyFile}
+	serverTLS, err := NewTLSConfig(tlsConfig)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := tlsClient(roots)
+
+	probe, err := StartStartupProbe("127.0.0.1:0", serverTLS)
+	require.NoError(t, err)
+	url := "https://" + probe.Addr()
+
+	resp, err := client.Get(url + "/health/startup")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	CompleteStartup()
+	ln, err := probe.Handover(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, probe.Addr(), ln.Addr().String())
+
+	r := NewRouter(ScopePublic, probe.Addr())
+	require.NoError(t, r.SetTLS(tlsConfig))
+	r.GET("/ping", func(c *Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+	r.UseListener(ln)
+	routerLn, err := r.Listen()
+	require.NoError(t, err)
+	assert.Same(t, ln, routerLn)
+	go func() { _ = r.Serve(routerLn) }()
+	t.Cleanup(func() { _ = r.Shutdown(context.Background()) })
+
+	resp, err = client.Get(url + "/ping")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "pong", string(body))
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/clients"
+)
+
+// DefaultTLSReloadInterval is how often certificate files are checked for rotation
+const DefaultTLSReloadInterval = time.Minute
+
+// TLSConfig configures TLS for a router.
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string             // PEM bundle trusted for client certificates (mTLS)
+	ClientAuth     tls.ClientAuthType // Defaults to RequireAndVerifyClientCert when ClientCAFile is set
+	MinVersion     uint16             // Defaults to TLS 1.2
+	ReloadInterval time.Duration      // Defaults to DefaultTLSReloadInterval
+}
+
+// ParseClientAuth converts a client_auth config value to a tls.ClientAuthType.
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch s {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client auth mode: %s", s)
+	}
+}
+
+// ParseTLSVersion converts a min_version config value ("1.2", "1.3") to a TLS version.
+func ParseTLSVersion(s string) (uint16, error) {
+	switch s {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", s)
+	}
+}
+
+// NewTLSConfig builds a server tls.Config from cfg. Certificate, key and
+// client CA files are re-read when they change on disk, so rotated
+// certificates are served without a restart.
+func NewTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth := cfg.ClientAuth
+	if cfg.ClientCAFile != "" && clientAuth == tls.NoClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	minVersion := cfg.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		ClientAuth:     clientAuth,
+		GetCertificate: reloader.getCertificate,
+		// Set here rather than left to http.Server, which only adds them to
+		// its own copy and not to the config returned for each client
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if cfg.ClientCAFile == "" {
+		return base, nil
+	}
+
+	// ClientCAs is a plain field, so the CA bundle is swapped in per handshake.
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		reloader.maybeReload()
+		conf := base.Clone()
+		conf.GetConfigForClient = nil
+		conf.ClientCAs = reloader.clientCAs()
+		return conf, nil
+	}
+	return base, nil
+}
+
+// certReloader serves the current certificate and client CA pool, reloading
+// them when the files' modification times change.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+	logger   *logrus.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(cfg *TLSConfig) (*certReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("TLS requires a certificate and key file")
+	}
+
+	interval := cfg.ReloadInterval
+	if interval <= 0 {
+		interval = DefaultTLSReloadInterval
+	}
+
+	r := &certReloader{
+		certFile: cfg.CertFile,
+		keyFile:  cfg.KeyFile,
+		caFile:   cfg.ClientCAFile,
+		interval: interval,
+		logger:   logrus.StandardLogger(),
+	}
+	if loggerClient, err := clients.GetTyped[*logger.Logger](logger.ClientName); err == nil {
+		r.logger = loggerClient.GetLogger()
+	}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) clientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// maybeReload reloads the files if they changed since the last load. Checks
+// happen at most once per interval. A failed reload is logged, keeps the
+// previous certificate and is retried (and logged again) at the next interval.
+func (r *certReloader) maybeReload() {
+	now := time.Now()
+
+	r.mu.Lock()
+	if now.Sub(r.checked) < r.interval {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = now
+	loaded := r.modTime
+	r.mu.Unlock()
+
+	modTime, err := r.latestModTime()
+	if err == nil {
+		if !modTime.After(loaded) {
+			return
+		}
+		err = r.load(modTime)
+	}
+	if err != nil {
+		r.logger.WithError(err).WithField("cert_file", r.certFile).
+			Error("Failed to reload TLS certificate, serving the previous one")
+	}
+}
+
+// latestModTime returns the newest modification time of the watched files
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = modTime
+	return nil
+}
@@ -0,0 +1,284 @@
+package http
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Codo"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, c.certPEM(), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, c.keyPEM(t), 0o600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM(), c.keyPEM(t))
+	require.NoError(t, err)
+	return cert
+}
+
+func startTLSRouter(t *testing.T, cfg *TLSConfig) (*Router, string) {
+	t.Helper()
+	r := NewRouter(ScopeHidden, "127.0.0.1:0")
+	require.NoError(t, r.SetTLS(cfg))
+	assert.True(t, r.TLSEnabled())
+
+	r.GET("/whoami", func(c *Context) error {
+		return c.String(http.StatusOK, c.ClientSubject())
+	})
+
+	ln, err := r.Listen()
+	require.NoError(t, err)
+	go func() { _ = r.Serve(ln) }()
+	t.Cleanup(func() { _ = r.Shutdown(t.Context()) })
+
+	return r, "https://" + ln.Addr().String()
+}
+
+func tlsClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		},
+	}
+}
+
+func TestRouter_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, true)
+	server := newTestCert(t, "localhost", ca, false)
+	client := newTestCert(t, "billing-service", ca, false)
+
+	certFile, keyFile := server.write(t, dir, "server")
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.certPEM(), 0o600))
+
+	_, url := startTLSRouter(t, &TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	t.Run("verified client", func(t *testing.T) {
+		resp, err := tlsClient(roots, client.tlsCertificate(t)).Get(url + "/whoami")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "CN=billing-service,O=Codo", string(body))
+	})
+
+	t.Run("negotiates HTTP/2", func(t *testing.T) {
+		c := tlsClient(roots, client.tlsCertificate(t))
+		c.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+		resp, err := c.Get(url + "/whoami")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "HTTP/2.0", resp.Proto)
+		assert.Equal(t, "h2", resp.TLS.NegotiatedProtocol)
+	})
+
+	t.Run("missing client certificate", func(t *testing.T) {
+		resp, err := tlsClient(roots).Get(url + "/whoami")
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+
+	t.Run("untrusted client certificate", func(t *testing.T) {
+		rogue := newTestCert(t, "rogue", nil, true)
+		resp, err := tlsClient(roots, rogue.tlsCertificate(t)).Get(url + "/whoami")
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+}
+
+func TestRouter_TLSWithoutClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, true)
+	certFile, keyFile := newTestCert(t, "localhost", ca, false).write(t, dir, "server")
+
+	_, url := startTLSRouter(t, &TLSConfig{CertFile: certFile, KeyFile: keyFile})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	resp, err := tlsClient(roots).Get(url + "/whoami")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, string(body))
+}
+
+func TestRouter_TLSCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, true)
+	certFile, keyFile := newTestCert(t, "first", ca, false).write(t, dir, "server")
+
+	_, url := startTLSRouter(t, &TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Millisecond,
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	served := func() string {
+		// A new transport per call forces a fresh handshake
+		resp, err := tlsClient(roots).Get(url + "/whoami")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "first", served())
+
+	newTestCert(t, "second", ca, false).write(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, "second", served())
+}
+
+func TestCertReloader_LogsFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "Test CA", nil, true)
+	certFile, keyFile := newTestCert(t, "first", ca, false).write(t, dir, "server")
+
+	r, err := newCertReloader(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Hour})
+	require.NoError(t, err)
+	var logs bytes.Buffer
+	r.logger = logrus.New()
+	r.logger.SetOutput(&logs)
+	before, _ := r.getCertificate(nil)
+
+	// A rotation that leaves a broken certificate behind
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	r.checked = time.Time{}
+
+	after, _ := r.getCertificate(nil)
+	assert.Same(t, before, after, "the previous certificate is kept")
+	assert.Equal(t, 1, strings.Count(logs.String(), "Failed to reload TLS certificate"))
+
+	// Checks, and so logs, happen at most once per interval
+	r.getCertificate(nil)
+	assert.Equal(t, 1, strings.Count(logs.String(), "Failed to reload TLS certificate"))
+
+	r.checked = time.Time{}
+	r.getCertificate(nil)
+	assert.Equal(t, 2, strings.Count(logs.String(), "Failed to reload TLS certificate"), "retried at the next interval")
+}
+
+func TestRouter_SetTLS_Errors(t *testing.T) {
+	r := NewRouter(ScopeHidden, ":0")
+
+	err := r.SetTLS(&TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"})
+	assert.Error(t, err)
+	assert.False(t, r.TLSEnabled())
+
+	err = r.SetTLS(&TLSConfig{})
+	assert.Error(t, err)
+
+	assert.NoError(t, r.SetTLS(nil))
+	assert.False(t, r.TLSEnabled())
+}
+
+func TestParseClientAuth(t *testing.T) {
+	mode, err := ParseClientAuth("require_and_verify")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, mode)
+
+	mode, err = ParseClientAuth("")
+	assert.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, mode)
+
+	_, err = ParseClientAuth("always")
+	assert.Error(t, err)
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	v, err := ParseTLSVersion("1.3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), v)
+
+	_, err = ParseTLSVersion("1.0")
+	assert.Error(t, err)
+}
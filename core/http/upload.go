@@ -0,0 +1,462 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// UploadStore receives files streamed by Context.StreamUpload.
+// The storage client (clients/storage) implements it.
+type UploadStore interface {
+	// Store streams r to key and returns the number of bytes written
+	Store(ctx context.Context, key string, r io.Reader, contentType string) (int64, error)
+	// Delete removes key; used to clean up after a rejected upload
+	Delete(ctx context.Context, key string) error
+}
+
+// UploadedFile describes a file streamed to an UploadStore.
+// Use it (or *UploadedFile, []UploadedFile) as a form field type with StreamUpload.
+type UploadedFile struct {
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// UploadConfig holds defaults for streamed uploads
+// Set via SetUploadConfig during initialization
+type UploadConfig struct {
+	MaxFileSize  int64 // Per-file limit when the upload tag has no max (default: 32MB)
+	MaxFieldSize int64 // Limit for each non-file form value (default: 1MB)
+}
+
+// DefaultUploadConfig returns the default upload configuration
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		MaxFileSize:  32 << 20,
+		MaxFieldSize: 1 << 20,
+	}
+}
+
+var (
+	uploadConfig   = DefaultUploadConfig()
+	uploadConfigMu sync.RWMutex
+)
+
+// SetUploadConfig sets the global upload configuration
+func SetUploadConfig(cfg UploadConfig) {
+	defaults := DefaultUploadConfig()
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = defaults.MaxFileSize
+	}
+	if cfg.MaxFieldSize <= 0 {
+		cfg.MaxFieldSize = defaults.MaxFieldSize
+	}
+
+	uploadConfigMu.Lock()
+	defer uploadConfigMu.Unlock()
+	uploadConfig = cfg
+}
+
+// GetUploadConfig returns the current upload configuration
+func GetUploadConfig() UploadConfig {
+	uploadConfigMu.RLock()
+	defer uploadConfigMu.RUnlock()
+	return uploadConfig
+}
+
+// uploadRule is parsed from the `upload` struct tag, e.g.
+//
+//	`upload:"max=10MB,types=image/png|image/jpeg,prefix=avatars/"`
+type uploadRule struct {
+	maxSize int64
+	types   []string // Allowed media types; "image/*" matches any image
+	prefix  string   // Key prefix in the store
+}
+
+var (
+	uploadedFileType      = reflect.TypeOf(UploadedFile{})
+	uploadedFilePtrType   = reflect.TypeOf((*UploadedFile)(nil))
+	uploadedFileSliceType = reflect.TypeOf([]UploadedFile{})
+)
+
+// uploadField is a form struct field bound by StreamUpload
+type uploadField struct {
+	index int
+	name  string
+	rule  *uploadRule // Nil for non-file fields
+}
+
+// StreamUpload reads a multipart/form-data request part by part. File parts
+// are streamed to store as they arrive, without buffering the whole body in
+// memory or temp files. Other parts are bound to form fields by their
+// `form` tag.
+//
+// File fields have type UploadedFile, *UploadedFile or []UploadedFile and
+// declare limits in an `upload` tag:
+//
+//	type AvatarForm struct {
+//	    Name   string        `form:"name" validate:"required"`
+//	    Avatar *UploadedFile `form:"avatar" upload:"max=5MB,types=image/png|image/jpeg,prefix=avatars/" validate:"required"`
+//	}
+//
+// Content types are sniffed from the file content, not taken from the
+// client, and the key's extension follows the sniffed type. Files over the limit fail with 413, disallowed types with 415.
+// The form is validated after all parts are read; on any error, files
+// already stored by this call are deleted. Raise or disable the body limit
+// middleware for upload routes with BodyLimitedHandler.
+func (c *Context) StreamUpload(form any, store UploadStore) error {
+	val := reflect.ValueOf(form)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return errors.Internal("StreamUpload requires a pointer to a struct")
+	}
+	if store == nil {
+		return errors.Internal("StreamUpload requires an upload store")
+	}
+	val = val.Elem()
+
+	fields, err := uploadFields(val.Type())
+	if err != nil {
+		return err
+	}
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return errors.UnsupportedMediaType("Expected a multipart/form-data request").
+			WithDetail("content_type", c.Request().Header.Get("Content-Type"))
+	}
+
+	ctx := c.Request().Context()
+	cfg := GetUploadConfig()
+	var stored []string
+
+	err = c.readUploadParts(ctx, reader, val, fields, store, cfg, &stored)
+	if err == nil {
+		err = Validate(form)
+	}
+	if err != nil {
+		cleanupUploads(context.WithoutCancel(ctx), store, stored)
+		return err
+	}
+	return nil
+}
+
+func (c *Context) readUploadParts(ctx context.Context, reader *multipart.Reader, val reflect.Value, fields map[string]uploadField, store UploadStore, cfg UploadConfig, stored *[]string) error {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &BindError{Cause: err, BindType: BindTypeMultipart}
+		}
+
+		field, ok := fields[part.FormName()]
+		if !ok {
+			part.Close()
+			continue
+		}
+
+		if field.rule == nil {
+			err = bindUploadValue(val.Field(field.index), part, field.name, cfg.MaxFieldSize)
+		} else if part.FileName() != "" {
+			err = streamUploadPart(ctx, val.Field(field.index), part, field, store, cfg, stored)
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// streamUploadPart sniffs the content type, then copies the part to store
+// through a size limit
+func streamUploadPart(ctx context.Context, dst reflect.Value, part *multipart.Part, field uploadField, store UploadStore, cfg UploadConfig, stored *[]string) error {
+	if dst.Type() != uploadedFileSliceType && isUploadSet(dst) {
+		return errors.BadRequest("Only one file is allowed for this field").
+			WithDetail("field", field.name)
+	}
+
+	br := bufio.NewReaderSize(part, 512)
+	head, _ := br.Peek(512)
+	contentType := sniffContentType(head)
+	if !field.rule.allows(contentType) {
+		return errors.UnsupportedMediaType("File type is not allowed").
+			WithDetail("field", field.name).
+			WithDetail("content_type", contentType).
+			WithDetail("allowed", strings.Join(field.rule.types, ", "))
+	}
+
+	maxSize := field.rule.maxSize
+	if maxSize <= 0 {
+		maxSize = cfg.MaxFileSize
+	}
+
+	filename := path.Base(strings.ReplaceAll(part.FileName(), "\\", "/"))
+	key := field.rule.prefix + uuid.NewString() + uploadExtension(filename, contentType)
+
+	limited := &uploadLimitReader{r: br, remaining: maxSize}
+	size, err := store.Store(ctx, key, limited, contentType)
+	if limited.exceeded {
+		_ = store.Delete(context.WithoutCancel(ctx), key)
+		return errors.PayloadTooLarge("File exceeds the maximum size").
+			WithDetail("field", field.name).
+			WithDetail("max_bytes", maxSize)
+	}
+	if err != nil {
+		return errors.WrapInternal(err, "Failed to store upload").
+			WithDetail("field", field.name)
+	}
+	*stored = append(*stored, key)
+
+	file := UploadedFile{Key: key, Filename: filename, Size: size, ContentType: contentType}
+	switch dst.Type() {
+	case uploadedFileType:
+		dst.Set(reflect.ValueOf(file))
+	case uploadedFilePtrType:
+		dst.Set(reflect.ValueOf(&file))
+	case uploadedFileSliceType:
+		dst.Set(reflect.Append(dst, reflect.ValueOf(file)))
+	}
+	return nil
+}
+
+// uploadExtension returns the key extension for a file of contentType. The
+// client's extension is kept only if it is one of the type's extensions, so
+// stores that derive the served type from the key (the local storage
+// backend) serve the sniffed type, never e.g. HTML named after the client's
+// file.
+func uploadExtension(filename, contentType string) string {
+	exts, _ := mime.ExtensionsByType(contentType)
+	ext := strings.ToLower(path.Ext(filename))
+	if slices.Contains(exts, ext) {
+		return ext
+	}
+	if len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func isUploadSet(dst reflect.Value) bool {
+	if dst.Kind() == reflect.Ptr {
+		return !dst.IsNil()
+	}
+	return dst.FieldByName("Key").String() != ""
+}
+
+func cleanupUploads(ctx context.Context, store UploadStore, keys []string) {
+	for _, key := range keys {
+		_ = store.Delete(ctx, key)
+	}
+}
+
+// bindUploadValue reads a non-file part into a basic-kind field
+func bindUploadValue(dst reflect.Value, part *multipart.Part, name string, maxSize int64) error {
+	data, err := io.ReadAll(io.LimitReader(part, maxSize+1))
+	if err != nil {
+		return &BindError{Cause: err, BindType: BindTypeMultipart, FieldName: name}
+	}
+	if int64(len(data)) > maxSize {
+		return errors.PayloadTooLarge("Form value exceeds the maximum size").
+			WithDetail("field", name).
+			WithDetail("max_bytes", maxSize)
+	}
+
+	if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.String {
+		dst.Set(reflect.Append(dst, reflect.ValueOf(string(data))))
+		return nil
+	}
+	if err := setFormValue(dst, string(data)); err != nil {
+		return &BindError{Cause: err, BindType: BindTypeMultipart, FieldName: name}
+	}
+	return nil
+}
+
+func setFormValue(dst reflect.Value, s string) error {
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported field type %s", dst.Type())
+	}
+	return nil
+}
+
+// uploadFields maps form names to struct fields. File fields must have an
+// UploadedFile type; invalid upload tags are programming errors.
+func uploadFields(typ reflect.Type) (map[string]uploadField, error) {
+	fields := make(map[string]uploadField)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Tag.Get("form")
+		if idx := strings.Index(name, ","); idx != -1 {
+			name = name[:idx]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+
+		field := uploadField{index: i, name: name}
+		switch sf.Type {
+		case uploadedFileType, uploadedFilePtrType, uploadedFileSliceType:
+			rule, err := parseUploadTag(sf.Tag.Get("upload"))
+			if err != nil {
+				return nil, errors.WrapInternal(err, "Invalid upload tag").
+					WithDetail("field", sf.Name)
+			}
+			field.rule = rule
+		}
+		fields[name] = field
+	}
+	return fields, nil
+}
+
+func parseUploadTag(tag string) (*uploadRule, error) {
+	rule := &uploadRule{}
+	if tag == "" {
+		return rule, nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "max":
+			size, err := ParseByteSize(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.maxSize = size
+		case "types":
+			for _, t := range strings.Split(value, "|") {
+				if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+					rule.types = append(rule.types, t)
+				}
+			}
+		case "prefix":
+			rule.prefix = value
+		default:
+			return nil, fmt.Errorf("unknown upload option %q", key)
+		}
+	}
+	return rule, nil
+}
+
+// allows reports whether contentType matches the rule. No types allows all.
+func (r *uploadRule) allows(contentType string) bool {
+	if len(r.types) == 0 {
+		return true
+	}
+	for _, t := range r.types {
+		if t == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffContentType detects the media type of head without parameters
+func sniffContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// ParseByteSize parses sizes like "512", "64KB", "10MB" or "1GB" (binary units)
+func ParseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// uploadLimitReader fails once more than remaining bytes are read
+type uploadLimitReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+var errUploadTooLarge = fmt.Errorf("upload exceeds the maximum size")
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe one byte to tell "exactly at the limit" from "over it"
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			l.exceeded = true
+			return 0, errUploadTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
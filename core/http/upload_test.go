@@ -0,0 +1,266 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// memoryUploadStore is an in-memory UploadStore
+type memoryUploadStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryUploadStore() *memoryUploadStore {
+	return &memoryUploadStore{objects: make(map[string][]byte)}
+}
+
+func (s *memoryUploadStore) Store(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *memoryUploadStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryUploadStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type uploadPart struct {
+	field, filename string
+	data            []byte
+}
+
+func newUploadContext(t *testing.T, parts ...uploadPart) *Context {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var pw io.Writer
+		var err error
+		if p.filename != "" {
+			pw, err = w.CreateFormFile(p.field, p.filename)
+		} else {
+			pw, err = w.CreateFormField(p.field)
+		}
+		require.NoError(t, err)
+		_, err = pw.Write(p.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return &Context{Context: echo.New().NewContext(req, httptest.NewRecorder())}
+}
+
+type avatarForm struct {
+	Name   string        `form:"name" validate:"required"`
+	Public bool          `form:"public"`
+	Avatar *UploadedFile `form:"avatar" upload:"max=1KB,types=image/png|image/jpeg,prefix=avatars/" validate:"required"`
+}
+
+func TestContext_StreamUpload(t *testing.T) {
+	t.Run("streams file and binds values", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "public", data: []byte("true")},
+			uploadPart{field: "avatar", filename: "Me.PNG", data: pngHeader},
+		)
+
+		var form avatarForm
+		require.NoError(t, c.StreamUpload(&form, store))
+
+		assert.Equal(t, "Jane", form.Name)
+		assert.True(t, form.Public)
+		require.NotNil(t, form.Avatar)
+		assert.Equal(t, "Me.PNG", form.Avatar.Filename)
+		assert.Equal(t, "image/png", form.Avatar.ContentType)
+		assert.Equal(t, int64(len(pngHeader)), form.Avatar.Size)
+		assert.True(t, strings.HasPrefix(form.Avatar.Key, "avatars/"))
+		assert.True(t, strings.HasSuffix(form.Avatar.Key, ".png"))
+		assert.Equal(t, pngHeader, store.objects[form.Avatar.Key])
+	})
+
+	t.Run("rejects oversized file and removes it", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		big := append(append([]byte{}, pngHeader...), make([]byte, 2048)...)
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "avatar", filename: "big.png", data: big},
+		)
+
+		var form avatarForm
+		err := c.StreamUpload(&form, store)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*errors.Error).HTTPStatus)
+		assert.Equal(t, 0, store.len())
+	})
+
+	t.Run("file at exactly the limit is accepted", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		exact := append(append([]byte{}, pngHeader...), make([]byte, 1024-len(pngHeader))...)
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "avatar", filename: "exact.png", data: exact},
+		)
+
+		var form avatarForm
+		require.NoError(t, c.StreamUpload(&form, store))
+		assert.Equal(t, int64(1024), form.Avatar.Size)
+	})
+
+	t.Run("names the key after the sniffed type", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		polyglot := append(append([]byte{}, pngHeader...), "<script>alert(1)</script>"...)
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "avatar", filename: "x.html", data: polyglot},
+		)
+
+		var form avatarForm
+		require.NoError(t, c.StreamUpload(&form, store))
+		assert.Equal(t, "x.html", form.Avatar.Filename)
+		assert.Equal(t, "image/png", form.Avatar.ContentType)
+		assert.True(t, strings.HasSuffix(form.Avatar.Key, ".png"), form.Avatar.Key)
+	})
+
+	t.Run("rejects disallowed type by content", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "avatar", filename: "fake.png", data: []byte("plain text pretending to be an image")},
+		)
+
+		var form avatarForm
+		err := c.StreamUpload(&form, store)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, err.(*errors.Error).HTTPStatus)
+		assert.Equal(t, 0, store.len())
+	})
+
+	t.Run("validation failure removes stored files", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		c := newUploadContext(t,
+			uploadPart{field: "avatar", filename: "me.png", data: pngHeader},
+		)
+
+		var form avatarForm
+		err := c.StreamUpload(&form, store)
+		require.Error(t, err)
+		assert.IsType(t, &ValidationErrorList{}, err)
+		assert.Equal(t, 0, store.len())
+	})
+
+	t.Run("multiple files", func(t *testing.T) {
+		type galleryForm struct {
+			Photos []UploadedFile `form:"photos" upload:"types=image/*"`
+		}
+		store := newMemoryUploadStore()
+		c := newUploadContext(t,
+			uploadPart{field: "photos", filename: "a.png", data: pngHeader},
+			uploadPart{field: "photos", filename: "b.png", data: pngHeader},
+		)
+
+		var form galleryForm
+		require.NoError(t, c.StreamUpload(&form, store))
+		assert.Len(t, form.Photos, 2)
+		assert.Equal(t, 2, store.len())
+	})
+
+	t.Run("second file for single field is rejected", func(t *testing.T) {
+		store := newMemoryUploadStore()
+		c := newUploadContext(t,
+			uploadPart{field: "name", data: []byte("Jane")},
+			uploadPart{field: "avatar", filename: "a.png", data: pngHeader},
+			uploadPart{field: "avatar", filename: "b.png", data: pngHeader},
+		)
+
+		var form avatarForm
+		err := c.StreamUpload(&form, store)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*errors.Error).HTTPStatus)
+		assert.Equal(t, 0, store.len())
+	})
+
+	t.Run("requires multipart request", func(t *testing.T) {
+		c, _ := newTestContext(http.MethodPost, "/upload", `{"name":"Jane"}`)
+
+		var form avatarForm
+		err := c.StreamUpload(&form, newMemoryUploadStore())
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, err.(*errors.Error).HTTPStatus)
+	})
+
+	t.Run("invalid upload tag", func(t *testing.T) {
+		type badForm struct {
+			File UploadedFile `form:"file" upload:"max=lots"`
+		}
+		c := newUploadContext(t, uploadPart{field: "file", filename: "a.png", data: pngHeader})
+
+		var form badForm
+		err := c.StreamUpload(&form, newMemoryUploadStore())
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.(*errors.Error).HTTPStatus)
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"64KB", 64 << 10},
+		{"10mb", 10 << 20},
+		{"1 GB", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	_, err := ParseByteSize("ten")
+	assert.Error(t, err)
+	_, err = ParseByteSize("-1MB")
+	assert.Error(t, err)
+}
+
+func TestUploadRule_Allows(t *testing.T) {
+	rule := &uploadRule{types: []string{"image/*", "application/pdf"}}
+	assert.True(t, rule.allows("image/png"))
+	assert.True(t, rule.allows("application/pdf"))
+	assert.False(t, rule.allows("text/plain"))
+	assert.True(t, (&uploadRule{}).allows("text/plain"))
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// VersionPlaceholder is replaced by a handler's version in VersioningConfig.Prefix
+const VersionPlaceholder = "{version}"
+
+// WarningCodeDeprecated is the warning code added to deprecated route responses
+const WarningCodeDeprecated = "DEPRECATED"
+
+// VersioningConfig controls how versioned handlers are mounted
+// Set via SetVersioningConfig during initialization
+type VersioningConfig struct {
+	Prefix         string // Mount prefix template (default: "/api/{version}")
+	Header         string // Header used for negotiation (default: "Accept-Version")
+	Negotiate      bool   // Serve unversioned paths using the header (default: false)
+	DefaultVersion string // Version for unversioned requests without the header (default: latest)
+}
+
+// DefaultVersioningConfig returns the default versioning configuration
+func DefaultVersioningConfig() VersioningConfig {
+	return VersioningConfig{
+		Prefix: "/api/" + VersionPlaceholder,
+		Header: "Accept-Version",
+	}
+}
+
+var (
+	versioningConfig   = DefaultVersioningConfig()
+	versioningConfigMu sync.RWMutex
+)
+
+// SetVersioningConfig sets the global versioning configuration
+// Should be called during bootstrap before handlers are registered
+func SetVersioningConfig(cfg VersioningConfig) {
+	defaults := DefaultVersioningConfig()
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaults.Prefix
+	}
+	if cfg.Header == "" {
+		cfg.Header = defaults.Header
+	}
+
+	versioningConfigMu.Lock()
+	defer versioningConfigMu.Unlock()
+	versioningConfig = cfg
+}
+
+// GetVersioningConfig returns the current versioning configuration
+func GetVersioningConfig() VersioningConfig {
+	versioningConfigMu.RLock()
+	defer versioningConfigMu.RUnlock()
+	return versioningConfig
+}
+
+// VersionedHandler is an optional Handler extension that mounts the handler
+// under the versioned prefix. A handler returning "v2" with Prefix()
+// "/contacts" is served at "/api/v2/contacts" by default.
+type VersionedHandler interface {
+	Version() string
+}
+
+// Deprecation describes a deprecated route
+type Deprecation struct {
+	Since   time.Time // When the route was deprecated (zero: unspecified)
+	Sunset  time.Time // When the route will be removed (zero: unspecified)
+	Link    string    // Migration guide URL
+	Message string    // Warning message (default: "This endpoint is deprecated")
+}
+
+// DeprecatedHandler is an optional Handler extension for deprecated routes.
+// Deprecated routes emit Deprecation, Sunset and Link headers and add a
+// DEPRECATED warning to the response.
+type DeprecatedHandler interface {
+	// Deprecation returns deprecation info for a route, identified by its
+	// method and full path pattern. Return nil if the route is current.
+	Deprecation(method, path string) *Deprecation
+}
+
+// VersionPrefix returns the mount prefix for version
+func (c VersioningConfig) VersionPrefix(version string) string {
+	return strings.ReplaceAll(c.Prefix, VersionPlaceholder, version)
+}
+
+// MountPrefix returns the path prefix a handler's routes are mounted under,
+// including the version prefix for VersionedHandlers
+func MountPrefix(h Handler) string {
+	if v, ok := h.(VersionedHandler); ok && v.Version() != "" {
+		return GetVersioningConfig().VersionPrefix(v.Version()) + h.Prefix()
+	}
+	return h.Prefix()
+}
+
+// deprecationMiddleware signals deprecation for routes whose handler
+// reports it.
+func deprecationMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h, ok := HandlerFor(c).(DeprecatedHandler); ok {
+			if d := h.Deprecation(c.Request().Method, c.Path()); d != nil {
+				applyDeprecation(c, d)
+			}
+		}
+		return next(c)
+	}
+}
+
+// applyDeprecation sets the RFC 9745 and RFC 8594 headers and queues a warning
+func applyDeprecation(c echo.Context, d *Deprecation) {
+	header := c.Response().Header()
+	if d.Since.IsZero() {
+		header.Set("Deprecation", "true")
+	} else {
+		header.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+	}
+
+	message := d.Message
+	if message == "" {
+		message = "This endpoint is deprecated"
+	}
+	warning := NewWarning(WarningCodeDeprecated, message)
+	if !d.Sunset.IsZero() {
+		warning = warning.WithDetail("sunset", d.Sunset.UTC().Format(time.RFC3339))
+	}
+	if d.Link != "" {
+		warning = warning.WithDetail("link", d.Link)
+	}
+	AppendWarning(c, warning)
+}
+
+// negotiateVersion rewrites unversioned request paths of versioned handlers
+// to the version named in the negotiation header, or the default version.
+// Other paths, including those of unversioned handlers, are left alone.
+func (r *Router) negotiateVersion(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cfg := GetVersioningConfig()
+		base, suffix, _ := strings.Cut(cfg.Prefix, VersionPlaceholder)
+
+		req := c.Request()
+		rest, ok := strings.CutPrefix(req.URL.Path, base)
+		if !ok {
+			return next(c)
+		}
+
+		// Explicitly versioned paths are routed as-is.
+		segment, _, _ := strings.Cut(rest, "/")
+		if r.hasVersion(segment) || !r.ownsPath("/"+strings.TrimPrefix(rest, "/")) {
+			return next(c)
+		}
+
+		version := req.Header.Get(cfg.Header)
+		if version == "" {
+			version = cfg.DefaultVersion
+		}
+		if version == "" {
+			version = r.latestVersion()
+		}
+		if !r.hasVersion(version) {
+			return errors.BadRequest(fmt.Sprintf("Unsupported API version: %s", version)).
+				WithDetail("supported", r.Versions())
+		}
+
+		path := base + version + suffix
+		if rest != "" {
+			path += "/" + strings.TrimPrefix(rest, "/")
+		}
+		req.URL.Path = path
+		req.URL.RawPath = ""
+
+		c.Response().Header().Add(echo.HeaderVary, cfg.Header)
+		c.Response().Header().Set("API-Version", version)
+		return next(c)
+	}
+}
+
+func (r *Router) addVersion(version, prefix string) {
+	r.versionsMu.Lock()
+	defer r.versionsMu.Unlock()
+	if r.versions == nil {
+		r.versions = make(map[string]bool)
+	}
+	r.versions[version] = true
+	r.versioned = append(r.versioned, strings.TrimSuffix(prefix, "/"))
+}
+
+// ownsPath reports whether path, without its version prefix, falls under a
+// versioned handler's prefix
+func (r *Router) ownsPath(path string) bool {
+	r.versionsMu.RLock()
+	defer r.versionsMu.RUnlock()
+	for _, prefix := range r.versioned {
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) hasVersion(version string) bool {
+	r.versionsMu.RLock()
+	defer r.versionsMu.RUnlock()
+	return r.versions[version]
+}
+
+// Versions returns the API versions mounted on the router, oldest first
+func (r *Router) Versions() []string {
+	r.versionsMu.RLock()
+	defer r.versionsMu.RUnlock()
+
+	versions := make([]string, 0, len(r.versions))
+	for v := range r.versions {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+	return versions
+}
+
+func (r *Router) latestVersion() string {
+	versions := r.Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+// versionLess orders "v2" before "v10", falling back to string order
+func versionLess(a, b string) bool {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+type versionedHandler struct {
+	mockHandler
+	version     string
+	deprecation *Deprecation
+}
+
+func (h *versionedHandler) Version() string { return h.version }
+
+func (h *versionedHandler) Deprecation(method, path string) *Deprecation {
+	return h.deprecation
+}
+
+func newVersionedHandler(version string, deprecation *Deprecation) *versionedHandler {
+	return &versionedHandler{
+		mockHandler: mockHandler{
+			prefix: "/contacts",
+			scope:  ScopePublic,
+			routes: func(g *echo.Group) {
+				g.GET("", WrapHandler(func(c *Context) error {
+					return c.Success(map[string]string{"version": version})
+				}))
+			},
+		},
+		version:     version,
+		deprecation: deprecation,
+	}
+}
+
+func setupVersionedRouter(t *testing.T, cfg VersioningConfig, handlers ...Handler) *Router {
+	t.Helper()
+	ClearHandlers()
+	t.Cleanup(ClearHandlers)
+
+	previous := GetVersioningConfig()
+	SetVersioningConfig(cfg)
+	t.Cleanup(func() { SetVersioningConfig(previous) })
+
+	for _, h := range handlers {
+		RegisterHandler(h)
+	}
+	r := NewRouter(ScopePublic, ":0")
+	r.SetErrorHandler(func(err error, c echo.Context) {
+		if e, ok := err.(*errors.Error); ok {
+			_ = c.NoContent(e.HTTPStatus)
+			return
+		}
+		r.Echo().DefaultHTTPErrorHandler(err, c)
+	})
+	require.NoError(t, r.RegisterHandlers())
+	return r
+}
+
+func serveVersioned(r *Router, path, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if version != "" {
+		req.Header.Set("Accept-Version", version)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVersioning_MountsUnderPrefix(t *testing.T) {
+	r := setupVersionedRouter(t, VersioningConfig{},
+		newVersionedHandler("v1", nil), newVersionedHandler("v2", nil))
+
+	rec := serveVersioned(r, "/api/v1/contacts", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"version":"v1"`)
+
+	rec = serveVersioned(r, "/api/v2/contacts", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"version":"v2"`)
+
+	assert.Equal(t, []string{"v1", "v2"}, r.Versions())
+
+	// Without negotiation unversioned paths are not served
+	rec = serveVersioned(r, "/api/contacts", "v1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestVersioning_CustomPrefix(t *testing.T) {
+	r := setupVersionedRouter(t, VersioningConfig{Prefix: "/{version}"}, newVersionedHandler("v3", nil))
+
+	rec := serveVersioned(r, "/v3/contacts", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestVersioning_Negotiate(t *testing.T) {
+	r := setupVersionedRouter(t, VersioningConfig{Negotiate: true},
+		newVersionedHandler("v2", nil), newVersionedHandler("v10", nil))
+
+	t.Run("header selects version", func(t *testing.T) {
+		rec := serveVersioned(r, "/api/contacts", "v2")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"version":"v2"`)
+		assert.Equal(t, "v2", rec.Header().Get("API-Version"))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), "Accept-Version")
+	})
+
+	t.Run("latest version without header", func(t *testing.T) {
+		rec := serveVersioned(r, "/api/contacts", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"version":"v10"`)
+	})
+
+	t.Run("explicit path wins", func(t *testing.T) {
+		rec := serveVersioned(r, "/api/v2/contacts", "v10")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"version":"v2"`)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		rec := serveVersioned(r, "/api/contacts", "v9")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestVersioning_NegotiateUnversionedHandlers(t *testing.T) {
+	legacy := &mockHandler{
+		prefix: "/api/v1/legacy",
+		scope:  ScopePublic,
+		routes: func(g *echo.Group) {
+			g.GET("", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		},
+	}
+	r := setupVersionedRouter(t, VersioningConfig{Negotiate: true},
+		newVersionedHandler("v2", nil), legacy)
+
+	rec := serveVersioned(r, "/api/v1/legacy", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("API-Version"))
+
+	rec = serveVersioned(r, "/api/unknown", "v9")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveVersioned(r, "/api/contacts", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestVersioning_NegotiateDefaultVersion(t *testing.T) {
+	r := setupVersionedRouter(t, VersioningConfig{Negotiate: true, DefaultVersion: "v1"},
+		newVersionedHandler("v1", nil), newVersionedHandler("v2", nil))
+
+	rec := serveVersioned(r, "/api/contacts", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"version":"v1"`)
+}
+
+func TestVersioning_Deprecation(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	r := setupVersionedRouter(t, VersioningConfig{},
+		newVersionedHandler("v1", &Deprecation{
+			Since:   since,
+			Sunset:  sunset,
+			Link:    "https://example.com/migrate",
+			Message: "Use /api/v2/contacts",
+		}),
+		newVersionedHandler("v2", nil))
+
+	rec := serveVersioned(r, "/api/v1/contacts", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "@1735689600", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Tue, 30 Jun 2026 00:00:00 GMT", rec.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, rec.Header().Get("Link"))
+
+	var body struct {
+		Warnings []Warning `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Warnings, 1)
+	assert.Equal(t, WarningCodeDeprecated, body.Warnings[0].Code)
+	assert.Equal(t, "Use /api/v2/contacts", body.Warnings[0].Message)
+	assert.Equal(t, "2026-06-30T00:00:00Z", body.Warnings[0].Details["sunset"])
+
+	// Current version carries no deprecation signals
+	rec = serveVersioned(r, "/api/v2/contacts", "")
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+	assert.NotContains(t, rec.Body.String(), WarningCodeDeprecated)
+}
+
+func TestVersioning_DeprecationDefaults(t *testing.T) {
+	r := setupVersionedRouter(t, VersioningConfig{}, newVersionedHandler("v1", &Deprecation{}))
+
+	rec := serveVersioned(r, "/api/v1/contacts", "")
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Empty(t, rec.Header().Get("Sunset"))
+	assert.Contains(t, rec.Body.String(), "This endpoint is deprecated")
+}
+
+func TestVersionLess(t *testing.T) {
+	assert.True(t, versionLess("v2", "v10"))
+	assert.False(t, versionLess("v10", "v2"))
+	assert.True(t, versionLess("beta", "stable"))
+}
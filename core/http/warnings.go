@@ -1,5 +1,7 @@
 package http
 
+import "github.com/labstack/echo/v4"
+
 // Warning represents a non-fatal issue in the response
 // Warnings allow APIs to return partial success responses, such as:
 // - Batch operations with some failures
@@ -28,3 +30,20 @@ func (w Warning) WithDetail(key string, value any) Warning {
 	w.Details[key] = value
 	return w
 }
+
+// warningsKey stores warnings queued by middleware in the echo context
+const warningsKey = "codo.warnings"
+
+// AppendWarning queues a warning from middleware. Queued warnings are
+// included in the response when the handler replies with Success, Created
+// or Accepted.
+func AppendWarning(c echo.Context, w Warning) {
+	warnings, _ := c.Get(warningsKey).([]Warning)
+	c.Set(warningsKey, append(warnings, w))
+}
+
+// queuedWarnings returns the warnings queued by AppendWarning
+func queuedWarnings(c echo.Context) []Warning {
+	warnings, _ := c.Get(warningsKey).([]Warning)
+	return warnings
+}
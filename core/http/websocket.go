@@ -0,0 +1,508 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket message types.
+const (
+	WebSocketTextMessage   = websocket.TextMessage
+	WebSocketBinaryMessage = websocket.BinaryMessage
+)
+
+// webSocketCloseWait bounds the close handshake when WriteWait is unset, so
+// an unresponsive peer cannot hold the connection open.
+const webSocketCloseWait = 10 * time.Second
+
+// ErrWebSocketClosed is returned when sending on a closed connection.
+var ErrWebSocketClosed = fmt.Errorf("websocket connection closed")
+
+// ErrWebSocketQueueFull is returned when a connection's send queue is full.
+// The connection is closed, since a client that cannot keep up would
+// otherwise hold server memory indefinitely.
+var ErrWebSocketQueueFull = fmt.Errorf("websocket send queue full")
+
+// WebSocketConfig holds connection settings for a WebSocket endpoint.
+type WebSocketConfig struct {
+	// ReadLimit is the maximum size in bytes of an incoming message.
+	ReadLimit int64
+
+	// WriteWait is the time allowed to write a single message.
+	WriteWait time.Duration
+
+	// PongWait is the time allowed between pongs before the connection is
+	// considered dead. The read deadline is extended on every pong.
+	PongWait time.Duration
+
+	// PingInterval is how often pings are sent. Must be less than PongWait.
+	PingInterval time.Duration
+
+	// SendQueueSize bounds the number of outgoing messages buffered per
+	// connection.
+	SendQueueSize int
+
+	// HandshakeTimeout bounds the upgrade handshake.
+	HandshakeTimeout time.Duration
+
+	// RequireIdentity rejects the upgrade with 401 when no identity was set
+	// by the auth middleware.
+	RequireIdentity bool
+
+	// CheckOrigin validates the Origin header. Nil accepts same-origin
+	// requests only.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// DefaultWebSocketConfig returns the default WebSocket configuration.
+func DefaultWebSocketConfig() *WebSocketConfig {
+	return &WebSocketConfig{
+		ReadLimit:        64 * 1024,
+		WriteWait:        10 * time.Second,
+		PongWait:         60 * time.Second,
+		PingInterval:     54 * time.Second,
+		SendQueueSize:    64,
+		HandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// WebSocketHooks are the lifecycle callbacks for a WebSocket endpoint.
+// All hooks are optional.
+type WebSocketHooks struct {
+	// OnConnect is called after the upgrade. Returning an error closes the
+	// connection with a policy violation.
+	OnConnect func(conn *WebSocketConn) error
+
+	// OnMessage is called for every message received from the client.
+	// Returning an error closes the connection.
+	OnMessage func(conn *WebSocketConn, messageType int, data []byte) error
+
+	// OnClose is called once the connection is closed. err is the reason the
+	// read loop ended, nil for a normal closure.
+	OnClose func(conn *WebSocketConn, err error)
+}
+
+// WebSocket is a WebSocket endpoint. Register it through Handler.Routes:
+//
+//	g.GET("/events", http.WrapHandler(h.socket.Handle))
+type WebSocket struct {
+	config   *WebSocketConfig
+	hooks    WebSocketHooks
+	upgrader websocket.Upgrader
+}
+
+// NewWebSocket creates a WebSocket endpoint with the given hooks.
+// If cfg is nil, DefaultWebSocketConfig is used.
+func NewWebSocket(hooks WebSocketHooks, cfg *WebSocketConfig) *WebSocket {
+	if cfg == nil {
+		cfg = DefaultWebSocketConfig()
+	}
+	return &WebSocket{
+		config: cfg,
+		hooks:  hooks,
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: cfg.HandshakeTimeout,
+			CheckOrigin:      cfg.CheckOrigin,
+		},
+	}
+}
+
+// Handle upgrades the request and runs the connection until it closes.
+func (ws *WebSocket) Handle(c *Context) error {
+	if !c.IsWebSocket() {
+		return errors.BadRequest("WebSocket upgrade required")
+	}
+
+	identity, _ := auth.GetIdentity(c.Context)
+	if identity == nil && ws.config.RequireIdentity {
+		return errors.Unauthorized("Authentication required")
+	}
+
+	raw, err := ws.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an HTTP error response.
+		return nil
+	}
+
+	conn := newWebSocketConn(raw, identity, ws.config)
+	conn.ctx, conn.cancel = context.WithCancel(context.WithoutCancel(c.Request().Context()))
+	ws.run(conn)
+	return nil
+}
+
+// run drives the connection until it closes.
+func (ws *WebSocket) run(conn *WebSocketConn) {
+	sockets.add(conn)
+	defer sockets.remove(conn)
+
+	go conn.writeLoop()
+
+	var err error
+	if ws.hooks.OnConnect != nil {
+		if err = ws.hooks.OnConnect(conn); err != nil {
+			conn.CloseWithReason(websocket.ClosePolicyViolation, "connection rejected")
+		}
+	}
+	if err == nil {
+		err = conn.readLoop(ws.hooks.OnMessage)
+	}
+
+	conn.shutdown()
+
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		err = nil
+	}
+	if ws.hooks.OnClose != nil {
+		ws.hooks.OnClose(conn, err)
+	}
+}
+
+// outgoingMessage is a queued message awaiting the write loop.
+type outgoingMessage struct {
+	messageType int
+	data        []byte
+}
+
+// WebSocketConn is a single client connection.
+type WebSocketConn struct {
+	id       string
+	identity *auth.Identity
+	conn     *websocket.Conn
+	config   *WebSocketConfig
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	send      chan outgoingMessage
+	closing   chan struct{}
+	written   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	mu      sync.RWMutex
+	values  map[string]any
+	onClose []func()
+}
+
+func newWebSocketConn(raw *websocket.Conn, identity *auth.Identity, cfg *WebSocketConfig) *WebSocketConn {
+	queueSize := cfg.SendQueueSize
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	return &WebSocketConn{
+		id:        uuid.New().String(),
+		identity:  identity,
+		conn:      raw,
+		config:    cfg,
+		ctx:       context.Background(),
+		cancel:    func() {},
+		send:      make(chan outgoingMessage, queueSize),
+		closing:   make(chan struct{}),
+		written:   make(chan struct{}),
+		done:      make(chan struct{}),
+		closeCode: websocket.CloseNormalClosure,
+		values:    make(map[string]any),
+	}
+}
+
+// ID returns the unique connection ID.
+func (c *WebSocketConn) ID() string {
+	return c.id
+}
+
+// Identity returns the authenticated identity captured at upgrade time,
+// or nil for anonymous connections.
+func (c *WebSocketConn) Identity() *auth.Identity {
+	return c.identity
+}
+
+// Context returns a context that is cancelled when the connection closes.
+// It carries the values of the upgrade request, including the identity.
+func (c *WebSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+// Set stores a value on the connection.
+func (c *WebSocketConn) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+// Get retrieves a value stored on the connection.
+func (c *WebSocketConn) Get(key string) any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.values[key]
+}
+
+// Send queues a text message. It never blocks: if the queue is full the
+// connection is closed and ErrWebSocketQueueFull is returned.
+func (c *WebSocketConn) Send(data []byte) error {
+	return c.enqueue(outgoingMessage{messageType: websocket.TextMessage, data: data})
+}
+
+// SendBinary queues a binary message.
+func (c *WebSocketConn) SendBinary(data []byte) error {
+	return c.enqueue(outgoingMessage{messageType: websocket.BinaryMessage, data: data})
+}
+
+// SendJSON marshals v and queues it as a text message.
+func (c *WebSocketConn) SendJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(data)
+}
+
+func (c *WebSocketConn) enqueue(msg outgoingMessage) error {
+	select {
+	case <-c.closing:
+		return ErrWebSocketClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		c.CloseWithReason(websocket.CloseTryAgainLater, "send queue full")
+		return ErrWebSocketQueueFull
+	}
+}
+
+// Close closes the connection with a normal closure.
+func (c *WebSocketConn) Close() {
+	c.CloseWithReason(websocket.CloseNormalClosure, "")
+}
+
+// CloseWithReason starts a graceful close with the given close code.
+// Messages already queued are flushed before the close frame is sent.
+func (c *WebSocketConn) CloseWithReason(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closeCode = code
+		c.closeText = reason
+		c.mu.Unlock()
+		close(c.closing)
+	})
+}
+
+// Done returns a channel that is closed once the connection is fully closed.
+func (c *WebSocketConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// OnClose registers a function called once the connection is closed.
+func (c *WebSocketConn) OnClose(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onClose = append(c.onClose, fn)
+}
+
+// readLoop reads messages until the connection fails or is closed.
+func (c *WebSocketConn) readLoop(onMessage func(*WebSocketConn, int, []byte) error) error {
+	if c.config.ReadLimit > 0 {
+		c.conn.SetReadLimit(c.config.ReadLimit)
+	}
+	c.extendReadDeadline()
+	c.conn.SetPongHandler(func(string) error {
+		c.extendReadDeadline()
+		return nil
+	})
+
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		c.extendReadDeadline()
+
+		if onMessage == nil {
+			continue
+		}
+		if err := onMessage(c, messageType, data); err != nil {
+			c.CloseWithReason(websocket.CloseInternalServerErr, "")
+			return err
+		}
+	}
+}
+
+func (c *WebSocketConn) extendReadDeadline() {
+	if c.config.PongWait > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
+	}
+}
+
+// writeLoop owns all writes to the connection: queued messages, pings and
+// the final close frame.
+func (c *WebSocketConn) writeLoop() {
+	defer close(c.written)
+
+	var ticks <-chan time.Time
+	if c.config.PingInterval > 0 {
+		ticker := time.NewTicker(c.config.PingInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg.messageType, msg.data); err != nil {
+				c.abort()
+				return
+			}
+		case <-ticks:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, c.writeDeadline()); err != nil {
+				c.abort()
+				return
+			}
+		case <-c.closing:
+			c.flush()
+			c.mu.RLock()
+			frame := websocket.FormatCloseMessage(c.closeCode, c.closeText)
+			c.mu.RUnlock()
+			_ = c.conn.WriteControl(websocket.CloseMessage, frame, c.closeDeadline())
+			// Give the peer WriteWait to acknowledge before the read loop
+			// gives up on it.
+			_ = c.conn.NetConn().SetReadDeadline(c.closeDeadline())
+			return
+		}
+	}
+}
+
+// flush writes any messages still queued at close time.
+func (c *WebSocketConn) flush() {
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.write(msg.messageType, msg.data); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *WebSocketConn) write(messageType int, data []byte) error {
+	_ = c.conn.SetWriteDeadline(c.writeDeadline())
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *WebSocketConn) writeDeadline() time.Time {
+	if c.config.WriteWait <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.config.WriteWait)
+}
+
+// closeDeadline is the write deadline, or webSocketCloseWait from now if
+// WriteWait is unset.
+func (c *WebSocketConn) closeDeadline() time.Time {
+	if c.config.WriteWait <= 0 {
+		return time.Now().Add(webSocketCloseWait)
+	}
+	return c.writeDeadline()
+}
+
+// abort tears down the connection after a write failure.
+func (c *WebSocketConn) abort() {
+	c.closeOnce.Do(func() { close(c.closing) })
+	_ = c.conn.Close()
+}
+
+// shutdown releases the connection once the read loop has ended.
+func (c *WebSocketConn) shutdown() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closeCode = websocket.CloseGoingAway
+		c.mu.Unlock()
+		close(c.closing)
+	})
+	// Let the write loop flush and send the close frame first.
+	<-c.written
+	_ = c.conn.Close()
+	c.cancel()
+
+	c.mu.Lock()
+	hooks := c.onClose
+	c.onClose = nil
+	c.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+
+	close(c.done)
+}
+
+// socketSet tracks open connections so they can be closed on shutdown.
+// Hijacked connections are not tracked by http.Server.Shutdown.
+type socketSet struct {
+	mu    sync.Mutex
+	conns map[*WebSocketConn]struct{}
+}
+
+var sockets = &socketSet{conns: make(map[*WebSocketConn]struct{})}
+
+func (s *socketSet) add(c *WebSocketConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[c] = struct{}{}
+}
+
+func (s *socketSet) remove(c *WebSocketConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, c)
+}
+
+func (s *socketSet) snapshot() []*WebSocketConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]*WebSocketConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// WebSocketCount returns the number of open WebSocket connections.
+func WebSocketCount() int {
+	sockets.mu.Lock()
+	defer sockets.mu.Unlock()
+	return len(sockets.conns)
+}
+
+// CloseWebSockets sends a going-away close frame to every open connection
+// and waits for them to finish or for ctx to expire.
+func CloseWebSockets(ctx context.Context) error {
+	conns := sockets.snapshot()
+	for _, c := range conns {
+		c.CloseWithReason(websocket.CloseGoingAway, "server shutting down")
+	}
+	for _, c := range conns {
+		select {
+		case <-c.Done():
+		case <-ctx.Done():
+			// Force the remaining connections closed.
+			for _, rest := range sockets.snapshot() {
+				_ = rest.conn.Close()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/codoworks/codo-framework/clients/rabbitmq"
+	"github.com/google/uuid"
+)
+
+// WebSocketEvent is the envelope delivered to sockets by a WebSocketHub.
+type WebSocketEvent struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WebSocketHub fans out topic messages to subscribed sockets.
+// Subscriptions use RabbitMQ topic patterns:
+//   - * matches exactly one word (e.g., "orders.*.created")
+//   - # matches zero or more words (e.g., "orders.#")
+type WebSocketHub struct {
+	mu sync.RWMutex
+
+	// patterns maps a subscription pattern to its subscribers.
+	patterns map[string]map[*WebSocketConn]struct{}
+
+	// conns maps a socket to the patterns it is subscribed to.
+	conns map[*WebSocketConn]map[string]struct{}
+}
+
+// NewWebSocketHub creates an empty hub.
+func NewWebSocketHub() *WebSocketHub {
+	return &WebSocketHub{
+		patterns: make(map[string]map[*WebSocketConn]struct{}),
+		conns:    make(map[*WebSocketConn]map[string]struct{}),
+	}
+}
+
+// Subscribe subscribes a socket to a topic pattern.
+// The socket is unsubscribed from everything when it closes.
+func (h *WebSocketHub) Subscribe(conn *WebSocketConn, pattern string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	topics, known := h.conns[conn]
+	if !known {
+		topics = make(map[string]struct{})
+		h.conns[conn] = topics
+		conn.OnClose(func() { h.UnsubscribeAll(conn) })
+	}
+	topics[pattern] = struct{}{}
+
+	subs, ok := h.patterns[pattern]
+	if !ok {
+		subs = make(map[*WebSocketConn]struct{})
+		h.patterns[pattern] = subs
+	}
+	subs[conn] = struct{}{}
+}
+
+// Unsubscribe removes a socket's subscription to a topic pattern.
+func (h *WebSocketHub) Unsubscribe(conn *WebSocketConn, pattern string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribe(conn, pattern)
+}
+
+// UnsubscribeAll removes all of a socket's subscriptions.
+func (h *WebSocketHub) UnsubscribeAll(conn *WebSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for pattern := range h.conns[conn] {
+		h.unsubscribe(conn, pattern)
+	}
+	delete(h.conns, conn)
+}
+
+func (h *WebSocketHub) unsubscribe(conn *WebSocketConn, pattern string) {
+	if subs, ok := h.patterns[pattern]; ok {
+		delete(subs, conn)
+		if len(subs) == 0 {
+			delete(h.patterns, pattern)
+		}
+	}
+	if topics, ok := h.conns[conn]; ok {
+		delete(topics, pattern)
+	}
+}
+
+// Subscribers returns the number of sockets subscribed to a pattern.
+func (h *WebSocketHub) Subscribers(pattern string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.patterns[pattern])
+}
+
+// Publish delivers a payload to every socket with a matching subscription
+// and returns the number of sockets it was queued for. Sockets whose send
+// queue is full are closed and skipped.
+func (h *WebSocketHub) Publish(topic string, payload any) (int, error) {
+	raw, ok := payload.(json.RawMessage)
+	if !ok {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		raw = data
+	}
+
+	data, err := json.Marshal(WebSocketEvent{Topic: topic, Payload: raw})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, conn := range h.matching(topic) {
+		if conn.Send(data) == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// matching returns the sockets subscribed to a pattern matching topic,
+// each at most once.
+func (h *WebSocketHub) matching(topic string) []*WebSocketConn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	seen := make(map[*WebSocketConn]struct{})
+	var conns []*WebSocketConn
+	for pattern, subs := range h.patterns {
+		if !TopicMatches(pattern, topic) {
+			continue
+		}
+		for conn := range subs {
+			if _, dup := seen[conn]; dup {
+				continue
+			}
+			seen[conn] = struct{}{}
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// Bridge subscribes the hub to a RabbitMQ topic pattern so matching
+// messages are fanned out to sockets. Every server instance gets its own
+// exclusive queue, so each instance sees every message for its sockets.
+// Options are applied after those defaults and may override them.
+func (h *WebSocketHub) Bridge(client rabbitmq.RabbitMQClient, pattern string, opts ...rabbitmq.SubscribeOption) error {
+	defaults := []rabbitmq.SubscribeOption{
+		rabbitmq.WithQueueName("ws-" + strings.NewReplacer("*", "star", "#", "hash", ".", "-").Replace(pattern) + "-" + uuid.New().String()),
+		rabbitmq.WithExclusive(),
+	}
+
+	return client.Subscribe(pattern, func(ctx context.Context, msg *rabbitmq.Message) error {
+		_, err := h.Publish(msg.Topic, msg.Payload)
+		return err
+	}, append(defaults, opts...)...)
+}
+
+// TopicMatches reports whether a dot-separated topic matches a RabbitMQ
+// topic pattern.
+func TopicMatches(pattern, topic string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(topic, "."))
+}
+
+func matchWords(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(topic); i++ {
+				if matchWords(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(topic) == 0 {
+				return false
+			}
+		default:
+			if len(topic) == 0 || pattern[0] != topic[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		topic = topic[1:]
+	}
+	return len(topic) == 0
+}
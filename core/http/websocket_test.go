@@ -0,0 +1,332 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codoworks/codo-framework/clients/rabbitmq"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebSocketTestServer(t *testing.T, ws *WebSocket, mw ...echo.MiddlewareFunc) string {
+	t.Helper()
+	e := echo.New()
+	e.GET("/ws", WrapHandler(ws.Handle), mw...)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+}
+
+func dialWebSocket(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withIdentity(id string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auth.SetIdentity(c, &auth.Identity{ID: id})
+			return next(c)
+		}
+	}
+}
+
+func TestDefaultWebSocketConfig(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+
+	assert.Equal(t, int64(64*1024), cfg.ReadLimit)
+	assert.Equal(t, 64, cfg.SendQueueSize)
+	assert.Less(t, cfg.PingInterval, cfg.PongWait)
+	assert.False(t, cfg.RequireIdentity)
+}
+
+func TestWebSocket_Echo(t *testing.T) {
+	ws := NewWebSocket(WebSocketHooks{
+		OnMessage: func(conn *WebSocketConn, messageType int, data []byte) error {
+			return conn.Send(data)
+		},
+	}, nil)
+	conn := dialWebSocket(t, newWebSocketTestServer(t, ws))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestWebSocket_Identity(t *testing.T) {
+	identities := make(chan *auth.Identity, 1)
+	ws := NewWebSocket(WebSocketHooks{
+		OnConnect: func(conn *WebSocketConn) error {
+			identities <- conn.Identity()
+			return nil
+		},
+	}, nil)
+	dialWebSocket(t, newWebSocketTestServer(t, ws, withIdentity("user-123")))
+
+	select {
+	case identity := <-identities:
+		require.NotNil(t, identity)
+		assert.Equal(t, "user-123", identity.ID)
+	case <-time.After(time.Second):
+		t.Fatal("OnConnect not called")
+	}
+}
+
+func TestWebSocket_RequireIdentity(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.RequireIdentity = true
+	ws := NewWebSocket(WebSocketHooks{}, cfg)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set(echo.HeaderConnection, "Upgrade")
+	req.Header.Set(echo.HeaderUpgrade, "websocket")
+	rec := httptest.NewRecorder()
+	c := &Context{Context: e.NewContext(req, rec)}
+
+	err := ws.Handle(c)
+	require.Error(t, err)
+	assert.True(t, errors.IsUnauthorized(err))
+}
+
+func TestWebSocket_RequiresUpgrade(t *testing.T) {
+	ws := NewWebSocket(WebSocketHooks{}, nil)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	rec := httptest.NewRecorder()
+	c := &Context{Context: e.NewContext(req, rec)}
+
+	err := ws.Handle(c)
+	require.Error(t, err)
+}
+
+func TestWebSocket_OnConnectRejects(t *testing.T) {
+	ws := NewWebSocket(WebSocketHooks{
+		OnConnect: func(conn *WebSocketConn) error {
+			return assert.AnError
+		},
+	}, nil)
+	conn := dialWebSocket(t, newWebSocketTestServer(t, ws))
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+}
+
+func TestWebSocket_OnClose(t *testing.T) {
+	closed := make(chan error, 1)
+	ws := NewWebSocket(WebSocketHooks{
+		OnClose: func(conn *WebSocketConn, err error) {
+			closed <- err
+		},
+	}, nil)
+	conn := dialWebSocket(t, newWebSocketTestServer(t, ws))
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	require.NoError(t, conn.WriteMessage(websocket.CloseMessage, msg))
+
+	select {
+	case err := <-closed:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("OnClose not called")
+	}
+}
+
+func TestWebSocket_ReadDeadline(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.PongWait = 50 * time.Millisecond
+	cfg.PingInterval = time.Hour
+
+	closed := make(chan error, 1)
+	ws := NewWebSocket(WebSocketHooks{
+		OnClose: func(conn *WebSocketConn, err error) {
+			closed <- err
+		},
+	}, cfg)
+	dialWebSocket(t, newWebSocketTestServer(t, ws))
+
+	select {
+	case err := <-closed:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection was not closed after read deadline")
+	}
+}
+
+func TestWebSocket_Ping(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.PingInterval = 20 * time.Millisecond
+	url := newWebSocketTestServer(t, NewWebSocket(WebSocketHooks{}, cfg))
+	conn := dialWebSocket(t, url)
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatal("no ping received")
+	}
+}
+
+func TestWebSocketConn_SendQueueFull(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.SendQueueSize = 1
+	c := newWebSocketConn(nil, nil, cfg)
+
+	require.NoError(t, c.Send([]byte("one")))
+	assert.ErrorIs(t, c.Send([]byte("two")), ErrWebSocketQueueFull)
+	assert.ErrorIs(t, c.Send([]byte("three")), ErrWebSocketClosed)
+}
+
+func TestWebSocketConn_CloseDeadline(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.WriteWait = 0
+	c := newWebSocketConn(nil, nil, cfg)
+
+	assert.True(t, c.writeDeadline().IsZero())
+	deadline := c.closeDeadline()
+	assert.False(t, deadline.IsZero(), "close must be bounded without WriteWait")
+	assert.WithinDuration(t, time.Now().Add(webSocketCloseWait), deadline, time.Second)
+}
+
+func TestWebSocketConn_Values(t *testing.T) {
+	c := newWebSocketConn(nil, nil, DefaultWebSocketConfig())
+
+	c.Set("room", "lobby")
+	assert.Equal(t, "lobby", c.Get("room"))
+	assert.Nil(t, c.Get("missing"))
+	assert.NotEmpty(t, c.ID())
+}
+
+func TestCloseWebSockets(t *testing.T) {
+	connected := make(chan struct{}, 1)
+	ws := NewWebSocket(WebSocketHooks{
+		OnConnect: func(conn *WebSocketConn) error {
+			connected <- struct{}{}
+			return nil
+		},
+	}, nil)
+	conn := dialWebSocket(t, newWebSocketTestServer(t, ws))
+	<-connected
+
+	// Echo the close frame like a well-behaved client.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, CloseWebSockets(ctx))
+	assert.Equal(t, 0, WebSocketCount())
+}
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.updated", false},
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders.eu.created", false},
+		{"orders.#", "orders", true},
+		{"orders.#", "orders.eu.created", true},
+		{"#.created", "orders.eu.created", true},
+		{"#", "anything.at.all", true},
+		{"*.created", "created", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.topic, func(t *testing.T) {
+			assert.Equal(t, tt.want, TopicMatches(tt.pattern, tt.topic))
+		})
+	}
+}
+
+func TestWebSocketHub_Publish(t *testing.T) {
+	hub := NewWebSocketHub()
+	a := newWebSocketConn(nil, nil, DefaultWebSocketConfig())
+	b := newWebSocketConn(nil, nil, DefaultWebSocketConfig())
+
+	hub.Subscribe(a, "orders.*")
+	hub.Subscribe(a, "orders.#")
+	hub.Subscribe(b, "payments.#")
+
+	n, err := hub.Publish("orders.created", map[string]string{"id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	msg := <-a.send
+	var event WebSocketEvent
+	require.NoError(t, json.Unmarshal(msg.data, &event))
+	assert.Equal(t, "orders.created", event.Topic)
+	assert.JSONEq(t, `{"id":"1"}`, string(event.Payload))
+	assert.Empty(t, b.send)
+}
+
+func TestWebSocketHub_Unsubscribe(t *testing.T) {
+	hub := NewWebSocketHub()
+	c := newWebSocketConn(nil, nil, DefaultWebSocketConfig())
+
+	hub.Subscribe(c, "orders.#")
+	hub.Subscribe(c, "payments.#")
+	assert.Equal(t, 1, hub.Subscribers("orders.#"))
+
+	hub.Unsubscribe(c, "orders.#")
+	assert.Equal(t, 0, hub.Subscribers("orders.#"))
+	assert.Equal(t, 1, hub.Subscribers("payments.#"))
+
+	hub.UnsubscribeAll(c)
+	assert.Equal(t, 0, hub.Subscribers("payments.#"))
+}
+
+func TestWebSocketHub_Bridge(t *testing.T) {
+	hub := NewWebSocketHub()
+	client := rabbitmq.NewMock()
+	c := newWebSocketConn(nil, nil, DefaultWebSocketConfig())
+	hub.Subscribe(c, "orders.created")
+
+	require.NoError(t, hub.Bridge(client, "orders.created"))
+	assert.True(t, client.HasHandler("orders.created"))
+
+	require.NoError(t, client.SimulateMessage(context.Background(), "orders.created", map[string]int{"id": 7}))
+
+	msg := <-c.send
+	var event WebSocketEvent
+	require.NoError(t, json.Unmarshal(msg.data, &event))
+	assert.Equal(t, "orders.created", event.Topic)
+	assert.JSONEq(t, `{"id":7}`, string(event.Payload))
+}
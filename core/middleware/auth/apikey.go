@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// apiKeyPrefix starts every issued API key, so leaked keys are easy to find
+const apiKeyPrefix = "codo_"
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKey struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	IdentityID string         `json:"identity_id"` // ID of the identity the key acts as
+	Traits     map[string]any `json:"traits,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ExpiresAt  time.Time      `json:"expires_at,omitzero"` // Zero never expires
+	RevokedAt  time.Time      `json:"revoked_at,omitzero"`
+}
+
+// Active reports whether the key can authenticate at t
+func (k *APIKey) Active(t time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || t.Before(k.ExpiresAt))
+}
+
+// APIKeyStore stores API keys by the hash of the key
+type APIKeyStore struct {
+	client *db.Client
+	table  string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewAPIKeyStore creates an API key store using the given table, created
+// on first use
+func NewAPIKeyStore(client *db.Client, table string) *APIKeyStore {
+	return &APIKeyStore{client: client, table: table}
+}
+
+// ensureTable creates the table if it does not exist.
+func (s *APIKeyStore) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			identity_id VARCHAR(255) NOT NULL,
+			traits TEXT NOT NULL,
+			created_at BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			revoked_at BIGINT NOT NULL
+		)
+	`, s.table)
+
+	if _, err := s.client.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create api key table: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+// Create issues a new key for an identity and returns it with its record.
+// The key is only available here; store it on the caller's side.
+func (s *APIKeyStore) Create(ctx context.Context, name, identityID string, traits map[string]any, expiresAt time.Time) (string, *APIKey, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return "", nil, err
+	}
+
+	id, err := randomString(8)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + id + "_" + secret
+
+	if traits == nil {
+		traits = map[string]any{}
+	}
+	data, err := json.Marshal(traits)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rec := &APIKey{
+		ID:         id,
+		Name:       name,
+		IdentityID: identityID,
+		Traits:     traits,
+		CreatedAt:  time.Now().Truncate(time.Millisecond),
+		ExpiresAt:  expiresAt,
+	}
+	insert := s.client.Rebind(fmt.Sprintf(
+		"INSERT INTO %s (id, name, key_hash, identity_id, traits, created_at, expires_at, revoked_at) VALUES (?, ?, ?, ?, ?, ?, ?, 0)",
+		s.table))
+	if _, err := s.client.ExecContext(ctx, insert, id, name, hashAPIKey(key), identityID, string(data),
+		rec.CreatedAt.UnixMilli(), unixMilli(expiresAt)); err != nil {
+		return "", nil, err
+	}
+	return key, rec, nil
+}
+
+// Lookup returns the record of a key, or nil if the key is unknown
+func (s *APIKeyStore) Lookup(ctx context.Context, key string) (*APIKey, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var row struct {
+		ID         string `db:"id"`
+		Name       string `db:"name"`
+		IdentityID string `db:"identity_id"`
+		Traits     string `db:"traits"`
+		CreatedAt  int64  `db:"created_at"`
+		ExpiresAt  int64  `db:"expires_at"`
+		RevokedAt  int64  `db:"revoked_at"`
+	}
+	query := s.client.Rebind(fmt.Sprintf(
+		"SELECT id, name, identity_id, traits, created_at, expires_at, revoked_at FROM %s WHERE key_hash = ?",
+		s.table))
+	if err := s.client.GetContext(ctx, &row, query, hashAPIKey(key)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rec := &APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		IdentityID: row.IdentityID,
+		CreatedAt:  time.UnixMilli(row.CreatedAt),
+		ExpiresAt:  fromUnixMilli(row.ExpiresAt),
+		RevokedAt:  fromUnixMilli(row.RevokedAt),
+	}
+	if err := json.Unmarshal([]byte(row.Traits), &rec.Traits); err != nil {
+		return nil, fmt.Errorf("invalid api key traits: %w", err)
+	}
+	return rec, nil
+}
+
+// Revoke revokes a key by ID. Cached identities remain valid until the
+// auth cache entry expires.
+func (s *APIKeyStore) Revoke(ctx context.Context, id string) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+	update := s.client.Rebind(fmt.Sprintf("UPDATE %s SET revoked_at = ? WHERE id = ?", s.table))
+	_, err := s.client.ExecContext(ctx, update, time.Now().UnixMilli(), id)
+	return err
+}
+
+// APIKeyAuthenticator validates API keys against an APIKeyStore
+type APIKeyAuthenticator struct {
+	store  *APIKeyStore
+	header string
+}
+
+// NewAPIKeyAuthenticator creates an API key authenticator from
+// middleware.auth.api_key, using the db client
+func NewAPIKeyAuthenticator(cfg *config.AuthMiddlewareConfig) (Authenticator, error) {
+	client, err := clients.GetTyped[*db.Client]("db")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get db client: %w", err)
+	}
+
+	header := cfg.APIKey.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+	table := cfg.APIKey.Table
+	if table == "" {
+		table = "api_keys"
+	}
+	return &APIKeyAuthenticator{store: NewAPIKeyStore(client, table), header: header}, nil
+}
+
+// Store returns the store keys are validated against
+func (a *APIKeyAuthenticator) Store() *APIKeyStore {
+	return a.store
+}
+
+// Method returns auth.MethodAPIKey
+func (a *APIKeyAuthenticator) Method() string { return auth.MethodAPIKey }
+
+// Credential returns the configured header's value
+func (a *APIKeyAuthenticator) Credential(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(a.header))
+}
+
+// Authenticate looks the key up and checks that it is active
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*auth.Identity, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, errors.Unauthorized("Invalid API key")
+	}
+
+	rec, err := a.store.Lookup(ctx, key)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to look up API key")
+	}
+	if rec == nil {
+		return nil, errors.Unauthorized("Invalid API key")
+	}
+	if !rec.Active(time.Now()) {
+		return nil, errors.Unauthorized("API key expired or revoked")
+	}
+
+	return &auth.Identity{
+		ID:        rec.IdentityID,
+		SessionID: rec.ID,
+		Traits:    rec.Traits,
+		ExpiresAt: rec.ExpiresAt,
+	}, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys are random, so a fast
+// hash is enough to keep them unusable if the table leaks.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -36,63 +35,69 @@ type SessionValidator interface {
 	GetCookieName() string
 }
 
-// CachedSession holds a validated session with expiration
-type CachedSession struct {
-	Identity  *auth.Identity
-	ExpiresAt time.Time
-}
-
-// AuthMiddleware implements the Middleware interface for authentication
+// AuthMiddleware implements the Middleware interface for authentication.
+// Requests are authenticated by the first configured authenticator whose
+// credential they carry (see Authenticator).
 type AuthMiddleware struct {
 	middleware.BaseMiddleware
-	kratosClient  SessionValidator
-	skipPaths     map[string]bool
-	devMode       bool // Enables verbose logging
-	devBypassAuth bool // Skip real auth when true
-	devIdentity   *auth.Identity
-	// Session cache
-	cache        map[string]*CachedSession
-	cacheMutex   sync.RWMutex
-	cacheEnabled bool
-	cacheTTL     time.Duration
+	kratosClient   SessionValidator // nil if no Kratos authenticator is configured
+	authenticators []Authenticator
+	skipPaths      map[string]bool
+	devMode        bool // Enables verbose logging
+	devBypassAuth  bool // Skip real auth when true
+	devIdentity    *auth.Identity
+	// Session cache settings, applied process-wide by Activate
+	cacheCfg sessionCacheConfig
+	cacheTTL time.Duration
 	// Logger for dev mode
 	logger *logrus.Logger
 }
 
 // Enabled checks if auth middleware should be enabled
 func (m *AuthMiddleware) Enabled(cfg any) bool {
-	// Check if Kratos client is available
-	if !clients.Has("kratos") {
-		return false
-	}
+	authCfg, _ := cfg.(*config.AuthMiddlewareConfig)
 
-	// Check config if provided
-	if cfg == nil {
-		return true // Enabled by default
+	// Kratos authenticators need the Kratos client
+	if needsKratos(authCfg) && !clients.Has(kratos.ClientName) {
+		return false
 	}
 
-	authCfg, ok := cfg.(*config.AuthMiddlewareConfig)
-	if !ok {
+	// Enabled by default without config
+	if authCfg == nil {
 		return true
 	}
-
 	return authCfg.Enabled
 }
 
+// needsKratos reports whether any configured authenticator uses Kratos
+func needsKratos(cfg *config.AuthMiddlewareConfig) bool {
+	for _, name := range authenticatorNames(cfg) {
+		if kratosAuthenticators[name] {
+			return true
+		}
+	}
+	return false
+}
+
 // Configure initializes the auth middleware with configuration
 func (m *AuthMiddleware) Configure(cfg any) error {
+	authCfg, _ := cfg.(*config.AuthMiddlewareConfig)
+
 	// Get Kratos client from registry
-	client, err := clients.Get("kratos")
-	if err != nil {
-		return fmt.Errorf("failed to get kratos client: %w", err)
+	m.kratosClient = nil
+	if needsKratos(authCfg) {
+		validator, err := sessionValidator()
+		if err != nil {
+			return err
+		}
+		m.kratosClient = validator
 	}
 
-	// Type assert to SessionValidator interface
-	validator, ok := client.(SessionValidator)
-	if !ok {
-		return fmt.Errorf("kratos client does not implement SessionValidator interface")
+	authenticators, err := buildAuthenticators(authCfg)
+	if err != nil {
+		return err
 	}
-	m.kratosClient = validator
+	m.authenticators = authenticators
 
 	// Initialize defaults
 	m.skipPaths = make(map[string]bool)
@@ -101,8 +106,6 @@ func (m *AuthMiddleware) Configure(cfg any) error {
 	m.devIdentity = nil
 
 	// Initialize cache with defaults
-	m.cache = make(map[string]*CachedSession)
-	m.cacheEnabled = true
 	m.cacheTTL = 15 * time.Minute
 
 	// Get logger for dev mode logging
@@ -111,8 +114,8 @@ func (m *AuthMiddleware) Configure(cfg any) error {
 	}
 
 	// Parse config if provided
-	authCfg, ok := cfg.(*config.AuthMiddlewareConfig)
-	if !ok {
+	if authCfg == nil {
+		m.cacheCfg = sessionCacheConfig{enabled: true, maxEntries: DefaultSessionCacheSize}
 		return nil
 	}
 
@@ -128,28 +131,58 @@ func (m *AuthMiddleware) Configure(cfg any) error {
 		m.devIdentity = &auth.Identity{
 			ID:     authCfg.DevIdentity.ID,
 			Traits: authCfg.DevIdentity.Traits,
+			Method: auth.MethodDev,
+		}
+		// Set by the operator, so its email counts as verified
+		if email := m.devIdentity.Email(); email != "" {
+			m.devIdentity.VerifiedAddresses = []string{email}
 		}
 	}
 
 	// Cache settings
-	m.cacheEnabled = authCfg.CacheEnabled
 	if authCfg.CacheTTL > 0 {
 		m.cacheTTL = authCfg.CacheTTL
 	}
+	m.cacheCfg = sessionCacheConfig{
+		enabled:    authCfg.CacheEnabled,
+		store:      authCfg.CacheStore,
+		prefix:     authCfg.CacheKeyPrefix,
+		maxEntries: authCfg.CacheMaxEntries,
+	}
+	if m.cacheCfg.enabled {
+		switch m.cacheCfg.store {
+		case "", "memory", "redis":
+		default:
+			return fmt.Errorf("unknown session cache store: %s", m.cacheCfg.store)
+		}
+	}
 
 	return nil
 }
 
+// Activate creates the session cache shared by this instance, its route
+// override copies and the invalidation hooks. The orchestrator calls it on
+// the primary instance only, so the cache settings of route overrides are
+// ignored.
+func (m *AuthMiddleware) Activate() error {
+	return activateCache(m.cacheCfg)
+}
+
 // Handler returns the authentication middleware function
 func (m *AuthMiddleware) Handler() echo.MiddlewareFunc {
-	kratosClient := m.kratosClient
+	authenticators := m.authenticators
 	skipPaths := m.skipPaths
 	devMode := m.devMode
 	devBypassAuth := m.devBypassAuth
 	devIdentity := m.devIdentity
-	cacheEnabled := m.cacheEnabled
 	log := m.logger
 
+	// Keep the historical message for the cookie-only default
+	missing := "No credentials"
+	if len(authenticators) == 1 && authenticators[0].Method() == auth.MethodSessionCookie {
+		missing = "No session cookie"
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Check skip paths (prefix matching)
@@ -173,74 +206,94 @@ func (m *AuthMiddleware) Handler() echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			// Get session cookie
-			cookie, err := c.Cookie(kratosClient.GetCookieName())
-			if err != nil {
-				return errors.Unauthorized("No session cookie").
-					WithPhase(errors.PhaseMiddleware)
-			}
-
-			var identity *auth.Identity
-
-			// Check cache first
-			if cacheEnabled {
-				if cached, found := m.getCachedSession(cookie.Value); found {
-					identity = cached
-					// Dev mode: log cache hit
-					if devMode && log != nil {
-						log.WithFields(logrus.Fields{
-							"user_id":   identity.ID,
-							"user_name": identity.Name(),
-							"cache":     "hit",
-						}).Info("[Auth] Session validated from cache")
-					}
+			// The first authenticator whose credential is present decides
+			for _, authenticator := range authenticators {
+				credential := authenticator.Credential(c.Request())
+				if credential == "" {
+					continue
 				}
-			}
 
-			// Cache miss - validate with Kratos
-			if identity == nil {
-				identity, err = kratosClient.ValidateSession(c.Request().Context(), cookie.Value)
-				if err != nil {
-					message := "Invalid session"
-					if err == kratos.ErrSessionExpired {
-						message = "Session expired"
+				ctx := c.Request().Context()
+				method := authenticator.Method()
+				key := CacheKey(method, credential)
+				cache := getActiveCache()
+				var identity *auth.Identity
+
+				// Check cache first
+				if cache != nil {
+					cached, err := cache.Get(ctx, key)
+					if err != nil && log != nil {
+						log.WithError(err).Warn("[Auth] Session cache read failed")
+					}
+					if cached != nil {
+						identity = cached
+						// Dev mode: log cache hit
+						if devMode && log != nil {
+							log.WithFields(logrus.Fields{
+								"user_id":   identity.ID,
+								"user_name": identity.Name(),
+								"method":    method,
+								"cache":     "hit",
+							}).Info("[Auth] Session validated from cache")
+						}
 					}
-
-					return errors.Unauthorized(message).
-						WithPhase(errors.PhaseMiddleware)
 				}
 
-				// Store in cache if enabled
-				if cacheEnabled {
-					m.setCachedSession(cookie.Value, identity)
-				}
+				// Cache miss - validate the credential
+				if identity == nil {
+					var err error
+					identity, err = authenticator.Authenticate(ctx, credential)
+					if err != nil {
+						return authError(err)
+					}
+					identity.Method = method
 
-				// Dev mode: always log user info
-				if devMode && log != nil {
-					fields := logrus.Fields{
-						"user_id":   identity.ID,
-						"user_name": identity.Name(),
+					// Store in cache if enabled
+					if cache != nil {
+						if err := m.setCachedSession(ctx, cache, key, identity); err != nil && log != nil {
+							log.WithError(err).Warn("[Auth] Session cache write failed")
+						}
 					}
-					if cacheEnabled {
-						fields["cache"] = "miss"
-						log.WithFields(fields).Info("[Auth] Session validated from Kratos")
-					} else {
+
+					// Dev mode: always log user info
+					if devMode && log != nil {
+						fields := logrus.Fields{
+							"user_id":   identity.ID,
+							"user_name": identity.Name(),
+							"method":    method,
+						}
+						if cache != nil {
+							fields["cache"] = "miss"
+						}
 						log.WithFields(fields).Info("[Auth] Session validated")
 					}
 				}
-			}
 
-			// Set identity in context
-			auth.SetIdentity(c, identity)
+				// Set identity in context
+				auth.SetIdentity(c, identity)
 
-			// Set headers for downstream service propagation
-			setIdentityHeaders(c, identity)
+				// Set headers for downstream service propagation
+				setIdentityHeaders(c, identity)
 
-			return next(c)
+				return next(c)
+			}
+
+			return errors.Unauthorized(missing).
+				WithPhase(errors.PhaseMiddleware)
 		}
 	}
 }
 
+// authError returns an authenticator error as a framework error
+func authError(err error) error {
+	if fwkErr, ok := err.(*errors.Error); ok {
+		return fwkErr.WithPhase(errors.PhaseMiddleware)
+	}
+	return errors.Unauthorized("Invalid credentials").
+		WithCause(err).
+		WithPhase(errors.PhaseMiddleware)
+}
+
 // setIdentityHeaders sets X-Kratos-* headers on the request for downstream propagation
 func setIdentityHeaders(c echo.Context, identity *auth.Identity) {
 	req := c.Request()
@@ -253,34 +306,15 @@ func setIdentityHeaders(c echo.Context, identity *auth.Identity) {
 	}
 }
 
-// getCachedSession retrieves a session from cache if valid (with lazy cleanup)
-func (m *AuthMiddleware) getCachedSession(cookie string) (*auth.Identity, bool) {
-	m.cacheMutex.RLock()
-	cached, exists := m.cache[cookie]
-	m.cacheMutex.RUnlock()
-
-	if !exists {
-		return nil, false
+// setCachedSession stores a session in cache, at most until the identity
+// itself expires
+func (m *AuthMiddleware) setCachedSession(ctx context.Context, cache SessionCache, key string, identity *auth.Identity) error {
+	ttl := m.cacheTTL
+	if !identity.ExpiresAt.IsZero() {
+		ttl = min(ttl, time.Until(identity.ExpiresAt))
 	}
-
-	if time.Now().After(cached.ExpiresAt) {
-		// Lazy cleanup: remove expired entry
-		m.cacheMutex.Lock()
-		delete(m.cache, cookie)
-		m.cacheMutex.Unlock()
-		return nil, false
-	}
-
-	return cached.Identity, true
-}
-
-// setCachedSession stores a session in cache
-func (m *AuthMiddleware) setCachedSession(cookie string, identity *auth.Identity) {
-	m.cacheMutex.Lock()
-	defer m.cacheMutex.Unlock()
-
-	m.cache[cookie] = &CachedSession{
-		Identity:  identity,
-		ExpiresAt: time.Now().Add(m.cacheTTL),
+	if ttl <= 0 {
+		return nil // Already expired
 	}
+	return cache.Set(ctx, key, identity, ttl)
 }
@@ -38,6 +38,12 @@ func setupAuthMiddleware(t *testing.T, mockKratos *kratos.MockClient, cfg *confi
 	if err := m.Configure(cfg); err != nil {
 		return nil, err
 	}
+	if err := m.Activate(); err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() {
+		activateCache(sessionCacheConfig{})
+	})
 
 	return m, nil
 }
@@ -314,6 +320,7 @@ func TestAuthMiddleware_DevMode(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "dev-user")
+	assert.Contains(t, rec.Body.String(), `"verified_addresses":["dev@example.com"]`, "the configured email counts as verified")
 }
 
 func TestAuthMiddleware_DevMode_Disabled(t *testing.T) {
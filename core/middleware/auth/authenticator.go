@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/codoworks/codo-framework/clients/kratos"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// HeaderSessionToken carries a Kratos session token
+const HeaderSessionToken = "X-Session-Token"
+
+// Authenticator validates one kind of request credential. The auth
+// middleware tries its authenticators in order; the first whose credential
+// the request carries decides.
+type Authenticator interface {
+	// Method is the auth method recorded on identities, e.g. auth.MethodJWT
+	Method() string
+
+	// Credential returns the request's credential, or "" if it carries none
+	Credential(r *http.Request) string
+
+	// Authenticate validates a credential and returns its identity. Return
+	// an *errors.Error to choose the response; other errors become 401.
+	Authenticate(ctx context.Context, credential string) (*auth.Identity, error)
+}
+
+// AuthenticatorFactory builds an authenticator from the auth middleware config
+type AuthenticatorFactory func(cfg *config.AuthMiddlewareConfig) (Authenticator, error)
+
+var (
+	authenticatorsMu sync.RWMutex
+	authenticators   = map[string]AuthenticatorFactory{}
+
+	// kratosAuthenticators need the kratos client
+	kratosAuthenticators = map[string]bool{
+		auth.MethodSessionCookie: true,
+		auth.MethodSessionToken:  true,
+	}
+)
+
+func init() {
+	RegisterAuthenticator(auth.MethodSessionCookie, newSessionCookieAuthenticator)
+	RegisterAuthenticator(auth.MethodSessionToken, newSessionTokenAuthenticator)
+	RegisterAuthenticator(auth.MethodJWT, NewJWTAuthenticator)
+	RegisterAuthenticator(auth.MethodAPIKey, NewAPIKeyAuthenticator)
+}
+
+// RegisterAuthenticator makes an authenticator available by name in
+// middleware.auth.authenticators. Registering a name again replaces it.
+func RegisterAuthenticator(name string, factory AuthenticatorFactory) {
+	authenticatorsMu.Lock()
+	defer authenticatorsMu.Unlock()
+	authenticators[name] = factory
+}
+
+// Authenticators returns the names of the registered authenticators, sorted
+func Authenticators() []string {
+	authenticatorsMu.RLock()
+	defer authenticatorsMu.RUnlock()
+
+	names := make([]string, 0, len(authenticators))
+	for name := range authenticators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// authenticatorNames returns the configured authenticator names, defaulting
+// to the session cookie
+func authenticatorNames(cfg *config.AuthMiddlewareConfig) []string {
+	if cfg == nil || len(cfg.Authenticators) == 0 {
+		return []string{auth.MethodSessionCookie}
+	}
+	return cfg.Authenticators
+}
+
+// buildAuthenticators creates the configured authenticators in order
+func buildAuthenticators(cfg *config.AuthMiddlewareConfig) ([]Authenticator, error) {
+	if cfg == nil {
+		cfg = &config.AuthMiddlewareConfig{}
+	}
+
+	var chain []Authenticator
+	for _, name := range authenticatorNames(cfg) {
+		authenticatorsMu.RLock()
+		factory, ok := authenticators[name]
+		authenticatorsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown authenticator %q", name)
+		}
+
+		a, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("authenticator %s: %w", name, err)
+		}
+		chain = append(chain, a)
+	}
+	return chain, nil
+}
+
+// sessionValidator returns the kratos client as a SessionValidator
+func sessionValidator() (SessionValidator, error) {
+	client, err := clients.Get(kratos.ClientName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kratos client: %w", err)
+	}
+	validator, ok := client.(SessionValidator)
+	if !ok {
+		return nil, fmt.Errorf("kratos client does not implement SessionValidator interface")
+	}
+	return validator, nil
+}
+
+// sessionError maps a Kratos validation error to a response
+func sessionError(err error) error {
+	message := "Invalid session"
+	if err == kratos.ErrSessionExpired {
+		message = "Session expired"
+	}
+	return errors.Unauthorized(message)
+}
+
+// sessionCookieAuthenticator validates the Kratos session cookie
+type sessionCookieAuthenticator struct {
+	validator SessionValidator
+}
+
+func newSessionCookieAuthenticator(*config.AuthMiddlewareConfig) (Authenticator, error) {
+	validator, err := sessionValidator()
+	if err != nil {
+		return nil, err
+	}
+	return &sessionCookieAuthenticator{validator: validator}, nil
+}
+
+func (a *sessionCookieAuthenticator) Method() string { return auth.MethodSessionCookie }
+
+func (a *sessionCookieAuthenticator) Credential(r *http.Request) string {
+	cookie, err := r.Cookie(a.validator.GetCookieName())
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (a *sessionCookieAuthenticator) Authenticate(ctx context.Context, cookie string) (*auth.Identity, error) {
+	identity, err := a.validator.ValidateSession(ctx, cookie)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return identity, nil
+}
+
+// SessionTokenValidator is implemented by session validators that accept
+// Kratos session tokens
+type SessionTokenValidator interface {
+	ValidateSessionToken(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+// sessionTokenAuthenticator validates a Kratos session token sent in the
+// X-Session-Token header, as used by native apps
+type sessionTokenAuthenticator struct {
+	validator SessionTokenValidator
+}
+
+func newSessionTokenAuthenticator(*config.AuthMiddlewareConfig) (Authenticator, error) {
+	validator, err := sessionValidator()
+	if err != nil {
+		return nil, err
+	}
+	tokens, ok := validator.(SessionTokenValidator)
+	if !ok {
+		return nil, fmt.Errorf("kratos client does not implement SessionTokenValidator interface")
+	}
+	return &sessionTokenAuthenticator{validator: tokens}, nil
+}
+
+func (a *sessionTokenAuthenticator) Method() string { return auth.MethodSessionToken }
+
+func (a *sessionTokenAuthenticator) Credential(r *http.Request) string {
+	return r.Header.Get(HeaderSessionToken)
+}
+
+func (a *sessionTokenAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	identity, err := a.validator.ValidateSessionToken(ctx, token)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return identity, nil
+}
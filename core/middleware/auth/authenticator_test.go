@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/kratos"
+	codoauth "github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db/testdb"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// serveAuth runs a request through the middleware and returns the response
+// and the identity the handler saw
+func serveAuth(m *AuthMiddleware, req *http.Request) (*httptest.ResponseRecorder, *codoauth.Identity) {
+	e := newEchoWithErrorHandler()
+	var identity *codoauth.Identity
+	e.Use(m.Handler())
+	e.GET("/test", func(c echo.Context) error {
+		identity, _ = codoauth.GetIdentity(c)
+		return c.String(http.StatusOK, "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec, identity
+}
+
+func TestAuthMiddleware_AuthenticatorChain(t *testing.T) {
+	mockKratos := kratos.NewMockClient()
+	mockKratos.ValidateTokenFunc = func(ctx context.Context, token string) (*codoauth.Identity, error) {
+		if token != "native-token" {
+			return nil, kratos.ErrSessionExpired
+		}
+		return &codoauth.Identity{ID: "app-user"}, nil
+	}
+	clients.MustRegister(testdb.New(t))
+
+	key := newTestKey(t, "rsa")
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		Authenticators: []string{
+			codoauth.MethodSessionCookie,
+			codoauth.MethodSessionToken,
+			codoauth.MethodJWT,
+			codoauth.MethodAPIKey,
+		},
+		JWT:    config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)},
+		APIKey: config.APIKeyAuthConfig{Header: "X-API-Key", Table: "api_keys"},
+	}
+	m, err := setupAuthMiddleware(t, mockKratos, cfg)
+	require.NoError(t, err)
+	require.Len(t, m.authenticators, 4)
+
+	apiKey, _, err := m.authenticators[3].(*APIKeyAuthenticator).Store().
+		Create(context.Background(), "billing", "service-billing", map[string]any{"scope": "invoices"}, time.Time{})
+	require.NoError(t, err)
+
+	t.Run("session cookie", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.AddCookie(&http.Cookie{Name: "ory_kratos_session", Value: "cookie"})
+		rec, identity := serveAuth(m, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, identity)
+		assert.Equal(t, "test-user", identity.ID)
+		assert.Equal(t, codoauth.MethodSessionCookie, identity.Method)
+	})
+
+	t.Run("session token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(HeaderSessionToken, "native-token")
+		rec, identity := serveAuth(m, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, identity)
+		assert.Equal(t, "app-user", identity.ID)
+		assert.Equal(t, codoauth.MethodSessionToken, identity.Method)
+	})
+
+	t.Run("expired session token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(HeaderSessionToken, "old-token")
+		rec, _ := serveAuth(m, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Session expired")
+	})
+
+	t.Run("jwt", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+key.sign(t, map[string]any{
+			"sub": "service-orders",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}))
+		rec, identity := serveAuth(m, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, identity)
+		assert.Equal(t, "service-orders", identity.ID)
+		assert.Equal(t, codoauth.MethodJWT, identity.Method)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec, identity := serveAuth(m, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, identity)
+		assert.Equal(t, "service-billing", identity.ID)
+		assert.Equal(t, "invoices", identity.Traits["scope"])
+		assert.Equal(t, codoauth.MethodAPIKey, identity.Method)
+	})
+
+	t.Run("first credential present decides", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(HeaderSessionToken, "old-token")
+		req.Header.Set("X-API-Key", apiKey)
+		rec, _ := serveAuth(m, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		rec, _ := serveAuth(m, httptest.NewRequest(http.MethodGet, "/test", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "No credentials")
+	})
+}
+
+func TestAuthMiddleware_WithoutKratos(t *testing.T) {
+	clients.ResetRegistry()
+	t.Cleanup(clients.ResetRegistry)
+
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		Authenticators:       []string{codoauth.MethodJWT},
+		JWT:                  config.JWTAuthConfig{JWKSFile: writeJWKS(t, newTestKey(t, "ed25519"))},
+	}
+	m := &AuthMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware("auth", "middleware.auth", middleware.PriorityAuth, middleware.RouterProtected),
+	}
+
+	assert.True(t, m.Enabled(cfg), "JWT alone does not need Kratos")
+	require.NoError(t, m.Configure(cfg))
+	assert.Nil(t, m.kratosClient)
+}
+
+func TestAuthMiddleware_UnknownAuthenticator(t *testing.T) {
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		Authenticators:       []string{codoauth.MethodSessionCookie, "ldap"},
+	}
+	_, err := setupAuthMiddleware(t, kratos.NewMockClient(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown authenticator "ldap"`)
+}
+
+func TestAuthMiddleware_CacheUntilIdentityExpires(t *testing.T) {
+	calls := 0
+	mockKratos := kratos.NewMockClient()
+	mockKratos.ValidateFunc = func(ctx context.Context, cookie string) (*codoauth.Identity, error) {
+		calls++
+		return &codoauth.Identity{ID: "user", ExpiresAt: time.Now().Add(-time.Second)}, nil
+	}
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		CacheEnabled:         true,
+		CacheTTL:             time.Hour,
+	}
+	m, err := setupAuthMiddleware(t, mockKratos, cfg)
+	require.NoError(t, err)
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.AddCookie(&http.Cookie{Name: "ory_kratos_session", Value: "cookie"})
+		rec, _ := serveAuth(m, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 2, calls, "expired identities are not served from cache")
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewAPIKeyStore(testdb.New(t), "api_keys")
+	a := &APIKeyAuthenticator{store: store, header: "X-API-Key"}
+
+	key, rec, err := store.Create(ctx, "ci", "service-ci", nil, time.Time{})
+	require.NoError(t, err)
+	assert.Contains(t, key, apiKeyPrefix+rec.ID+"_")
+
+	identity, err := a.Authenticate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "service-ci", identity.ID)
+	assert.Equal(t, rec.ID, identity.SessionID)
+
+	_, err = a.Authenticate(ctx, key+"x")
+	assert.Error(t, err, "unknown key")
+	_, err = a.Authenticate(ctx, "not-a-key")
+	assert.Error(t, err)
+
+	require.NoError(t, store.Revoke(ctx, rec.ID))
+	_, err = a.Authenticate(ctx, key)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "revoked")
+
+	expired, _, err := store.Create(ctx, "old", "service-old", nil, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = a.Authenticate(ctx, expired)
+	assert.Error(t, err)
+
+	var stored int
+	require.NoError(t, store.client.GetContext(ctx, &stored, "SELECT COUNT(*) FROM api_keys WHERE key_hash = ?", hashAPIKey(key)))
+	assert.Equal(t, 1, stored, "only the hash is stored")
+}
+
+func TestAuthenticators(t *testing.T) {
+	assert.Subset(t, Authenticators(), []string{"api_key", "jwt", "session_cookie", "session_token"})
+}
+
+// writeJWKS writes a key set with the public key and returns its path
+func writeJWKS(t *testing.T, keys ...*testKey) string {
+	t.Helper()
+	set := map[string]any{"keys": []any{}}
+	for _, k := range keys {
+		set["keys"] = append(set["keys"].([]any), k.jwk)
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
@@ -0,0 +1,434 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// refetchInterval limits key set fetches triggered by unknown key IDs
+const refetchInterval = time.Minute
+
+// registeredClaims are JWT claims that are not copied into identity traits
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// DefaultJWTAlgorithms are the signature algorithms accepted when
+// middleware.auth.jwt.algorithms is empty. Only asymmetric algorithms can be
+// verified with a key set's public keys.
+var DefaultJWTAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTAuthenticator validates bearer JWTs signed with a key from a JSON Web
+// Key Set. Signatures are verified by github.com/golang-jwt/jwt for the
+// configured algorithms; tokens must carry exp.
+type JWTAuthenticator struct {
+	keys         *keySet
+	algorithms   []string
+	issuer       string
+	audience     []string
+	subjectClaim string
+	leeway       time.Duration
+	now          func() time.Time
+}
+
+// NewJWTAuthenticator creates a JWT authenticator from middleware.auth.jwt
+func NewJWTAuthenticator(cfg *config.AuthMiddlewareConfig) (Authenticator, error) {
+	jwtCfg := cfg.JWT
+	if jwtCfg.JWKSURL == "" && jwtCfg.JWKSFile == "" {
+		return nil, fmt.Errorf("jwks_url or jwks_file is required")
+	}
+
+	refresh := jwtCfg.RefreshInterval
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	subject := jwtCfg.SubjectClaim
+	if subject == "" {
+		subject = "sub"
+	}
+	algorithms := jwtCfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultJWTAlgorithms
+	}
+	for _, alg := range algorithms {
+		if !slices.Contains(DefaultJWTAlgorithms, alg) {
+			return nil, fmt.Errorf("unsupported algorithm %q", alg)
+		}
+	}
+
+	a := &JWTAuthenticator{
+		keys: &keySet{
+			url:     jwtCfg.JWKSURL,
+			file:    jwtCfg.JWKSFile,
+			refresh: refresh,
+			client:  &http.Client{Timeout: 10 * time.Second},
+		},
+		algorithms:   algorithms,
+		issuer:       jwtCfg.Issuer,
+		audience:     jwtCfg.Audience,
+		subjectClaim: subject,
+		leeway:       jwtCfg.Leeway,
+		now:          time.Now,
+	}
+
+	// A local key set must be readable at startup
+	if jwtCfg.JWKSFile != "" {
+		if err := a.keys.load(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Method returns auth.MethodJWT
+func (a *JWTAuthenticator) Method() string { return auth.MethodJWT }
+
+// Credential returns the token of an "Authorization: Bearer" header
+func (a *JWTAuthenticator) Credential(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Authenticate verifies the token's signature and claims
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	claims, err := a.verify(ctx, token)
+	if err != nil {
+		return nil, errors.Unauthorized("Invalid token").WithCause(err)
+	}
+
+	subject, _ := claims[a.subjectClaim].(string)
+	if subject == "" {
+		return nil, errors.Unauthorized("Invalid token").
+			WithCause(fmt.Errorf("missing %s claim", a.subjectClaim))
+	}
+
+	traits := make(map[string]any)
+	for name, value := range claims {
+		if !slices.Contains(registeredClaims, name) {
+			traits[name] = value
+		}
+	}
+	jti, _ := claims["jti"].(string)
+	exp, _ := numericDate(claims["exp"])
+
+	identity := &auth.Identity{
+		ID:        subject,
+		SessionID: jti,
+		Traits:    traits,
+		ExpiresAt: exp,
+	}
+	// OpenID Connect issuers vouch for the email with email_verified
+	if verified, _ := claims["email_verified"].(bool); verified && identity.Email() != "" {
+		identity.VerifiedAddresses = []string{identity.Email()}
+	}
+	return identity, nil
+}
+
+// verify checks the token's signature and time, issuer and audience claims
+func (a *JWTAuthenticator) verify(ctx context.Context, token string) (map[string]any, error) {
+	parser := &jwt.Parser{ValidMethods: a.algorithms, SkipClaimsValidation: true}
+	parsed, err := parser.Parse(token, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any(parsed.Claims.(jwt.MapClaims))
+	if err := a.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims validates exp, nbf, iat, iss and aud
+func (a *JWTAuthenticator) checkClaims(claims map[string]any) error {
+	now := a.now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(exp.Add(a.leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.leeway).Before(nbf) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(a.leeway).Before(iat) {
+		return fmt.Errorf("token issued in the future")
+	}
+
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if len(a.audience) > 0 && !slices.ContainsFunc(audiences(claims["aud"]), func(aud string) bool {
+		return slices.Contains(a.audience, aud)
+	}) {
+		return fmt.Errorf("unexpected audience")
+	}
+	return nil
+}
+
+// numericDate converts a JWT NumericDate claim
+func numericDate(v any) (time.Time, bool) {
+	seconds, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// audiences returns the aud claim, a string or an array of strings
+func audiences(v any) []string {
+	switch aud := v.(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		var out []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// keySet caches the public keys of a JSON Web Key Set by key ID
+type keySet struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	group     singleflight.Group // Collapses concurrent fetches
+	mu        sync.Mutex
+	keys      map[string]jsonWebKey
+	fetchedAt time.Time
+}
+
+// key returns the key with the given ID for alg. A stale set is refreshed in
+// the background while its keys are still served; an unknown ID waits for a
+// fetch, at most once a minute, so rotated keys are picked up.
+func (s *keySet) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	age := time.Since(s.fetchedAt)
+	jwk, known := s.lookup(kid)
+	loaded := s.keys != nil
+	s.mu.Unlock()
+
+	switch {
+	case !loaded || (!known && age > refetchInterval):
+		if err := s.wait(ctx); err != nil && !loaded {
+			return nil, err
+		}
+		s.mu.Lock()
+		jwk, known = s.lookup(kid)
+		s.mu.Unlock()
+	case age > s.refresh:
+		s.group.DoChan("", s.fetch)
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if jwk.Alg != "" && jwk.Alg != alg {
+		return nil, fmt.Errorf("key %q is not for algorithm %s", kid, alg)
+	}
+	return jwk.key, nil
+}
+
+// wait fetches the key set, sharing a fetch already in flight, until ctx is
+// done
+func (s *keySet) wait(ctx context.Context) error {
+	select {
+	case res := <-s.group.DoChan("", s.fetch):
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lookup finds a key by ID. Tokens without a kid match a single-key set.
+// Callers hold s.mu.
+func (s *keySet) lookup(kid string) (jsonWebKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, jwk := range s.keys {
+			return jwk, true
+		}
+	}
+	jwk, ok := s.keys[kid]
+	return jwk, ok
+}
+
+func (s *keySet) load() error {
+	_, err := s.fetch()
+	return err
+}
+
+// fetch reads the key set from its file or URL and replaces the cached keys.
+// A failed fetch keeps the current keys.
+func (s *keySet) fetch() (any, error) {
+	keys, err := s.read()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A failed fetch is not retried before refetchInterval
+	s.fetchedAt = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+	return nil, nil
+}
+
+func (s *keySet) read() (map[string]jsonWebKey, error) {
+	var data []byte
+	if s.file != "" {
+		var err error
+		if data, err = os.ReadFile(s.file); err != nil {
+			return nil, fmt.Errorf("failed to read key set: %w", err)
+		}
+	} else {
+		resp, err := s.client.Get(s.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch key set: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch key set: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err != nil {
+			return nil, fmt.Errorf("failed to read key set: %w", err)
+		}
+	}
+	return parseKeySet(data)
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	key crypto.PublicKey
+}
+
+// parseKeySet parses the signature keys of a JSON Web Key Set
+func parseKeySet(data []byte) (map[string]jsonWebKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]jsonWebKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", jwk.Kid, err)
+		}
+		jwk.key = key
+		keys[jwk.Kid] = jwk
+	}
+	return keys, nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	codoauth "github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/config"
+)
+
+// testKey is a signing key with its JWK
+type testKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+	jwk    map[string]any
+}
+
+func newTestKey(t *testing.T, kind string) *testKey {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch kind {
+	case "rsa":
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		return &testKey{kid: "rsa-1", alg: "RS256", signer: key, jwk: map[string]any{
+			"kty": "RSA", "kid": "rsa-1", "use": "sig",
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+		}}
+	case "ec":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return &testKey{kid: "ec-1", alg: "ES256", signer: key, jwk: map[string]any{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+		}}
+	case "ed25519":
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		return &testKey{kid: "ed-1", alg: "EdDSA", signer: key, jwk: map[string]any{
+			"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": b64(pub),
+		}}
+	}
+	t.Fatalf("unknown key kind %q", kind)
+	return nil
+}
+
+// sign returns a compact JWT with the given claims
+func (k *testKey) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	signed := b64(header) + "." + b64(payload)
+
+	var signature []byte
+	switch key := k.signer.(type) {
+	case *rsa.PrivateKey:
+		sum := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, sum[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(signed))
+	}
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func newJWTAuthenticator(t *testing.T, jwtCfg config.JWTAuthConfig) *JWTAuthenticator {
+	t.Helper()
+	a, err := NewJWTAuthenticator(&config.AuthMiddlewareConfig{JWT: jwtCfg})
+	require.NoError(t, err)
+	return a.(*JWTAuthenticator)
+}
+
+func TestJWTAuthenticator_Algorithms(t *testing.T) {
+	for _, kind := range []string{"rsa", "ec", "ed25519"} {
+		t.Run(kind, func(t *testing.T) {
+			key := newTestKey(t, kind)
+			a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)})
+
+			identity, err := a.Authenticate(context.Background(), key.sign(t, map[string]any{
+				"sub":   "user-1",
+				"jti":   "token-1",
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"email": "user@example.com",
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", identity.ID)
+			assert.Equal(t, "token-1", identity.SessionID)
+			assert.Equal(t, "user@example.com", identity.Email())
+			assert.False(t, identity.EmailVerified(), "no email_verified claim")
+			assert.NotContains(t, identity.Traits, "exp")
+			assert.False(t, identity.ExpiresAt.IsZero())
+		})
+	}
+}
+
+func TestJWTAuthenticator_EmailVerified(t *testing.T) {
+	key := newTestKey(t, "ec")
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)})
+
+	identity, err := a.Authenticate(context.Background(), key.sign(t, map[string]any{
+		"sub":            "user-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"email":          "user@example.com",
+		"email_verified": true,
+	}))
+	require.NoError(t, err)
+	assert.True(t, identity.EmailVerified())
+}
+
+func TestJWTAuthenticator_Claims(t *testing.T) {
+	key := newTestKey(t, "ec")
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{
+		JWKSFile:     writeJWKS(t, key),
+		Issuer:       "https://auth.example.com",
+		Audience:     []string{"orders"},
+		SubjectClaim: "client_id",
+		Leeway:       time.Minute,
+	})
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := func() map[string]any {
+		return map[string]any{
+			"client_id": "svc-orders",
+			"iss":       "https://auth.example.com",
+			"aud":       []string{"billing", "orders"},
+			"exp":       exp,
+		}
+	}
+
+	identity, err := a.Authenticate(context.Background(), key.sign(t, valid()))
+	require.NoError(t, err)
+	assert.Equal(t, "svc-orders", identity.ID)
+
+	tests := []struct {
+		name   string
+		modify func(claims map[string]any)
+	}{
+		{"expired", func(c map[string]any) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }},
+		{"missing exp", func(c map[string]any) { delete(c, "exp") }},
+		{"not valid yet", func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "billing" }},
+		{"missing subject", func(c map[string]any) { delete(c, "client_id") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			_, err := a.Authenticate(context.Background(), key.sign(t, claims))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Invalid token")
+		})
+	}
+
+	t.Run("within leeway", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+		_, err := a.Authenticate(context.Background(), key.sign(t, claims))
+		assert.NoError(t, err)
+	})
+}
+
+func TestJWTAuthenticator_InvalidSignature(t *testing.T) {
+	key := newTestKey(t, "rsa")
+	other := newTestKey(t, "rsa") // Same kid, different key
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)})
+	claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	_, err := a.Authenticate(context.Background(), other.sign(t, claims))
+	assert.Error(t, err)
+
+	_, err = a.Authenticate(context.Background(), "not.a-jwt")
+	assert.Error(t, err)
+
+	// alg "none" is never accepted
+	token := key.sign(t, claims)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa-1"}`))
+	_, err = a.Authenticate(context.Background(), header+token[strings.Index(token, "."):])
+	assert.Error(t, err)
+}
+
+func TestJWTAuthenticator_JWKSURL(t *testing.T) {
+	first := newTestKey(t, "rsa")
+	rotated := newTestKey(t, "ed25519")
+
+	var fetches atomic.Int32
+	var current atomic.Pointer[testKey]
+	current.Store(first)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []any{current.Load().jwk}})
+	}))
+	defer server.Close()
+
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSURL: server.URL, RefreshInterval: time.Hour})
+	claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for range 3 {
+		_, err := a.Authenticate(context.Background(), first.sign(t, claims))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "keys are cached")
+
+	// Unknown key IDs refetch the set, at most once per refetchInterval
+	current.Store(rotated)
+	_, err := a.Authenticate(context.Background(), rotated.sign(t, claims))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	a.keys.fetchedAt = time.Now().Add(-2 * refetchInterval)
+	_, err = a.Authenticate(context.Background(), rotated.sign(t, claims))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWTAuthenticator_AllowedAlgorithms(t *testing.T) {
+	key := newTestKey(t, "rsa")
+	claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key), Algorithms: []string{"ES256"}})
+	_, err := a.Authenticate(context.Background(), key.sign(t, claims))
+	assert.Error(t, err, "algorithms outside the allow-list are rejected")
+
+	// HS256 with the public key as the secret is never accepted
+	a = newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)})
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	b64 := base64.RawURLEncoding.EncodeToString
+	signed := b64([]byte(`{"alg":"HS256","kid":"rsa-1"}`)) + "." + b64(payload)
+	mac := hmac.New(sha256.New, []byte(key.jwk["n"].(string)))
+	mac.Write([]byte(signed))
+	_, err = a.Authenticate(context.Background(), signed+"."+b64(mac.Sum(nil)))
+	assert.Error(t, err)
+
+	// A key that names its algorithm only verifies that algorithm
+	key.jwk["alg"] = "PS256"
+	a = newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, key)})
+	_, err = a.Authenticate(context.Background(), key.sign(t, claims))
+	assert.Error(t, err)
+
+	_, err = NewJWTAuthenticator(&config.AuthMiddlewareConfig{JWT: config.JWTAuthConfig{
+		JWKSFile:   writeJWKS(t, key),
+		Algorithms: []string{"HS256"},
+	}})
+	assert.Error(t, err, "symmetric algorithms cannot be configured")
+}
+
+func TestJWTAuthenticator_StaleKeysServedDuringRefresh(t *testing.T) {
+	key := newTestKey(t, "ec")
+
+	var fetches atomic.Int32
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-block
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []any{key.jwk}})
+	}))
+	defer server.Close()
+	defer close(block)
+
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSURL: server.URL, RefreshInterval: time.Hour})
+	claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	_, err := a.Authenticate(context.Background(), key.sign(t, claims))
+	require.NoError(t, err)
+
+	// The refresh hangs; tokens keep verifying against the cached keys
+	a.keys.mu.Lock()
+	a.keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+	a.keys.mu.Unlock()
+	for range 3 {
+		_, err := a.Authenticate(context.Background(), key.sign(t, claims))
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), fetches.Load(), "concurrent refreshes are collapsed")
+}
+
+func TestJWTAuthenticator_Credential(t *testing.T) {
+	a := newJWTAuthenticator(t, config.JWTAuthConfig{JWKSFile: writeJWKS(t, newTestKey(t, "ed25519"))})
+	assert.Equal(t, codoauth.MethodJWT, a.Method())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Empty(t, a.Credential(req))
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Empty(t, a.Credential(req))
+	req.Header.Set("Authorization", "Bearer abc.def.ghi")
+	assert.Equal(t, "abc.def.ghi", a.Credential(req))
+}
+
+func TestNewJWTAuthenticator_RequiresKeySet(t *testing.T) {
+	_, err := NewJWTAuthenticator(&config.AuthMiddlewareConfig{})
+	assert.Error(t, err)
+
+	_, err = NewJWTAuthenticator(&config.AuthMiddlewareConfig{JWT: config.JWTAuthConfig{JWKSFile: "/missing/jwks.json"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+)
+
+// Session cache defaults
+const (
+	DefaultSessionCacheSize   = 10000
+	DefaultSessionCachePrefix = "session:"
+	sessionSweepInterval      = time.Minute
+)
+
+// activeCache is the cache used by the auth middleware and evicted by
+// InvalidateSession and InvalidateIdentity
+var (
+	activeCache    SessionCache
+	activeCacheCfg sessionCacheConfig
+	activeMu       sync.RWMutex
+)
+
+// sessionCacheConfig holds the settings a session cache is built from
+type sessionCacheConfig struct {
+	enabled    bool
+	store      string
+	prefix     string
+	maxEntries int
+}
+
+// SessionCache stores validated identities by cache key (see CacheKey)
+type SessionCache interface {
+	// Get returns the identity for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*auth.Identity, error)
+
+	// Set stores an identity for ttl.
+	Set(ctx context.Context, key string, identity *auth.Identity, ttl time.Duration) error
+
+	// Delete evicts one key.
+	Delete(ctx context.Context, key string) error
+
+	// DeleteIdentity evicts every key cached for an identity.
+	DeleteIdentity(ctx context.Context, identityID string) error
+}
+
+// CacheKey returns the cache key of a credential. Credentials are hashed so
+// session cookies and tokens are never stored as keys.
+func CacheKey(method, credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return method + ":" + hex.EncodeToString(sum[:])
+}
+
+// InvalidateSession evicts a credential's cached identity, e.g. on logout.
+// It is a no-op when caching is disabled.
+func InvalidateSession(ctx context.Context, method, credential string) error {
+	cache := getActiveCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.Delete(ctx, CacheKey(method, credential))
+}
+
+// InvalidateIdentity evicts every cached session of an identity, e.g. when
+// a webhook reports it was disabled or its sessions were revoked. It is a
+// no-op when caching is disabled.
+func InvalidateIdentity(ctx context.Context, identityID string) error {
+	cache := getActiveCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.DeleteIdentity(ctx, identityID)
+}
+
+func getActiveCache() SessionCache {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return activeCache
+}
+
+// activateCache makes a cache built from cfg the active cache. The current
+// cache is kept when its settings are unchanged.
+func activateCache(cfg sessionCacheConfig) error {
+	activeMu.RLock()
+	current := activeCache != nil && activeCacheCfg == cfg
+	activeMu.RUnlock()
+	if current {
+		return nil
+	}
+
+	cache, err := newSessionCache(cfg)
+	if err != nil {
+		return err
+	}
+	setActiveCache(cache)
+
+	activeMu.Lock()
+	activeCacheCfg = cfg
+	activeMu.Unlock()
+	return nil
+}
+
+// newSessionCache returns a session cache, or nil if caching is disabled
+func newSessionCache(cfg sessionCacheConfig) (SessionCache, error) {
+	if !cfg.enabled {
+		return nil, nil
+	}
+
+	switch cfg.store {
+	case "", "memory":
+		return NewMemorySessionCache(cfg.maxEntries), nil
+	case "redis":
+		client, err := clients.GetTyped[redis.RedisClient](redis.ClientName)
+		if err != nil {
+			return nil, fmt.Errorf("session cache store: %w", err)
+		}
+		prefix := cfg.prefix
+		if prefix == "" {
+			prefix = DefaultSessionCachePrefix
+		}
+		return NewRedisSessionCache(client, prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown session cache store: %s", cfg.store)
+	}
+}
+
+// setActiveCache makes cache the target of the invalidation hooks and stops
+// the sweeper of the cache it replaces
+func setActiveCache(cache SessionCache) {
+	activeMu.Lock()
+	prev := activeCache
+	activeCache = cache
+	activeMu.Unlock()
+
+	if prev, ok := prev.(*MemorySessionCache); ok && prev != cache {
+		prev.Close()
+	}
+}
+
+// RedisSessionCache stores sessions in Redis, shared by all instances. Each
+// identity has a set of its session keys.
+type RedisSessionCache struct {
+	client redis.RedisClient
+	prefix string
+}
+
+// NewRedisSessionCache creates a Redis-backed session cache
+func NewRedisSessionCache(client redis.RedisClient, prefix string) *RedisSessionCache {
+	return &RedisSessionCache{client: client, prefix: prefix}
+}
+
+func (c *RedisSessionCache) identityKey(identityID string) string {
+	return c.prefix + "identity:" + identityID
+}
+
+// Get reads a session
+func (c *RedisSessionCache) Get(ctx context.Context, key string) (*auth.Identity, error) {
+	raw, err := c.client.Get(ctx, c.prefix+key)
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var identity auth.Identity
+	if err := json.Unmarshal([]byte(raw), &identity); err != nil {
+		return nil, fmt.Errorf("invalid cached session: %w", err)
+	}
+	return &identity, nil
+}
+
+// Set writes a session and adds it to its identity's set
+func (c *RedisSessionCache) Set(ctx context.Context, key string, identity *auth.Identity, ttl time.Duration) error {
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	if err := c.client.Set(ctx, c.prefix+key, string(data), ttl); err != nil {
+		return err
+	}
+
+	identityKey := c.identityKey(identity.ID)
+	if err := c.client.SAdd(ctx, identityKey, c.prefix+key); err != nil {
+		return err
+	}
+	// Identity sets live as long as their longest-lived session
+	current, err := c.client.TTL(ctx, identityKey)
+	if err != nil {
+		return err
+	}
+	if current < ttl {
+		return c.client.Expire(ctx, identityKey, ttl)
+	}
+	return nil
+}
+
+// Delete deletes a session
+func (c *RedisSessionCache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, c.prefix+key)
+}
+
+// DeleteIdentity deletes all sessions in the identity's set, then the set
+func (c *RedisSessionCache) DeleteIdentity(ctx context.Context, identityID string) error {
+	identityKey := c.identityKey(identityID)
+	keys, err := c.client.SMembers(ctx, identityKey)
+	if err != nil {
+		return err
+	}
+	return c.client.Del(ctx, append(keys, identityKey)...)
+}
+
+// MemorySessionCache keeps up to a fixed number of sessions in process
+// memory, evicting the least recently used. Expired sessions are swept in
+// the background. Sessions are per instance, so invalidation only reaches
+// the local process.
+type MemorySessionCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used first
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+type memorySession struct {
+	key       string
+	identity  *auth.Identity
+	expiresAt time.Time
+}
+
+// NewMemorySessionCache creates an in-memory LRU session cache holding up to
+// maxEntries sessions (DefaultSessionCacheSize if not positive). Call Close
+// to stop the background sweeper.
+func NewMemorySessionCache(maxEntries int) *MemorySessionCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultSessionCacheSize
+	}
+	c := &MemorySessionCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		stop:       make(chan struct{}),
+	}
+	go c.sweepLoop(sessionSweepInterval)
+	return c
+}
+
+// Get returns a session, dropping it if expired
+func (c *MemorySessionCache) Get(ctx context.Context, key string) (*auth.Identity, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	session := elem.Value.(*memorySession)
+	if time.Now().After(session.expiresAt) {
+		c.remove(elem)
+		return nil, nil
+	}
+	c.order.MoveToFront(elem)
+	return session.identity, nil
+}
+
+// Set stores a session, evicting the least recently used when full
+func (c *MemorySessionCache) Set(ctx context.Context, key string, identity *auth.Identity, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session := &memorySession{key: key, identity: identity, expiresAt: time.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = session
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(session)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete drops a session
+func (c *MemorySessionCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	return nil
+}
+
+// DeleteIdentity drops all sessions of an identity
+func (c *MemorySessionCache) DeleteIdentity(ctx context.Context, identityID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.entries {
+		if elem.Value.(*memorySession).identity.ID == identityID {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of cached sessions, including expired ones not yet
+// swept
+func (c *MemorySessionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Close stops the background sweeper. The cache remains usable.
+func (c *MemorySessionCache) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+func (c *MemorySessionCache) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.sweep(now)
+		}
+	}
+}
+
+// sweep drops expired sessions
+func (c *MemorySessionCache) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.entries {
+		if now.After(elem.Value.(*memorySession).expiresAt) {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *MemorySessionCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memorySession).key)
+}
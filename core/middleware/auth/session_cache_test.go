@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/kratos"
+	"github.com/codoworks/codo-framework/clients/redis"
+	codoauth "github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+)
+
+func newMiniredis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	require.NoError(t, err)
+
+	client := redis.New()
+	require.NoError(t, client.Initialize(&redis.Config{Host: mr.Host(), Port: port}))
+	t.Cleanup(func() { client.Shutdown() })
+	return mr, client
+}
+
+func TestCacheKey(t *testing.T) {
+	key := CacheKey(codoauth.MethodSessionCookie, "secret-cookie")
+	assert.True(t, strings.HasPrefix(key, codoauth.MethodSessionCookie+":"))
+	assert.NotContains(t, key, "secret-cookie")
+	assert.Equal(t, key, CacheKey(codoauth.MethodSessionCookie, "secret-cookie"))
+	assert.NotEqual(t, key, CacheKey(codoauth.MethodJWT, "secret-cookie"))
+}
+
+func TestSessionCaches(t *testing.T) {
+	caches := map[string]func(t *testing.T) SessionCache{
+		"memory": func(t *testing.T) SessionCache {
+			cache := NewMemorySessionCache(10)
+			t.Cleanup(cache.Close)
+			return cache
+		},
+		"redis": func(t *testing.T) SessionCache {
+			_, client := newMiniredis(t)
+			return NewRedisSessionCache(client, DefaultSessionCachePrefix)
+		},
+	}
+
+	for name, newCache := range caches {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(t)
+			ctx := context.Background()
+
+			missing, err := cache.Get(ctx, "k1")
+			require.NoError(t, err)
+			assert.Nil(t, missing)
+
+			jane := &codoauth.Identity{ID: "jane", Traits: map[string]any{"email": "jane@example.com"}}
+			require.NoError(t, cache.Set(ctx, "k1", jane, time.Minute))
+			require.NoError(t, cache.Set(ctx, "k2", jane, time.Minute))
+			require.NoError(t, cache.Set(ctx, "k3", &codoauth.Identity{ID: "max"}, time.Minute))
+
+			got, err := cache.Get(ctx, "k1")
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			assert.Equal(t, "jane@example.com", got.Email())
+
+			require.NoError(t, cache.Delete(ctx, "k1"))
+			got, _ = cache.Get(ctx, "k1")
+			assert.Nil(t, got)
+
+			require.NoError(t, cache.DeleteIdentity(ctx, "jane"))
+			got, _ = cache.Get(ctx, "k2")
+			assert.Nil(t, got, "all sessions of the identity are evicted")
+			got, _ = cache.Get(ctx, "k3")
+			assert.NotNil(t, got, "other identities are kept")
+		})
+	}
+}
+
+func TestMemorySessionCache_LRU(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemorySessionCache(2)
+	defer cache.Close()
+
+	cache.Set(ctx, "a", &codoauth.Identity{ID: "a"}, time.Minute)
+	cache.Set(ctx, "b", &codoauth.Identity{ID: "b"}, time.Minute)
+	cache.Get(ctx, "a") // b is now least recently used
+	cache.Set(ctx, "c", &codoauth.Identity{ID: "c"}, time.Minute)
+
+	assert.Equal(t, 2, cache.Len())
+	got, _ := cache.Get(ctx, "b")
+	assert.Nil(t, got)
+	got, _ = cache.Get(ctx, "a")
+	assert.NotNil(t, got)
+}
+
+func TestMemorySessionCache_Sweep(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemorySessionCache(10)
+	defer cache.Close()
+
+	cache.Set(ctx, "short", &codoauth.Identity{ID: "a"}, time.Millisecond)
+	cache.Set(ctx, "long", &codoauth.Identity{ID: "b"}, time.Hour)
+
+	cache.sweep(time.Now().Add(time.Second))
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestAuthMiddleware_RedisSessionCache(t *testing.T) {
+	mr, client := newMiniredis(t)
+	calls := 0
+	mockKratos := kratos.NewMockClient()
+	mockKratos.ValidateFunc = func(ctx context.Context, cookie string) (*codoauth.Identity, error) {
+		calls++
+		return &codoauth.Identity{ID: "user-1"}, nil
+	}
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		CacheEnabled:         true,
+		CacheTTL:             time.Hour,
+		CacheStore:           "redis",
+	}
+
+	_, err := newSessionCache(sessionCacheConfig{enabled: true, store: "redis"})
+	require.Error(t, err, "the redis store needs the redis client")
+
+	clients.MustRegister(client)
+	m, err := setupAuthMiddleware(t, mockKratos, cfg)
+	require.NoError(t, err)
+
+	request := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.AddCookie(&http.Cookie{Name: "ory_kratos_session", Value: "s3cret-session"})
+		return req
+	}
+
+	for range 2 {
+		rec, identity := serveAuth(m, request())
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "user-1", identity.ID)
+	}
+	assert.Equal(t, 1, calls)
+
+	for _, key := range mr.Keys() {
+		assert.NotContains(t, key, "s3cret-session", "credentials are hashed")
+	}
+
+	// Logout evicts the session in every instance sharing the store
+	require.NoError(t, InvalidateSession(context.Background(), codoauth.MethodSessionCookie, "s3cret-session"))
+	serveAuth(m, request())
+	assert.Equal(t, 2, calls)
+
+	require.NoError(t, InvalidateIdentity(context.Background(), "user-1"))
+	serveAuth(m, request())
+	assert.Equal(t, 3, calls)
+}
+
+func TestAuthMiddleware_CacheDisabled(t *testing.T) {
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+	}
+	_, err := setupAuthMiddleware(t, kratos.NewMockClient(), cfg)
+	require.NoError(t, err)
+	assert.Nil(t, getActiveCache())
+	assert.NoError(t, InvalidateIdentity(context.Background(), "user-1"))
+}
+
+func TestAuthMiddleware_RouteCopiesShareCache(t *testing.T) {
+	cfg := &config.AuthMiddlewareConfig{
+		BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true},
+		CacheEnabled:         true,
+		CacheMaxEntries:      100,
+	}
+	m, err := setupAuthMiddleware(t, kratos.NewMockClient(), cfg)
+	require.NoError(t, err)
+	active := getActiveCache()
+	require.NotNil(t, active)
+
+	// A route override copy configured with other cache settings neither
+	// replaces nor closes the active cache
+	override := *cfg
+	override.CacheMaxEntries = 10
+	override.CacheTTL = time.Second
+	clone := *m
+	require.NoError(t, clone.Configure(&override))
+	assert.Same(t, active, getActiveCache())
+	select {
+	case <-active.(*MemorySessionCache).stop:
+		t.Fatal("active cache was closed")
+	default:
+	}
+
+	// Reactivating with unchanged settings keeps the cached sessions
+	require.NoError(t, m.Configure(cfg))
+	require.NoError(t, m.Activate())
+	assert.Same(t, active, getActiveCache())
+
+	require.NoError(t, clone.Activate())
+	assert.NotSame(t, active, getActiveCache())
+	<-active.(*MemorySessionCache).stop
+}
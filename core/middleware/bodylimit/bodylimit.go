@@ -0,0 +1,248 @@
+package bodylimit
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	codohttp "github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// ratioFloor is the decoded size below which MaxRatio is not enforced, so
+// small, highly repetitive payloads are not mistaken for bombs.
+const ratioFloor = 1 << 20
+
+func init() {
+	middleware.RegisterMiddleware(&BodyLimitMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"bodylimit",
+			"middleware.bodylimit",
+			middleware.PriorityBodyLimit,
+			middleware.RouterAll,
+		),
+	})
+}
+
+// limits are the resolved limits for a request.
+type limits struct {
+	maxSize         int64
+	maxDecompressed int64
+	disabled        bool
+}
+
+// BodyLimitMiddleware caps request body sizes and decodes compressed bodies.
+type BodyLimitMiddleware struct {
+	middleware.BaseMiddleware
+	defaults   limits
+	decompress bool
+	maxRatio   int64
+	skipPaths  []string
+}
+
+// Enabled checks if the middleware is enabled
+func (m *BodyLimitMiddleware) Enabled(cfg any) bool {
+	if cfg == nil {
+		return true // Enabled by default
+	}
+
+	blCfg, ok := cfg.(*config.BodyLimitMiddlewareConfig)
+	if !ok {
+		return true
+	}
+
+	return blCfg.Enabled
+}
+
+// Configure initializes the middleware with limits from config
+func (m *BodyLimitMiddleware) Configure(cfg any) error {
+	defaults := config.DefaultMiddlewareConfig().BodyLimit
+	blCfg, ok := cfg.(*config.BodyLimitMiddlewareConfig)
+	if !ok || blCfg == nil {
+		blCfg = &defaults
+	}
+
+	m.defaults = limits{
+		maxSize:         blCfg.MaxSize,
+		maxDecompressed: blCfg.MaxDecompressedSize,
+	}
+	if m.defaults.maxSize <= 0 {
+		m.defaults.maxSize = defaults.MaxSize
+	}
+	m.decompress = blCfg.Decompress
+	m.maxRatio = int64(blCfg.MaxRatio)
+	if m.maxRatio <= 0 {
+		m.maxRatio = int64(defaults.MaxRatio)
+	}
+	m.skipPaths = blCfg.SkipPaths
+
+	return nil
+}
+
+// Handler returns the body limit middleware function
+func (m *BodyLimitMiddleware) Handler() echo.MiddlewareFunc {
+	skipPaths := m.skipPaths
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Body == nil || req.Body == http.NoBody || middleware.MatchAnyPath(skipPaths, req.URL.Path) {
+				return next(c)
+			}
+
+			l := m.resolve(c)
+			if l.disabled {
+				return next(c)
+			}
+
+			if req.ContentLength > l.maxSize {
+				return tooLarge("Request body too large", l.maxSize)
+			}
+
+			raw := &limitedReader{r: req.Body, closer: req.Body, max: l.maxSize}
+			req.Body = raw
+
+			var decoded *limitedReader
+			if encoding := contentEncoding(req.Header.Get(echo.HeaderContentEncoding)); encoding != "" {
+				if !m.decompress {
+					return errors.UnsupportedMediaType("Compressed request bodies are not accepted").
+						WithPhase(errors.PhaseMiddleware).
+						WithDetail("content_encoding", encoding)
+				}
+				dec, err := newDecoder(encoding, raw)
+				if err != nil {
+					if raw.exceeded {
+						return tooLarge("Request body too large", l.maxSize)
+					}
+					return err
+				}
+				decoded = &limitedReader{r: dec, closer: raw, max: l.maxDecompressed, source: raw, maxRatio: m.maxRatio}
+				req.Body = decoded
+				req.Header.Del(echo.HeaderContentEncoding)
+				req.Header.Del(echo.HeaderContentLength)
+				req.ContentLength = -1
+			}
+
+			err := next(c)
+
+			// Handlers usually surface a failed read as a bind error;
+			// report the limit instead unless a response was already sent.
+			if !c.Response().Committed {
+				if raw.exceeded {
+					return tooLarge("Request body too large", l.maxSize)
+				}
+				if decoded != nil && decoded.exceeded {
+					return tooLarge("Decompressed request body too large", l.maxDecompressed)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// resolve returns the limits for a request: the owning handler's BodyLimit
+// policy merged over the configured defaults.
+func (m *BodyLimitMiddleware) resolve(c echo.Context) limits {
+	l := m.defaults
+	if h, ok := codohttp.HandlerFor(c).(codohttp.BodyLimitedHandler); ok {
+		if p := h.BodyLimit(c.Request().Method, c.Path()); p != nil {
+			l.disabled = p.Disabled
+			if p.MaxSize > 0 {
+				l.maxSize = p.MaxSize
+			}
+			if p.MaxDecompressedSize > 0 {
+				l.maxDecompressed = p.MaxDecompressedSize
+			}
+		}
+	}
+	if l.maxDecompressed <= 0 {
+		l.maxDecompressed = l.maxSize
+	}
+	return l
+}
+
+// contentEncoding normalizes the Content-Encoding header; identity is empty
+func contentEncoding(header string) string {
+	encoding := strings.ToLower(strings.TrimSpace(header))
+	if encoding == "identity" {
+		return ""
+	}
+	return encoding
+}
+
+func newDecoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.WrapBadRequest(err, "Invalid gzip request body").
+				WithPhase(errors.PhaseMiddleware)
+		}
+		return zr, nil
+	case "deflate":
+		return flate.NewReader(r), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	default:
+		return nil, errors.UnsupportedMediaType("Unsupported Content-Encoding").
+			WithPhase(errors.PhaseMiddleware).
+			WithDetail("content_encoding", encoding).
+			WithDetail("supported", "gzip, deflate, br")
+	}
+}
+
+func tooLarge(msg string, maxBytes int64) *errors.Error {
+	return errors.PayloadTooLarge(msg).
+		WithPhase(errors.PhaseMiddleware).
+		WithDetail("max_bytes", maxBytes)
+}
+
+// limitedReader fails reads once more than max bytes have been read. When
+// source is set it also fails once the bytes read exceed maxRatio times the
+// bytes read from source, which catches decompression bombs early.
+type limitedReader struct {
+	r        io.Reader
+	closer   io.Closer
+	max      int64
+	read     int64
+	exceeded bool
+
+	source   *limitedReader
+	maxRatio int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errBodyTooLarge
+	}
+	// Read at most one byte past the limit to detect overflow
+	if remaining := l.max - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+
+	if l.read > l.max || l.isBomb() {
+		l.exceeded = true
+		return 0, errBodyTooLarge
+	}
+	return n, err
+}
+
+func (l *limitedReader) isBomb() bool {
+	return l.source != nil && l.maxRatio > 0 && l.read > ratioFloor && l.read > l.source.read*l.maxRatio
+}
+
+func (l *limitedReader) Close() error {
+	return l.closer.Close()
+}
+
+var errBodyTooLarge = fmt.Errorf("request body too large")
@@ -0,0 +1,182 @@
+package bodylimit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	codohttp "github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+// echoBody replies with the body it read, failing like a binder would
+func echoBody(c echo.Context) error {
+	data, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return errors.WrapBadRequest(err, "Failed to read body")
+	}
+	return c.String(http.StatusOK, string(data))
+}
+
+func newEcho(m *BodyLimitMiddleware) *echo.Echo {
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/upload", echoBody)
+	return e
+}
+
+func post(e *echo.Echo, path string, body []byte, encoding string, chunked bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if chunked {
+		req.ContentLength = -1
+	}
+	if encoding != "" {
+		req.Header.Set(echo.HeaderContentEncoding, encoding)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestBodyLimitMiddleware_MaxSize(t *testing.T) {
+	e := newEcho(testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{MaxSize: 10}))
+
+	ok := post(e, "/upload", []byte("0123456789"), "", false)
+	assert.Equal(t, http.StatusOK, ok.Code)
+
+	t.Run("rejected by Content-Length", func(t *testing.T) {
+		rec := post(e, "/upload", []byte("0123456789X"), "", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("rejected while reading chunked body", func(t *testing.T) {
+		rec := post(e, "/upload", []byte("0123456789X"), "", true)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), errors.CodePayloadTooLarge)
+	})
+}
+
+func TestBodyLimitMiddleware_Decompression(t *testing.T) {
+	e := newEcho(testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{MaxSize: 1 << 20, Decompress: true}))
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := post(e, "/upload", gzipped(t, []byte(`{"name":"Jane"}`)), "gzip", false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"name":"Jane"}`, rec.Body.String())
+	})
+
+	t.Run("br", func(t *testing.T) {
+		var buf bytes.Buffer
+		bw := brotli.NewWriter(&buf)
+		bw.Write([]byte("hello brotli"))
+		require.NoError(t, bw.Close())
+
+		rec := post(e, "/upload", buf.Bytes(), "br", false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "hello brotli", rec.Body.String())
+	})
+
+	t.Run("invalid gzip", func(t *testing.T) {
+		rec := post(e, "/upload", []byte("not gzip"), "gzip", false)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		rec := post(e, "/upload", []byte("data"), "compress", false)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+}
+
+func TestBodyLimitMiddleware_DecompressionBomb(t *testing.T) {
+	bomb := gzipped(t, make([]byte, 8<<20)) // ~8KB expanding to 8MB
+
+	t.Run("decoded size limit", func(t *testing.T) {
+		e := newEcho(testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{MaxSize: 1 << 20, Decompress: true, MaxRatio: 100000}))
+		rec := post(e, "/upload", bomb, "gzip", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("ratio limit", func(t *testing.T) {
+		e := newEcho(testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{
+			MaxSize:             1 << 20,
+			MaxDecompressedSize: 64 << 20,
+			Decompress:          true,
+			MaxRatio:            100,
+		}))
+		rec := post(e, "/upload", bomb, "gzip", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
+
+func TestBodyLimitMiddleware_DecompressDisabled(t *testing.T) {
+	e := newEcho(testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{MaxSize: 1 << 20, Decompress: false}))
+
+	rec := post(e, "/upload", gzipped(t, []byte("x")), "gzip", false)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+// uploadHandler raises the limit for its import route.
+type uploadHandler struct{}
+
+func (h *uploadHandler) Prefix() string                     { return "/files" }
+func (h *uploadHandler) Scope() codohttp.RouterScope        { return codohttp.ScopePublic }
+func (h *uploadHandler) Middlewares() []echo.MiddlewareFunc { return nil }
+func (h *uploadHandler) Initialize() error                  { return nil }
+
+func (h *uploadHandler) Routes(g *echo.Group) {
+	g.POST("", echoBody)
+	g.POST("/import", echoBody)
+	g.POST("/stream", echoBody)
+}
+
+func (h *uploadHandler) BodyLimit(method, path string) *codohttp.BodyLimitPolicy {
+	switch path {
+	case "/files/import":
+		return &codohttp.BodyLimitPolicy{MaxSize: 100}
+	case "/files/stream":
+		return &codohttp.BodyLimitPolicy{Disabled: true}
+	}
+	return nil
+}
+
+func TestBodyLimitMiddleware_HandlerPolicy(t *testing.T) {
+	codohttp.ClearHandlers()
+	t.Cleanup(codohttp.ClearHandlers)
+	codohttp.RegisterHandler(&uploadHandler{})
+
+	m := testutil.NewMiddleware[*BodyLimitMiddleware](t, "bodylimit", &config.BodyLimitMiddlewareConfig{MaxSize: 10})
+
+	router := codohttp.NewRouter(codohttp.ScopePublic, ":0")
+	router.Echo().HTTPErrorHandler = testutil.ErrorHandler
+	router.Use(m.Handler())
+	require.NoError(t, router.RegisterHandlers())
+	e := router.Echo()
+
+	body := bytes.Repeat([]byte("a"), 50)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(e, "/files", body, "", false).Code)
+	assert.Equal(t, http.StatusOK, post(e, "/files/import", body, "", false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(e, "/files/import", bytes.Repeat(body, 3), "", false).Code)
+
+	big := post(e, "/files/stream", bytes.Repeat(body, 100), "", false)
+	assert.Equal(t, http.StatusOK, big.Code)
+	assert.Equal(t, 5000, big.Body.Len())
+}
@@ -0,0 +1,503 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db"
+	codohttp "github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// HeaderCache reports whether a stored response was used ("HIT" or "MISS").
+const HeaderCache = "X-Cache"
+
+const (
+	headerETag = "ETag"
+
+	// tagsContextKey holds tags added by the handler via Tag.
+	tagsContextKey = "cache.tags"
+)
+
+// activeStore is the store used by Invalidate and repository write events.
+var (
+	activeStore  Store
+	activeLogger *logrus.Logger
+	activeMu     sync.RWMutex
+	listenOnce   sync.Once
+)
+
+func init() {
+	middleware.RegisterMiddleware(&CacheMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"cache",
+			"middleware.cache",
+			middleware.PriorityCache,
+			middleware.RouterAll,
+		),
+	})
+}
+
+// CacheMiddleware computes ETags, answers conditional GETs and optionally
+// stores whole responses.
+type CacheMiddleware struct {
+	middleware.BaseMiddleware
+	etag        bool
+	store       Store
+	storeKey    string // Store and key prefix the store was built for
+	ttl         time.Duration
+	paths       []string
+	skipPaths   []string
+	varyHeaders []string
+	maxBodySize int
+	logger      *logrus.Logger
+}
+
+// Enabled checks if the middleware is enabled
+func (m *CacheMiddleware) Enabled(cfg any) bool {
+	if cfg == nil {
+		return false // Disabled by default
+	}
+
+	cacheCfg, ok := cfg.(*config.CacheMiddlewareConfig)
+	if !ok {
+		return false
+	}
+
+	return cacheCfg.Enabled
+}
+
+// Configure initializes the middleware and selects the store
+func (m *CacheMiddleware) Configure(cfg any) error {
+	defaults := config.DefaultMiddlewareConfig().Cache
+	cacheCfg, ok := cfg.(*config.CacheMiddlewareConfig)
+	if !ok || cacheCfg == nil {
+		cacheCfg = &defaults
+	}
+
+	m.etag = cacheCfg.ETag
+	m.ttl = cacheCfg.TTL
+	if m.ttl <= 0 {
+		m.ttl = defaults.TTL
+	}
+	m.maxBodySize = cacheCfg.MaxBodySize
+	if m.maxBodySize <= 0 {
+		m.maxBodySize = defaults.MaxBodySize
+	}
+	m.paths = cacheCfg.Paths
+	m.skipPaths = cacheCfg.SkipPaths
+	m.varyHeaders = cacheCfg.VaryHeaders
+
+	if loggerClient, err := clients.GetTyped[*logger.Logger]("logger"); err == nil {
+		m.logger = loggerClient.GetLogger()
+	}
+
+	prefix := cacheCfg.KeyPrefix
+	if prefix == "" {
+		prefix = defaults.KeyPrefix
+	}
+
+	// Copies configured for route overrides keep the store they were copied
+	// with, so Invalidate reaches their entries too
+	storeKey := cacheCfg.Store + "\x00" + prefix
+	if m.store != nil && m.storeKey == storeKey {
+		return nil
+	}
+
+	switch cacheCfg.Store {
+	case "":
+		m.store = nil
+	case "memory":
+		m.store = NewMemoryStore()
+	case "redis":
+		client, err := clients.GetTyped[redis.RedisClient](redis.ClientName)
+		if err != nil {
+			return fmt.Errorf("cache store: %w", err)
+		}
+		m.store = NewRedisStore(client, prefix)
+	default:
+		return fmt.Errorf("unknown cache store: %s", cacheCfg.Store)
+	}
+	m.storeKey = storeKey
+	return nil
+}
+
+// Activate makes the store the target of Invalidate and repository write
+// events. The orchestrator calls it on the primary instance only.
+func (m *CacheMiddleware) Activate() error {
+	setActiveStore(m.store, m.logger)
+	return nil
+}
+
+// SetStore replaces the store (useful for testing)
+func (m *CacheMiddleware) SetStore(store Store) {
+	m.store = store
+	m.storeKey = ""
+	setActiveStore(store, m.logger)
+}
+
+// setActiveStore makes store the target of Invalidate and, once a store
+// exists, subscribes to repository writes.
+func setActiveStore(store Store, log *logrus.Logger) {
+	activeMu.Lock()
+	activeStore = store
+	activeLogger = log
+	activeMu.Unlock()
+
+	if store != nil {
+		listenOnce.Do(func() {
+			db.OnWrite(invalidateWrite)
+		})
+	}
+}
+
+// Tag adds invalidation tags to the response being stored for c.
+// Example: cache.Tag(c, "contacts", "contacts:"+id)
+func Tag(c echo.Context, tags ...string) {
+	existing, _ := c.Get(tagsContextKey).([]string)
+	c.Set(tagsContextKey, append(existing, tags...))
+}
+
+// Invalidate drops stored responses tagged with any of the tags.
+// It is a no-op when no store is configured.
+func Invalidate(ctx context.Context, tags ...string) error {
+	activeMu.RLock()
+	store := activeStore
+	activeMu.RUnlock()
+
+	if store == nil || len(tags) == 0 {
+		return nil
+	}
+	return store.Invalidate(ctx, tags...)
+}
+
+// invalidateWrite drops responses tagged with the written table, and with
+// "table:id" for single-record writes.
+func invalidateWrite(ctx context.Context, event db.WriteEvent) {
+	tags := []string{event.Table}
+	if event.ID != "" {
+		tags = append(tags, event.Table+":"+event.ID)
+	}
+
+	if err := Invalidate(context.WithoutCancel(ctx), tags...); err != nil {
+		activeMu.RLock()
+		log := activeLogger
+		activeMu.RUnlock()
+		if log != nil {
+			log.WithError(err).WithField("table", event.Table).Warn("Failed to invalidate cached responses")
+		}
+	}
+}
+
+// Handler returns the cache middleware function
+func (m *CacheMiddleware) Handler() echo.MiddlewareFunc {
+	etag := m.etag
+	store := m.store
+	skipPaths := m.skipPaths
+	maxBodySize := m.maxBodySize
+	log := m.logger
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if (req.Method != http.MethodGet && req.Method != http.MethodHead) ||
+				c.IsWebSocket() || middleware.MatchAnyPath(skipPaths, req.URL.Path) {
+				return next(c)
+			}
+
+			// Only GET responses are stored; HEAD still gets ETags. Reads
+			// inside a transaction, such as a transactional batch, may see
+			// uncommitted writes, so they bypass the store.
+			var policy *codohttp.CachePolicy
+			var key string
+			if req.Method == http.MethodGet && !db.InTx(req.Context()) {
+				policy = m.resolve(c)
+			}
+			if policy != nil {
+				key = m.cacheKey(c, policy)
+				entry, err := store.Get(req.Context(), key)
+				if err != nil && log != nil {
+					log.WithError(err).Warn("Failed to read cached response")
+				}
+				if entry != nil {
+					return replay(c, entry)
+				}
+			}
+
+			res := c.Response()
+			before := res.Header().Clone()
+			buf := &bufferedWriter{ResponseWriter: res.Writer, limit: maxBodySize}
+			res.Writer = buf
+
+			err := next(c)
+			res.Writer = buf.ResponseWriter
+
+			// Streamed or oversized responses went straight to the client.
+			if buf.streaming {
+				return err
+			}
+
+			header := res.Header()
+			body := buf.body.Bytes()
+			if err == nil && buf.status == http.StatusOK {
+				if etag && header.Get(headerETag) == "" {
+					header.Set(headerETag, strongETag(body))
+				}
+				if policy != nil && storable(header) {
+					entry := &Entry{
+						Status: http.StatusOK,
+						Header: handlerHeaders(before, header),
+						Body:   append([]byte(nil), body...),
+					}
+					tags := append(append([]string(nil), policy.Tags...), tagsFrom(c)...)
+					if err := store.Set(context.WithoutCancel(req.Context()), key, entry, tags, policy.TTL); err != nil && log != nil {
+						log.WithError(err).Warn("Failed to store response")
+					}
+					header.Set(HeaderCache, "MISS")
+				}
+			}
+
+			if buf.status != 0 {
+				write(c, buf.status, body)
+			}
+			return err
+		}
+	}
+}
+
+// resolve returns the storage policy for a request, or nil when the
+// response should not be stored.
+func (m *CacheMiddleware) resolve(c echo.Context) *codohttp.CachePolicy {
+	if m.store == nil {
+		return nil
+	}
+
+	var policy *codohttp.CachePolicy
+	if h, ok := codohttp.HandlerFor(c).(codohttp.CacheableHandler); ok {
+		policy = h.CachePolicy(c.Request().Method, c.Path())
+	}
+	if policy == nil && middleware.MatchAnyPath(m.paths, c.Request().URL.Path) {
+		policy = &codohttp.CachePolicy{}
+	}
+	if policy == nil || policy.Disabled {
+		return nil
+	}
+
+	resolved := *policy
+	if resolved.TTL <= 0 {
+		resolved.TTL = m.ttl
+	}
+	return &resolved
+}
+
+// cacheKey identifies a stored variant by host, router scope, path,
+// canonical query, vary headers and caller identity.
+func (m *CacheMiddleware) cacheKey(c echo.Context, policy *codohttp.CachePolicy) string {
+	req := c.Request()
+
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	write(req.Host)
+	if owner := codohttp.HandlerFor(c); owner != nil {
+		write(owner.Scope().String())
+	}
+	write(req.URL.Path)
+	write(req.URL.Query().Encode())
+	for _, name := range append(append([]string(nil), m.varyHeaders...), policy.Vary...) {
+		write(name + "=" + req.Header.Get(name))
+	}
+	if identity, err := auth.GetIdentity(c); err == nil && identity != nil {
+		write("identity=" + identity.ID)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a stored response, honouring conditional headers.
+func replay(c echo.Context, entry *Entry) error {
+	header := c.Response().Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	header.Set(HeaderCache, "HIT")
+
+	if notModified(c.Request(), header) {
+		stripContentHeaders(header)
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	c.Response().WriteHeader(entry.Status)
+	_, err := c.Response().Write(entry.Body)
+	return err
+}
+
+// write sends a buffered response. The handler already committed the echo
+// Response through the buffer, so the underlying writer is used directly.
+func write(c echo.Context, status int, body []byte) {
+	res := c.Response()
+	if status == http.StatusOK && notModified(c.Request(), res.Header()) {
+		stripContentHeaders(res.Header())
+		res.Status = http.StatusNotModified
+		res.Size = 0
+		res.Writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	res.Writer.WriteHeader(status)
+	if len(body) > 0 {
+		res.Writer.Write(body)
+	}
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// as RFC 9110 requires.
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, header.Get(headerETag))
+	}
+
+	ims := req.Header.Get(echo.HeaderIfModifiedSince)
+	lastModified := header.Get(echo.HeaderLastModified)
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// etagMatches uses weak comparison, as If-None-Match requires.
+func etagMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// strongETag hashes the serialized response body.
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// storable reports whether the response may be shared from the store.
+func storable(header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(header.Get(echo.HeaderCacheControl)), "no-store")
+}
+
+// handlerHeaders returns headers set or changed while the handler ran, so
+// headers from outer middleware (request IDs, rate limits) are not replayed.
+func handlerHeaders(before, after http.Header) http.Header {
+	stored := make(http.Header)
+	for name, values := range after {
+		if prev, ok := before[name]; ok && strings.Join(prev, "\x00") == strings.Join(values, "\x00") {
+			continue
+		}
+		stored[name] = append([]string(nil), values...)
+	}
+	stored.Del(echo.HeaderContentLength)
+	stored.Del(echo.HeaderContentEncoding)
+	stored.Del(echo.HeaderXRequestID)
+	stored.Del(HeaderCache)
+	return stored
+}
+
+func stripContentHeaders(header http.Header) {
+	header.Del(echo.HeaderContentType)
+	header.Del(echo.HeaderContentLength)
+}
+
+func tagsFrom(c echo.Context) []string {
+	tags, _ := c.Get(tagsContextKey).([]string)
+	return tags
+}
+
+// bufferedWriter holds the response until the handler returns so the ETag
+// can be computed. Flushes and bodies over limit switch it to streaming.
+type bufferedWriter struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	status    int
+	limit     int
+	streaming bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if !w.streaming && w.limit > 0 && w.body.Len()+len(b) > w.limit {
+		w.stream()
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) Flush() {
+	w.stream()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// stream writes anything buffered and passes later writes through.
+func (w *bufferedWriter) stream() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
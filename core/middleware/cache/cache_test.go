@@ -0,0 +1,339 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/errors"
+	codohttp "github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+func doRequest(e *echo.Echo, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCacheMiddleware_ETag(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", nil)
+
+	e := echo.New()
+	e.Use(m.Handler())
+	e.Match([]string{http.MethodGet, http.MethodHead}, "/contacts", codohttp.WrapHandler(func(c *codohttp.Context) error {
+		return c.Success([]string{"a", "b"})
+	}))
+
+	first := doRequest(e, http.MethodGet, "/contacts", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Contains(t, first.Body.String(), `"payload":["a","b"]`)
+
+	again := doRequest(e, http.MethodGet, "/contacts", nil)
+	assert.Equal(t, etag, again.Header().Get("ETag"))
+
+	notModified := doRequest(e, http.MethodGet, "/contacts", map[string]string{"If-None-Match": `"other", ` + etag})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	changed := doRequest(e, http.MethodGet, "/contacts", map[string]string{"If-None-Match": `"other"`})
+	assert.Equal(t, http.StatusOK, changed.Code)
+
+	head := doRequest(e, http.MethodHead, "/contacts", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, head.Code)
+}
+
+func TestCacheMiddleware_LastModified(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", &config.CacheMiddlewareConfig{})
+
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	e := echo.New()
+	e.Use(m.Handler())
+	e.GET("/contacts/:id", codohttp.WrapHandler(func(c *codohttp.Context) error {
+		c.SetLastModified(updated)
+		return c.Success(map[string]string{"id": c.Param("id")})
+	}))
+
+	first := doRequest(e, http.MethodGet, "/contacts/1", nil)
+	assert.Equal(t, updated.Format(http.TimeFormat), first.Header().Get(echo.HeaderLastModified))
+	assert.Empty(t, first.Header().Get("ETag"), "etag disabled")
+
+	since := doRequest(e, http.MethodGet, "/contacts/1", map[string]string{
+		echo.HeaderIfModifiedSince: updated.Format(http.TimeFormat),
+	})
+	assert.Equal(t, http.StatusNotModified, since.Code)
+
+	before := doRequest(e, http.MethodGet, "/contacts/1", map[string]string{
+		echo.HeaderIfModifiedSince: updated.Add(-time.Hour).Format(http.TimeFormat),
+	})
+	assert.Equal(t, http.StatusOK, before.Code)
+}
+
+func TestCacheMiddleware_PassThrough(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", nil)
+
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.GET("/missing", func(c echo.Context) error {
+		return errors.NotFound("nope")
+	})
+	e.GET("/stream", func(c echo.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("data: 1\n\n"))
+		c.Response().Flush()
+		return nil
+	})
+	e.POST("/contacts", func(c echo.Context) error {
+		return c.String(http.StatusCreated, "ok")
+	})
+
+	missing := doRequest(e, http.MethodGet, "/missing", nil)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Empty(t, missing.Header().Get("ETag"))
+
+	stream := doRequest(e, http.MethodGet, "/stream", nil)
+	assert.Equal(t, "data: 1\n\n", stream.Body.String())
+	assert.Empty(t, stream.Header().Get("ETag"))
+
+	post := doRequest(e, http.MethodPost, "/contacts", nil)
+	assert.Equal(t, http.StatusCreated, post.Code)
+	assert.Empty(t, post.Header().Get("ETag"))
+}
+
+// cachedHandler opts its list route into response caching.
+type cachedHandler struct {
+	calls int32
+}
+
+func (h *cachedHandler) Prefix() string                     { return "/contacts" }
+func (h *cachedHandler) Scope() codohttp.RouterScope        { return codohttp.ScopeProtected }
+func (h *cachedHandler) Middlewares() []echo.MiddlewareFunc { return nil }
+func (h *cachedHandler) Initialize() error                  { return nil }
+
+func (h *cachedHandler) Routes(g *echo.Group) {
+	g.GET("", func(c echo.Context) error {
+		n := atomic.AddInt32(&h.calls, 1)
+		c.Response().Header().Set("X-Call", strconv.Itoa(int(n)))
+		return c.JSON(http.StatusOK, map[string]int32{"call": n})
+	})
+	g.GET("/:id", func(c echo.Context) error {
+		n := atomic.AddInt32(&h.calls, 1)
+		Tag(c, "contacts:"+c.Param("id"))
+		return c.JSON(http.StatusOK, map[string]int32{"call": n})
+	})
+}
+
+func (h *cachedHandler) CachePolicy(method, path string) *codohttp.CachePolicy {
+	if path == "/contacts" {
+		return &codohttp.CachePolicy{Tags: []string{"contacts"}}
+	}
+	return &codohttp.CachePolicy{TTL: time.Minute}
+}
+
+func newCachedRouter(t *testing.T, m *CacheMiddleware) (*echo.Echo, *cachedHandler) {
+	t.Helper()
+
+	codohttp.ClearHandlers()
+	t.Cleanup(codohttp.ClearHandlers)
+	h := &cachedHandler{}
+	codohttp.RegisterHandler(h)
+
+	router := codohttp.NewRouter(codohttp.ScopeProtected, ":0")
+	router.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id := c.Request().Header.Get("X-User"); id != "" {
+				auth.SetIdentity(c, &auth.Identity{ID: id})
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-"+c.Request().Header.Get("X-Req"))
+			return next(c)
+		}
+	})
+	router.Use(m.Handler())
+	require.NoError(t, router.RegisterHandlers())
+	return router.Echo(), h
+}
+
+func TestCacheMiddleware_Store(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", &config.CacheMiddlewareConfig{ETag: true, Store: "memory"})
+	e, h := newCachedRouter(t, m)
+
+	alice := map[string]string{"X-User": "alice", "X-Req": "1"}
+	first := doRequest(e, http.MethodGet, "/contacts?b=2&a=1", alice)
+	assert.Equal(t, "MISS", first.Header().Get(HeaderCache))
+
+	alice["X-Req"] = "2"
+	second := doRequest(e, http.MethodGet, "/contacts?a=1&b=2", alice)
+	assert.Equal(t, "HIT", second.Header().Get(HeaderCache))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "1", second.Header().Get("X-Call"))
+	assert.Equal(t, "req-2", second.Header().Get(echo.HeaderXRequestID))
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&h.calls))
+
+	revalidate := doRequest(e, http.MethodGet, "/contacts?a=1&b=2", map[string]string{
+		"X-User":        "alice",
+		"If-None-Match": first.Header().Get("ETag"),
+	})
+	assert.Equal(t, http.StatusNotModified, revalidate.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&h.calls))
+
+	// Identity and query select separate variants.
+	bob := doRequest(e, http.MethodGet, "/contacts?a=1&b=2", map[string]string{"X-User": "bob"})
+	assert.Equal(t, "MISS", bob.Header().Get(HeaderCache))
+	other := doRequest(e, http.MethodGet, "/contacts?a=2", map[string]string{"X-User": "alice"})
+	assert.Equal(t, "MISS", other.Header().Get(HeaderCache))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&h.calls))
+}
+
+func TestCacheMiddleware_SkipsTransactions(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", &config.CacheMiddlewareConfig{Store: "memory"})
+	e, h := newCachedRouter(t, m)
+
+	inTx := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+		req = req.WithContext(db.ContextWithTx(req.Context(), nil, nil))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Not stored while in a transaction
+	assert.Empty(t, inTx().Header().Get(HeaderCache))
+	assert.Equal(t, "MISS", doRequest(e, http.MethodGet, "/contacts", nil).Header().Get(HeaderCache))
+
+	// Not served from the store either
+	assert.Empty(t, inTx().Header().Get(HeaderCache))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&h.calls))
+}
+
+func TestCacheMiddleware_Invalidate(t *testing.T) {
+	m := testutil.NewMiddleware[*CacheMiddleware](t, "cache", &config.CacheMiddlewareConfig{Store: "memory"})
+	e, h := newCachedRouter(t, m)
+	ctx := context.Background()
+
+	doRequest(e, http.MethodGet, "/contacts", nil)
+	doRequest(e, http.MethodGet, "/contacts/1", nil)
+	doRequest(e, http.MethodGet, "/contacts/2", nil)
+	require.Equal(t, int32(3), atomic.LoadInt32(&h.calls))
+
+	require.NoError(t, Invalidate(ctx, "contacts:1"))
+	assert.Equal(t, "MISS", doRequest(e, http.MethodGet, "/contacts/1", nil).Header().Get(HeaderCache))
+	assert.Equal(t, "HIT", doRequest(e, http.MethodGet, "/contacts/2", nil).Header().Get(HeaderCache))
+	assert.Equal(t, "HIT", doRequest(e, http.MethodGet, "/contacts", nil).Header().Get(HeaderCache))
+
+	// Repository writes invalidate the table tag and the record tag.
+	invalidateWrite(ctx, db.WriteEvent{Table: "contacts", ID: "2", Op: db.WriteUpdate})
+	assert.Equal(t, "MISS", doRequest(e, http.MethodGet, "/contacts", nil).Header().Get(HeaderCache))
+	assert.Equal(t, "MISS", doRequest(e, http.MethodGet, "/contacts/2", nil).Header().Get(HeaderCache))
+	assert.Equal(t, "HIT", doRequest(e, http.MethodGet, "/contacts/1", nil).Header().Get(HeaderCache))
+}
+
+func TestCacheMiddleware_Configure(t *testing.T) {
+	m := &CacheMiddleware{}
+	setActiveStore(nil, nil) // Other tests leave theirs active
+	t.Cleanup(func() { setActiveStore(nil, nil) })
+
+	assert.Error(t, m.Configure(&config.CacheMiddlewareConfig{Store: "disk"}))
+	assert.Error(t, m.Configure(&config.CacheMiddlewareConfig{Store: "redis"}))
+
+	var nilCfg *config.CacheMiddlewareConfig
+	require.NoError(t, m.Configure(nilCfg))
+	assert.True(t, m.etag)
+	assert.Nil(t, m.store)
+
+	// Configure leaves the active store alone; a copy configured with the
+	// same store settings keeps the store it was copied with
+	require.NoError(t, m.Configure(&config.CacheMiddlewareConfig{Store: "memory"}))
+	store := m.store
+	assert.Nil(t, activeStore)
+	clone := *m
+	require.NoError(t, clone.Configure(&config.CacheMiddlewareConfig{Store: "memory", TTL: time.Second}))
+	assert.Same(t, store, clone.store)
+	require.NoError(t, clone.Configure(&config.CacheMiddlewareConfig{Store: "memory", KeyPrefix: "other:"}))
+	assert.NotSame(t, store, clone.store)
+	require.NoError(t, m.Activate())
+	assert.Same(t, store, activeStore)
+
+	assert.False(t, m.Enabled(nil))
+	cfg := config.DefaultMiddlewareConfig().Cache
+	assert.False(t, m.Enabled(&cfg))
+	cfg.Enabled = true
+	assert.True(t, m.Enabled(&cfg))
+}
+
+func TestEtagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`W/"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`"x", "abc"`, `"abc"`))
+	assert.True(t, etagMatches(`*`, `"abc"`))
+	assert.False(t, etagMatches(`"abcd"`, `"abc"`))
+	assert.False(t, etagMatches(`*`, ""))
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"redis": func(t *testing.T) Store {
+			mr := miniredis.RunT(t)
+			port, err := strconv.Atoi(mr.Port())
+			require.NoError(t, err)
+
+			client := redis.New()
+			require.NoError(t, client.Initialize(&redis.Config{Host: mr.Host(), Port: port}))
+			t.Cleanup(func() { client.Shutdown() })
+			return NewRedisStore(client, "httpcache:")
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := context.Background()
+
+			missing, err := store.Get(ctx, "k1")
+			require.NoError(t, err)
+			assert.Nil(t, missing)
+
+			entry := &Entry{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": {"application/json"}},
+				Body:   []byte(`{"ok":true}`),
+			}
+			require.NoError(t, store.Set(ctx, "k1", entry, []string{"contacts"}, time.Minute))
+			require.NoError(t, store.Set(ctx, "k2", entry, []string{"groups"}, time.Minute))
+
+			got, err := store.Get(ctx, "k1")
+			require.NoError(t, err)
+			assert.Equal(t, entry, got)
+
+			require.NoError(t, store.Invalidate(ctx, "contacts"))
+			got, err = store.Get(ctx, "k1")
+			require.NoError(t, err)
+			assert.Nil(t, got)
+
+			got, err = store.Get(ctx, "k2")
+			require.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+)
+
+// Entry is a stored response.
+type Entry struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Store persists responses and the tags that invalidate them.
+type Store interface {
+	// Get returns the entry for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Set stores an entry and indexes it under each tag.
+	Set(ctx context.Context, key string, entry *Entry, tags []string, ttl time.Duration) error
+
+	// Invalidate drops every entry indexed under any of the tags.
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// RedisStore stores responses in Redis. Each tag is a set of entry keys.
+type RedisStore struct {
+	client redis.RedisClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed store.
+func NewRedisStore(client redis.RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) tagKey(tag string) string {
+	return s.prefix + "tag:" + tag
+}
+
+// Get reads an entry.
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	raw, err := s.client.Get(ctx, s.prefix+key)
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		return nil, fmt.Errorf("invalid cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Set writes an entry and adds it to its tag sets.
+func (s *RedisStore) Set(ctx context.Context, key string, entry *Entry, tags []string, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, s.prefix+key, string(data), ttl); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		tagKey := s.tagKey(tag)
+		if err := s.client.SAdd(ctx, tagKey, s.prefix+key); err != nil {
+			return err
+		}
+		// Tag sets live as long as their longest-lived entry.
+		current, err := s.client.TTL(ctx, tagKey)
+		if err != nil {
+			return err
+		}
+		if current < ttl {
+			if err := s.client.Expire(ctx, tagKey, ttl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Invalidate deletes all entries in the tag sets, then the sets.
+func (s *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		tagKey := s.tagKey(tag)
+		keys, err := s.client.SMembers(ctx, tagKey)
+		if err != nil {
+			return err
+		}
+		if err := s.client.Del(ctx, append(keys, tagKey)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryStore keeps responses in process memory. Entries are per instance,
+// so invalidation only reaches the local process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	tags      map[string]map[string]bool
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	entry   *Entry
+	expires time.Time
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		tags:    make(map[string]map[string]bool),
+	}
+}
+
+// Get reads an entry, dropping it if expired.
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	return e.entry, nil
+}
+
+// Set writes an entry and indexes it under its tags.
+func (s *MemoryStore) Set(ctx context.Context, key string, entry *Entry, tags []string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	s.entries[key] = memoryEntry{entry: entry, expires: now.Add(ttl)}
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]bool)
+			s.tags[tag] = keys
+		}
+		keys[key] = true
+	}
+	return nil
+}
+
+// Invalidate drops all entries under the tags.
+func (s *MemoryStore) Invalidate(ctx context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			delete(s.entries, key)
+		}
+		delete(s.tags, tag)
+	}
+	return nil
+}
+
+// sweep drops expired entries at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	for tag, keys := range s.tags {
+		for key := range keys {
+			if _, ok := s.entries[key]; !ok {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+// Package csrf protects cookie-authenticated routes against cross-site
+// request forgery. Unsafe requests must come from an allowed origin and
+// carry a token the client received in the X-CSRF-Token response header.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// tokenContextKey holds the request's token, see Token.
+const tokenContextKey = "csrf.token"
+
+// safeMethods never change state, so they are not checked.
+var safeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+func init() {
+	middleware.RegisterMiddleware(&CSRFMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"csrf",
+			"middleware.csrf",
+			middleware.PriorityCSRF,
+			middleware.RouterProtected, // Cookie-authenticated router
+		),
+	})
+}
+
+// CSRFMiddleware checks the origin and token of unsafe requests.
+type CSRFMiddleware struct {
+	middleware.BaseMiddleware
+	mode           string
+	headerName     string
+	formField      string
+	ttl            time.Duration
+	cookie         http.Cookie // Double-submit cookie template
+	checkOrigin    bool
+	trustedOrigins map[string]bool
+	exemptBearer   bool
+	skipPaths      []string
+	store          Store // Synchronizer tokens
+}
+
+// Enabled checks if the middleware is enabled
+func (m *CSRFMiddleware) Enabled(cfg any) bool {
+	if cfg == nil {
+		return true // Enabled by default
+	}
+
+	csrfCfg, ok := cfg.(*config.CSRFMiddlewareConfig)
+	if !ok {
+		return true
+	}
+
+	return csrfCfg.Enabled
+}
+
+// Configure initializes the middleware and, in synchronizer mode, the store
+func (m *CSRFMiddleware) Configure(cfg any) error {
+	defaults := config.DefaultMiddlewareConfig().CSRF
+	csrfCfg, ok := cfg.(*config.CSRFMiddlewareConfig)
+	if !ok || csrfCfg == nil {
+		csrfCfg = &defaults
+	}
+	if err := csrfCfg.Validate(); err != nil {
+		return err
+	}
+
+	m.mode = or(csrfCfg.Mode, defaults.Mode)
+	m.headerName = or(csrfCfg.HeaderName, defaults.HeaderName)
+	m.formField = or(csrfCfg.FormField, defaults.FormField)
+	m.ttl = csrfCfg.TTL
+	if m.ttl <= 0 {
+		m.ttl = defaults.TTL
+	}
+	m.cookie = http.Cookie{
+		Name:     or(csrfCfg.CookieName, defaults.CookieName),
+		Path:     or(csrfCfg.CookiePath, defaults.CookiePath),
+		Domain:   csrfCfg.CookieDomain,
+		MaxAge:   int(m.ttl.Seconds()),
+		Secure:   csrfCfg.CookieSecure,
+		HttpOnly: false, // Read by scripts to echo the token
+		SameSite: sameSite(or(csrfCfg.SameSite, defaults.SameSite)),
+	}
+	m.checkOrigin = csrfCfg.CheckOrigin
+	m.trustedOrigins = make(map[string]bool, len(csrfCfg.TrustedOrigins))
+	for _, origin := range csrfCfg.TrustedOrigins {
+		m.trustedOrigins[normalizeOrigin(origin)] = true
+	}
+	m.exemptBearer = csrfCfg.ExemptBearer
+	m.skipPaths = csrfCfg.SkipPaths
+
+	m.store = nil
+	if m.mode != config.CSRFModeSynchronizer {
+		return nil
+	}
+	switch or(csrfCfg.Store, defaults.Store) {
+	case "memory":
+		m.store = NewMemoryStore()
+	case "redis":
+		client, err := clients.GetTyped[redis.RedisClient](redis.ClientName)
+		if err != nil {
+			return fmt.Errorf("csrf store: %w", err)
+		}
+		m.store = NewRedisStore(client, or(csrfCfg.KeyPrefix, defaults.KeyPrefix))
+	}
+	return nil
+}
+
+// SetStore replaces the synchronizer token store (useful for testing)
+func (m *CSRFMiddleware) SetStore(store Store) {
+	m.store = store
+}
+
+// Handler returns the CSRF middleware function
+func (m *CSRFMiddleware) Handler() echo.MiddlewareFunc {
+	skipPaths := m.skipPaths
+	exemptBearer := m.exemptBearer
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if middleware.MatchAnyPath(skipPaths, req.URL.Path) {
+				return next(c)
+			}
+			if exemptBearer && !cookieAuthenticated(c) {
+				return next(c)
+			}
+
+			expected, err := m.token(c)
+			if err != nil {
+				return err
+			}
+			if expected != "" {
+				c.Set(tokenContextKey, expected)
+				c.Response().Header().Set(m.headerName, expected)
+			}
+
+			if safeMethods[req.Method] {
+				return next(c)
+			}
+
+			if m.checkOrigin {
+				if err := m.verifyOrigin(c); err != nil {
+					return err
+				}
+			}
+
+			if expected == "" {
+				return errors.Forbidden("CSRF protection requires a session").
+					WithPhase(errors.PhaseMiddleware)
+			}
+			submitted := m.submitted(c)
+			if submitted == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(expected)) != 1 {
+				return errors.Forbidden("Invalid CSRF token").
+					WithPhase(errors.PhaseMiddleware)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Token returns the CSRF token for the request, e.g. to render into a form.
+// It is empty if the middleware did not run.
+func Token(c echo.Context) string {
+	token, _ := c.Get(tokenContextKey).(string)
+	return token
+}
+
+// token returns the request's expected token, issuing one if there is none.
+// In synchronizer mode it is empty without a session.
+func (m *CSRFMiddleware) token(c echo.Context) (string, error) {
+	if m.mode == config.CSRFModeSynchronizer {
+		return m.sessionToken(c)
+	}
+
+	if cookie, err := c.Cookie(m.cookie.Name); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	cookie := m.cookie
+	cookie.Value = token
+	c.SetCookie(&cookie)
+	return token, nil
+}
+
+// sessionToken returns the stored token of the authenticated session
+func (m *CSRFMiddleware) sessionToken(c echo.Context) (string, error) {
+	identity, err := auth.GetIdentity(c)
+	if err != nil {
+		return "", nil
+	}
+	session := identity.SessionID
+	if session == "" {
+		session = "identity:" + identity.ID
+	}
+
+	ctx := c.Request().Context()
+	token, err := m.store.Get(ctx, session)
+	if err != nil {
+		return "", errors.Unavailable("CSRF token store unavailable").
+			WithCause(err).
+			WithPhase(errors.PhaseMiddleware)
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	if token, err = newToken(); err != nil {
+		return "", err
+	}
+	if err := m.store.Set(ctx, session, token, m.ttl); err != nil {
+		return "", errors.Unavailable("CSRF token store unavailable").
+			WithCause(err).
+			WithPhase(errors.PhaseMiddleware)
+	}
+	return token, nil
+}
+
+// submitted returns the token sent with the request, from the header or,
+// for form posts, the form field
+func (m *CSRFMiddleware) submitted(c echo.Context) string {
+	if token := c.Request().Header.Get(m.headerName); token != "" {
+		return token
+	}
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	if strings.HasPrefix(contentType, echo.MIMEApplicationForm) || strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		return c.FormValue(m.formField)
+	}
+	return ""
+}
+
+// verifyOrigin rejects requests whose Origin, or Referer without Origin, is
+// neither the request's own origin nor trusted. Without either header,
+// browsers that report Sec-Fetch-Site: cross-site are rejected.
+func (m *CSRFMiddleware) verifyOrigin(c echo.Context) error {
+	req := c.Request()
+	origin := req.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		if referer, err := url.Parse(req.Header.Get("Referer")); err == nil && referer.Host != "" {
+			origin = referer.Scheme + "://" + referer.Host
+		}
+	}
+
+	if origin == "" {
+		if req.Header.Get("Sec-Fetch-Site") == "cross-site" {
+			return errors.Forbidden("Cross-origin request rejected").
+				WithPhase(errors.PhaseMiddleware)
+		}
+		return nil
+	}
+
+	origin = normalizeOrigin(origin)
+	if origin == normalizeOrigin(c.Scheme()+"://"+req.Host) || m.trustedOrigins[origin] {
+		return nil
+	}
+	return errors.Forbidden("Cross-origin request rejected").
+		WithPhase(errors.PhaseMiddleware).
+		WithDetail("origin", origin)
+}
+
+// cookieAuthenticated reports whether the request may be authenticated by a
+// cookie, which browsers attach to cross-site requests. It is false only when
+// auth authenticated the request by a JWT, session token or API key, which
+// the client sets itself, so a forged request cannot carry them. Headers
+// alone are not trusted: a junk Authorization header can ride along with a
+// session cookie.
+func cookieAuthenticated(c echo.Context) bool {
+	if identity, err := auth.GetIdentity(c); err == nil {
+		switch identity.Method {
+		case auth.MethodJWT, auth.MethodSessionToken, auth.MethodAPIKey, auth.MethodDev:
+			return false
+		}
+	}
+	return true
+}
+
+// newToken returns a random token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WrapInternal(err, "Failed to generate CSRF token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(origin, "/"))
+}
+
+func sameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func or(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
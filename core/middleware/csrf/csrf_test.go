@@ -0,0 +1,388 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/middleware"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+// newTestServer serves the middleware behind an optional identity
+func newTestServer(m *CSRFMiddleware, identity *auth.Identity) *echo.Echo {
+	e := testutil.NewEcho()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if identity != nil {
+				auth.SetIdentity(c, identity)
+			}
+			return next(c)
+		}
+	})
+	e.Use(m.Handler())
+
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, Token(c))
+	}
+	e.GET("/contacts", handler)
+	e.POST("/contacts", handler)
+	e.POST("/webhooks/stripe", handler)
+	return e
+}
+
+func serve(e *echo.Echo, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func csrfCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func defaultConfig() *config.CSRFMiddlewareConfig {
+	cfg := config.DefaultMiddlewareConfig().CSRF
+	return &cfg
+}
+
+func TestCSRFMiddleware_Enabled(t *testing.T) {
+	m := &CSRFMiddleware{}
+
+	assert.True(t, m.Enabled(nil))
+	assert.True(t, m.Enabled("invalid"))
+	assert.True(t, m.Enabled(&config.CSRFMiddlewareConfig{BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true}}))
+	assert.False(t, m.Enabled(&config.CSRFMiddlewareConfig{}))
+}
+
+func TestCSRFMiddleware_Registered(t *testing.T) {
+	m, ok := middleware.GetGlobalRegistry().Get("csrf")
+	require.True(t, ok)
+	assert.Equal(t, middleware.PriorityCSRF, m.Priority())
+	assert.Equal(t, middleware.RouterProtected, m.Routers())
+}
+
+func TestCSRFMiddleware_Configure_InvalidMode(t *testing.T) {
+	m := &CSRFMiddleware{}
+	err := m.Configure(&config.CSRFMiddlewareConfig{Mode: "magic"})
+	assert.Error(t, err)
+}
+
+func TestCSRFMiddleware_DoubleSubmit_IssuesCookie(t *testing.T) {
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), nil)
+
+	rec := serve(e, httptest.NewRequest(http.MethodGet, "/contacts", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	cookie := csrfCookie(rec)
+	require.NotNil(t, cookie)
+	assert.NotEmpty(t, cookie.Value)
+	assert.False(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	assert.Equal(t, cookie.Value, rec.Header().Get("X-CSRF-Token"))
+	assert.Equal(t, cookie.Value, rec.Body.String(), "handlers should see the token")
+}
+
+func TestCSRFMiddleware_DoubleSubmit_ReusesCookie(t *testing.T) {
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "existing"})
+	rec := serve(e, req)
+
+	assert.Nil(t, csrfCookie(rec))
+	assert.Equal(t, "existing", rec.Header().Get("X-CSRF-Token"))
+}
+
+func TestCSRFMiddleware_DoubleSubmit_Validation(t *testing.T) {
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), nil)
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   int
+	}{
+		{"matching token", "tok", "tok", http.StatusOK},
+		{"mismatched token", "tok", "other", http.StatusForbidden},
+		{"missing token", "tok", "", http.StatusForbidden},
+		{"missing cookie", "", "tok", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			rec := serve(e, req)
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), "Invalid CSRF token")
+			}
+		})
+	}
+}
+
+func TestCSRFMiddleware_FormField(t *testing.T) {
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), nil)
+
+	form := url.Values{"csrf_token": {"tok"}}
+	req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+
+	rec := serve(e, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCSRFMiddleware_FormField_IgnoredForJSON(t *testing.T) {
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts?csrf_token=tok", strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+
+	rec := serve(e, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFMiddleware_Origin(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.TrustedOrigins = []string{"https://App.example.com/"}
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg), nil)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"same origin", map[string]string{"Origin": "http://example.com"}, http.StatusOK},
+		{"trusted origin", map[string]string{"Origin": "https://app.example.com"}, http.StatusOK},
+		{"cross origin", map[string]string{"Origin": "https://evil.test"}, http.StatusForbidden},
+		{"null origin", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"same origin referer", map[string]string{"Referer": "http://example.com/contacts/new"}, http.StatusOK},
+		{"cross origin referer", map[string]string{"Referer": "https://evil.test/form"}, http.StatusForbidden},
+		{"no origin", map[string]string{}, http.StatusOK},
+		{"cross-site fetch metadata", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+			req.Header.Set("X-CSRF-Token", "tok")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := serve(e, req)
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), "Cross-origin request rejected")
+			}
+		})
+	}
+}
+
+func TestCSRFMiddleware_Origin_Disabled(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.CheckOrigin = false
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+	req.Header.Set("X-CSRF-Token", "tok")
+	req.Header.Set("Origin", "https://evil.test")
+
+	rec := serve(e, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCSRFMiddleware_ExemptBearer(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		header   string
+		want     int
+	}{
+		{"bearer header", nil, "Bearer abc", http.StatusForbidden},
+		{"jwt identity", &auth.Identity{ID: "u1", Method: auth.MethodJWT}, "", http.StatusOK},
+		{"session token identity", &auth.Identity{ID: "u1", Method: auth.MethodSessionToken}, "", http.StatusOK},
+		{"api key identity", &auth.Identity{ID: "u1", Method: auth.MethodAPIKey}, "", http.StatusOK},
+		{"session cookie identity", &auth.Identity{ID: "u1", Method: auth.MethodSessionCookie}, "", http.StatusForbidden},
+		{"session cookie with junk bearer", &auth.Identity{ID: "u1", Method: auth.MethodSessionCookie}, "Bearer junk", http.StatusForbidden},
+		{"no credentials", nil, "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", nil), tt.identity)
+
+			req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := serve(e, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestCSRFMiddleware_ExemptBearer_Disabled(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.ExemptBearer = false
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg), &auth.Identity{ID: "u1", Method: auth.MethodJWT})
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer abc")
+
+	rec := serve(e, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFMiddleware_SkipPaths(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.SkipPaths = []string{"/webhooks/*"}
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg), nil)
+
+	rec := serve(e, httptest.NewRequest(http.MethodPost, "/webhooks/stripe", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(e, httptest.NewRequest(http.MethodPost, "/contacts", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFMiddleware_Synchronizer(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Mode = config.CSRFModeSynchronizer
+	m := testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg)
+	identity := &auth.Identity{ID: "u1", SessionID: "s1", Method: auth.MethodSessionCookie}
+	e := newTestServer(m, identity)
+
+	rec := serve(e, httptest.NewRequest(http.MethodGet, "/contacts", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, csrfCookie(rec), "synchronizer mode should not set a cookie")
+	token := rec.Header().Get("X-CSRF-Token")
+	require.NotEmpty(t, token)
+
+	// The token is stable for the session
+	rec = serve(e, httptest.NewRequest(http.MethodGet, "/contacts", nil))
+	assert.Equal(t, token, rec.Header().Get("X-CSRF-Token"))
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts", nil)
+	req.Header.Set("X-CSRF-Token", token)
+	rec = serve(e, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// A cookie cannot stand in for the stored token
+	req = httptest.NewRequest(http.MethodPost, "/contacts", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "forged"})
+	req.Header.Set("X-CSRF-Token", "forged")
+	rec = serve(e, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Another session has its own token
+	other := newTestServer(m, &auth.Identity{ID: "u1", SessionID: "s2", Method: auth.MethodSessionCookie})
+	req = httptest.NewRequest(http.MethodPost, "/contacts", nil)
+	req.Header.Set("X-CSRF-Token", token)
+	rec = serve(other, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFMiddleware_Synchronizer_RequiresSession(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Mode = config.CSRFModeSynchronizer
+	e := newTestServer(testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg), nil)
+
+	rec := serve(e, httptest.NewRequest(http.MethodGet, "/contacts", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-CSRF-Token"))
+
+	rec = serve(e, httptest.NewRequest(http.MethodPost, "/contacts", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "requires a session")
+}
+
+func TestCSRFMiddleware_Synchronizer_RedisStore(t *testing.T) {
+	mock := redis.NewMock()
+	clients.MustRegister(mock)
+	t.Cleanup(func() {
+		clients.ResetRegistry()
+	})
+
+	cfg := defaultConfig()
+	cfg.Mode = config.CSRFModeSynchronizer
+	cfg.Store = "redis"
+	m := testutil.NewMiddleware[*CSRFMiddleware](t, "csrf", cfg)
+	e := newTestServer(m, &auth.Identity{ID: "u1", SessionID: "s1", Method: auth.MethodSessionCookie})
+
+	rec := serve(e, httptest.NewRequest(http.MethodGet, "/contacts", nil))
+	token := rec.Header().Get("X-CSRF-Token")
+	require.NotEmpty(t, token)
+
+	stored, err := mock.Get(context.Background(), "csrf:s1")
+	require.NoError(t, err)
+	assert.Equal(t, token, stored)
+}
+
+func TestCSRFMiddleware_Synchronizer_RedisStoreMissingClient(t *testing.T) {
+	clients.ResetRegistry()
+
+	cfg := defaultConfig()
+	cfg.Mode = config.CSRFModeSynchronizer
+	cfg.Store = "redis"
+
+	m := &CSRFMiddleware{}
+	assert.Error(t, m.Configure(cfg))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	token, err := s.Get(ctx, "s1")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, s.Set(ctx, "s1", "tok", time.Hour))
+	token, err = s.Get(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "tok", token)
+
+	require.NoError(t, s.Set(ctx, "s2", "old", -time.Second))
+	token, err = s.Get(ctx, "s2")
+	require.NoError(t, err)
+	assert.Empty(t, token, "expired tokens should not be returned")
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	s := NewMemoryStore()
+	s.tokens["old"] = memoryToken{token: "a", expires: time.Now().Add(-time.Minute)}
+	s.tokens["new"] = memoryToken{token: "b", expires: time.Now().Add(time.Hour)}
+
+	s.sweep(time.Now())
+
+	assert.NotContains(t, s.tokens, "old")
+	assert.Contains(t, s.tokens, "new")
+}
@@ -0,0 +1,105 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+)
+
+// Store keeps synchronizer tokens by session.
+type Store interface {
+	// Get returns the token for a session, or "" if there is none.
+	Get(ctx context.Context, session string) (string, error)
+
+	// Set stores the token for a session.
+	Set(ctx context.Context, session, token string, ttl time.Duration) error
+}
+
+// RedisStore keeps tokens in Redis, shared by all instances.
+type RedisStore struct {
+	client redis.RedisClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed store.
+func NewRedisStore(client redis.RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get reads a token.
+func (s *RedisStore) Get(ctx context.Context, session string) (string, error) {
+	token, err := s.client.Get(ctx, s.prefix+session)
+	if errors.Is(err, goredis.Nil) {
+		return "", nil
+	}
+	return token, err
+}
+
+// Set writes a token.
+func (s *RedisStore) Set(ctx context.Context, session, token string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+session, token, ttl)
+}
+
+// MemoryStore keeps tokens in process memory. Tokens are per instance, so
+// run a single instance or use RedisStore.
+type MemoryStore struct {
+	mu        sync.Mutex
+	tokens    map[string]memoryToken
+	lastSweep time.Time
+}
+
+type memoryToken struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: make(map[string]memoryToken)}
+}
+
+// Get reads a token, dropping it if expired.
+func (s *MemoryStore) Get(ctx context.Context, session string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[session]
+	if !ok {
+		return "", nil
+	}
+	if time.Now().After(t.expires) {
+		delete(s.tokens, session)
+		return "", nil
+	}
+	return t.token, nil
+}
+
+// Set writes a token.
+func (s *MemoryStore) Set(ctx context.Context, session, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	s.tokens[session] = memoryToken{token: token, expires: now.Add(ttl)}
+	return nil
+}
+
+// sweep drops expired tokens at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for session, t := range s.tokens {
+		if now.After(t.expires) {
+			delete(s.tokens, session)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// HeaderReplayed is set on responses served from the idempotency store.
+const HeaderReplayed = "Idempotent-Replayed"
+
+func init() {
+	middleware.RegisterMiddleware(&IdempotencyMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"idempotency",
+			"middleware.idempotency",
+			middleware.PriorityIdempotency,
+			middleware.RouterAll,
+		),
+	})
+}
+
+// IdempotencyMiddleware replays stored responses for repeated requests
+// carrying the same Idempotency-Key header.
+type IdempotencyMiddleware struct {
+	middleware.BaseMiddleware
+	store      Store
+	headerName string
+	ttl        time.Duration
+	lockTTL    time.Duration
+	methods    map[string]bool
+	paths      []string
+	skipPaths  []string
+	logger     *logrus.Logger
+}
+
+// Enabled checks if the middleware is enabled and a store is available
+func (m *IdempotencyMiddleware) Enabled(cfg any) bool {
+	if !clients.Has(redis.ClientName) && !clients.Has("db") {
+		return false
+	}
+
+	if cfg == nil {
+		return true
+	}
+
+	idemCfg, ok := cfg.(*config.IdempotencyMiddlewareConfig)
+	if !ok {
+		return true
+	}
+
+	return idemCfg.Enabled
+}
+
+// Configure initializes the middleware and selects the store
+func (m *IdempotencyMiddleware) Configure(cfg any) error {
+	defaults := config.DefaultMiddlewareConfig().Idempotency
+	idemCfg, ok := cfg.(*config.IdempotencyMiddlewareConfig)
+	if !ok || idemCfg == nil {
+		idemCfg = &defaults
+	}
+
+	m.headerName = idemCfg.HeaderName
+	if m.headerName == "" {
+		m.headerName = defaults.HeaderName
+	}
+	m.ttl = idemCfg.TTL
+	if m.ttl <= 0 {
+		m.ttl = defaults.TTL
+	}
+	m.lockTTL = idemCfg.LockTTL
+	if m.lockTTL <= 0 {
+		m.lockTTL = defaults.LockTTL
+	}
+
+	methods := idemCfg.Methods
+	if len(methods) == 0 {
+		methods = defaults.Methods
+	}
+	m.methods = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		m.methods[strings.ToUpper(method)] = true
+	}
+
+	m.paths = idemCfg.Paths
+	m.skipPaths = idemCfg.SkipPaths
+
+	if loggerClient, err := clients.GetTyped[*logger.Logger]("logger"); err == nil {
+		m.logger = loggerClient.GetLogger()
+	}
+
+	prefix := idemCfg.KeyPrefix
+	if prefix == "" {
+		prefix = defaults.KeyPrefix
+	}
+	table := idemCfg.TableName
+	if table == "" {
+		table = defaults.TableName
+	}
+
+	store, err := selectStore(idemCfg.Store, prefix, table)
+	if err != nil {
+		return err
+	}
+	m.store = store
+	return nil
+}
+
+// selectStore picks the configured store, preferring Redis when unset.
+func selectStore(kind, prefix, table string) (Store, error) {
+	useRedis := kind == "redis" || (kind == "" && clients.Has(redis.ClientName))
+
+	if useRedis {
+		client, err := clients.GetTyped[redis.RedisClient](redis.ClientName)
+		if err != nil {
+			return nil, fmt.Errorf("idempotency store: %w", err)
+		}
+		return NewRedisStore(client, prefix), nil
+	}
+
+	if kind != "" && kind != "sql" {
+		return nil, fmt.Errorf("unknown idempotency store: %s", kind)
+	}
+
+	client, err := clients.GetTyped[*db.Client]("db")
+	if err != nil {
+		return nil, fmt.Errorf("idempotency store: %w", err)
+	}
+	return NewSQLStore(client, table), nil
+}
+
+// SetStore replaces the store (useful for testing)
+func (m *IdempotencyMiddleware) SetStore(store Store) {
+	m.store = store
+}
+
+// Handler returns the idempotency middleware function
+func (m *IdempotencyMiddleware) Handler() echo.MiddlewareFunc {
+	store := m.store
+	headerName := m.headerName
+	ttl := m.ttl
+	lockTTL := m.lockTTL
+	methods := m.methods
+	paths := m.paths
+	skipPaths := m.skipPaths
+	log := m.logger
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idemKey := req.Header.Get(headerName)
+			if idemKey == "" || !methods[req.Method] || !pathApplies(req.URL.Path, paths, skipPaths) {
+				return next(c)
+			}
+
+			if len(idemKey) > 255 {
+				return errors.BadRequest(fmt.Sprintf("%s must be at most 255 characters", headerName)).
+					WithPhase(errors.PhaseMiddleware)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return errors.WrapBadRequest(err, "Failed to read request body").
+					WithPhase(errors.PhaseMiddleware)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			key := scopedKey(c, idemKey)
+			fingerprint := requestFingerprint(req, body)
+			ctx := req.Context()
+
+			existing, claimed, err := store.Claim(ctx, key, fingerprint, lockTTL)
+			if err != nil {
+				return errors.WrapInternal(err, "Failed to check idempotency key").
+					WithPhase(errors.PhaseMiddleware)
+			}
+
+			if !claimed {
+				if existing.Fingerprint != fingerprint {
+					return errors.New(errors.CodeValidation,
+						fmt.Sprintf("%s was already used with a different request", headerName),
+						http.StatusUnprocessableEntity).
+						WithPhase(errors.PhaseMiddleware)
+				}
+				if !existing.Completed {
+					return errors.Conflict("A request with this idempotency key is already in progress").
+						WithPhase(errors.PhaseMiddleware).
+						WithRetry(true, time.Second)
+				}
+				return replay(c, existing)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			handlerErr := next(c)
+
+			// Handler errors are rendered further out and server errors are
+			// worth retrying, so only committed non-5xx responses are kept.
+			status := c.Response().Status
+			if handlerErr != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+				if err := store.Release(context.WithoutCancel(ctx), key); err != nil && log != nil {
+					log.WithError(err).Warn("Failed to release idempotency key")
+				}
+				return handlerErr
+			}
+
+			rec := &Record{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      status,
+				Header:      storedHeaders(c.Response().Header()),
+				Body:        recorder.body.Bytes(),
+			}
+			if err := store.Save(context.WithoutCancel(ctx), key, rec, ttl); err != nil && log != nil {
+				log.WithError(err).Warn("Failed to store idempotent response")
+			}
+			return nil
+		}
+	}
+}
+
+// replay writes a stored response.
+func replay(c echo.Context, rec *Record) error {
+	header := c.Response().Header()
+	for name, values := range rec.Header {
+		header[name] = values
+	}
+	header.Set(HeaderReplayed, "true")
+
+	c.Response().WriteHeader(rec.Status)
+	_, err := c.Response().Write(rec.Body)
+	return err
+}
+
+// scopedKey namespaces the client key by identity and route so keys from
+// different users or endpoints never collide. The result is hashed to keep
+// storage keys short.
+func scopedKey(c echo.Context, idemKey string) string {
+	subject := "anonymous"
+	if identity, err := auth.GetIdentity(c); err == nil && identity != nil {
+		subject = identity.ID
+	}
+	sum := sha256.Sum256([]byte(subject + "\x00" + c.Request().Method + "\x00" + c.Request().URL.Path + "\x00" + idemKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint hashes the parts of the request a replay must match.
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storedHeaders copies response headers worth replaying.
+func storedHeaders(header http.Header) http.Header {
+	stored := header.Clone()
+	stored.Del(echo.HeaderXRequestID)
+	stored.Del(echo.HeaderContentLength)
+	// Compression is reapplied per client by the gzip middleware.
+	stored.Del(echo.HeaderContentEncoding)
+	return stored
+}
+
+// pathApplies reports whether the middleware handles the given path.
+func pathApplies(p string, paths, skipPaths []string) bool {
+	if middleware.MatchAnyPath(skipPaths, p) {
+		return false
+	}
+	return len(paths) == 0 || middleware.MatchAnyPath(paths, p)
+}
+
+// responseRecorder tees the response body into a buffer.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,245 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/db/testdb"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+func doRequest(e *echo.Echo, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	clients.MustRegister(redis.NewMock())
+	t.Cleanup(clients.ResetRegistry)
+	m := testutil.NewMiddleware[*IdempotencyMiddleware](t, "idempotency", nil)
+
+	var calls int32
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/contacts", func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set("X-Call", "first")
+		return c.JSON(http.StatusCreated, map[string]int32{"call": n})
+	})
+
+	first := doRequest(e, http.MethodPost, "/contacts", "key-1", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(HeaderReplayed))
+
+	second := doRequest(e, http.MethodPost, "/contacts", "key-1", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Equal(t, "first", second.Header().Get("X-Call"))
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyMiddleware_DifferentBody(t *testing.T) {
+	clients.MustRegister(redis.NewMock())
+	t.Cleanup(clients.ResetRegistry)
+	m := testutil.NewMiddleware[*IdempotencyMiddleware](t, "idempotency", nil)
+
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/contacts", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	doRequest(e, http.MethodPost, "/contacts", "key-1", `{"name":"a"}`)
+	rec := doRequest(e, http.MethodPost, "/contacts", "key-1", `{"name":"b"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), errors.CodeValidation)
+}
+
+func TestIdempotencyMiddleware_ConcurrentDuplicate(t *testing.T) {
+	clients.MustRegister(redis.NewMock())
+	t.Cleanup(clients.ResetRegistry)
+	m := testutil.NewMiddleware[*IdempotencyMiddleware](t, "idempotency", nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/contacts", func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.NoContent(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- doRequest(e, http.MethodPost, "/contacts", "key-1", `{}`)
+	}()
+	<-started
+
+	rec := doRequest(e, http.MethodPost, "/contacts", "key-1", `{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyMiddleware_ErrorReleasesKey(t *testing.T) {
+	clients.MustRegister(redis.NewMock())
+	t.Cleanup(clients.ResetRegistry)
+	m := testutil.NewMiddleware[*IdempotencyMiddleware](t, "idempotency", nil)
+
+	var calls int32
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/contacts", func(c echo.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.Unavailable("try again")
+		}
+		return c.NoContent(http.StatusCreated)
+	})
+
+	first := doRequest(e, http.MethodPost, "/contacts", "key-1", `{}`)
+	assert.Equal(t, http.StatusServiceUnavailable, first.Code)
+
+	second := doRequest(e, http.MethodPost, "/contacts", "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyMiddleware_Passthrough(t *testing.T) {
+	clients.MustRegister(redis.NewMock())
+	t.Cleanup(clients.ResetRegistry)
+	m := testutil.NewMiddleware[*IdempotencyMiddleware](t, "idempotency", &config.IdempotencyMiddlewareConfig{
+		Paths:     []string{"/api/**"},
+		SkipPaths: []string{"/api/internal/*"},
+	})
+
+	var calls int32
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	handler := func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return c.NoContent(http.StatusOK)
+	}
+	e.Any("/*", handler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+	}{
+		{"no header", http.MethodPost, "/api/contacts", ""},
+		{"safe method", http.MethodGet, "/api/contacts", "key-1"},
+		{"path not configured", http.MethodPost, "/other", "key-1"},
+		{"skipped path", http.MethodPost, "/api/internal/jobs", "key-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			doRequest(e, tt.method, tt.path, tt.key, `{}`)
+			rec := doRequest(e, tt.method, tt.path, tt.key, `{}`)
+
+			assert.Empty(t, rec.Header().Get(HeaderReplayed))
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_Enabled(t *testing.T) {
+	m := &IdempotencyMiddleware{}
+
+	t.Run("no store available", func(t *testing.T) {
+		clients.ResetRegistry()
+		assert.False(t, m.Enabled(nil))
+	})
+
+	t.Run("redis registered", func(t *testing.T) {
+		clients.MustRegister(redis.NewMock())
+		t.Cleanup(clients.ResetRegistry)
+
+		assert.True(t, m.Enabled(nil))
+		cfg := config.DefaultMiddlewareConfig().Idempotency
+		cfg.Enabled = false
+		assert.False(t, m.Enabled(&cfg))
+	})
+}
+
+func TestPathApplies(t *testing.T) {
+	assert.True(t, pathApplies("/anything", nil, nil))
+	assert.True(t, pathApplies("/api", []string{"/api/**"}, nil))
+	assert.True(t, pathApplies("/api/v1/contacts", []string{"/api/**"}, nil))
+	assert.False(t, pathApplies("/apiv2", []string{"/api/**"}, nil))
+	assert.True(t, pathApplies("/api/v1/contacts/batch/move", []string{"/api/v1/contacts/batch/*"}, nil))
+	assert.False(t, pathApplies("/api/v1/contacts", []string{"/api/**"}, []string{"/api/v1/*"}))
+}
+
+func TestSQLStore(t *testing.T) {
+	store := NewSQLStore(testdb.New(t), "idempotency_keys")
+	ctx := context.Background()
+
+	existing, claimed, err := store.Claim(ctx, "k1", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, existing)
+
+	existing, claimed, err = store.Claim(ctx, "k1", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Completed)
+
+	rec := &Record{
+		Fingerprint: "fp",
+		Completed:   true,
+		Status:      http.StatusCreated,
+		Header:      http.Header{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":1}`),
+	}
+	require.NoError(t, store.Save(ctx, "k1", rec, time.Hour))
+
+	existing, claimed, err = store.Claim(ctx, "k1", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, rec, existing)
+
+	require.NoError(t, store.Release(ctx, "k1"))
+	_, claimed, err = store.Claim(ctx, "k1", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestSQLStore_ExpiredClaim(t *testing.T) {
+	store := NewSQLStore(testdb.New(t), "idempotency_keys")
+	ctx := context.Background()
+
+	_, claimed, err := store.Claim(ctx, "k1", "fp", -time.Second)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	_, claimed, err = store.Claim(ctx, "k1", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
@@ -0,0 +1,196 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/db"
+)
+
+// Record is the stored state of an idempotency key.
+// A record without Completed set belongs to a request still in flight.
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store persists idempotency records.
+type Store interface {
+	// Claim reserves key for an in-flight request. If the key is already
+	// taken, the existing record is returned and claimed is false.
+	Claim(ctx context.Context, key, fingerprint string, ttl time.Duration) (existing *Record, claimed bool, err error)
+
+	// Save stores the final response for a claimed key.
+	Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+
+	// Release drops a claim so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// RedisStore stores records in Redis.
+type RedisStore struct {
+	client redis.RedisClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed store.
+func NewRedisStore(client redis.RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Claim reserves key with SETNX.
+func (s *RedisStore) Claim(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	pending, err := json.Marshal(&Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The claim can expire between SETNX and GET, so retry a few times.
+	for attempt := 0; attempt < 3; attempt++ {
+		ok, err := s.client.SetNX(ctx, s.prefix+key, string(pending), ttl)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return nil, true, nil
+		}
+
+		raw, err := s.client.Get(ctx, s.prefix+key)
+		if errors.Is(err, goredis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		var rec Record
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, false, fmt.Errorf("invalid idempotency record: %w", err)
+		}
+		return &rec, false, nil
+	}
+
+	return nil, false, fmt.Errorf("failed to claim idempotency key %q", key)
+}
+
+// Save overwrites the claim with the completed record.
+func (s *RedisStore) Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, string(data), ttl)
+}
+
+// Release deletes the claim.
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key)
+}
+
+// SQLStore stores records in a database table, created on first use.
+type SQLStore struct {
+	client *db.Client
+	table  string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewSQLStore creates a database-backed store using the given table.
+func NewSQLStore(client *db.Client, table string) *SQLStore {
+	return &SQLStore{client: client, table: table}
+}
+
+// ensureTable creates the table if it does not exist.
+func (s *SQLStore) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			idempotency_key VARCHAR(255) PRIMARY KEY,
+			record TEXT NOT NULL,
+			expires_at BIGINT NOT NULL
+		)
+	`, s.table)
+
+	if _, err := s.client.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create idempotency table: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+// Claim reserves key by inserting a pending row. The primary key makes
+// concurrent claims for the same key fail.
+func (s *SQLStore) Claim(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+
+	// Expired rows would otherwise block the insert.
+	deleteExpired := s.client.Rebind(fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = ? AND expires_at <= ?", s.table))
+	if _, err := s.client.ExecContext(ctx, deleteExpired, key, now.UnixMilli()); err != nil {
+		return nil, false, err
+	}
+
+	pending, err := json.Marshal(&Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	insert := s.client.Rebind(fmt.Sprintf("INSERT INTO %s (idempotency_key, record, expires_at) VALUES (?, ?, ?)", s.table))
+	_, insertErr := s.client.ExecContext(ctx, insert, key, string(pending), now.Add(ttl).UnixMilli())
+	if insertErr == nil {
+		return nil, true, nil
+	}
+
+	var raw string
+	get := s.client.Rebind(fmt.Sprintf("SELECT record FROM %s WHERE idempotency_key = ?", s.table))
+	if err := s.client.GetContext(ctx, &raw, get, key); err != nil {
+		// No row means the insert failed for another reason.
+		return nil, false, insertErr
+	}
+
+	var rec Record
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		return nil, false, fmt.Errorf("invalid idempotency record: %w", err)
+	}
+	return &rec, false, nil
+}
+
+// Save updates the claimed row with the completed record.
+func (s *SQLStore) Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	update := s.client.Rebind(fmt.Sprintf("UPDATE %s SET record = ?, expires_at = ? WHERE idempotency_key = ?", s.table))
+	_, err = s.client.ExecContext(ctx, update, string(data), time.Now().Add(ttl).UnixMilli(), key)
+	return err
+}
+
+// Release deletes the claimed row.
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	del := s.client.Rebind(fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = ?", s.table))
+	_, err := s.client.ExecContext(ctx, del, key)
+	return err
+}
@@ -1,6 +1,10 @@
 package middleware
 
-import "github.com/labstack/echo/v4"
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/http"
+)
 
 // Middleware defines the interface that all middleware must implement
 type Middleware interface {
@@ -29,6 +33,15 @@ type Middleware interface {
 	Handler() echo.MiddlewareFunc
 }
 
+// Activator is implemented by middleware that publishes process-wide state,
+// such as a store used by package-level helpers. Configure must not publish
+// it, because route overrides configure copies of the middleware; the
+// orchestrator calls Activate on the primary instance once its
+// configuration has been accepted.
+type Activator interface {
+	Activate() error
+}
+
 // Router defines which routers a middleware applies to using a bitmask
 type Router uint8
 
@@ -45,6 +58,18 @@ func (r Router) Includes(target Router) bool {
 	return r&target != 0
 }
 
+// RouterFor returns the Router bit for an http.RouterScope
+func RouterFor(scope http.RouterScope) Router {
+	switch scope {
+	case http.ScopeProtected:
+		return RouterProtected
+	case http.ScopeHidden:
+		return RouterHidden
+	default:
+		return RouterPublic
+	}
+}
+
 // String returns a human-readable representation of the router scope
 func (r Router) String() string {
 	switch r {
@@ -95,11 +120,15 @@ const (
 	PriorityLogger          = 5 // Request/response logging (runs outside ErrorHandler to capture final status)
 	PriorityPagination      = 102 // Pagination parameter extraction (after logger for logging)
 	PriorityAuth            = 105 // Authentication (Kratos session validation)
+	PriorityCSRF            = 107 // CSRF checks (after auth, to exempt non-cookie credentials)
 	PriorityTimeout         = 110 // Request timeout
 	PriorityCORS            = 120 // Cross-origin handling
 	PriorityRateLimit       = 130 // Rate limiting per IP
-	PrioritySecurityHeaders = 140 // XSS, HSTS, etc.
+	PriorityBodyLimit       = 135 // Request body size limits and decompression (after rate limiting, before anything reads the body)
+	PrioritySecurityHeaders = 140 // CSP, HSTS, etc.
 	PriorityCompression     = 150 // Gzip responses
+	PriorityCache           = 155 // ETag, conditional GET and response caching (inside compression, hashes uncompressed bodies)
+	PriorityIdempotency     = 160 // Idempotency-Key replay (inside compression, stores uncompressed responses)
 
 	// Consumer middleware (200+): App-specific middlewares
 	PriorityConsumerMin = 200
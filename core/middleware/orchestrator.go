@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/codoworks/codo-framework/core/config"
 	"github.com/codoworks/codo-framework/core/http"
@@ -14,6 +16,9 @@ type Orchestrator struct {
 	registry *Registry
 	config   *config.Config
 	active   []Middleware // Sorted by priority
+
+	state atomic.Pointer[routeState] // Swapped by Reload
+	mu    sync.Mutex                 // Serializes Reload
 }
 
 // NewOrchestrator creates a new middleware orchestrator
@@ -25,38 +30,116 @@ func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	}
 }
 
-// Initialize configures all enabled middleware and sorts them by priority
+// Initialize configures all enabled middleware and sorts them by priority.
+// Middleware that is disabled but enabled for some routes by
+// middleware.routes is configured too and runs on those routes only.
 func (o *Orchestrator) Initialize() error {
-	allMiddlewares := o.registry.All()
+	state, _, err := o.build(nil)
+	if err != nil {
+		return err
+	}
+	if err := state.activate(nil); err != nil {
+		return err
+	}
+	o.active = state.active()
+	o.state.Store(state)
+	return nil
+}
 
-	for _, m := range allMiddlewares {
-		// Get config section for this middleware
-		cfg := o.getConfigSection(m.ConfigKey())
+// Reload reconfigures middleware from cfg, e.g. after the config file
+// changed. Middleware whose config section is unchanged keeps its handler
+// and state; changed middleware is configured as a new instance, so
+// in-flight requests finish with the old settings. Route rules are
+// recompiled. Returns the names of the reconfigured middleware. On error
+// the current configuration stays in place.
+func (o *Orchestrator) Reload(cfg *config.Config) ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	next := &Orchestrator{registry: o.registry, config: cfg}
+	state, changed, err := next.build(o.state.Load())
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) > 0 {
+		if err := state.activate(changed); err != nil {
+			return nil, err
+		}
+	}
+	o.config, o.active = cfg, state.active()
+	o.state.Store(state)
+	return changed, nil
+}
 
-		// Check base middleware config (dev mode aware)
-		if !o.shouldEnableBasedOnMode(cfg) {
-			continue // Skip based on dev mode rules
+// build configures middleware from o.config. With a previous state, only
+// middleware whose settings changed is configured again, as a copy of the
+// registered instance, and its name is returned.
+func (o *Orchestrator) build(prev *routeState) (*routeState, []string, error) {
+	routeEnabled, err := o.routeEnabled()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state := newRouteState(o.config.Middleware.Routes)
+	var changed []string
+	for _, m := range sortByPriority(o.registry.All()) {
+		name := m.Name()
+		state.sections[name] = o.getConfigSection(m.ConfigKey())
+		state.enabledBy[name] = routeEnabled[name]
+
+		if prev != nil && prev.unchanged(name, state.sections[name], routeEnabled[name]) {
+			state.keep(prev, name)
+			continue
+		}
+		if prev != nil {
+			m = cloneMiddleware(m)
+			changed = append(changed, name)
 		}
 
-		// Let middleware do additional custom checks
-		if !m.Enabled(cfg) {
-			continue // Skip disabled middleware
+		// Get a fresh config section for this middleware
+		cfg := o.getConfigSection(m.ConfigKey())
+
+		// Check base middleware config (dev mode aware), then let middleware
+		// do additional custom checks
+		if !o.shouldEnableBasedOnMode(cfg) || !m.Enabled(cfg) {
+			if !routeEnabled[name] {
+				continue // Skip disabled middleware
+			}
+			cfg = forceEnabled(cfg)
+			if !o.shouldEnableBasedOnMode(cfg) || !m.Enabled(cfg) {
+				continue // Unavailable, e.g. a missing client
+			}
+			state.routeOnly[name] = true
 		}
 
 		// Configure
 		if err := m.Configure(cfg); err != nil {
-			return fmt.Errorf("configure middleware %s: %w", m.Name(), err)
+			return nil, nil, fmt.Errorf("configure middleware %s: %w", name, err)
 		}
+		state.add(m, m.Handler())
+	}
 
-		o.active = append(o.active, m)
+	// Rules reconfigure copies of the middleware, so they are rebuilt
+	// whenever middleware or the rules change
+	if prev != nil && len(changed) == 0 && reflect.DeepEqual(prev.routes, state.routes) {
+		state.rules = prev.rules
+		return state, nil, nil
+	}
+	if err := o.compileRoutes(state); err != nil {
+		return nil, nil, err
 	}
+	return state, changed, nil
+}
 
-	// Sort by priority (stable sort preserves registration order for ties)
-	sort.SliceStable(o.active, func(i, j int) bool {
-		return o.active[i].Priority() < o.active[j].Priority()
+// sortByPriority sorts middleware by priority, then by name
+func sortByPriority(ms []Middleware) []Middleware {
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].Priority() != ms[j].Priority() {
+			return ms[i].Priority() < ms[j].Priority()
+		}
+		return ms[i].Name() < ms[j].Name()
 	})
-
-	return nil
+	return ms
 }
 
 // shouldEnableBasedOnMode checks BaseMiddlewareConfig fields using reflection
@@ -94,13 +177,23 @@ func (o *Orchestrator) shouldEnableBasedOnMode(cfg any) bool {
 	return true
 }
 
-// Apply applies middleware to the given router based on router type
+// Apply applies middleware to the given router. Each route runs the
+// middleware for the router type, adjusted by its handler's
+// http.MiddlewarePolicy and the middleware.routes rules (see Chain).
+// Every registered middleware is wrapped, so Reload can turn middleware
+// on and off without re-applying.
 func (o *Orchestrator) Apply(router *http.Router, routerType Router) {
-	for _, m := range o.active {
-		// Check if this middleware applies to this router type
-		if m.Routers().Includes(routerType) {
-			router.Use(m.Handler())
+	checked := false
+	for _, m := range sortByPriority(o.registry.All()) {
+		// Check requirements inside the error handler so failures render
+		if !checked && m.Priority() > PriorityErrorHandler {
+			router.Use(o.requireAvailable(routerType))
+			checked = true
 		}
+		router.Use(o.routed(m.Name(), routerType))
+	}
+	if !checked {
+		router.Use(o.requireAvailable(routerType))
 	}
 }
 
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath reports whether a request or route path matches a pattern.
+// Patterns use path.Match syntax, where "*" matches a single segment;
+// a trailing "/**" matches the prefix itself and any subpath.
+func MatchPath(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched
+}
+
+// MatchAnyPath reports whether p matches any of the patterns.
+func MatchAnyPath(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/api/v1/contacts", "/api/v1/contacts", true},
+		{"/api/v1/contacts", "/api/v1/contacts/1", false},
+		{"/api/v1/contacts/*", "/api/v1/contacts/1", true},
+		{"/api/v1/contacts/*", "/api/v1/contacts/1/notes", false},
+		{"/api/**", "/api", true},
+		{"/api/**", "/api/v1/contacts/1", true},
+		{"/api/**", "/apiv2", false},
+		{"/api/v1/contacts/:id", "/api/v1/contacts/:id", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPath(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestMatchAnyPath(t *testing.T) {
+	assert.True(t, MatchAnyPath([]string{"/health", "/api/**"}, "/api/v1"))
+	assert.False(t, MatchAnyPath([]string{"/health"}, "/api/v1"))
+	assert.False(t, MatchAnyPath(nil, "/api/v1"))
+}
@@ -0,0 +1,284 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// Rate limit response headers.
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+// Supported KeyBy values.
+const (
+	KeyByIP       = "ip"
+	KeyByIdentity = "identity"
+	KeyByAPIKey   = "api_key"
+)
+
+func init() {
+	middleware.RegisterMiddleware(&RateLimitMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"ratelimit",
+			"middleware.ratelimit",
+			middleware.PriorityRateLimit,
+			middleware.RouterAll,
+		),
+	})
+}
+
+// rule is a resolved rate limit for a request.
+type rule struct {
+	policy   Policy
+	keyBy    string
+	scope    string // Separates per-route budgets from the global one
+	disabled bool
+}
+
+// RateLimitMiddleware limits request rates per client.
+type RateLimitMiddleware struct {
+	middleware.BaseMiddleware
+	store        Store
+	defaults     rule
+	routes       []config.RateLimitRouteConfig
+	apiKeyHeader string
+	skipPaths    []string
+	logger       *logrus.Logger
+}
+
+// Enabled checks if the middleware is enabled
+func (m *RateLimitMiddleware) Enabled(cfg any) bool {
+	if cfg == nil {
+		return false
+	}
+
+	rlCfg, ok := cfg.(*config.RateLimitMiddlewareConfig)
+	if !ok {
+		return false
+	}
+
+	return rlCfg.Enabled
+}
+
+// Configure initializes the middleware and selects the store
+func (m *RateLimitMiddleware) Configure(cfg any) error {
+	defaults := config.DefaultMiddlewareConfig().RateLimit
+	rlCfg, ok := cfg.(*config.RateLimitMiddlewareConfig)
+	if !ok || rlCfg == nil {
+		rlCfg = &defaults
+	}
+
+	m.defaults = rule{
+		policy: Policy{
+			Algorithm: orDefault(rlCfg.Algorithm, defaults.Algorithm),
+			Limit:     rlCfg.Limit,
+			Window:    rlCfg.Window,
+			Burst:     rlCfg.Burst,
+		},
+		keyBy: orDefault(rlCfg.KeyBy, defaults.KeyBy),
+		scope: "global",
+	}
+	if m.defaults.policy.Limit <= 0 {
+		m.defaults.policy.Limit = defaults.Limit
+	}
+	if m.defaults.policy.Window <= 0 {
+		m.defaults.policy.Window = defaults.Window
+	}
+
+	if err := validate(m.defaults.policy.Algorithm, m.defaults.keyBy); err != nil {
+		return err
+	}
+	for _, route := range rlCfg.Routes {
+		if err := validate(route.Algorithm, route.KeyBy); err != nil {
+			return fmt.Errorf("rate limit route %q: %w", route.Path, err)
+		}
+	}
+
+	m.routes = rlCfg.Routes
+	m.apiKeyHeader = orDefault(rlCfg.APIKeyHeader, defaults.APIKeyHeader)
+	m.skipPaths = rlCfg.SkipPaths
+
+	if loggerClient, err := clients.GetTyped[*logger.Logger]("logger"); err == nil {
+		m.logger = loggerClient.GetLogger()
+	}
+
+	switch orDefault(rlCfg.Store, defaults.Store) {
+	case "memory":
+		m.store = NewMemoryStore()
+	case "redis":
+		client, err := clients.GetTyped[redis.RedisClient](redis.ClientName)
+		if err != nil {
+			return fmt.Errorf("rate limit store: %w", err)
+		}
+		m.store = NewRedisStore(client, orDefault(rlCfg.KeyPrefix, defaults.KeyPrefix))
+	default:
+		return fmt.Errorf("unknown rate limit store: %s", rlCfg.Store)
+	}
+
+	return nil
+}
+
+// SetStore replaces the store (useful for testing)
+func (m *RateLimitMiddleware) SetStore(store Store) {
+	m.store = store
+}
+
+// Handler returns the rate limiting middleware function
+func (m *RateLimitMiddleware) Handler() echo.MiddlewareFunc {
+	store := m.store
+	skipPaths := m.skipPaths
+	log := m.logger
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if middleware.MatchAnyPath(skipPaths, c.Request().URL.Path) {
+				return next(c)
+			}
+
+			r := m.resolve(c)
+			if r.disabled {
+				return next(c)
+			}
+
+			key := r.scope + "|" + m.clientKey(c, r.keyBy)
+			res, err := store.Take(c.Request().Context(), key, r.policy)
+			if err != nil {
+				// Fail open: an unavailable store should not take the API down.
+				if log != nil {
+					log.WithError(err).Warn("Rate limit store unavailable, allowing request")
+				}
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderLimit, strconv.Itoa(res.Limit))
+			header.Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+			header.Set(HeaderReset, strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				retryAfter := ceilSeconds(res.RetryAfter)
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+				return errors.TooManyRequests("Rate limit exceeded").
+					WithPhase(errors.PhaseMiddleware).
+					WithRetry(true, time.Duration(retryAfter)*time.Second).
+					WithDetail("limit", res.Limit).
+					WithDetail("window", r.policy.Window.String())
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// resolve picks the rule for a request: the first matching configured route,
+// then the owning handler's RateLimit policy, then the default.
+func (m *RateLimitMiddleware) resolve(c echo.Context) rule {
+	method := c.Request().Method
+	routePath := c.Path()
+	reqPath := c.Request().URL.Path
+
+	for _, route := range m.routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, method) {
+			continue
+		}
+		if !middleware.MatchPath(route.Path, routePath) && !middleware.MatchPath(route.Path, reqPath) {
+			continue
+		}
+		r := m.merge(route.Algorithm, route.Limit, route.Window, route.Burst, route.KeyBy, route.Disabled)
+		r.scope = method + " " + routePath
+		return r
+	}
+
+	if h, ok := http.HandlerFor(c).(http.RateLimitedHandler); ok {
+		if p := h.RateLimit(method, routePath); p != nil {
+			r := m.merge(p.Algorithm, p.Limit, p.Window, p.Burst, p.KeyBy, p.Disabled)
+			r.scope = method + " " + routePath
+			return r
+		}
+	}
+
+	return m.defaults
+}
+
+// merge overlays non-zero override fields onto the default rule.
+func (m *RateLimitMiddleware) merge(algorithm string, limit int, window time.Duration, burst int, keyBy string, disabled bool) rule {
+	r := m.defaults
+	r.disabled = disabled
+	r.keyBy = orDefault(keyBy, r.keyBy)
+	r.policy.Algorithm = orDefault(algorithm, r.policy.Algorithm)
+	if limit > 0 {
+		r.policy.Limit = limit
+		// An inherited burst sized for the default limit no longer applies.
+		r.policy.Burst = 0
+	}
+	if window > 0 {
+		r.policy.Window = window
+	}
+	if burst > 0 {
+		r.policy.Burst = burst
+	}
+	return r
+}
+
+// clientKey identifies the caller. Identity and API key fall back to the
+// client IP when absent.
+func (m *RateLimitMiddleware) clientKey(c echo.Context, keyBy string) string {
+	switch keyBy {
+	case KeyByIdentity:
+		if identity, err := auth.GetIdentity(c); err == nil && identity != nil {
+			return "id:" + identity.ID
+		}
+	case KeyByAPIKey:
+		if apiKey := c.Request().Header.Get(m.apiKeyHeader); apiKey != "" {
+			// Hashed so raw keys never reach the store.
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.RealIP()
+}
+
+func validate(algorithm, keyBy string) error {
+	switch algorithm {
+	case "", AlgorithmTokenBucket, AlgorithmSlidingWindow:
+	default:
+		return fmt.Errorf("unknown rate limit algorithm: %s", algorithm)
+	}
+	switch keyBy {
+	case "", KeyByIP, KeyByIdentity, KeyByAPIKey:
+	default:
+		return fmt.Errorf("unknown rate limit key_by: %s", keyBy)
+	}
+	return nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,314 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	codohttp "github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+func doRequest(e *echo.Echo, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func okHandler(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestRateLimitMiddleware_TokenBucket(t *testing.T) {
+	m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{Limit: 2, Window: time.Minute})
+
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.GET("/contacts", okHandler)
+
+	first := doRequest(e, http.MethodGet, "/contacts", nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get(HeaderLimit))
+	assert.Equal(t, "1", first.Header().Get(HeaderRemaining))
+	assert.Equal(t, "30", first.Header().Get(HeaderReset))
+
+	assert.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/contacts", nil).Code)
+
+	denied := doRequest(e, http.MethodGet, "/contacts", nil)
+	assert.Equal(t, http.StatusTooManyRequests, denied.Code)
+	assert.Equal(t, "0", denied.Header().Get(HeaderRemaining))
+	assert.Equal(t, "30", denied.Header().Get(echo.HeaderRetryAfter))
+	assert.Contains(t, denied.Body.String(), errors.CodeTooManyRequests)
+}
+
+func TestRateLimitMiddleware_SkipPaths(t *testing.T) {
+	m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{
+		Limit:     1,
+		SkipPaths: []string{"/health/**"},
+	})
+
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.GET("/health/alive", okHandler)
+
+	for i := 0; i < 3; i++ {
+		rec := doRequest(e, http.MethodGet, "/health/alive", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderLimit))
+	}
+}
+
+func TestRateLimitMiddleware_RouteOverride(t *testing.T) {
+	m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{
+		Limit: 100,
+		Routes: []config.RateLimitRouteConfig{
+			{Path: "/auth/*", Method: http.MethodPost, Limit: 1},
+			{Path: "/internal/**", Disabled: true},
+		},
+	})
+
+	e := testutil.NewEcho()
+	e.Use(m.Handler())
+	e.POST("/auth/login", okHandler)
+	e.GET("/auth/me", okHandler)
+	e.GET("/internal/jobs", okHandler)
+
+	assert.Equal(t, http.StatusOK, doRequest(e, http.MethodPost, "/auth/login", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(e, http.MethodPost, "/auth/login", nil).Code)
+
+	// Other methods fall back to the default limit and budget.
+	me := doRequest(e, http.MethodGet, "/auth/me", nil)
+	assert.Equal(t, http.StatusOK, me.Code)
+	assert.Equal(t, "100", me.Header().Get(HeaderLimit))
+
+	internal := doRequest(e, http.MethodGet, "/internal/jobs", nil)
+	assert.Equal(t, http.StatusOK, internal.Code)
+	assert.Empty(t, internal.Header().Get(HeaderLimit))
+}
+
+// limitedHandler declares a policy for its export route.
+type limitedHandler struct{}
+
+func (h *limitedHandler) Prefix() string                     { return "/contacts" }
+func (h *limitedHandler) Scope() codohttp.RouterScope        { return codohttp.ScopePublic }
+func (h *limitedHandler) Middlewares() []echo.MiddlewareFunc { return nil }
+func (h *limitedHandler) Initialize() error                  { return nil }
+
+func (h *limitedHandler) Routes(g *echo.Group) {
+	g.GET("", okHandler)
+	g.POST("/export", okHandler)
+}
+
+func (h *limitedHandler) RateLimit(method, path string) *codohttp.RateLimitPolicy {
+	if path == "/contacts/export" {
+		return &codohttp.RateLimitPolicy{Limit: 1, Window: time.Hour}
+	}
+	return nil
+}
+
+func TestRateLimitMiddleware_HandlerPolicy(t *testing.T) {
+	codohttp.ClearHandlers()
+	t.Cleanup(codohttp.ClearHandlers)
+	codohttp.RegisterHandler(&limitedHandler{})
+
+	m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{Limit: 100})
+
+	router := codohttp.NewRouter(codohttp.ScopePublic, ":0")
+	router.Echo().HTTPErrorHandler = testutil.ErrorHandler
+	router.Use(m.Handler())
+	require.NoError(t, router.RegisterHandlers())
+	e := router.Echo()
+
+	export := doRequest(e, http.MethodPost, "/contacts/export", nil)
+	assert.Equal(t, http.StatusOK, export.Code)
+	assert.Equal(t, "1", export.Header().Get(HeaderLimit))
+
+	denied := doRequest(e, http.MethodPost, "/contacts/export", nil)
+	assert.Equal(t, http.StatusTooManyRequests, denied.Code)
+	assert.Equal(t, "3600", denied.Header().Get(echo.HeaderRetryAfter))
+
+	list := doRequest(e, http.MethodGet, "/contacts", nil)
+	assert.Equal(t, http.StatusOK, list.Code)
+	assert.Equal(t, "100", list.Header().Get(HeaderLimit))
+}
+
+func TestRateLimitMiddleware_KeyBy(t *testing.T) {
+	t.Run("identity", func(t *testing.T) {
+		m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{Limit: 1, KeyBy: KeyByIdentity})
+
+		e := testutil.NewEcho()
+		e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				if id := c.Request().Header.Get("X-User"); id != "" {
+					auth.SetIdentity(c, &auth.Identity{ID: id})
+				}
+				return next(c)
+			}
+		})
+		e.Use(m.Handler())
+		e.GET("/", okHandler)
+
+		alice := map[string]string{"X-User": "alice"}
+		bob := map[string]string{"X-User": "bob"}
+		assert.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/", alice).Code)
+		assert.Equal(t, http.StatusTooManyRequests, doRequest(e, http.MethodGet, "/", alice).Code)
+		assert.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/", bob).Code)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		m := testutil.NewMiddleware[*RateLimitMiddleware](t, "ratelimit", &config.RateLimitMiddlewareConfig{Limit: 1, KeyBy: KeyByAPIKey})
+
+		e := testutil.NewEcho()
+		e.Use(m.Handler())
+		e.GET("/", okHandler)
+
+		first := map[string]string{"X-API-Key": "key-1"}
+		second := map[string]string{"X-API-Key": "key-2"}
+		assert.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/", first).Code)
+		assert.Equal(t, http.StatusTooManyRequests, doRequest(e, http.MethodGet, "/", first).Code)
+		assert.Equal(t, http.StatusOK, doRequest(e, http.MethodGet, "/", second).Code)
+	})
+}
+
+func TestRateLimitMiddleware_Configure(t *testing.T) {
+	m := &RateLimitMiddleware{}
+
+	assert.Error(t, m.Configure(&config.RateLimitMiddlewareConfig{Algorithm: "leaky"}))
+	assert.Error(t, m.Configure(&config.RateLimitMiddlewareConfig{KeyBy: "cookie"}))
+	assert.Error(t, m.Configure(&config.RateLimitMiddlewareConfig{Store: "redis"}))
+
+	var nilCfg *config.RateLimitMiddlewareConfig
+	require.NoError(t, m.Configure(nilCfg))
+	assert.Equal(t, 100, m.defaults.policy.Limit)
+	assert.Equal(t, AlgorithmTokenBucket, m.defaults.policy.Algorithm)
+}
+
+func TestRateLimitMiddleware_Enabled(t *testing.T) {
+	m := &RateLimitMiddleware{}
+
+	assert.False(t, m.Enabled(nil))
+	cfg := config.DefaultMiddlewareConfig().RateLimit
+	assert.False(t, m.Enabled(&cfg))
+	cfg.Enabled = true
+	assert.True(t, m.Enabled(&cfg))
+}
+
+// storeTests runs the same scenarios against each store implementation.
+func storeTests(t *testing.T, newStore func(now func() time.Time) Store) {
+	ctx := context.Background()
+	start := time.UnixMilli(1_700_000_080_000) // 40s into a minute window
+
+	t.Run("token bucket refills", func(t *testing.T) {
+		now := start
+		store := newStore(func() time.Time { return now })
+		p := Policy{Algorithm: AlgorithmTokenBucket, Limit: 2, Window: time.Second}
+
+		for i := 0; i < 2; i++ {
+			res, err := store.Take(ctx, "bucket", p)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+		}
+
+		res, err := store.Take(ctx, "bucket", p)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+		now = now.Add(500 * time.Millisecond)
+		res, err = store.Take(ctx, "bucket", p)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+
+	t.Run("token bucket burst", func(t *testing.T) {
+		store := newStore(func() time.Time { return start })
+		p := Policy{Algorithm: AlgorithmTokenBucket, Limit: 1, Window: time.Minute, Burst: 3}
+
+		for i := 0; i < 3; i++ {
+			res, err := store.Take(ctx, "burst", p)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 2-i, res.Remaining)
+		}
+		res, err := store.Take(ctx, "burst", p)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		now := start
+		store := newStore(func() time.Time { return now })
+		p := Policy{Algorithm: AlgorithmSlidingWindow, Limit: 4, Window: time.Minute}
+
+		for i := 0; i < 4; i++ {
+			res, err := store.Take(ctx, "window", p)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+		}
+		res, err := store.Take(ctx, "window", p)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 20*time.Second, res.Reset)
+
+		// 30s into the next window half of the previous count still applies.
+		now = now.Add(50 * time.Second)
+		for i := 0; i < 2; i++ {
+			res, err = store.Take(ctx, "window", p)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed, "request %d", i)
+		}
+		res, err = store.Take(ctx, "window", p)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	storeTests(t, func(now func() time.Time) Store {
+		s := NewMemoryStore()
+		s.now = now
+		return s
+	})
+}
+
+func TestRedisStore(t *testing.T) {
+	storeTests(t, func(now func() time.Time) Store {
+		mr := miniredis.RunT(t)
+		port, err := strconv.Atoi(mr.Port())
+		require.NoError(t, err)
+
+		client := redis.New()
+		require.NoError(t, client.Initialize(&redis.Config{Host: mr.Host(), Port: port}))
+		t.Cleanup(func() { client.Shutdown() })
+
+		s := NewRedisStore(client, "ratelimit:")
+		s.now = now
+		return s
+	})
+}
+
+func TestRedisStore_Error(t *testing.T) {
+	mock := redis.NewMock()
+	s := NewRedisStore(mock, "ratelimit:")
+
+	_, err := s.Take(context.Background(), "k", Policy{Limit: 1, Window: time.Second})
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codoworks/codo-framework/clients/redis"
+)
+
+// tokenBucketScript refills and takes from a bucket stored as a hash.
+// Returns {allowed, tokens} with tokens as a string to keep the fraction.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`
+
+// slidingWindowScript counts requests in the current and previous windows.
+// Returns {allowed, current, previous}.
+const slidingWindowScript = `
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+if prev * weight + cur >= limit then
+	return {0, cur, prev}
+end
+
+cur = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {1, cur, prev}
+`
+
+// RedisStore keeps allowances in Redis so limits are shared across
+// instances. Each take is a single atomic Lua script.
+type RedisStore struct {
+	client redis.RedisClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a Redis-backed store.
+func NewRedisStore(client redis.RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+// Take consumes one request for key under policy.
+func (s *RedisStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	now := s.now()
+	// Hash tags keep a key's windows on the same cluster slot.
+	base := s.prefix + "{" + key + "}"
+	ttl := (2 * p.Window).Milliseconds()
+
+	if p.Algorithm == AlgorithmSlidingWindow {
+		idx, elapsed := windowStart(now, p.Window)
+		weight := 1 - float64(elapsed)/float64(p.Window)
+		keys := []string{
+			base + ":" + strconv.FormatInt(idx, 10),
+			base + ":" + strconv.FormatInt(idx-1, 10),
+		}
+
+		raw, err := s.client.Eval(ctx, slidingWindowScript, keys, p.Limit, weight, ttl)
+		if err != nil {
+			return Result{}, err
+		}
+		vals, err := scriptValues(raw, 3)
+		if err != nil {
+			return Result{}, err
+		}
+		return slidingResult(p, vals[0] == 1, int64(vals[1]), int64(vals[2]), elapsed), nil
+	}
+
+	raw, err := s.client.Eval(ctx, tokenBucketScript, []string{base},
+		p.capacity(), p.refillPerMs(), now.UnixMilli(), ttl)
+	if err != nil {
+		return Result{}, err
+	}
+	vals, err := scriptValues(raw, 2)
+	if err != nil {
+		return Result{}, err
+	}
+	return bucketResult(p, vals[0] == 1, vals[1]), nil
+}
+
+// scriptValues converts a script's array reply into floats.
+func scriptValues(raw any, n int) ([]float64, error) {
+	items, ok := raw.([]any)
+	if !ok || len(items) != n {
+		return nil, fmt.Errorf("unexpected rate limit script reply: %v", raw)
+	}
+
+	vals := make([]float64, n)
+	for i, item := range items {
+		switch v := item.(type) {
+		case int64:
+			vals[i] = float64(v)
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected rate limit script reply: %w", err)
+			}
+			vals[i] = f
+		default:
+			return nil, fmt.Errorf("unexpected rate limit script reply: %v", raw)
+		}
+	}
+	return vals, nil
+}
@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Supported algorithms.
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Policy describes a limit for a single key.
+type Policy struct {
+	Algorithm string
+	Limit     int           // Requests allowed per window
+	Window    time.Duration // Window length
+	Burst     int           // Token bucket capacity, defaults to Limit
+}
+
+// capacity returns the token bucket size.
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return float64(p.Limit)
+}
+
+// refillPerMs returns the token bucket refill rate.
+func (p Policy) refillPerMs() float64 {
+	return float64(p.Limit) / float64(p.Window.Milliseconds())
+}
+
+// Result is the outcome of taking one request from a key's allowance.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the allowance is fully restored
+	RetryAfter time.Duration // Until the next request is allowed, when denied
+}
+
+// Store tracks request allowances.
+type Store interface {
+	// Take consumes one request for key under policy.
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// bucketResult builds a Result from the token count left after a take.
+func bucketResult(p Policy, allowed bool, tokens float64) Result {
+	rate := p.refillPerMs()
+	capacity := p.capacity()
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(capacity),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     millis((capacity - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = millis((1 - tokens) / rate)
+	}
+	return res
+}
+
+// slidingResult builds a Result from the current and previous window counts
+// after a take. elapsed is the time since the current window started.
+func slidingResult(p Policy, allowed bool, cur, prev int64, elapsed time.Duration) Result {
+	weight := 1 - float64(elapsed)/float64(p.Window)
+	estimate := float64(prev)*weight + float64(cur)
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: max(p.Limit-int(math.Ceil(estimate)), 0),
+		Reset:     p.Window - elapsed,
+	}
+	if allowed {
+		return res
+	}
+
+	// Find when the previous window's weighted share has decayed enough.
+	res.RetryAfter = res.Reset
+	if cur < int64(p.Limit) && prev > 0 {
+		wait := time.Duration(float64(p.Window)*(1-float64(int64(p.Limit)-cur)/float64(prev))) - elapsed
+		if wait > 0 && wait < res.RetryAfter {
+			res.RetryAfter = wait
+		}
+	}
+	return res
+}
+
+// millis rounds ms up to a whole millisecond, ignoring float error.
+func millis(ms float64) time.Duration {
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(ms-1e-6)) * time.Millisecond
+}
+
+// windowStart returns the index of the fixed window containing now and how
+// far into it now is.
+func windowStart(now time.Time, window time.Duration) (int64, time.Duration) {
+	ms := window.Milliseconds()
+	idx := now.UnixMilli() / ms
+	return idx, time.Duration(now.UnixMilli()-idx*ms) * time.Millisecond
+}
+
+// MemoryStore keeps allowances in process memory. Limits are per instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryEntry struct {
+	// Token bucket state
+	tokens float64
+	last   time.Time
+
+	// Sliding window state
+	window    int64
+	cur, prev int64
+
+	expires time.Time
+}
+
+// NewMemoryStore creates an in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Take consumes one request for key under policy.
+func (s *MemoryStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &memoryEntry{tokens: p.capacity(), last: now}
+		s.entries[key] = e
+	}
+	e.expires = now.Add(2 * p.Window)
+
+	if p.Algorithm == AlgorithmSlidingWindow {
+		idx, elapsed := windowStart(now, p.Window)
+		switch idx {
+		case e.window:
+		case e.window + 1:
+			e.prev, e.cur = e.cur, 0
+		default:
+			e.prev, e.cur = 0, 0
+		}
+		e.window = idx
+
+		weight := 1 - float64(elapsed)/float64(p.Window)
+		allowed := float64(e.prev)*weight+float64(e.cur) < float64(p.Limit)
+		if allowed {
+			e.cur++
+		}
+		return slidingResult(p, allowed, e.cur, e.prev, elapsed), nil
+	}
+
+	elapsed := float64(now.Sub(e.last).Milliseconds())
+	e.tokens = math.Min(p.capacity(), e.tokens+max(elapsed, 0)*p.refillPerMs())
+	e.last = now
+
+	allowed := e.tokens >= 1
+	if allowed {
+		e.tokens--
+	}
+	return bucketResult(p, allowed, e.tokens), nil
+}
+
+// sweep drops expired entries at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/http"
+)
+
+// Sources of a middleware's presence in a route chain
+const (
+	SourceRouter  = "router"  // On for the router by its Routers() mask
+	SourceHandler = "handler" // Required or skipped by the route's http.MiddlewareHandler
+	SourceConfig  = "config"  // Decided by a middleware.routes rule
+)
+
+// chainContextKey caches the resolved RouteChain on the echo context
+const chainContextKey = "middleware.chain"
+
+// routerContextKey holds the Router type serving the request
+const routerContextKey = "middleware.router"
+
+// RouteMiddleware is one entry of a route's effective middleware chain
+type RouteMiddleware struct {
+	Middleware Middleware
+	Source     string // SourceRouter, SourceHandler or SourceConfig
+	Overridden bool   // Settings overridden by a middleware.routes rule
+
+	handler echo.MiddlewareFunc // Default or reconfigured handler
+}
+
+// RouteChain is the effective middleware chain of a route
+type RouteChain struct {
+	Middleware []RouteMiddleware // In execution order
+	Missing    []string          // Required by the handler but not available
+
+	index map[string]int
+}
+
+// Names returns the names of the middleware in the chain, in order
+func (c *RouteChain) Names() []string {
+	names := make([]string, len(c.Middleware))
+	for i, m := range c.Middleware {
+		names[i] = m.Middleware.Name()
+	}
+	return names
+}
+
+func (c *RouteChain) step(name string) *RouteMiddleware {
+	if i, ok := c.index[name]; ok {
+		return &c.Middleware[i]
+	}
+	return nil
+}
+
+// routeRule is a compiled middleware.routes entry
+type routeRule struct {
+	config.MiddlewareRouteConfig
+	regex    *regexp.Regexp
+	handlers map[string]echo.MiddlewareFunc // Reconfigured middleware by name
+}
+
+func (r *routeRule) matches(method, path string) bool {
+	if len(r.Methods) > 0 && !slices.ContainsFunc(r.Methods, func(m string) bool {
+		return strings.EqualFold(m, method)
+	}) {
+		return false
+	}
+	if r.regex != nil {
+		return r.regex.MatchString(path)
+	}
+	return MatchPath(r.Path, path)
+}
+
+func (r *routeRule) names(name string) bool {
+	_, overridden := r.Override[name]
+	return overridden || slices.Contains(r.Enable, name) || slices.Contains(r.Disable, name)
+}
+
+// cachedMethods are the methods whose chains are cached. Any token is a
+// valid method, so chains for others are built per request rather than
+// letting clients grow the cache without bound.
+var cachedMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+	"CONNECT": true,
+	"TRACE":   true,
+}
+
+type chainKey struct {
+	echo   *echo.Echo
+	method string
+	path   string
+}
+
+// routeState is the configured middleware and route rules requests run
+// with. Reload builds a new state and swaps it in.
+type routeState struct {
+	routable  []Middleware                   // Configured middleware, sorted by priority
+	handlers  map[string]echo.MiddlewareFunc // Default handler by name
+	routeOnly map[string]bool                // Disabled, but enabled for some routes by middleware.routes
+	sections  map[string]any                 // Config section each middleware was built from
+	enabledBy map[string]bool                // Named in a rule's enable list
+	routes    []config.MiddlewareRouteConfig
+	rules     []*routeRule
+	chains    sync.Map // chainKey -> *RouteChain
+}
+
+func newRouteState(routes []config.MiddlewareRouteConfig) *routeState {
+	return &routeState{
+		handlers:  make(map[string]echo.MiddlewareFunc),
+		routeOnly: make(map[string]bool),
+		sections:  make(map[string]any),
+		enabledBy: make(map[string]bool),
+		routes:    routes,
+	}
+}
+
+// add records configured middleware and its default handler
+func (s *routeState) add(m Middleware, handler echo.MiddlewareFunc) {
+	s.routable = append(s.routable, m)
+	s.handlers[m.Name()] = handler
+}
+
+// unchanged reports whether middleware was built from the same settings.
+// Rules enabling it only matter if it is otherwise disabled.
+func (s *routeState) unchanged(name string, section any, enabledBy bool) bool {
+	prev, ok := s.sections[name]
+	if !ok || !reflect.DeepEqual(prev, section) {
+		return false
+	}
+	active := s.middleware(name) != nil && !s.routeOnly[name]
+	return active || s.enabledBy[name] == enabledBy
+}
+
+// keep carries middleware over from a previous state unchanged
+func (s *routeState) keep(prev *routeState, name string) {
+	if m := prev.middleware(name); m != nil {
+		s.add(m, prev.handlers[name])
+		s.routeOnly[name] = prev.routeOnly[name]
+	}
+}
+
+func (s *routeState) middleware(name string) Middleware {
+	for _, m := range s.routable {
+		if m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// active returns the configured middleware that is not route-only
+func (s *routeState) active() []Middleware {
+	active := []Middleware{}
+	for _, m := range s.routable {
+		if !s.routeOnly[m.Name()] {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// activate publishes the process-wide state of the named middleware, or of
+// all configured middleware when names is nil. Route override copies are
+// never activated.
+func (s *routeState) activate(names []string) error {
+	for _, m := range s.routable {
+		a, ok := m.(Activator)
+		if !ok || (names != nil && !slices.Contains(names, m.Name())) {
+			continue
+		}
+		if err := a.Activate(); err != nil {
+			return fmt.Errorf("activate middleware %s: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
+
+// routeEnabled returns the middleware named in any rule's enable list,
+// after checking that every middleware the rules name is registered
+func (o *Orchestrator) routeEnabled() (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	for i, route := range o.config.Middleware.Routes {
+		names := slices.Concat(route.Enable, route.Disable, slices.Collect(maps.Keys(route.Override)))
+		for _, name := range names {
+			if _, ok := o.registry.Get(name); !ok {
+				return nil, fmt.Errorf("middleware.routes[%d]: unknown middleware %q", i, name)
+			}
+		}
+		for _, name := range route.Enable {
+			enabled[name] = true
+		}
+	}
+	return enabled, nil
+}
+
+// compileRoutes builds the route rules, configuring a separate instance of
+// each middleware whose settings a rule overrides
+func (o *Orchestrator) compileRoutes(state *routeState) error {
+	for i, route := range state.routes {
+		rule := &routeRule{MiddlewareRouteConfig: route, handlers: make(map[string]echo.MiddlewareFunc)}
+		if route.Regex != "" {
+			re, err := regexp.Compile(route.Regex)
+			if err != nil {
+				return fmt.Errorf("middleware.routes[%d]: invalid regex: %w", i, err)
+			}
+			rule.regex = re
+		}
+
+		for name, settings := range route.Override {
+			m := state.middleware(name)
+			if m == nil {
+				continue // Not running anywhere, nothing to reconfigure
+			}
+			handler, err := o.reconfigure(m, settings, state.routeOnly[name])
+			if err != nil {
+				return fmt.Errorf("middleware.routes[%d]: override %s: %w", i, name, err)
+			}
+			rule.handlers[name] = handler
+		}
+		state.rules = append(state.rules, rule)
+	}
+	return nil
+}
+
+// reconfigure configures a copy of m with settings overlaid on its config
+// section and returns the copy's handler
+func (o *Orchestrator) reconfigure(m Middleware, settings map[string]any, routeOnly bool) (echo.MiddlewareFunc, error) {
+	cfg, err := overrideSection(o.getConfigSection(m.ConfigKey()), settings)
+	if err != nil {
+		return nil, err
+	}
+	if routeOnly {
+		cfg = forceEnabled(cfg)
+	}
+
+	clone := cloneMiddleware(m)
+	if err := clone.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return clone.Handler(), nil
+}
+
+// overrideSection overlays settings on a config section. Struct sections are
+// decoded as YAML, so keys and value formats match the config file.
+func overrideSection(section any, settings map[string]any) (any, error) {
+	switch s := section.(type) {
+	case nil:
+		return settings, nil
+	case map[string]any:
+		merged := maps.Clone(s)
+		maps.Copy(merged, settings)
+		return merged, nil
+	}
+
+	if val := reflect.ValueOf(section); val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("settings of type %T cannot be overridden", section)
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	// getConfigSection returns a copy, so the section is decoded in place
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+// forceEnabled returns a config section with its Enabled flag set
+func forceEnabled(section any) any {
+	if s, ok := section.(map[string]any); ok {
+		enabled := maps.Clone(s)
+		enabled["enabled"] = true
+		return enabled
+	}
+
+	val := reflect.ValueOf(section)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return section
+	}
+	field := val.Elem().FieldByName("Enabled")
+	if field.IsValid() && field.Kind() == reflect.Bool && field.CanSet() {
+		field.SetBool(true)
+	}
+	return section
+}
+
+// cloneMiddleware returns a shallow copy of a middleware struct so it can be
+// configured independently of the registered instance
+func cloneMiddleware(m Middleware) Middleware {
+	val := reflect.ValueOf(m)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return m
+	}
+	clone := reflect.New(val.Elem().Type())
+	clone.Elem().Set(val.Elem())
+	return clone.Interface().(Middleware)
+}
+
+// Chain returns the effective middleware chain of a route on router,
+// identified by its method and full path pattern.
+func (o *Orchestrator) Chain(router *http.Router, routerType Router, method, path string) *RouteChain {
+	return o.chain(router.Echo(), routerType, method, path)
+}
+
+func (o *Orchestrator) chain(e *echo.Echo, routerType Router, method, path string) *RouteChain {
+	state := o.state.Load()
+	if state == nil {
+		return &RouteChain{index: make(map[string]int)} // Not initialized
+	}
+
+	if !cachedMethods[method] {
+		return state.buildChain(e, routerType, method, path)
+	}
+	key := chainKey{echo: e, method: method, path: path}
+	if chain, ok := state.chains.Load(key); ok {
+		return chain.(*RouteChain)
+	}
+	chain := state.buildChain(e, routerType, method, path)
+	state.chains.Store(key, chain)
+	return chain
+}
+
+// buildChain resolves each middleware for a route: the router mask first,
+// then the handler's MiddlewarePolicy, then the first matching rule naming it
+func (s *routeState) buildChain(e *echo.Echo, routerType Router, method, path string) *RouteChain {
+	var policy *http.MiddlewarePolicy
+	if h, ok := http.HandlerForRoute(e, method, path).(http.MiddlewareHandler); ok {
+		policy = h.MiddlewarePolicy(method, path)
+	}
+
+	chain := &RouteChain{index: make(map[string]int)}
+	if policy != nil {
+		for _, name := range policy.Require {
+			if s.middleware(name) == nil {
+				chain.Missing = append(chain.Missing, name)
+			}
+		}
+	}
+
+	for _, m := range s.routable {
+		name := m.Name()
+		step := RouteMiddleware{Middleware: m, Source: SourceRouter, handler: s.handlers[name]}
+		on := m.Routers().Includes(routerType) && !s.routeOnly[name]
+
+		if policy != nil {
+			if slices.Contains(policy.Require, name) {
+				on, step.Source = true, SourceHandler
+			}
+			if slices.Contains(policy.Skip, name) {
+				on, step.Source = false, SourceHandler
+			}
+		}
+
+		for _, rule := range s.rules {
+			if !rule.names(name) || !rule.matches(method, path) {
+				continue
+			}
+			step.Source = SourceConfig
+			if slices.Contains(rule.Enable, name) {
+				on = true
+			} else if slices.Contains(rule.Disable, name) {
+				on = false
+			}
+			if handler, ok := rule.handlers[name]; ok {
+				step.handler, step.Overridden = handler, true
+			}
+			break
+		}
+
+		if on {
+			chain.index[name] = len(chain.Middleware)
+			chain.Middleware = append(chain.Middleware, step)
+		}
+	}
+	return chain
+}
+
+// routeChain returns the chain for the current request, resolving it once
+// so a request runs with one configuration even if Reload swaps it
+func (o *Orchestrator) routeChain(c echo.Context, routerType Router) *RouteChain {
+	if chain, ok := c.Get(chainContextKey).(*RouteChain); ok {
+		return chain
+	}
+	chain := o.chain(c.Echo(), routerType, c.Request().Method, c.Path())
+	c.Set(chainContextKey, chain)
+	SetRouter(c, routerType)
+	return chain
+}
+
+// RouterOf returns the Router type serving the request, for middleware whose
+// behavior differs by router. It is 0 outside routers set up by Apply.
+func RouterOf(c echo.Context) Router {
+	r, _ := c.Get(routerContextKey).(Router)
+	return r
+}
+
+// SetRouter records the Router type serving the request (useful for testing)
+func SetRouter(c echo.Context, r Router) {
+	c.Set(routerContextKey, r)
+}
+
+// routed runs the named middleware's handler if it is in the route's chain
+func (o *Orchestrator) routed(name string, routerType Router) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			step := o.routeChain(c, routerType).step(name)
+			if step == nil {
+				return next(c)
+			}
+			return step.handler(next)(c)
+		}
+	}
+}
+
+// requireAvailable rejects requests to routes whose handler requires
+// middleware that is not available. It runs inside the error handler.
+func (o *Orchestrator) requireAvailable(routerType Router) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if missing := o.routeChain(c, routerType).Missing; len(missing) > 0 {
+				return errors.Internal("Route requires unavailable middleware").
+					WithPhase(errors.PhaseMiddleware).
+					WithDetail("middleware", missing)
+			}
+			return next(c)
+		}
+	}
+}
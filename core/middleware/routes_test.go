@@ -0,0 +1,352 @@
+package middleware
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/http"
+)
+
+// tagMiddleware appends its name, and its configured timeout if any, to the
+// X-Chain response header
+type tagMiddleware struct {
+	BaseMiddleware
+	timeout time.Duration
+}
+
+func newTagMiddleware(name, configKey string, priority int, routers Router) *tagMiddleware {
+	return &tagMiddleware{BaseMiddleware: NewBaseMiddleware(name, configKey, priority, routers)}
+}
+
+func (m *tagMiddleware) Enabled(cfg any) bool {
+	if t, ok := cfg.(*config.TimeoutMiddlewareConfig); ok {
+		return t.Enabled
+	}
+	return true
+}
+
+func (m *tagMiddleware) Configure(cfg any) error {
+	if t, ok := cfg.(*config.TimeoutMiddlewareConfig); ok {
+		m.timeout = t.Duration
+	}
+	return nil
+}
+
+func (m *tagMiddleware) Handler() echo.MiddlewareFunc {
+	tag := m.Name()
+	if m.timeout > 0 {
+		tag += "=" + m.timeout.String()
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Add("X-Chain", tag)
+			return next(c)
+		}
+	}
+}
+
+// policyHandler declares middleware requirements for its routes
+type policyHandler struct {
+	prefix string
+	policy *http.MiddlewarePolicy
+}
+
+func (h *policyHandler) Prefix() string                                              { return h.prefix }
+func (h *policyHandler) Scope() http.RouterScope                                     { return http.ScopePublic }
+func (h *policyHandler) Middlewares() []echo.MiddlewareFunc                          { return nil }
+func (h *policyHandler) Initialize() error                                           { return nil }
+func (h *policyHandler) Routes(g *echo.Group)                                        { g.GET("", ok) }
+func (h *policyHandler) MiddlewarePolicy(method, path string) *http.MiddlewarePolicy { return h.policy }
+
+func ok(c echo.Context) error { return c.NoContent(nethttp.StatusNoContent) }
+
+func newRoutesOrchestrator(t *testing.T, cfg *config.Config) *Orchestrator {
+	t.Helper()
+
+	registry := &Registry{middlewares: make(map[string]Middleware)}
+	for _, m := range []Middleware{
+		newTagMiddleware("errorhandler", "", PriorityErrorHandler, RouterAll),
+		newTagMiddleware("auth", "", PriorityAuth, RouterProtected),
+		newTagMiddleware("timeout", "middleware.timeout", PriorityTimeout, RouterAll),
+		newTagMiddleware("gzip", "", PriorityCompression, RouterAll),
+	} {
+		registry.middlewares[m.Name()] = m
+	}
+
+	o := &Orchestrator{registry: registry, config: cfg}
+	require.NoError(t, o.Initialize())
+	return o
+}
+
+func serve(r *http.Router, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func chainHeader(rec *httptest.ResponseRecorder) string {
+	return strings.Join(rec.Header().Values("X-Chain"), " ")
+}
+
+func TestOrchestrator_RouteRules(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Timeout.Duration = 30 * time.Second
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/reports/**", Override: map[string]map[string]any{"timeout": {"duration": "5m"}}},
+		{Regex: `^/webhooks/[a-z]+$`, Methods: []string{"post"}, Disable: []string{"gzip", "timeout"}},
+		{Path: "/account", Enable: []string{"auth"}},
+	}
+	o := newRoutesOrchestrator(t, cfg)
+
+	r := http.NewRouter(http.ScopePublic, ":0")
+	o.Apply(r, RouterPublic)
+	for _, path := range []string{"/contacts", "/reports/daily", "/webhooks/stripe", "/account"} {
+		r.Echo().GET(path, ok)
+		r.Echo().POST(path, ok)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/contacts", "errorhandler timeout=30s gzip"},
+		{"GET", "/reports/daily", "errorhandler timeout=5m0s gzip"},
+		{"POST", "/webhooks/stripe", "errorhandler"},
+		{"GET", "/webhooks/stripe", "errorhandler timeout=30s gzip"},
+		{"GET", "/account", "errorhandler auth timeout=30s gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, chainHeader(serve(r, tt.method, tt.path)))
+		})
+	}
+
+	chain := o.Chain(r, RouterPublic, "GET", "/reports/daily")
+	assert.Equal(t, []string{"errorhandler", "timeout", "gzip"}, chain.Names())
+	assert.Equal(t, SourceConfig, chain.Middleware[1].Source)
+	assert.True(t, chain.Middleware[1].Overridden)
+}
+
+func TestOrchestrator_ChainCacheBounded(t *testing.T) {
+	o := newRoutesOrchestrator(t, config.NewWithDefaults())
+
+	r := http.NewRouter(http.ScopePublic, ":0")
+	o.Apply(r, RouterPublic)
+	r.Echo().GET("/contacts/:id", ok)
+
+	for i := range 100 {
+		serve(r, "GET", "/contacts/"+strconv.Itoa(i))
+		serve(r, "FOO"+strconv.Itoa(i), "/contacts/1")
+		serve(r, "GET", "/missing/"+strconv.Itoa(i))
+	}
+
+	cached := 0
+	o.state.Load().chains.Range(func(_, _ any) bool {
+		cached++
+		return true
+	})
+	assert.LessOrEqual(t, cached, 2, "one chain per route pattern and standard method")
+
+	// Other methods still get their chain
+	assert.Equal(t, "errorhandler timeout=1m0s gzip", chainHeader(serve(r, "FOO", "/contacts/1")))
+}
+
+func TestOrchestrator_RouteEnablesDisabledMiddleware(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Timeout.Enabled = false
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/slow", Enable: []string{"timeout"}},
+	}
+	o := newRoutesOrchestrator(t, cfg)
+
+	assert.NotContains(t, names(o.ListAll()), "timeout")
+
+	r := http.NewRouter(http.ScopePublic, ":0")
+	o.Apply(r, RouterPublic)
+	r.Echo().GET("/slow", ok)
+	r.Echo().GET("/fast", ok)
+
+	assert.Equal(t, "errorhandler timeout=1m0s gzip", chainHeader(serve(r, "GET", "/slow")))
+	assert.Equal(t, "errorhandler gzip", chainHeader(serve(r, "GET", "/fast")))
+}
+
+func TestOrchestrator_HandlerMiddlewarePolicy(t *testing.T) {
+	saved := http.AllHandlers()
+	http.ClearHandlers()
+	t.Cleanup(func() {
+		http.ClearHandlers()
+		for _, h := range saved {
+			http.RegisterHandler(h)
+		}
+	})
+
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/overridden", Disable: []string{"auth"}},
+	}
+	o := newRoutesOrchestrator(t, cfg)
+
+	http.RegisterHandler(&policyHandler{prefix: "/me", policy: &http.MiddlewarePolicy{Require: []string{"auth"}, Skip: []string{"gzip"}}})
+	http.RegisterHandler(&policyHandler{prefix: "/overridden", policy: &http.MiddlewarePolicy{Require: []string{"auth"}}})
+	http.RegisterHandler(&policyHandler{prefix: "/broken", policy: &http.MiddlewarePolicy{Require: []string{"csrf"}}})
+
+	r := http.NewRouter(http.ScopePublic, ":0")
+	o.Apply(r, RouterPublic)
+	require.NoError(t, r.RegisterHandlers())
+
+	assert.Equal(t, "errorhandler auth timeout=1m0s", chainHeader(serve(r, "GET", "/me")))
+	assert.Equal(t, "errorhandler timeout=1m0s gzip", chainHeader(serve(r, "GET", "/overridden")))
+
+	chain := o.Chain(r, RouterPublic, "GET", "/me")
+	assert.Equal(t, SourceHandler, chain.Middleware[1].Source)
+
+	rec := serve(r, "GET", "/broken")
+	assert.Equal(t, nethttp.StatusInternalServerError, rec.Code)
+	assert.Equal(t, []string{"csrf"}, o.Chain(r, RouterPublic, "GET", "/broken").Missing)
+}
+
+func TestOrchestrator_UnknownRouteMiddleware(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/a", Disable: []string{"csrf"}},
+	}
+	o := &Orchestrator{registry: &Registry{middlewares: make(map[string]Middleware)}, config: cfg}
+
+	err := o.Initialize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown middleware "csrf"`)
+}
+
+func TestOrchestrator_OverrideUnknownSetting(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/a", Override: map[string]map[string]any{"timeout": {"duraton": "5m"}}},
+	}
+	registry := &Registry{middlewares: map[string]Middleware{
+		"timeout": newTagMiddleware("timeout", "middleware.timeout", PriorityTimeout, RouterAll),
+	}}
+	o := &Orchestrator{registry: registry, config: cfg}
+
+	err := o.Initialize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "override timeout")
+}
+
+func names(ms []Middleware) []string {
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.Name()
+	}
+	return out
+}
+
+func TestOrchestrator_Reload(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Timeout.Duration = 30 * time.Second
+	o := newRoutesOrchestrator(t, cfg)
+
+	r := http.NewRouter(http.ScopePublic, ":0")
+	o.Apply(r, RouterPublic)
+	r.Echo().GET("/reports", ok)
+	assert.Equal(t, "errorhandler timeout=30s gzip", chainHeader(serve(r, "GET", "/reports")))
+	gzip := o.Chain(r, RouterPublic, "GET", "/reports").Middleware[2].Middleware
+
+	next := cfg.Clone()
+	next.Middleware.Timeout.Duration = 10 * time.Second
+	next.Middleware.Routes = []config.MiddlewareRouteConfig{{Path: "/reports", Enable: []string{"auth"}}}
+	changed, err := o.Reload(next)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"timeout"}, changed)
+
+	assert.Equal(t, "errorhandler auth timeout=10s gzip", chainHeader(serve(r, "GET", "/reports")))
+	assert.Same(t, gzip, o.Chain(r, RouterPublic, "GET", "/reports").Middleware[3].Middleware, "unchanged middleware is kept")
+
+	next = next.Clone()
+	next.Middleware.Timeout.Enabled = false
+	_, err = o.Reload(next)
+	require.NoError(t, err)
+	assert.Equal(t, "errorhandler auth gzip", chainHeader(serve(r, "GET", "/reports")))
+
+	broken := next.Clone()
+	broken.Middleware.Routes = []config.MiddlewareRouteConfig{{Path: "/a", Disable: []string{"csrf"}}}
+	_, err = o.Reload(broken)
+	require.Error(t, err)
+	assert.Equal(t, "errorhandler auth gzip", chainHeader(serve(r, "GET", "/reports")), "failed reload keeps the current config")
+}
+
+// activatedMiddleware records the timeout of every activated instance;
+// copies share the record
+type activatedMiddleware struct {
+	tagMiddleware
+	activated *[]time.Duration
+}
+
+func (m *activatedMiddleware) Activate() error {
+	*m.activated = append(*m.activated, m.timeout)
+	return nil
+}
+
+func TestOrchestrator_ActivatesPrimaryOnly(t *testing.T) {
+	cfg := config.NewWithDefaults()
+	cfg.Middleware.Timeout.Duration = 30 * time.Second
+	cfg.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/reports", Override: map[string]map[string]any{"timeout": {"duration": "5m"}}},
+	}
+
+	var activated []time.Duration
+	m := &activatedMiddleware{
+		tagMiddleware: *newTagMiddleware("timeout", "middleware.timeout", PriorityTimeout, RouterAll),
+		activated:     &activated,
+	}
+	registry := &Registry{middlewares: map[string]Middleware{m.Name(): m}}
+	o := &Orchestrator{registry: registry, config: cfg}
+	require.NoError(t, o.Initialize())
+	assert.Equal(t, []time.Duration{30 * time.Second}, activated, "route override copies are not activated")
+
+	// A reload that only changes the rules keeps the active instance
+	next := cfg.Clone()
+	next.Middleware.Routes = []config.MiddlewareRouteConfig{
+		{Path: "/reports", Override: map[string]map[string]any{"timeout": {"duration": "1m"}}},
+	}
+	_, err := o.Reload(next)
+	require.NoError(t, err)
+	assert.Len(t, activated, 1)
+
+	next = next.Clone()
+	next.Middleware.Timeout.Duration = 10 * time.Second
+	_, err = o.Reload(next)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{30 * time.Second, 10 * time.Second}, activated)
+
+	broken := next.Clone()
+	broken.Middleware.Timeout.Duration = 20 * time.Second
+	broken.Middleware.Routes = []config.MiddlewareRouteConfig{{Path: "/a", Disable: []string{"csrf"}}}
+	_, err = o.Reload(broken)
+	require.Error(t, err)
+	assert.Len(t, activated, 2, "failed reload activates nothing")
+}
+
+func TestOrchestrator_RouterOf(t *testing.T) {
+	o := newRoutesOrchestrator(t, config.NewWithDefaults())
+
+	r := http.NewRouter(http.ScopeHidden, ":0")
+	o.Apply(r, RouterHidden)
+	r.Echo().GET("/router", func(c echo.Context) error {
+		return c.String(nethttp.StatusOK, RouterOf(c).String())
+	})
+
+	assert.Equal(t, "hidden", serve(r, "GET", "/router").Body.String())
+
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	assert.Equal(t, Router(0), RouterOf(c))
+}
@@ -0,0 +1,123 @@
+package securityheaders
+
+import (
+	"sort"
+	"strings"
+)
+
+// Nonce is the CSP source replaced by the request's nonce, e.g.
+// NewCSP().Add("script-src", "'self'", Nonce)
+const Nonce = "'nonce'"
+
+// CSP builds a Content-Security-Policy header value
+type CSP struct {
+	directives map[string][]string
+}
+
+// NewCSP creates an empty policy
+func NewCSP() *CSP {
+	return &CSP{directives: make(map[string][]string)}
+}
+
+// CSPFromMap creates a policy from directives mapped to their sources, as in
+// the csp config section
+func CSPFromMap(directives map[string][]string) *CSP {
+	p := NewCSP()
+	for directive, sources := range directives {
+		p.Set(directive, sources...)
+	}
+	return p
+}
+
+// Add appends sources to a directive. Directives without sources, such as
+// upgrade-insecure-requests, are added with none.
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	directive = strings.ToLower(directive)
+	p.directives[directive] = append(p.directives[directive], sources...)
+	return p
+}
+
+// Set replaces a directive's sources
+func (p *CSP) Set(directive string, sources ...string) *CSP {
+	p.directives[strings.ToLower(directive)] = append([]string{}, sources...)
+	return p
+}
+
+// Remove removes a directive
+func (p *CSP) Remove(directive string) *CSP {
+	delete(p.directives, strings.ToLower(directive))
+	return p
+}
+
+// Has reports whether the policy has a directive
+func (p *CSP) Has(directive string) bool {
+	_, ok := p.directives[strings.ToLower(directive)]
+	return ok
+}
+
+// HasNonce reports whether any directive uses the Nonce source
+func (p *CSP) HasNonce() bool {
+	for _, sources := range p.directives {
+		for _, source := range sources {
+			if source == Nonce {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the policy with default-src first and the other directives
+// sorted. Nonce sources are left as Nonce; see Render.
+func (p *CSP) String() string {
+	names := make([]string, 0, len(p.directives))
+	for name := range p.directives {
+		if name != "default-src" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if p.Has("default-src") {
+		names = append([]string{"default-src"}, names...)
+	}
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, strings.TrimSpace(name+" "+strings.Join(p.directives[name], " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Render returns the policy with Nonce sources replaced by nonce
+func (p *CSP) Render(nonce string) string {
+	return renderNonce(p.String(), nonce)
+}
+
+func renderNonce(policy, nonce string) string {
+	return strings.ReplaceAll(policy, Nonce, "'nonce-"+nonce+"'")
+}
+
+// permissionsPolicy formats a Permissions-Policy header value, e.g.
+// camera=(), geolocation=(self "https://maps.example.com")
+func permissionsPolicy(features map[string][]string) string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		allow := make([]string, 0, len(features[name]))
+		for _, origin := range features[name] {
+			switch {
+			case origin == "self", origin == "*", strings.HasPrefix(origin, `"`):
+				allow = append(allow, origin)
+			default:
+				allow = append(allow, `"`+origin+`"`)
+			}
+		}
+		parts = append(parts, name+"=("+strings.Join(allow, " ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,197 @@
+package securityheaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/codoworks/codo-framework/clients/logger"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/http"
+)
+
+// maxReportSize limits the size of a report request body
+const maxReportSize = 64 << 10
+
+func init() {
+	http.RegisterHandler(&ReportHandler{})
+}
+
+// Violation is a CSP violation report, in either the report-uri format or
+// the Reporting API format
+type Violation struct {
+	DocumentURL        string
+	EffectiveDirective string
+	BlockedURL         string
+	Disposition        string // "enforce" or "report"
+	SourceFile         string
+	LineNumber         int
+	ColumnNumber       int
+	Sample             string
+}
+
+// ReportHandler receives CSP violation reports on the public router at
+// report_path and logs them as warnings
+type ReportHandler struct {
+	path   string
+	logger *logrus.Logger
+}
+
+// Prefix returns the report path, or "" when reports are disabled
+func (h *ReportHandler) Prefix() string {
+	return h.path
+}
+
+// Scope returns the router scope (Public only - browsers send reports without credentials)
+func (h *ReportHandler) Scope() http.RouterScope {
+	return http.ScopePublic
+}
+
+// Middlewares returns handler-specific middlewares (none needed)
+func (h *ReportHandler) Middlewares() []echo.MiddlewareFunc {
+	return nil
+}
+
+// Initialize reads the report path from config and gets the logger
+func (h *ReportHandler) Initialize() error {
+	cfg := config.DefaultMiddlewareConfig().SecurityHeaders
+	if global := http.GetGlobalConfig(); global != nil {
+		cfg = global.Middleware.SecurityHeaders
+	}
+
+	h.path = ""
+	if cfg.Enabled {
+		h.path = cfg.ReportPath
+	}
+
+	h.logger = logrus.StandardLogger()
+	if loggerClient, err := clients.GetTyped[*logger.Logger](logger.ClientName); err == nil {
+		h.logger = loggerClient.GetLogger()
+	}
+	return nil
+}
+
+// Routes registers the report route
+func (h *ReportHandler) Routes(g *echo.Group) {
+	if h.path == "" {
+		return // Reports disabled
+	}
+	g.POST("", h.handleReport)
+}
+
+// handleReport logs each violation in the request
+func (h *ReportHandler) handleReport(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxReportSize+1))
+	if err != nil {
+		return errors.BadRequest("Invalid CSP report").WithCause(err)
+	}
+	if len(body) > maxReportSize {
+		return errors.BadRequest("CSP report too large")
+	}
+
+	violations, err := ParseReport(body)
+	if err != nil {
+		return errors.BadRequest("Invalid CSP report").WithCause(err)
+	}
+
+	for _, v := range violations {
+		h.logger.WithFields(logrus.Fields{
+			"document_url":        v.DocumentURL,
+			"effective_directive": v.EffectiveDirective,
+			"blocked_url":         v.BlockedURL,
+			"disposition":         v.Disposition,
+			"source_file":         v.SourceFile,
+			"line_number":         v.LineNumber,
+			"column_number":       v.ColumnNumber,
+			"sample":              v.Sample,
+			"user_agent":          c.Request().UserAgent(),
+		}).Warn("CSP violation")
+	}
+
+	return c.NoContent(nethttp.StatusNoContent)
+}
+
+// legacyReport is the report-uri format, sent as application/csp-report
+type legacyReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is the Reporting API format, sent as
+// application/reports+json in batches
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedURL         string `json:"blockedURL"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// ParseReport parses a report request body in either format. Reporting API
+// reports of other types are skipped.
+func ParseReport(body []byte) ([]Violation, error) {
+	var batch []reportingAPIReport
+	if err := json.Unmarshal(body, &batch); err == nil {
+		violations := make([]Violation, 0, len(batch))
+		for _, r := range batch {
+			if r.Type != "csp-violation" {
+				continue
+			}
+			violations = append(violations, Violation{
+				DocumentURL:        r.Body.DocumentURL,
+				EffectiveDirective: r.Body.EffectiveDirective,
+				BlockedURL:         r.Body.BlockedURL,
+				Disposition:        r.Body.Disposition,
+				SourceFile:         r.Body.SourceFile,
+				LineNumber:         r.Body.LineNumber,
+				ColumnNumber:       r.Body.ColumnNumber,
+				Sample:             r.Body.Sample,
+			})
+		}
+		return violations, nil
+	}
+
+	var legacy legacyReport
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+	r := legacy.Report
+	directive := r.EffectiveDirective
+	if directive == "" {
+		directive = r.ViolatedDirective
+	}
+	if directive == "" && r.DocumentURI == "" {
+		return nil, fmt.Errorf("no csp-report")
+	}
+	return []Violation{{
+		DocumentURL:        r.DocumentURI,
+		EffectiveDirective: directive,
+		BlockedURL:         r.BlockedURI,
+		Disposition:        r.Disposition,
+		SourceFile:         r.SourceFile,
+		LineNumber:         r.LineNumber,
+		ColumnNumber:       r.ColumnNumber,
+		Sample:             r.ScriptSample,
+	}}, nil
+}
@@ -0,0 +1,263 @@
+// Package securityheaders sets browser security headers: a
+// Content-Security-Policy with per-request nonces, Referrer-Policy,
+// Permissions-Policy, the cross-origin isolation headers, HSTS and the
+// legacy X- headers. Headers can differ by router, and CSP violation
+// reports are received and logged on the public router.
+package securityheaders
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// CSP header names
+const (
+	HeaderCSP           = "Content-Security-Policy"
+	HeaderCSPReportOnly = "Content-Security-Policy-Report-Only"
+)
+
+// routerNames maps the routers key in config to router types
+var routerNames = map[string]middleware.Router{
+	"public":    middleware.RouterPublic,
+	"protected": middleware.RouterProtected,
+	"hidden":    middleware.RouterHidden,
+}
+
+func init() {
+	middleware.RegisterMiddleware(&SecurityHeadersMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware(
+			"securityheaders",
+			"middleware.securityheaders",
+			middleware.PrioritySecurityHeaders,
+			middleware.RouterAll,
+		),
+	})
+}
+
+// SecurityHeadersMiddleware sets security headers on every response
+type SecurityHeadersMiddleware struct {
+	middleware.BaseMiddleware
+	base    *headerSet                       // Outside routers set up by the orchestrator
+	routers map[middleware.Router]*headerSet // By router
+}
+
+// headerSet is the headers sent on one router
+type headerSet struct {
+	headers   [][2]string // Name and value
+	cspHeader string
+	csp       string // With Nonce sources
+	nonce     bool   // csp has Nonce sources
+}
+
+// Enabled checks if the middleware is enabled
+func (m *SecurityHeadersMiddleware) Enabled(cfg any) bool {
+	if cfg == nil {
+		return true // Enabled by default
+	}
+
+	shCfg, ok := cfg.(*config.SecurityHeadersMiddlewareConfig)
+	if !ok {
+		return true
+	}
+
+	return shCfg.Enabled
+}
+
+// Configure builds the headers of each router from config
+func (m *SecurityHeadersMiddleware) Configure(cfg any) error {
+	shCfg, ok := cfg.(*config.SecurityHeadersMiddlewareConfig)
+	if !ok || shCfg == nil {
+		defaults := config.DefaultMiddlewareConfig().SecurityHeaders
+		shCfg = &defaults
+	}
+	if err := shCfg.Validate(); err != nil {
+		return err
+	}
+
+	m.base = newHeaderSet(shCfg.SecurityHeadersConfig, shCfg.CSPReportOnly, "")
+	m.routers = make(map[middleware.Router]*headerSet, len(routerNames))
+	for name, router := range routerNames {
+		// The report endpoint is only served on the public router
+		reportPath := ""
+		if router == middleware.RouterPublic {
+			reportPath = shCfg.ReportPath
+		}
+		merged := merge(shCfg.SecurityHeadersConfig, shCfg.Routers[name])
+		m.routers[router] = newHeaderSet(merged, shCfg.CSPReportOnly, reportPath)
+	}
+	return nil
+}
+
+// Handler returns the security headers middleware function
+func (m *SecurityHeadersMiddleware) Handler() echo.MiddlewareFunc {
+	base := m.base
+	if base == nil {
+		base = &headerSet{}
+	}
+	routers := m.routers
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			set := base
+			if s, ok := routers[middleware.RouterOf(c)]; ok {
+				set = s
+			}
+
+			h := c.Response().Header()
+			for _, header := range set.headers {
+				h.Set(header[0], header[1])
+			}
+			if set.csp != "" {
+				policy := set.csp
+				if set.nonce {
+					nonce, err := requestNonce(c)
+					if err != nil {
+						return err
+					}
+					policy = renderNonce(policy, nonce)
+				}
+				h.Set(set.cspHeader, policy)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RouteCSP returns route middleware that replaces the Content-Security-Policy
+// for the routes it wraps, e.g. a page embedding third-party widgets. It
+// keeps the report-only mode and the nonce of the router's policy.
+//
+//	g.GET("/checkout", h.Checkout, securityheaders.RouteCSP(
+//		securityheaders.NewCSP().
+//			Add("default-src", "'self'").
+//			Add("script-src", "'self'", securityheaders.Nonce, "https://js.stripe.com").
+//			Add("frame-src", "https://js.stripe.com"),
+//	))
+func RouteCSP(p *CSP) echo.MiddlewareFunc {
+	policy, nonce := p.String(), p.HasNonce()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			h := c.Response().Header()
+			header := HeaderCSP
+			if h.Get(HeaderCSPReportOnly) != "" {
+				header = HeaderCSPReportOnly
+			}
+
+			value := policy
+			if nonce {
+				n, err := requestNonce(c)
+				if err != nil {
+					return err
+				}
+				value = renderNonce(value, n)
+			}
+			h.Set(header, value)
+
+			return next(c)
+		}
+	}
+}
+
+// newHeaderSet builds the headers for h. The report URI defaults to
+// reportPath, the report endpoint when it is served on the same router.
+func newHeaderSet(h config.SecurityHeadersConfig, reportOnly bool, reportPath string) *headerSet {
+	set := &headerSet{}
+	add := func(name, value string) {
+		if value != "" {
+			set.headers = append(set.headers, [2]string{name, value})
+		}
+	}
+
+	add("Referrer-Policy", h.ReferrerPolicy)
+	if len(h.PermissionsPolicy) > 0 {
+		add("Permissions-Policy", permissionsPolicy(h.PermissionsPolicy))
+	}
+	add("Cross-Origin-Opener-Policy", h.CrossOriginOpenerPolicy)
+	add("Cross-Origin-Embedder-Policy", h.CrossOriginEmbedderPolicy)
+	add("Cross-Origin-Resource-Policy", h.CrossOriginResourcePolicy)
+	add("X-Frame-Options", h.FrameOptions)
+	add("X-Content-Type-Options", h.ContentTypeOptions)
+	add("Strict-Transport-Security", h.StrictTransportSecurity)
+	add("X-XSS-Protection", h.XSSProtection)
+
+	if len(h.CSP) == 0 {
+		return set
+	}
+	csp := CSPFromMap(h.CSP)
+	reportURI := h.ReportURI
+	if reportURI == "" {
+		reportURI = reportPath
+	}
+	if reportURI != "" && !csp.Has("report-uri") {
+		csp.Set("report-uri", reportURI)
+	}
+
+	set.cspHeader = HeaderCSP
+	if reportOnly {
+		set.cspHeader = HeaderCSPReportOnly
+	}
+	set.csp = csp.String()
+	set.nonce = csp.HasNonce()
+	return set
+}
+
+// merge applies a router's headers over the top-level ones. Empty values
+// keep the top-level value, "-" removes it, and maps replace it whole.
+func merge(base, router config.SecurityHeadersConfig) config.SecurityHeadersConfig {
+	pick := func(b, r string) string {
+		switch r {
+		case "":
+			return b
+		case "-":
+			return ""
+		default:
+			return r
+		}
+	}
+
+	merged := config.SecurityHeadersConfig{
+		CSP:                       base.CSP,
+		ReportURI:                 pick(base.ReportURI, router.ReportURI),
+		ReferrerPolicy:            pick(base.ReferrerPolicy, router.ReferrerPolicy),
+		PermissionsPolicy:         base.PermissionsPolicy,
+		CrossOriginOpenerPolicy:   pick(base.CrossOriginOpenerPolicy, router.CrossOriginOpenerPolicy),
+		CrossOriginEmbedderPolicy: pick(base.CrossOriginEmbedderPolicy, router.CrossOriginEmbedderPolicy),
+		CrossOriginResourcePolicy: pick(base.CrossOriginResourcePolicy, router.CrossOriginResourcePolicy),
+		FrameOptions:              pick(base.FrameOptions, router.FrameOptions),
+		ContentTypeOptions:        pick(base.ContentTypeOptions, router.ContentTypeOptions),
+		StrictTransportSecurity:   pick(base.StrictTransportSecurity, router.StrictTransportSecurity),
+		XSSProtection:             pick(base.XSSProtection, router.XSSProtection),
+	}
+	if router.CSP != nil {
+		merged.CSP = router.CSP
+	}
+	if router.PermissionsPolicy != nil {
+		merged.PermissionsPolicy = router.PermissionsPolicy
+	}
+	return merged
+}
+
+// requestNonce returns the request's nonce, creating it on first use
+func requestNonce(c echo.Context) (string, error) {
+	if nonce, ok := c.Get(http.CSPNonceKey).(string); ok && nonce != "" {
+		return nonce, nil
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WrapInternal(err, "Failed to generate CSP nonce").
+			WithPhase(errors.PhaseMiddleware)
+	}
+	nonce := base64.StdEncoding.EncodeToString(b)
+	c.Set(http.CSPNonceKey, nonce)
+	return nonce, nil
+}
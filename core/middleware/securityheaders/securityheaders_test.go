@@ -0,0 +1,338 @@
+package securityheaders
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/http"
+	"github.com/codoworks/codo-framework/core/middleware"
+	"github.com/codoworks/codo-framework/testutil"
+)
+
+func defaultConfig() *config.SecurityHeadersMiddlewareConfig {
+	cfg := config.DefaultMiddlewareConfig().SecurityHeaders
+	return &cfg
+}
+
+// serve runs a request through the middleware as if on router, and returns
+// the response and the nonce the handler saw
+func serve(m *SecurityHeadersMiddleware, router middleware.Router, extra ...echo.MiddlewareFunc) (*httptest.ResponseRecorder, string) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if router != 0 {
+				middleware.SetRouter(c, router)
+			}
+			return next(c)
+		}
+	})
+	e.Use(m.Handler())
+
+	var nonce string
+	e.GET("/", http.WrapHandler(func(c *http.Context) error {
+		nonce = c.CSPNonce()
+		return c.NoContent()
+	}), extra...)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/", nil))
+	return rec, nonce
+}
+
+func TestSecurityHeadersMiddleware_Enabled(t *testing.T) {
+	m := &SecurityHeadersMiddleware{}
+
+	assert.True(t, m.Enabled(nil))
+	assert.True(t, m.Enabled("invalid"))
+	assert.True(t, m.Enabled(&config.SecurityHeadersMiddlewareConfig{BaseMiddlewareConfig: config.BaseMiddlewareConfig{Enabled: true}}))
+	assert.False(t, m.Enabled(&config.SecurityHeadersMiddlewareConfig{}))
+}
+
+func TestSecurityHeadersMiddleware_Registered(t *testing.T) {
+	m, ok := middleware.GetGlobalRegistry().Get("securityheaders")
+	require.True(t, ok)
+	assert.Equal(t, middleware.PrioritySecurityHeaders, m.Priority())
+	assert.Equal(t, middleware.RouterAll, m.Routers())
+}
+
+func TestSecurityHeadersMiddleware_Defaults(t *testing.T) {
+	rec, nonce := serve(testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", nil), middleware.RouterPublic)
+
+	h := rec.Header()
+	assert.Equal(t, "strict-origin-when-cross-origin", h.Get("Referrer-Policy"))
+	assert.Equal(t, "camera=(), geolocation=(), microphone=()", h.Get("Permissions-Policy"))
+	assert.Equal(t, "same-origin", h.Get("Cross-Origin-Opener-Policy"))
+	assert.Equal(t, "same-origin", h.Get("Cross-Origin-Resource-Policy"))
+	assert.Empty(t, h.Get("Cross-Origin-Embedder-Policy"))
+	assert.Equal(t, "SAMEORIGIN", h.Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Equal(t, "max-age=31536000", h.Get("Strict-Transport-Security"))
+	assert.Equal(t, "0", h.Get("X-XSS-Protection"))
+
+	require.NotEmpty(t, nonce)
+	raw, err := base64.StdEncoding.DecodeString(nonce)
+	require.NoError(t, err)
+	assert.Len(t, raw, 16)
+
+	assert.Empty(t, h.Get(HeaderCSP), "report-only by default")
+	csp := h.Get(HeaderCSPReportOnly)
+	assert.True(t, strings.HasPrefix(csp, "default-src 'self'; "), csp)
+	assert.Contains(t, csp, "script-src 'self' 'nonce-"+nonce+"'")
+	assert.Contains(t, csp, "style-src 'self' 'nonce-"+nonce+"'")
+	assert.Contains(t, csp, "report-uri /csp-report")
+	assert.NotContains(t, csp, Nonce)
+}
+
+func TestSecurityHeadersMiddleware_NoncePerRequest(t *testing.T) {
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", nil)
+
+	_, first := serve(m, middleware.RouterPublic)
+	_, second := serve(m, middleware.RouterPublic)
+	assert.NotEqual(t, first, second)
+}
+
+func TestSecurityHeadersMiddleware_RouterDefaults(t *testing.T) {
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", nil)
+
+	for _, router := range []middleware.Router{middleware.RouterProtected, middleware.RouterHidden} {
+		t.Run(router.String(), func(t *testing.T) {
+			rec, nonce := serve(m, router)
+			assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rec.Header().Get(HeaderCSPReportOnly), "the report endpoint is only on the public router")
+			assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), "top-level headers apply")
+			assert.Empty(t, nonce, "no nonce without a nonce source")
+		})
+	}
+}
+
+func TestSecurityHeadersMiddleware_RouterOverrides(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Routers = map[string]config.SecurityHeadersConfig{
+		"public": {
+			ReferrerPolicy:          "no-referrer",
+			StrictTransportSecurity: "-",
+			PermissionsPolicy:       map[string][]string{"geolocation": {"self", "https://maps.example.com"}},
+			CSP:                     map[string][]string{},
+		},
+	}
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", cfg)
+
+	rec, _ := serve(m, middleware.RouterPublic)
+	h := rec.Header()
+	assert.Equal(t, "no-referrer", h.Get("Referrer-Policy"))
+	assert.Empty(t, h.Get("Strict-Transport-Security"), "- removes the header")
+	assert.Equal(t, `geolocation=(self "https://maps.example.com")`, h.Get("Permissions-Policy"))
+	assert.Empty(t, h.Get(HeaderCSPReportOnly), "an empty csp map removes the policy")
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+
+	// Routers without an entry use the top-level headers
+	rec, _ = serve(m, middleware.RouterProtected)
+	assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get("Referrer-Policy"))
+	assert.Contains(t, rec.Header().Get(HeaderCSPReportOnly), "script-src 'self' 'nonce-")
+}
+
+func TestSecurityHeadersMiddleware_OutsideRouters(t *testing.T) {
+	rec, _ := serve(testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", nil), 0)
+	csp := rec.Header().Get(HeaderCSPReportOnly)
+	assert.Contains(t, csp, "default-src 'self'")
+	assert.NotContains(t, csp, "report-uri")
+}
+
+func TestSecurityHeadersMiddleware_Enforce(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.CSPReportOnly = false
+	cfg.ReportURI = "https://reports.example.com/csp"
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", cfg)
+
+	for _, router := range []middleware.Router{middleware.RouterPublic, middleware.RouterProtected} {
+		rec, _ := serve(m, router)
+		assert.Empty(t, rec.Header().Get(HeaderCSPReportOnly), router.String())
+		assert.Contains(t, rec.Header().Get(HeaderCSP), "report-uri https://reports.example.com/csp", router.String())
+	}
+}
+
+func TestSecurityHeadersMiddleware_NoReportPath(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.ReportPath = ""
+	rec, _ := serve(testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", cfg), middleware.RouterPublic)
+
+	assert.NotContains(t, rec.Header().Get(HeaderCSPReportOnly), "report-uri")
+}
+
+func TestSecurityHeadersMiddleware_InvalidConfig(t *testing.T) {
+	m := &SecurityHeadersMiddleware{}
+	err := m.Configure(&config.SecurityHeadersMiddlewareConfig{
+		Routers: map[string]config.SecurityHeadersConfig{"admin": {}},
+	})
+	assert.Error(t, err)
+}
+
+func TestRouteCSP(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.CSPReportOnly = false
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", cfg)
+	route := RouteCSP(NewCSP().
+		Add("default-src", "'self'").
+		Add("script-src", "'self'", Nonce, "https://js.stripe.com").
+		Add("frame-src", "https://js.stripe.com"))
+
+	rec, nonce := serve(m, middleware.RouterPublic, route)
+	require.NotEmpty(t, nonce)
+	assert.Equal(t,
+		"default-src 'self'; frame-src https://js.stripe.com; script-src 'self' 'nonce-"+nonce+"' https://js.stripe.com",
+		rec.Header().Get(HeaderCSP))
+}
+
+func TestRouteCSP_ReportOnly(t *testing.T) {
+	m := testutil.NewMiddleware[*SecurityHeadersMiddleware](t, "securityheaders", nil)
+
+	rec, _ := serve(m, middleware.RouterPublic, RouteCSP(NewCSP().Add("default-src", "'none'")))
+	assert.Empty(t, rec.Header().Get(HeaderCSP))
+	assert.Equal(t, "default-src 'none'", rec.Header().Get(HeaderCSPReportOnly))
+}
+
+func TestCSP(t *testing.T) {
+	p := NewCSP().
+		Add("script-src", "'self'").
+		Add("Script-Src", Nonce).
+		Add("default-src", "'none'").
+		Add("upgrade-insecure-requests")
+
+	assert.True(t, p.HasNonce())
+	assert.Equal(t, "default-src 'none'; script-src 'self' 'nonce'; upgrade-insecure-requests", p.String())
+	assert.Equal(t, "default-src 'none'; script-src 'self' 'nonce-abc'; upgrade-insecure-requests", p.Render("abc"))
+
+	p.Set("script-src", "'self'").Remove("upgrade-insecure-requests")
+	assert.False(t, p.HasNonce())
+	assert.False(t, p.Has("upgrade-insecure-requests"))
+	assert.Equal(t, "default-src 'none'; script-src 'self'", p.String())
+}
+
+func TestCSPFromMap_Copies(t *testing.T) {
+	directives := map[string][]string{"default-src": {"'self'"}}
+	p := CSPFromMap(directives)
+	p.Add("default-src", "https://cdn.example.com")
+
+	assert.Equal(t, []string{"'self'"}, directives["default-src"])
+}
+
+func newTestReportHandler(t *testing.T, cfg *config.Config) (*echo.Echo, *bytes.Buffer) {
+	t.Helper()
+
+	http.SetGlobalConfig(cfg)
+	t.Cleanup(func() {
+		http.SetGlobalConfig(nil)
+	})
+
+	h := &ReportHandler{}
+	require.NoError(t, h.Initialize())
+
+	var logs bytes.Buffer
+	h.logger = logrus.New()
+	h.logger.SetOutput(&logs)
+	h.logger.SetFormatter(&logrus.JSONFormatter{})
+
+	e := echo.New()
+	h.Routes(e.Group(h.Prefix()))
+	return e, &logs
+}
+
+func postReport(e *echo.Echo, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(nethttp.MethodPost, "/csp-report", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReportHandler(t *testing.T) {
+	e, logs := newTestReportHandler(t, config.NewWithDefaults())
+
+	rec := postReport(e, "application/csp-report", `{"csp-report": {
+		"document-uri": "https://example.com/page",
+		"violated-directive": "script-src-elem",
+		"blocked-uri": "https://evil.test/x.js",
+		"disposition": "enforce",
+		"line-number": 12
+	}}`)
+	assert.Equal(t, nethttp.StatusNoContent, rec.Code)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "CSP violation", entry["msg"])
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, "https://example.com/page", entry["document_url"])
+	assert.Equal(t, "script-src-elem", entry["effective_directive"])
+	assert.Equal(t, "https://evil.test/x.js", entry["blocked_url"])
+	assert.Equal(t, float64(12), entry["line_number"])
+}
+
+func TestReportHandler_ReportingAPI(t *testing.T) {
+	e, logs := newTestReportHandler(t, config.NewWithDefaults())
+
+	rec := postReport(e, "application/reports+json", `[
+		{"type": "csp-violation", "body": {"documentURL": "https://example.com/a", "effectiveDirective": "img-src", "blockedURL": "https://x.test/i.png", "disposition": "report"}},
+		{"type": "deprecation", "body": {}},
+		{"type": "csp-violation", "body": {"documentURL": "https://example.com/b", "effectiveDirective": "style-src"}}
+	]`)
+	assert.Equal(t, nethttp.StatusNoContent, rec.Code)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"effective_directive":"img-src"`)
+	assert.Contains(t, lines[1], `"document_url":"https://example.com/b"`)
+}
+
+func TestReportHandler_InvalidReport(t *testing.T) {
+	e, logs := newTestReportHandler(t, config.NewWithDefaults())
+
+	for _, body := range []string{"not json", `{"other": {}}`} {
+		rec := postReport(e, "application/csp-report", body)
+		assert.NotEqual(t, nethttp.StatusNoContent, rec.Code, body)
+	}
+	assert.Empty(t, logs.String())
+}
+
+func TestReportHandler_TooLarge(t *testing.T) {
+	e, _ := newTestReportHandler(t, config.NewWithDefaults())
+
+	rec := postReport(e, "application/csp-report", `{"csp-report": {"script-sample": "`+strings.Repeat("a", maxReportSize)+`"}}`)
+	assert.NotEqual(t, nethttp.StatusNoContent, rec.Code)
+}
+
+func TestReportHandler_Disabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{"no report path", func(cfg *config.Config) { cfg.Middleware.SecurityHeaders.ReportPath = "" }},
+		{"middleware disabled", func(cfg *config.Config) { cfg.Middleware.SecurityHeaders.Enabled = false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewWithDefaults()
+			tt.modify(cfg)
+			e, _ := newTestReportHandler(t, cfg)
+
+			assert.Empty(t, e.Routes())
+		})
+	}
+}
+
+func TestReportHandler_Scope(t *testing.T) {
+	h := &ReportHandler{}
+	assert.Equal(t, http.ScopePublic, h.Scope())
+	assert.Nil(t, h.Middlewares())
+}
@@ -65,6 +65,11 @@ func (m *TimeoutMiddleware) Handler() echo.MiddlewareFunc {
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// WebSocket connections outlive any request timeout.
+			if c.IsWebSocket() {
+				return next(c)
+			}
+
 			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
 			defer cancel()
 
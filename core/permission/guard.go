@@ -0,0 +1,64 @@
+package permission
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// ObjectFunc resolves the object a guard checks from the request
+type ObjectFunc func(c echo.Context) (string, error)
+
+// Param resolves the object from a path parameter
+func Param(name string) ObjectFunc {
+	return func(c echo.Context) (string, error) {
+		if value := c.Param(name); value != "" {
+			return value, nil
+		}
+		return "", errors.BadRequest("Missing path parameter: " + name)
+	}
+}
+
+// Query resolves the object from a query parameter
+func Query(name string) ObjectFunc {
+	return func(c echo.Context) (string, error) {
+		if value := c.QueryParam(name); value != "" {
+			return value, nil
+		}
+		return "", errors.BadRequest("Missing query parameter: " + name)
+	}
+}
+
+// Static always checks the same object, e.g. a singleton resource
+func Static(object string) ObjectFunc {
+	return func(echo.Context) (string, error) {
+		return object, nil
+	}
+}
+
+// RequirePermission returns route middleware that responds 403 unless the
+// authenticated identity has relation on the resolved object in namespace.
+// Use it on routes behind the auth middleware:
+//
+//	g.GET("/projects/:id", h.Get, permission.RequirePermission("projects", "viewer", permission.Param("id")))
+func RequirePermission(namespace, relation string, objectFrom ObjectFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			object, err := objectFrom(c)
+			if err != nil {
+				return withPhase(err)
+			}
+			if err := Require(c, namespace, relation, object); err != nil {
+				return withPhase(err)
+			}
+			return next(c)
+		}
+	}
+}
+
+func withPhase(err error) error {
+	if fwkErr, ok := err.(*errors.Error); ok {
+		return fwkErr.WithPhase(errors.PhaseMiddleware)
+	}
+	return err
+}
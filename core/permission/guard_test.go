@@ -0,0 +1,89 @@
+package permission_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codoworks/codo-framework/clients/keto"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/permission"
+)
+
+// newGuardedEcho serves GET /projects/:id behind a viewer guard, with the
+// identity set the way the auth middleware sets it
+func newGuardedEcho(identity *auth.Identity, objectFrom permission.ObjectFunc) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		if fwkErr, ok := err.(*errors.Error); ok {
+			c.JSON(fwkErr.HTTPStatus, map[string]string{"code": fwkErr.Code, "message": fwkErr.Message})
+			return
+		}
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if identity != nil {
+				auth.SetIdentity(c, identity)
+			}
+			return next(c)
+		}
+	})
+	e.GET("/projects/:id", func(c echo.Context) error {
+		// The guard's result is cached for handler code
+		if err := permission.Require(c, "projects", "viewer", c.Param("id")); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "ok")
+	}, permission.RequirePermission("projects", "viewer", objectFrom))
+	return e
+}
+
+func TestRequirePermission(t *testing.T) {
+	_, calls := setupKeto(t, *keto.NewPermission("user-1", "viewer", "projects", "p1"))
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		path     string
+		want     int
+	}{
+		{"allowed", &auth.Identity{ID: "user-1"}, "/projects/p1", http.StatusOK},
+		{"denied", &auth.Identity{ID: "user-1"}, "/projects/p2", http.StatusForbidden},
+		{"other subject", &auth.Identity{ID: "user-2"}, "/projects/p1", http.StatusForbidden},
+		{"no identity", nil, "/projects/p1", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newGuardedEcho(tt.identity, permission.Param("id"))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+	assert.Equal(t, 3, *calls, "the handler reuses the guard's result")
+}
+
+func TestRequirePermission_ObjectFuncs(t *testing.T) {
+	setupKeto(t, *keto.NewPermission("user-1", "viewer", "projects", "p9"))
+	identity := &auth.Identity{ID: "user-1"}
+
+	rec := httptest.NewRecorder()
+	newGuardedEcho(identity, permission.Query("project")).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/projects/p9?project=p9", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	newGuardedEcho(identity, permission.Query("project")).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/projects/p9", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	newGuardedEcho(identity, permission.Static("p1")).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/projects/p9", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,189 @@
+// Package permission checks Keto permissions for the authenticated identity.
+// Results are cached for the rest of the request, so guards and handler code
+// can check the same permission without another round trip.
+package permission
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/clients/keto"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// Checker checks relation tuples. keto.Client and keto.MockClient implement it.
+type Checker interface {
+	CheckPermission(ctx context.Context, subject, relation, namespace, object string) (bool, error)
+	CheckPermissions(ctx context.Context, perms []keto.Permission) ([]bool, error)
+}
+
+// Context keys
+type contextKey string
+
+const cacheKey contextKey = "permission_cache"
+
+// cache holds the permission results of one request
+type cache struct {
+	mu      sync.Mutex
+	results map[keto.Permission]bool
+}
+
+func (c *cache) get(p keto.Permission) (allowed, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	allowed, ok = c.results[p]
+	return allowed, ok
+}
+
+func (c *cache) set(p keto.Permission, allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[p] = allowed
+}
+
+// requestCache returns the request's permission cache, creating it on first use
+func requestCache(c echo.Context) *cache {
+	if pc, ok := c.Request().Context().Value(cacheKey).(*cache); ok {
+		return pc
+	}
+	pc := &cache{results: make(map[keto.Permission]bool)}
+	c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), cacheKey, pc)))
+	return pc
+}
+
+// checker returns the keto client as a Checker
+func checker() (Checker, error) {
+	client, err := clients.Get(keto.ClientName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keto client: %w", err)
+	}
+	ch, ok := client.(Checker)
+	if !ok {
+		return nil, fmt.Errorf("keto client does not implement Checker interface")
+	}
+	return ch, nil
+}
+
+// subject returns the ID of the authenticated identity
+func subject(c echo.Context) (string, error) {
+	identity, err := auth.GetIdentity(c)
+	if err != nil {
+		return "", errors.Unauthorized("Not authenticated")
+	}
+	return identity.ID, nil
+}
+
+// Check reports whether the authenticated identity has relation on object in
+// namespace. Returns a 401 error without an identity.
+func Check(c echo.Context, namespace, relation, object string) (bool, error) {
+	subj, err := subject(c)
+	if err != nil {
+		return false, err
+	}
+
+	p := keto.Permission{Subject: subj, Relation: relation, Namespace: namespace, Object: object}
+	pc := requestCache(c)
+	if allowed, ok := pc.get(p); ok {
+		return allowed, nil
+	}
+
+	ch, err := checker()
+	if err != nil {
+		return false, errors.WrapInternal(err, "Permission check failed")
+	}
+	allowed, err := ch.CheckPermission(c.Request().Context(), subj, relation, namespace, object)
+	if err != nil {
+		return false, errors.Unavailable("Permission check failed").WithCause(err)
+	}
+	pc.set(p, allowed)
+	return allowed, nil
+}
+
+// Require returns a 403 error unless the authenticated identity has
+// relation on object in namespace
+func Require(c echo.Context, namespace, relation, object string) error {
+	allowed, err := Check(c, namespace, relation, object)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return Denied(namespace, relation, object)
+	}
+	return nil
+}
+
+// Denied returns the 403 error for a missing permission
+func Denied(namespace, relation, object string) *errors.Error {
+	return errors.Forbidden("Permission denied").
+		WithDetail("namespace", namespace).
+		WithDetail("relation", relation).
+		WithDetail("object", object)
+}
+
+// CheckAll checks relation on several objects with batch checks and returns the
+// result per object. Cached results are not checked again.
+func CheckAll(c echo.Context, namespace, relation string, objects []string) (map[string]bool, error) {
+	subj, err := subject(c)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := requestCache(c)
+	results := make(map[string]bool, len(objects))
+	var pending []keto.Permission
+	for _, object := range objects {
+		p := keto.Permission{Subject: subj, Relation: relation, Namespace: namespace, Object: object}
+		if allowed, ok := pc.get(p); ok {
+			results[object] = allowed
+			continue
+		}
+		if _, queued := results[object]; !queued {
+			results[object] = false
+			pending = append(pending, p)
+		}
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	ch, err := checker()
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Permission check failed")
+	}
+	allowed, err := ch.CheckPermissions(c.Request().Context(), pending)
+	if err != nil {
+		return nil, errors.Unavailable("Permission check failed").WithCause(err)
+	}
+	for i, p := range pending {
+		results[p.Object] = allowed[i]
+		pc.set(p, allowed[i])
+	}
+	return results, nil
+}
+
+// Filter returns the items whose object the authenticated identity has
+// relation on, keeping their order. Use it to trim list results.
+func Filter[T any](c echo.Context, namespace, relation string, items []T, objectOf func(T) string) ([]T, error) {
+	objects := make([]string, len(items))
+	for i, item := range items {
+		objects[i] = objectOf(item)
+	}
+
+	allowed, err := CheckAll(c, namespace, relation, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]T, 0, len(items))
+	for i, item := range items {
+		if allowed[objects[i]] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,150 @@
+package permission_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/clients/keto"
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/clients"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/permission"
+)
+
+// setupKeto registers a mock Keto client that counts checks
+func setupKeto(t *testing.T, granted ...keto.Permission) (*keto.MockClient, *int) {
+	t.Helper()
+	mock := keto.NewMockClient()
+	mock.AllowOnly(granted...)
+	check := mock.CheckFunc
+	calls := 0
+	mock.CheckFunc = func(ctx context.Context, subject, relation, namespace, object string) (bool, error) {
+		calls++
+		return check(ctx, subject, relation, namespace, object)
+	}
+
+	clients.MustRegister(mock)
+	t.Cleanup(clients.ResetRegistry)
+	return mock, &calls
+}
+
+func newContext(identity *auth.Identity) echo.Context {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	if identity != nil {
+		auth.SetIdentity(c, identity)
+	}
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	_, calls := setupKeto(t, *keto.NewPermission("user-1", "viewer", "projects", "p1"))
+	c := newContext(&auth.Identity{ID: "user-1"})
+
+	allowed, err := permission.Check(c, "projects", "viewer", "p1")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = permission.Check(c, "projects", "viewer", "p1")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, *calls, "results are cached per request")
+
+	allowed, err = permission.Check(c, "projects", "editor", "p1")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 2, *calls)
+
+	// A new request starts with an empty cache
+	_, err = permission.Check(newContext(&auth.Identity{ID: "user-1"}), "projects", "viewer", "p1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestCheck_NoIdentity(t *testing.T) {
+	setupKeto(t)
+
+	_, err := permission.Check(newContext(nil), "projects", "viewer", "p1")
+	assert.True(t, errors.IsUnauthorized(err))
+}
+
+func TestCheck_KetoError(t *testing.T) {
+	mock, _ := setupKeto(t)
+	mock.CheckFunc = func(ctx context.Context, subject, relation, namespace, object string) (bool, error) {
+		return false, fmt.Errorf("connection refused")
+	}
+
+	_, err := permission.Check(newContext(&auth.Identity{ID: "user-1"}), "projects", "viewer", "p1")
+	assert.Equal(t, http.StatusServiceUnavailable, errors.GetHTTPStatus(err))
+}
+
+func TestCheck_NoKeto(t *testing.T) {
+	clients.ResetRegistry()
+
+	_, err := permission.Check(newContext(&auth.Identity{ID: "user-1"}), "projects", "viewer", "p1")
+	assert.True(t, errors.IsInternal(err))
+}
+
+func TestRequire(t *testing.T) {
+	setupKeto(t)
+
+	err := permission.Require(newContext(&auth.Identity{ID: "user-1"}), "projects", "owner", "p1")
+	require.Error(t, err)
+	assert.True(t, errors.IsForbidden(err))
+	fwkErr := err.(*errors.Error)
+	assert.Equal(t, "owner", fwkErr.Details["relation"])
+	assert.Equal(t, "p1", fwkErr.Details["object"])
+}
+
+func TestCheckAll(t *testing.T) {
+	mock, calls := setupKeto(t,
+		*keto.NewPermission("user-1", "viewer", "projects", "p1"),
+		*keto.NewPermission("user-1", "viewer", "projects", "p3"),
+	)
+	batches := 0
+	mock.BatchCheckFunc = func(ctx context.Context, perms []keto.Permission) ([]bool, error) {
+		batches++
+		allowed := make([]bool, len(perms))
+		for i, p := range perms {
+			allowed[i], _ = mock.CheckPermission(ctx, p.Subject, p.Relation, p.Namespace, p.Object)
+		}
+		return allowed, nil
+	}
+	c := newContext(&auth.Identity{ID: "user-1"})
+
+	_, err := permission.Check(c, "projects", "viewer", "p1")
+	require.NoError(t, err)
+
+	results, err := permission.CheckAll(c, "projects", "viewer", []string{"p1", "p2", "p3", "p2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"p1": true, "p2": false, "p3": true}, results)
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 3, *calls, "p1 comes from the cache and p2 is checked once")
+
+	// Batch results are cached too
+	allowed, err := permission.Check(c, "projects", "viewer", "p3")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestFilter(t *testing.T) {
+	setupKeto(t,
+		*keto.NewPermission("user-1", "viewer", "projects", "p1"),
+		*keto.NewPermission("user-1", "viewer", "projects", "p3"),
+	)
+	type project struct{ ID string }
+	projects := []project{{"p1"}, {"p2"}, {"p3"}}
+
+	visible, err := permission.Filter(newContext(&auth.Identity{ID: "user-1"}), "projects", "viewer", projects,
+		func(p project) string { return p.ID })
+	require.NoError(t, err)
+	assert.Equal(t, []project{{"p1"}, {"p3"}}, visible)
+}
@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/errors"
+)
+
+// Evaluate evaluates p for the request's identity and path parameters. The
+// identity is nil if the request is not authenticated.
+func Evaluate(c echo.Context, p Policy) Decision {
+	identity, _ := auth.GetIdentity(c)
+	return p.Evaluate(Input{Identity: identity, Params: params(c)})
+}
+
+// Check returns nil if p allows the request, a 401 error without an
+// identity, or a 403 error
+func Check(c echo.Context, p Policy) error {
+	identity, err := auth.GetIdentity(c)
+	if err != nil {
+		return errors.Unauthorized("Not authenticated")
+	}
+	return Authorize(identity, p, params(c))
+}
+
+// Authorize returns nil if p allows identity, e.g. in a service outside of
+// a request, or a 403 error. params are interpolated into rule values.
+func Authorize(identity *auth.Identity, p Policy, params map[string]string) error {
+	d := p.Evaluate(Input{Identity: identity, Params: params})
+	if d.Allowed {
+		return nil
+	}
+	return Denied(d)
+}
+
+// Denied returns the 403 error for a denial. In dev mode the reason is
+// attached as a detail.
+func Denied(d Decision) *errors.Error {
+	err := errors.Forbidden("Access denied")
+	if explaining() {
+		err = err.WithDetail("reason", d.Reason)
+	}
+	return err
+}
+
+// Require returns route middleware that responds 403 unless p allows the
+// authenticated identity. Use it on routes behind the auth middleware:
+//
+//	g.DELETE("/users/:id", h.Delete, policy.Require(policy.Any(policy.Named("admin"), policy.IdentityID("{id}"))))
+func Require(p Policy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := Check(c, p); err != nil {
+				if fwkErr, ok := err.(*errors.Error); ok {
+					return fwkErr.WithPhase(errors.PhaseMiddleware)
+				}
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireNamed is Require for a registered policy
+func RequireNamed(name string) echo.MiddlewareFunc {
+	return Require(Named(name))
+}
+
+// params returns the request's path parameters
+func params(c echo.Context) map[string]string {
+	names, values := c.ParamNames(), c.ParamValues()
+	result := make(map[string]string, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			result[name] = values[i]
+		}
+	}
+	return result
+}
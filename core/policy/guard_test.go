@@ -0,0 +1,91 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/policy"
+)
+
+// newGuardedEcho serves DELETE /users/:id behind p, with the identity set
+// the way the auth middleware sets it
+func newGuardedEcho(identity *auth.Identity, p policy.Policy) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		if fwkErr, ok := err.(*errors.Error); ok {
+			c.JSON(fwkErr.HTTPStatus, map[string]any{"code": fwkErr.Code, "details": fwkErr.Details})
+			return
+		}
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if identity != nil {
+				auth.SetIdentity(c, identity)
+			}
+			return next(c)
+		}
+	})
+	e.DELETE("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, policy.Require(p))
+	return e
+}
+
+func TestRequire(t *testing.T) {
+	t.Cleanup(policy.Reset)
+	policy.Register("admin", policy.TraitEquals("role", "admin"))
+	selfOrAdmin := policy.Any(policy.IdentityID("{id}"), policy.Named("admin"))
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		path     string
+		want     int
+	}{
+		{"self", jane, "/users/user-1", http.StatusNoContent},
+		{"other", jane, "/users/user-2", http.StatusForbidden},
+		{"admin", &auth.Identity{ID: "admin-1", Traits: map[string]any{"role": "admin"}}, "/users/user-2", http.StatusNoContent},
+		{"no identity", nil, "/users/user-1", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newGuardedEcho(tt.identity, selfOrAdmin).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestRequire_Explain(t *testing.T) {
+	t.Cleanup(policy.Reset)
+	deny := func() map[string]any {
+		rec := httptest.NewRecorder()
+		newGuardedEcho(jane, policy.TraitEquals("role", "admin")).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/user-2", nil))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+
+	assert.Nil(t, deny()["details"], "reasons are hidden outside dev mode")
+
+	policy.SetExplain(true)
+	assert.Equal(t, map[string]any{"reason": `trait role is "support", want one of ["admin"]`}, deny()["details"])
+}
+
+func TestAuthorize(t *testing.T) {
+	assert.NoError(t, policy.Authorize(jane, policy.TraitEquals("teams", "{team}"), map[string]string{"team": "ops"}))
+
+	err := policy.Authorize(jane, policy.TraitEquals("role", "admin"), nil)
+	assert.True(t, errors.IsForbidden(err))
+	assert.Error(t, policy.Authorize(nil, policy.Authenticated(), nil))
+}
@@ -0,0 +1,288 @@
+// Package policy authorizes requests with rules over the authenticated
+// identity's traits, for checks too simple to need Keto, such as "trait role
+// is admin" or "email domain is ours". Policies are declared in Go or under
+// the policies config section and combined with All, Any and Not.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/codoworks/codo-framework/core/auth"
+)
+
+// maxDepth limits nested policy references, so cycles deny instead of
+// recursing forever
+const maxDepth = 32
+
+// Input is what a policy is evaluated against
+type Input struct {
+	Identity *auth.Identity
+	Params   map[string]string // Path parameters, interpolated into rule values as {name}
+
+	depth int // Nested policy references
+}
+
+// Decision is the outcome of evaluating a policy
+type Decision struct {
+	Allowed bool
+	Reason  string // Why the policy allowed or denied, for dev mode explanations
+
+	// Indeterminate is set when the policy could not be evaluated, e.g. an
+	// unknown policy name, a missing path parameter or no identity. It
+	// denies, and Not, Any and All never turn it into an allow.
+	Indeterminate bool
+}
+
+// Policy decides whether an input is allowed
+type Policy interface {
+	Evaluate(in Input) Decision
+}
+
+// Func adapts a function to a Policy
+type Func func(in Input) Decision
+
+// Evaluate calls f
+func (f Func) Evaluate(in Input) Decision {
+	return f(in)
+}
+
+func allow(format string, args ...any) Decision {
+	return Decision{Allowed: true, Reason: fmt.Sprintf(format, args...)}
+}
+
+func deny(format string, args ...any) Decision {
+	return Decision{Reason: fmt.Sprintf(format, args...)}
+}
+
+func indeterminate(format string, args ...any) Decision {
+	return Decision{Reason: fmt.Sprintf(format, args...), Indeterminate: true}
+}
+
+// All allows if every policy allows. It denies with the first indeterminate
+// decision, or else the first denial.
+func All(policies ...Policy) Policy {
+	return Func(func(in Input) Decision {
+		var denied *Decision
+		for _, p := range policies {
+			d := p.Evaluate(in)
+			if d.Indeterminate {
+				return d
+			}
+			if !d.Allowed && denied == nil {
+				denied = &d
+			}
+		}
+		if denied != nil {
+			return *denied
+		}
+		return allow("all rules allowed")
+	})
+}
+
+// Any allows if at least one policy allows. Otherwise the denial is
+// indeterminate if any policy's was.
+func Any(policies ...Policy) Policy {
+	return Func(func(in Input) Decision {
+		reasons := make([]string, 0, len(policies))
+		undecided := false
+		for _, p := range policies {
+			d := p.Evaluate(in)
+			if d.Allowed {
+				return d
+			}
+			reasons = append(reasons, d.Reason)
+			undecided = undecided || d.Indeterminate
+		}
+		d := deny("no rule allowed: %s", strings.Join(reasons, "; "))
+		d.Indeterminate = undecided
+		return d
+	})
+}
+
+// Not allows if p denies. An indeterminate decision stays a denial.
+func Not(p Policy) Policy {
+	return Func(func(in Input) Decision {
+		d := p.Evaluate(in)
+		switch {
+		case d.Indeterminate:
+			return indeterminate("not (%s)", d.Reason)
+		case d.Allowed:
+			return deny("not (%s)", d.Reason)
+		}
+		return allow("not (%s)", d.Reason)
+	})
+}
+
+// Authenticated allows any identity
+func Authenticated() Policy {
+	return Func(func(in Input) Decision {
+		if in.Identity == nil {
+			return deny("not authenticated")
+		}
+		return allow("authenticated")
+	})
+}
+
+// TraitEquals allows if the trait at path equals value. List traits match
+// if any element does.
+func TraitEquals(path, value string) Policy {
+	return TraitIn(path, value)
+}
+
+// TraitIn allows if the trait at path equals one of values
+func TraitIn(path string, values ...string) Policy {
+	return traitRule(path, func(trait string, params map[string]string) Decision {
+		for _, value := range values {
+			want, ok := interpolate(value, params)
+			if !ok {
+				return indeterminate("missing path parameter in %q", value)
+			}
+			if trait == want {
+				return allow("trait %s is %q", path, trait)
+			}
+		}
+		return deny("trait %s is %q, want one of %q", path, trait, values)
+	})
+}
+
+// TraitSuffix allows if the trait at path ends with suffix
+func TraitSuffix(path, suffix string) Policy {
+	return traitRule(path, func(trait string, params map[string]string) Decision {
+		want, ok := interpolate(suffix, params)
+		if !ok {
+			return indeterminate("missing path parameter in %q", suffix)
+		}
+		if strings.HasSuffix(trait, want) {
+			return allow("trait %s ends with %q", path, want)
+		}
+		return deny("trait %s is %q, want suffix %q", path, trait, want)
+	})
+}
+
+// TraitPresent allows if the trait at path is set and not empty
+func TraitPresent(path string) Policy {
+	return traitRule(path, func(trait string, _ map[string]string) Decision {
+		return allow("trait %s is present", path)
+	})
+}
+
+// EmailDomain allows if the email trait's domain is one of domains, ignoring
+// case. The email must be verified (auth.Identity.EmailVerified): anyone can
+// sign up with an address at any domain. An unverified email is
+// indeterminate.
+func EmailDomain(domains ...string) Policy {
+	return Func(func(in Input) Decision {
+		if in.Identity == nil {
+			return indeterminate("not authenticated")
+		}
+		email := in.Identity.Email()
+		_, domain, ok := strings.Cut(email, "@")
+		if !ok {
+			return deny("email %q has no domain", email)
+		}
+		if !in.Identity.EmailVerified() {
+			return indeterminate("email %q is not verified", email)
+		}
+		for _, want := range domains {
+			if strings.EqualFold(domain, want) {
+				return allow("email domain is %q", domain)
+			}
+		}
+		return deny("email domain is %q, want one of %q", domain, domains)
+	})
+}
+
+// IdentityID allows if the identity ID equals value, e.g. IdentityID("{id}")
+// for routes on the identity's own resources
+func IdentityID(value string) Policy {
+	return Func(func(in Input) Decision {
+		if in.Identity == nil {
+			return indeterminate("not authenticated")
+		}
+		want, ok := interpolate(value, in.Params)
+		if !ok {
+			return indeterminate("missing path parameter in %q", value)
+		}
+		if in.Identity.ID == want {
+			return allow("identity is %q", want)
+		}
+		return deny("identity is %q, want %q", in.Identity.ID, want)
+	})
+}
+
+// traitRule evaluates match against each value of the trait at path
+func traitRule(path string, match func(trait string, params map[string]string) Decision) Policy {
+	return Func(func(in Input) Decision {
+		if in.Identity == nil {
+			return indeterminate("not authenticated")
+		}
+		values := traitValues(in.Identity, path)
+		if len(values) == 0 {
+			return deny("trait %s is not set", path)
+		}
+
+		var d Decision
+		for _, value := range values {
+			if d = match(value, in.Params); d.Allowed || d.Indeterminate {
+				return d
+			}
+		}
+		return d
+	})
+}
+
+// traitValues returns the non-empty values of the trait at a dotted path as
+// strings. Lists return each element.
+func traitValues(identity *auth.Identity, path string) []string {
+	var value any = identity.Traits
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if value, ok = m[key]; !ok {
+			return nil
+		}
+	}
+
+	var values []string
+	add := func(v any) {
+		if v == nil {
+			return
+		}
+		if s := fmt.Sprint(v); s != "" {
+			values = append(values, s)
+		}
+	}
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			add(item)
+		}
+	case []string:
+		for _, item := range v {
+			add(item)
+		}
+	default:
+		add(v)
+	}
+	return values
+}
+
+var paramPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// interpolate replaces {name} with path parameters. Returns false if a
+// parameter is missing.
+func interpolate(value string, params map[string]string) (string, bool) {
+	ok := true
+	result := paramPattern.ReplaceAllStringFunc(value, func(match string) string {
+		param, found := params[match[1:len(match)-1]]
+		if !found || param == "" {
+			ok = false
+		}
+		return param
+	})
+	return result, ok
+}
@@ -0,0 +1,150 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codoworks/codo-framework/core/auth"
+	"github.com/codoworks/codo-framework/core/policy"
+)
+
+var jane = &auth.Identity{
+	ID: "user-1",
+	Traits: map[string]any{
+		"email": "jane@Example.com",
+		"role":  "support",
+		"teams": []any{"billing", "ops"},
+		"name":  map[string]any{"first": "Jane"},
+		"staff": true,
+	},
+	VerifiedAddresses: []string{"jane@example.com"},
+}
+
+func evaluate(p policy.Policy, params map[string]string) policy.Decision {
+	return p.Evaluate(policy.Input{Identity: jane, Params: params})
+}
+
+func TestTraitRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy policy.Policy
+		want   bool
+	}{
+		{"equals", policy.TraitEquals("role", "support"), true},
+		{"equals other", policy.TraitEquals("role", "admin"), false},
+		{"in", policy.TraitIn("role", "admin", "support"), true},
+		{"list element", policy.TraitEquals("teams", "ops"), true},
+		{"list missing element", policy.TraitEquals("teams", "sales"), false},
+		{"nested", policy.TraitEquals("name.first", "Jane"), true},
+		{"bool", policy.TraitEquals("staff", "true"), true},
+		{"suffix", policy.TraitSuffix("email", "@Example.com"), true},
+		{"present", policy.TraitPresent("teams"), true},
+		{"absent", policy.TraitPresent("manager"), false},
+		{"absent nested", policy.TraitEquals("name.last.x", "Doe"), false},
+		{"email domain ignores case", policy.EmailDomain("other.org", "example.com"), true},
+		{"email domain", policy.EmailDomain("other.org"), false},
+		{"authenticated", policy.Authenticated(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := evaluate(tt.policy, nil)
+			assert.Equal(t, tt.want, d.Allowed, d.Reason)
+			assert.NotEmpty(t, d.Reason)
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	admin := policy.TraitEquals("role", "admin")
+	support := policy.TraitEquals("role", "support")
+
+	assert.True(t, evaluate(policy.Any(admin, support), nil).Allowed)
+	assert.False(t, evaluate(policy.All(admin, support), nil).Allowed)
+	assert.True(t, evaluate(policy.Not(admin), nil).Allowed)
+
+	d := evaluate(policy.Any(admin, policy.EmailDomain("other.org")), nil)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, `no rule allowed: trait role is "support", want one of ["admin"]; email domain is "Example.com", want one of ["other.org"]`, d.Reason)
+
+	d = evaluate(policy.Not(support), nil)
+	assert.Equal(t, `not (trait role is "support")`, d.Reason)
+}
+
+func TestEmailDomainUnverified(t *testing.T) {
+	// Anyone can sign up with an address at any domain
+	mallory := &auth.Identity{ID: "user-2", Traits: map[string]any{"email": "mallory@example.com"}}
+	ours := policy.EmailDomain("example.com")
+
+	d := ours.Evaluate(policy.Input{Identity: mallory})
+	assert.False(t, d.Allowed)
+	assert.True(t, d.Indeterminate)
+	assert.Equal(t, `email "mallory@example.com" is not verified`, d.Reason)
+	assert.False(t, policy.Not(ours).Evaluate(policy.Input{Identity: mallory}).Allowed)
+
+	mallory.VerifiedAddresses = []string{"mallory@example.com"}
+	assert.True(t, ours.Evaluate(policy.Input{Identity: mallory}).Allowed)
+}
+
+func TestInterpolation(t *testing.T) {
+	self := policy.IdentityID("{id}")
+	assert.True(t, evaluate(self, map[string]string{"id": "user-1"}).Allowed)
+	assert.False(t, evaluate(self, map[string]string{"id": "user-2"}).Allowed)
+
+	d := evaluate(self, nil)
+	assert.False(t, d.Allowed)
+	assert.Contains(t, d.Reason, "missing path parameter")
+
+	team := policy.TraitEquals("teams", "{team}")
+	assert.True(t, evaluate(team, map[string]string{"team": "billing"}).Allowed)
+	assert.False(t, evaluate(team, map[string]string{"team": "sales"}).Allowed)
+	assert.False(t, evaluate(policy.TraitSuffix("email", "{domain}"), nil).Allowed, "a missing parameter never matches")
+}
+
+func TestNoIdentity(t *testing.T) {
+	for _, p := range []policy.Policy{
+		policy.Authenticated(),
+		policy.TraitPresent("role"),
+		policy.EmailDomain("example.com"),
+		policy.IdentityID("user-1"),
+	} {
+		d := p.Evaluate(policy.Input{})
+		assert.False(t, d.Allowed)
+		assert.Equal(t, "not authenticated", d.Reason)
+	}
+
+	// Only Authenticated decides without an identity
+	assert.False(t, policy.Authenticated().Evaluate(policy.Input{}).Indeterminate)
+	assert.True(t, policy.Not(policy.Authenticated()).Evaluate(policy.Input{}).Allowed)
+	assert.False(t, policy.Not(policy.TraitEquals("role", "admin")).Evaluate(policy.Input{}).Allowed)
+}
+
+func TestIndeterminate(t *testing.T) {
+	policy.Reset()
+	t.Cleanup(policy.Reset)
+	admin := policy.TraitEquals("role", "admin")
+	support := policy.TraitEquals("role", "support")
+
+	tests := []struct {
+		name   string
+		policy policy.Policy
+	}{
+		{"not unknown policy", policy.Not(policy.Named("missing"))},
+		{"not missing parameter", policy.Not(policy.IdentityID("{absent}"))},
+		{"not missing trait parameter", policy.Not(policy.TraitEquals("teams", "{team}"))},
+		{"not any", policy.Not(policy.Any(admin, policy.Named("missing")))},
+		{"not all", policy.Not(policy.All(admin, policy.Named("missing")))},
+		{"all", policy.All(support, policy.Not(policy.Named("missing")))},
+		{"double not", policy.Not(policy.Not(policy.Named("missing")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := evaluate(tt.policy, nil)
+			assert.False(t, d.Allowed, d.Reason)
+			assert.True(t, d.Indeterminate, d.Reason)
+		})
+	}
+
+	// A decided rule still wins
+	assert.True(t, evaluate(policy.Any(support, policy.Named("missing")), nil).Allowed)
+}
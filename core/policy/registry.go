@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/codoworks/codo-framework/core/config"
+)
+
+var (
+	mu         sync.RWMutex
+	policies   = make(map[string]Policy)
+	fromConfig = make(map[string]bool) // Names declared in config, replaced by Configure
+	explain    bool                    // Attach denial reasons to errors
+)
+
+// Register registers a named policy, replacing any with the same name
+func Register(name string, p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	policies[name] = p
+	delete(fromConfig, name)
+}
+
+// Get returns a named policy
+func Get(name string) (Policy, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := policies[name]
+	return p, ok
+}
+
+// Named refers to a registered policy by name. The policy is looked up on
+// each evaluation, so it may be registered or reloaded later. Unknown names
+// and cycles are indeterminate, so they deny even under Not.
+func Named(name string) Policy {
+	return Func(func(in Input) Decision {
+		if in.depth >= maxDepth {
+			return indeterminate("policy %q: references nested too deeply", name)
+		}
+		p, ok := Get(name)
+		if !ok {
+			return indeterminate("unknown policy %q", name)
+		}
+		in.depth++
+		d := p.Evaluate(in)
+		d.Reason = "policy " + name + ": " + d.Reason
+		return d
+	})
+}
+
+// SetExplain sets whether denial reasons are attached to 403 errors. Reasons
+// may reveal trait values, so Configure enables it in dev mode only.
+func SetExplain(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	explain = enabled
+}
+
+func explaining() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return explain
+}
+
+// Configure registers the policies declared in cfg, replacing those from a
+// previous config, and explains denials in dev mode. Policies registered in
+// Go are kept unless the config declares the same name.
+func Configure(cfg *config.Config) error {
+	built := make(map[string]Policy, len(cfg.Policies))
+	for name, pc := range cfg.Policies {
+		p, err := FromConfig(pc)
+		if err != nil {
+			return fmt.Errorf("policies.%s: %w", name, err)
+		}
+		built[name] = p
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name := range fromConfig {
+		delete(policies, name)
+	}
+	fromConfig = make(map[string]bool, len(built))
+	for name, p := range built {
+		policies[name] = p
+		fromConfig[name] = true
+	}
+	explain = cfg.IsDevMode()
+	return nil
+}
+
+// FromConfig builds a policy from its config declaration
+func FromConfig(cfg config.PolicyConfig) (Policy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.All != nil, cfg.Any != nil:
+		rules := cfg.All
+		combine := All
+		if cfg.Any != nil {
+			rules, combine = cfg.Any, Any
+		}
+		children := make([]Policy, 0, len(rules))
+		for _, rule := range rules {
+			child, err := FromConfig(rule)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		return combine(children...), nil
+	case cfg.Not != nil:
+		child, err := FromConfig(*cfg.Not)
+		if err != nil {
+			return nil, err
+		}
+		return Not(child), nil
+	case cfg.Policy != "":
+		return Named(cfg.Policy), nil
+	case cfg.IdentityID != "":
+		return IdentityID(cfg.IdentityID), nil
+	case cfg.EmailDomain != nil:
+		return EmailDomain(cfg.EmailDomain...), nil
+	}
+
+	switch {
+	case cfg.Equals != "":
+		return TraitEquals(cfg.Trait, cfg.Equals), nil
+	case cfg.In != nil:
+		return TraitIn(cfg.Trait, cfg.In...), nil
+	case cfg.Suffix != "":
+		return TraitSuffix(cfg.Trait, cfg.Suffix), nil
+	default:
+		return TraitPresent(cfg.Trait), nil
+	}
+}
+
+// Reset removes all policies. For testing only.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	policies = make(map[string]Policy)
+	fromConfig = make(map[string]bool)
+	explain = false
+}
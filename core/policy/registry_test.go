@@ -0,0 +1,60 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codoworks/codo-framework/core/config"
+	"github.com/codoworks/codo-framework/core/policy"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(policy.Reset)
+	policy.Register("in_code", policy.Authenticated())
+
+	cfg := config.NewWithDefaults()
+	cfg.Policies = map[string]config.PolicyConfig{
+		"staff": {Any: []config.PolicyConfig{
+			{Trait: "role", In: []string{"admin", "support"}},
+			{EmailDomain: []string{"example.com"}},
+		}},
+		"self_or_staff": {Any: []config.PolicyConfig{
+			{IdentityID: "{id}"},
+			{Policy: "staff"},
+		}},
+		"not_staff": {Not: &config.PolicyConfig{Policy: "staff"}},
+	}
+	require.NoError(t, policy.Configure(cfg))
+
+	d := evaluate(policy.Named("self_or_staff"), map[string]string{"id": "user-2"})
+	assert.True(t, d.Allowed, d.Reason)
+	assert.Equal(t, `policy self_or_staff: policy staff: trait role is "support"`, d.Reason)
+	assert.False(t, evaluate(policy.Named("not_staff"), nil).Allowed)
+
+	// A new config replaces config policies and keeps those registered in code
+	cfg.Policies = map[string]config.PolicyConfig{"admin": {Trait: "role", Equals: "admin"}}
+	require.NoError(t, policy.Configure(cfg))
+	_, ok := policy.Get("staff")
+	assert.False(t, ok)
+	_, ok = policy.Get("in_code")
+	assert.True(t, ok)
+
+	d = evaluate(policy.Named("missing"), nil)
+	assert.False(t, d.Allowed)
+	assert.Contains(t, d.Reason, `unknown policy "missing"`)
+
+	cfg.Policies = map[string]config.PolicyConfig{"broken": {Trait: "role"}}
+	assert.ErrorContains(t, policy.Configure(cfg), "policies.broken")
+}
+
+func TestNamed_Cycle(t *testing.T) {
+	t.Cleanup(policy.Reset)
+	policy.Register("a", policy.Named("b"))
+	policy.Register("b", policy.Named("a"))
+
+	d := evaluate(policy.Named("a"), nil)
+	assert.False(t, d.Allowed)
+	assert.Contains(t, d.Reason, "nested too deeply")
+}
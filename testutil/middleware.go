@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+// NewMiddleware returns a copy of the registered middleware name, configured
+// with cfg and activated as the orchestrator would. A nil cfg uses the defaults.
+func NewMiddleware[M middleware.Middleware](t *testing.T, name string, cfg any) M {
+	t.Helper()
+
+	registered, ok := middleware.GetGlobalRegistry().Get(name)
+	if !ok {
+		t.Fatalf("middleware %q is not registered", name)
+	}
+
+	val := reflect.ValueOf(registered)
+	clone := reflect.New(val.Elem().Type())
+	clone.Elem().Set(val.Elem())
+	m, ok := clone.Interface().(M)
+	if !ok {
+		t.Fatalf("middleware %q is a %T", name, registered)
+	}
+
+	if err := m.Configure(cfg); err != nil {
+		t.Fatalf("failed to configure middleware %q: %v", name, err)
+	}
+	if activator, ok := any(m).(middleware.Activator); ok {
+		if err := activator.Activate(); err != nil {
+			t.Fatalf("failed to activate middleware %q: %v", name, err)
+		}
+	}
+
+	return m
+}
+
+// NewEcho creates an Echo instance that renders errors with ErrorHandler.
+func NewEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	return e
+}
+
+// ErrorHandler renders framework errors with their status, code and message.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	if fwkErr, ok := err.(*errors.Error); ok {
+		c.JSON(fwkErr.HTTPStatus, map[string]string{
+			"code":    fwkErr.Code,
+			"message": fwkErr.Message,
+		})
+		return
+	}
+	c.Echo().DefaultHTTPErrorHandler(err, c)
+}
@@ -0,0 +1,75 @@
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/codoworks/codo-framework/core/errors"
+	"github.com/codoworks/codo-framework/core/middleware"
+)
+
+type probeMiddleware struct {
+	middleware.BaseMiddleware
+	cfg       any
+	activated bool
+}
+
+func (m *probeMiddleware) Configure(cfg any) error {
+	m.cfg = cfg
+	return nil
+}
+
+func (m *probeMiddleware) Activate() error {
+	m.activated = true
+	return nil
+}
+
+func TestNewMiddleware(t *testing.T) {
+	registered := &probeMiddleware{
+		BaseMiddleware: middleware.NewBaseMiddleware("testutil-probe", "middleware.probe", 1, middleware.RouterAll),
+	}
+	middleware.RegisterMiddleware(registered)
+
+	m := NewMiddleware[*probeMiddleware](t, "testutil-probe", "settings")
+	if m == registered {
+		t.Fatal("NewMiddleware should return a copy")
+	}
+	if m.Name() != "testutil-probe" {
+		t.Errorf("Name() = %q, want testutil-probe", m.Name())
+	}
+	if m.cfg != "settings" || !m.activated {
+		t.Errorf("copy should be configured and activated, got cfg=%v activated=%v", m.cfg, m.activated)
+	}
+	if registered.cfg != nil || registered.activated {
+		t.Error("the registered instance should be left alone")
+	}
+}
+
+func TestNewEcho(t *testing.T) {
+	e := NewEcho()
+	e.GET("/missing", func(c echo.Context) error {
+		return errors.NotFound("Contact not found")
+	})
+	e.GET("/plain", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if !strings.Contains(rec.Body.String(), `"message":"Contact not found"`) {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}